@@ -0,0 +1,188 @@
+package reqws
+
+import (
+	"math"
+	"time"
+)
+
+// AdaptiveConcurrencyConfig defines the configuration for the adaptive per-host
+// concurrency limit.
+type AdaptiveConcurrencyConfig struct {
+	InitialLimit  int           // Limit after warmup (default: 10)
+	MinLimit      int           // Lower bound of the limit, also used while measuring the baseline (default: 3)
+	MaxLimit      int           // Upper bound of the limit (default: 200)
+	Tolerance     float64       // Latency over baseline still considered healthy, as a ratio (default: 1.5)
+	Backoff       float64       // Limit multiplier on a failed attempt (default: 0.9)
+	Smoothing     float64       // Weight of each new limit estimate, 0-1 (default: 0.5)
+	Warmup        int           // Attempts at MinLimit that measure the baseline latency (default: 20)
+	ProbeInterval time.Duration // How often the baseline is measured again (default: 30s)
+}
+
+// AdaptiveLimitStats holds the adaptive concurrency state of one host.
+type AdaptiveLimitStats struct {
+	Limit    int           // Attempts currently allowed in flight
+	Gradient float64       // Last gradient: 1 near baseline latency, down to 0.5 as latency degrades
+	Baseline time.Duration // Latency without queueing, the minimum measured at MinLimit
+	Latency  time.Duration // Latency of the last attempt
+	Probing  bool          // The limit is held at MinLimit to measure the baseline
+	Samples  uint64        // Attempts measured
+	Failures uint64        // Attempts that failed and cut the limit
+}
+
+// WithAdaptiveConcurrency replaces a fixed per-host limit with one that follows
+// upstream latency, using a gradient algorithm: every attempt compares its latency
+// (until response headers) with the baseline latency of an unloaded upstream. Near
+// the baseline the limit grows by about its square root; as latency degrades it
+// shrinks in proportion, and transport errors, 5xx and 429 responses cut it by
+// Backoff. The limit stays within MinLimit and MaxLimit.
+//
+// The baseline is measured during warmup and then every ProbeInterval by holding
+// the limit at MinLimit for Warmup attempts, so it stays accurate when the
+// upstream gets permanently faster or slower.
+//
+// Attempts over the limit queue as with WithHostConcurrency(), retries behind
+// first attempts. Requests marked WithSheddable() are not queued: they fail with a
+// *ShedError while the host is at its limit, so a shrinking limit sheds
+// low-priority traffic first.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithAdaptiveConcurrency(reqws.AdaptiveConcurrencyConfig{MinLimit: 4, MaxLimit: 64})
+func (c *Client) WithAdaptiveConcurrency(config AdaptiveConcurrencyConfig) *Client {
+	if config.InitialLimit <= 0 {
+		config.InitialLimit = 10
+	}
+	if config.MinLimit <= 0 {
+		config.MinLimit = 3
+	}
+	if config.MaxLimit <= 0 {
+		config.MaxLimit = 200
+	}
+	if config.Tolerance < 1 {
+		config.Tolerance = 1.5
+	}
+	if config.Backoff <= 0 || config.Backoff >= 1 {
+		config.Backoff = 0.9
+	}
+	if config.Smoothing <= 0 || config.Smoothing > 1 {
+		config.Smoothing = 0.5
+	}
+	if config.Warmup <= 0 {
+		config.Warmup = 20
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = 30 * time.Second
+	}
+	config.InitialLimit = min(max(config.InitialLimit, config.MinLimit), config.MaxLimit)
+
+	if c.hostLimit == nil {
+		c.WithHostConcurrency(HostLimitConfig{})
+	}
+	c.hostLimit.adaptive = &config
+	c.hostLimit.limits = make(map[string]*adaptiveLimit)
+	return c
+}
+
+// AdaptiveConcurrencyStats returns the adaptive limit state per host.
+// Returns nil if adaptive concurrency is not enabled.
+func (c *Client) AdaptiveConcurrencyStats() map[string]AdaptiveLimitStats {
+	if c.hostLimit == nil || c.hostLimit.adaptive == nil {
+		return nil
+	}
+	return c.hostLimit.adaptiveStats()
+}
+
+// adaptiveLimit is the adaptive limit state of one host.
+type adaptiveLimit struct {
+	limit     float64 // Limit outside of probes
+	baseline  float64 // Seconds, 0 until the first probe completes
+	nextProbe time.Time
+
+	probeStart   time.Time
+	probeMin     float64
+	probeSamples int
+
+	// Attempts since the last limit update, which happens about once per round trip
+	windowSum      float64
+	windowSamples  int
+	windowFailures int
+
+	stats AdaptiveLimitStats
+}
+
+func newAdaptiveLimit(config *AdaptiveConcurrencyConfig) *adaptiveLimit {
+	a := &adaptiveLimit{limit: float64(config.InitialLimit)}
+	a.startProbe(config, time.Time{})
+	return a
+}
+
+// startProbe holds the limit at MinLimit to measure the baseline. Only attempts
+// started after now count, since earlier ones were sent at the higher limit.
+func (a *adaptiveLimit) startProbe(config *AdaptiveConcurrencyConfig, now time.Time) {
+	a.stats.Probing = true
+	a.stats.Limit = config.MinLimit
+	a.probeStart = now
+	a.probeMin = math.Inf(1)
+	a.probeSamples = 0
+}
+
+// update adjusts the limit after an attempt that ended at now and took latency.
+func (a *adaptiveLimit) update(config *AdaptiveConcurrencyConfig, now time.Time, latency time.Duration, failed bool) {
+	a.stats.Samples++
+	a.stats.Latency = latency
+	rtt := math.Max(latency.Seconds(), 1e-6)
+
+	if a.stats.Probing {
+		if now.Add(-latency).Before(a.probeStart) {
+			return
+		}
+		a.probeSamples++
+		if !failed {
+			a.probeMin = math.Min(a.probeMin, rtt)
+		}
+		if a.probeSamples < config.Warmup || math.IsInf(a.probeMin, 1) {
+			return
+		}
+		a.baseline = a.probeMin
+		a.stats.Baseline = time.Duration(a.baseline * float64(time.Second))
+		a.stats.Probing = false
+		a.stats.Limit = int(a.limit)
+		a.nextProbe = now.Add(config.ProbeInterval)
+		a.windowSum, a.windowSamples, a.windowFailures = 0, 0, 0
+		return
+	}
+
+	if now.After(a.nextProbe) {
+		a.startProbe(config, now)
+		return
+	}
+	if failed {
+		a.stats.Failures++
+		a.windowFailures++
+	} else {
+		if rtt < a.baseline {
+			a.baseline = rtt
+			a.stats.Baseline = latency
+		}
+		a.windowSum += rtt
+		a.windowSamples++
+	}
+
+	// Update once per window of half the limit in attempts, so the limit moves twice per round
+	// trip however many attempts are in flight
+	if a.windowSamples+a.windowFailures < int(a.limit)/2 {
+		return
+	}
+	if a.windowFailures > 0 {
+		a.limit *= config.Backoff
+	} else {
+		gradient := math.Max(0.5, math.Min(1, config.Tolerance*a.baseline/(a.windowSum/float64(a.windowSamples))))
+		a.stats.Gradient = gradient
+		estimate := a.limit*gradient + math.Sqrt(a.limit)
+		a.limit = a.limit*(1-config.Smoothing) + estimate*config.Smoothing
+	}
+	a.windowSum, a.windowSamples, a.windowFailures = 0, 0, 0
+	a.limit = math.Min(math.Max(a.limit, float64(config.MinLimit)), float64(config.MaxLimit))
+	a.stats.Limit = int(a.limit)
+}
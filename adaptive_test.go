@@ -0,0 +1,155 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// warmAdaptiveLimit returns an adaptive limit whose warmup measured a 10ms
+// baseline, and the time the warmup ended.
+func warmAdaptiveLimit(t *testing.T, config *AdaptiveConcurrencyConfig) (*adaptiveLimit, time.Time) {
+	t.Helper()
+	now := time.Now()
+	a := newAdaptiveLimit(config)
+	if !a.stats.Probing || a.stats.Limit != config.MinLimit {
+		t.Fatalf("new limit = %+v, want probing at MinLimit", a.stats)
+	}
+	for _, latency := range []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond} {
+		now = now.Add(time.Millisecond)
+		a.update(config, now, latency, false)
+	}
+	if a.stats.Probing || a.stats.Baseline != 10*time.Millisecond || a.stats.Limit != config.InitialLimit {
+		t.Fatalf("after warmup = %+v, want baseline 10ms at InitialLimit", a.stats)
+	}
+	return a, now
+}
+
+func TestAdaptiveLimitUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    AdaptiveConcurrencyConfig
+		latency   time.Duration
+		failed    bool
+		wantLimit int
+	}{
+		{"near baseline grows", AdaptiveConcurrencyConfig{}, 10 * time.Millisecond, false, 11},
+		{"within tolerance grows", AdaptiveConcurrencyConfig{}, 15 * time.Millisecond, false, 11},
+		{"degraded latency shrinks", AdaptiveConcurrencyConfig{}, 40 * time.Millisecond, false, 9},
+		{"failures back off", AdaptiveConcurrencyConfig{}, 10 * time.Millisecond, true, 9},
+		{"clamped to MaxLimit", AdaptiveConcurrencyConfig{MaxLimit: 10}, 10 * time.Millisecond, false, 10},
+		{"clamped to MinLimit", AdaptiveConcurrencyConfig{Backoff: 0.1}, 10 * time.Millisecond, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Warmup = 3
+			config := *NewClient("http://localhost", time.Second).WithAdaptiveConcurrency(tt.config).hostLimit.adaptive
+			a, now := warmAdaptiveLimit(t, &config)
+
+			// The limit moves once per window of half the limit in attempts
+			for i := 0; i < config.InitialLimit/2; i++ {
+				if a.stats.Limit != config.InitialLimit {
+					t.Fatalf("limit changed to %d after %d attempts", a.stats.Limit, i)
+				}
+				now = now.Add(time.Millisecond)
+				a.update(&config, now, tt.latency, tt.failed)
+			}
+			if a.stats.Limit != tt.wantLimit {
+				t.Errorf("limit = %d, want %d (stats %+v)", a.stats.Limit, tt.wantLimit, a.stats)
+			}
+		})
+	}
+}
+
+func TestAdaptiveLimitReprobes(t *testing.T) {
+	config := *NewClient("http://localhost", time.Second).
+		WithAdaptiveConcurrency(AdaptiveConcurrencyConfig{Warmup: 3, ProbeInterval: time.Minute}).hostLimit.adaptive
+	a, now := warmAdaptiveLimit(t, &config)
+
+	now = now.Add(time.Minute + time.Millisecond)
+	a.update(&config, now, 10*time.Millisecond, false)
+	if !a.stats.Probing || a.stats.Limit != config.MinLimit {
+		t.Fatalf("after ProbeInterval = %+v, want probing at MinLimit", a.stats)
+	}
+
+	// Attempts started before the probe ran at the higher limit and don't count
+	now = now.Add(time.Millisecond)
+	for i := 0; i < config.Warmup; i++ {
+		a.update(&config, now, 5*time.Millisecond, false)
+	}
+	if !a.stats.Probing {
+		t.Fatal("probe completed with attempts started before it")
+	}
+
+	for _, latency := range []time.Duration{25 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond} {
+		now = now.Add(time.Second)
+		a.update(&config, now, latency, false)
+	}
+	if a.stats.Probing || a.stats.Baseline != 20*time.Millisecond || a.stats.Limit != config.InitialLimit {
+		t.Errorf("after reprobe = %+v, want the slower 20ms baseline at the previous limit", a.stats)
+	}
+}
+
+func TestAdaptiveConcurrencyShedsAtLimit(t *testing.T) {
+	const requests = 2
+	arrived, release := make(chan struct{}, requests), make(chan struct{})
+	server, peak := concurrencyServer(t, arrived, release)
+	client := NewClient(server.URL, 5*time.Second).
+		WithAdaptiveConcurrency(AdaptiveConcurrencyConfig{MinLimit: 1, Warmup: 100})
+	if client.AdaptiveConcurrencyStats() == nil {
+		t.Fatal("AdaptiveConcurrencyStats = nil with adaptive concurrency enabled")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Do(context.Background(), GET("/")); err != nil {
+				t.Errorf("Do: %v", err)
+			}
+		}()
+	}
+	<-arrived
+	deadline := time.Now().Add(2 * time.Second)
+	for client.HostLimitStats().Queued != requests-1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := client.HostLimitStats(); stats.InFlight != 1 || stats.Queued != requests-1 {
+		t.Errorf("stats = %+v, want 1 in flight and the rest queued during warmup", stats)
+	}
+
+	// Sheddable requests fail instead of queueing
+	_, err := client.Do(context.Background(), GET("/"), WithSheddable())
+	var shedErr *ShedError
+	if !errors.As(err, &shedErr) {
+		t.Fatalf("sheddable request: err = %v, want a ShedError", err)
+	}
+	if shedErr.Reason != "adaptive concurrency limit 1 reached" || shedErr.InFlight != 1 {
+		t.Errorf("ShedError = %+v", shedErr)
+	}
+
+	close(release)
+	wg.Wait()
+	if got := peak.Load(); got != 1 {
+		t.Errorf("peak concurrency = %d, want MinLimit", got)
+	}
+	u, _ := url.Parse(server.URL)
+	stats := client.AdaptiveConcurrencyStats()[u.Host]
+	if !stats.Probing || stats.Limit != 1 || stats.Samples != requests {
+		t.Errorf("adaptive stats = %+v, want %d samples while probing at 1", stats, requests)
+	}
+}
+
+func TestAdaptiveConcurrencyStatsDisabled(t *testing.T) {
+	if stats := NewClient("http://localhost", time.Second).AdaptiveConcurrencyStats(); stats != nil {
+		t.Errorf("AdaptiveConcurrencyStats = %v, want nil", stats)
+	}
+	client := NewClient("http://localhost", time.Second).WithHostConcurrency(HostLimitConfig{MaxPerHost: 2})
+	if stats := client.AdaptiveConcurrencyStats(); stats != nil {
+		t.Errorf("AdaptiveConcurrencyStats = %v with a fixed limit, want nil", stats)
+	}
+}
@@ -0,0 +1,150 @@
+package reqws
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// APIKeyPlacement is where an API key is sent.
+type APIKeyPlacement int
+
+const (
+	APIKeyInHeader APIKeyPlacement = iota // Request header, e.g. X-Api-Key
+	APIKeyInQuery                         // Query parameter, e.g. ?api_key=
+	APIKeyInCookie                        // Cookie
+)
+
+// APIKeyFormat is how the primary and secondary keys are sent during a rotation overlap.
+type APIKeyFormat int
+
+const (
+	APIKeyRepeated    APIKeyFormat = iota // The header, query parameter or cookie appears twice, primary first
+	APIKeyCommaJoined                     // One value with both keys, "primary,secondary"
+)
+
+// APIKeyConfig describes API key authentication.
+//
+// During a key rotation, set Secondary to the other key (usually the old one) and
+// OverlapUntil to the end of the overlap period: until then both keys are sent, after
+// it only Primary.
+type APIKeyConfig struct {
+	Placement    APIKeyPlacement
+	Name         string // Header, query parameter or cookie name
+	Primary      string
+	Secondary    string       // Optional key sent alongside Primary until OverlapUntil
+	OverlapUntil time.Time    // End of the rotation overlap; zero sends only Primary
+	Format       APIKeyFormat // How both keys are sent during the overlap
+}
+
+// keys returns the keys to send at now.
+func (k *APIKeyConfig) keys(now time.Time) []string {
+	if k.Primary == "" {
+		return nil
+	}
+	if k.Secondary == "" || !now.Before(k.OverlapUntil) {
+		return []string{k.Primary}
+	}
+	if k.Format == APIKeyCommaJoined {
+		return []string{k.Primary + "," + k.Secondary}
+	}
+	return []string{k.Primary, k.Secondary}
+}
+
+// apply attaches the keys for now to header and returns the query to send.
+func (k *APIKeyConfig) apply(header http.Header, rawQuery string, now time.Time) string {
+	for _, key := range k.keys(now) {
+		switch k.Placement {
+		case APIKeyInHeader:
+			header.Add(k.Name, key)
+		case APIKeyInQuery:
+			param := url.QueryEscape(k.Name) + "=" + url.QueryEscape(key)
+			if rawQuery == "" {
+				rawQuery = param
+			} else {
+				rawQuery += "&" + param
+			}
+		case APIKeyInCookie:
+			cookie := (&http.Cookie{Name: k.Name, Value: key}).String()
+			if existing := header.Get("Cookie"); existing != "" {
+				cookie = existing + "; " + cookie
+			}
+			header.Set("Cookie", cookie)
+		}
+	}
+	return rawQuery
+}
+
+// WithAPIKey authenticates the request with an API key, replacing any key set on
+// the client with Client.WithAPIKey().
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/v1/quotes"),
+//		reqws.WithAPIKey(reqws.APIKeyConfig{
+//			Placement: reqws.APIKeyInHeader,
+//			Name:      "X-Api-Key",
+//			Primary:   newKey,
+//			Secondary: oldKey,
+//			OverlapUntil: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+//		}),
+//	)
+func WithAPIKey(cfg APIKeyConfig) RequestOption {
+	return func(c *requestConfig) {
+		c.apiKey = &cfg
+	}
+}
+
+// WithAPIKey authenticates every request and WebSocket dial made by the client with
+// an API key. Rotate it later with SetAPIKey().
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithAPIKey(reqws.APIKeyConfig{Placement: reqws.APIKeyInQuery, Name: "api_key", Primary: key})
+func (c *Client) WithAPIKey(cfg APIKeyConfig) *Client {
+	c.apiKey = &apiKeyState{cfg: cfg, now: time.Now}
+	return c
+}
+
+// SetAPIKey rotates the client's API key. Requests sent after it returns use the new
+// keys; placement, name and format are kept. secondary is sent alongside primary
+// until overlapUntil. Does nothing if WithAPIKey() was not called on the client.
+//
+// Example:
+//
+//	// Send both keys for a day, then only the new one
+//	client.SetAPIKey(newKey, oldKey, time.Now().Add(24*time.Hour))
+func (c *Client) SetAPIKey(primary, secondary string, overlapUntil time.Time) {
+	if c.apiKey == nil {
+		return
+	}
+	c.apiKey.mu.Lock()
+	defer c.apiKey.mu.Unlock()
+	c.apiKey.cfg.Primary = primary
+	c.apiKey.cfg.Secondary = secondary
+	c.apiKey.cfg.OverlapUntil = overlapUntil
+}
+
+type apiKeyState struct {
+	mu  sync.Mutex
+	cfg APIKeyConfig
+	now func() time.Time
+}
+
+// applyAPIKey attaches the request's or client's API key to header and returns the
+// query to send.
+func (c *Client) applyAPIKey(config *requestConfig, header http.Header, rawQuery string) string {
+	if config.apiKey != nil {
+		return config.apiKey.apply(header, rawQuery, time.Now())
+	}
+	if c.apiKey == nil {
+		return rawQuery
+	}
+	c.apiKey.mu.Lock()
+	cfg := c.apiKey.cfg
+	c.apiKey.mu.Unlock()
+	return cfg.apply(header, rawQuery, c.apiKey.now())
+}
@@ -0,0 +1,83 @@
+package reqws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sentAPIKey returns the request's query, its X-Api-Key values and its Cookie
+// header, separated by "|".
+func sentAPIKey(req recordedRequest) string {
+	return req.Query + "|" + strings.Join(req.Header.Values("X-Api-Key"), ",") + "|" + req.Header.Get("Cookie")
+}
+
+func TestWithAPIKey(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	overlap := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name string
+		cfg  APIKeyConfig
+		opts []RequestOption
+		want string
+	}{
+		{"header", APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: "new"}, nil, "|new|"},
+		{"header overlap", APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: "new", Secondary: "old", OverlapUntil: overlap}, nil, "|new,old|"},
+		{"overlap ended", APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: "new", Secondary: "old", OverlapUntil: expired}, nil, "|new|"},
+		{"query overlap", APIKeyConfig{Placement: APIKeyInQuery, Name: "api_key", Primary: "new", Secondary: "old", OverlapUntil: overlap},
+			[]RequestOption{WithQueryParam("page", "2")}, "page=2&api_key=new&api_key=old||"},
+		{"query comma joined", APIKeyConfig{Placement: APIKeyInQuery, Name: "api_key", Primary: "new", Secondary: "old", OverlapUntil: overlap, Format: APIKeyCommaJoined},
+			nil, "api_key=new%2Cold||"},
+		{"cookie", APIKeyConfig{Placement: APIKeyInCookie, Name: "key", Primary: "new", Secondary: "old", OverlapUntil: overlap},
+			[]RequestOption{WithCookie("session", "s1")}, "||session=s1; key=new; key=old"},
+		{"no primary", APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Secondary: "old", OverlapUntil: overlap}, nil, "||"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]RequestOption{GET("/"), WithAPIKey(tt.cfg)}, tt.opts...)
+			if _, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), opts...); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := sentAPIKey(log.Last(t)); got != tt.want {
+				t.Errorf("sent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientAPIKeyRotation(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	client := NewClient(server.URL, 5*time.Second).
+		WithAPIKey(APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: "k1"})
+	client.apiKey.now = func() time.Time { return now }
+
+	send := func(opts ...RequestOption) string {
+		t.Helper()
+		if _, err := client.Do(context.Background(), append([]RequestOption{GET("/")}, opts...)...); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		return sentAPIKey(log.Last(t))
+	}
+
+	if got := send(); got != "|k1|" {
+		t.Errorf("before rotation: %q", got)
+	}
+	client.SetAPIKey("k2", "k1", now.Add(24*time.Hour))
+	if got := send(); got != "|k2,k1|" {
+		t.Errorf("during overlap: %q", got)
+	}
+	now = now.Add(25 * time.Hour)
+	if got := send(); got != "|k2|" {
+		t.Errorf("after overlap: %q", got)
+	}
+	// A request key replaces the client's
+	if got := send(WithAPIKey(APIKeyConfig{Placement: APIKeyInQuery, Name: "key", Primary: "req"})); got != "key=req||" {
+		t.Errorf("request key: %q", got)
+	}
+
+	// SetAPIKey without WithAPIKey is a no-op
+	NewClient(server.URL, time.Second).SetAPIKey("k", "", time.Time{})
+}
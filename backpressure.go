@@ -0,0 +1,106 @@
+package reqws
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BackpressureConfig defines the configuration for upstream backpressure tracking.
+type BackpressureConfig struct {
+	DefaultWait time.Duration // Wait after a 429 or 503 without a usable Retry-After (default: 1s)
+	MaxWait     time.Duration // Upper bound of a suggested wait (default: 5m)
+
+	// OnBackpressure is called after every 429 or 503 response with the host and
+	// how long to back off. It runs on the request's goroutine and must not block.
+	OnBackpressure func(host string, wait time.Duration)
+}
+
+// WithBackpressure records how long each upstream host asked the client to back
+// off, for services that front an upstream and want to pass its rate limits on to
+// their own callers instead of queueing requests that would fail.
+//
+// After a 429 or 503 response, the wait from its Retry-After header (or
+// DefaultWait) is recorded per host and reported by SuggestedBackoff() until it
+// elapses, and OnBackpressure is called. Every attempt counts, including retries.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithBackpressure(reqws.BackpressureConfig{
+//			OnBackpressure: func(host string, wait time.Duration) {
+//				metrics.Backpressure.WithLabelValues(host).Set(wait.Seconds())
+//			},
+//		})
+//
+//	// In the service's own handler:
+//	if wait, ok := client.SuggestedBackoff("api.example.com"); ok {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+//		w.WriteHeader(http.StatusTooManyRequests)
+//		return
+//	}
+func (c *Client) WithBackpressure(config BackpressureConfig) *Client {
+	if config.DefaultWait <= 0 {
+		config.DefaultWait = time.Second
+	}
+	if config.MaxWait <= 0 {
+		config.MaxWait = 5 * time.Minute
+	}
+	c.backpressure = &backpressureTracker{config: config, until: make(map[string]time.Time)}
+	return c
+}
+
+// SuggestedBackoff returns how much longer host asked to be left alone, counting
+// down from its last 429 or 503 response. host is the URL host, with the port if
+// the URL has one. Returns false once the wait has elapsed, or if
+// WithBackpressure() is not enabled.
+func (c *Client) SuggestedBackoff(host string) (time.Duration, bool) {
+	if c.backpressure == nil {
+		return 0, false
+	}
+	return c.backpressure.suggested(host, time.Now())
+}
+
+type backpressureTracker struct {
+	config BackpressureConfig
+
+	mu    sync.Mutex
+	until map[string]time.Time // End of the backoff per host
+}
+
+// observe records the backoff requested by a 429 or 503 response from host.
+func (t *backpressureTracker) observe(host string, resp *http.Response) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+	now := time.Now()
+	wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	if !ok {
+		wait = t.config.DefaultWait
+	}
+	wait = min(wait, t.config.MaxWait)
+
+	t.mu.Lock()
+	// The latest response is the server's current estimate, even if it is shorter
+	t.until[host] = now.Add(wait)
+	for h, until := range t.until {
+		if !until.After(now) {
+			delete(t.until, h)
+		}
+	}
+	t.mu.Unlock()
+
+	if t.config.OnBackpressure != nil {
+		t.config.OnBackpressure(host, wait)
+	}
+}
+
+func (t *backpressureTracker) suggested(host string, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.until[host]
+	if !ok || !until.After(now) {
+		return 0, false
+	}
+	return until.Sub(now), true
+}
@@ -0,0 +1,142 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// backpressureServer returns a server that answers with status and Retry-After
+// taken from the request's query, and the host to look its backoff up by.
+func backpressureServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if after := r.URL.Query().Get("after"); after != "" {
+			w.Header().Set("Retry-After", after)
+		}
+		status := http.StatusOK
+		switch r.URL.Query().Get("status") {
+		case "429":
+			status = http.StatusTooManyRequests
+		case "500":
+			status = http.StatusInternalServerError
+		case "503":
+			status = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(status)
+	})
+	u, _ := url.Parse(server.URL)
+	return server, u.Host
+}
+
+func TestBackpressureSuggestedBackoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     string
+		retryAfter string
+		want       time.Duration // 0 for no backoff
+	}{
+		{"429 with seconds", "429", "30", 30 * time.Second},
+		{"503 with a date", "503", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+		{"503 without Retry-After", "503", "", 2 * time.Second},
+		{"invalid Retry-After", "429", "soon", 2 * time.Second},
+		{"capped at MaxWait", "429", "3600", 10 * time.Minute},
+		{"500 is not backpressure", "500", "30", 0},
+		{"200 is not backpressure", "200", "30", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, host := backpressureServer(t)
+			var reported []time.Duration
+			client := NewClient(server.URL, 5*time.Second).WithBackpressure(BackpressureConfig{
+				DefaultWait: 2 * time.Second,
+				MaxWait:     10 * time.Minute,
+				OnBackpressure: func(h string, wait time.Duration) {
+					if h != host {
+						t.Errorf("OnBackpressure host = %q, want %q", h, host)
+					}
+					reported = append(reported, wait)
+				},
+			})
+
+			_, err := client.Do(context.Background(), GET("/"),
+				WithQueryParam("status", tt.status), WithQueryParam("after", tt.retryAfter))
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			wait, ok := client.SuggestedBackoff(host)
+			if tt.want == 0 {
+				if ok || len(reported) != 0 {
+					t.Errorf("SuggestedBackoff = %v, %v with %d reports, want none", wait, ok, len(reported))
+				}
+				return
+			}
+			// HTTP dates have second precision
+			if !ok || wait > tt.want || wait < tt.want-2*time.Second {
+				t.Errorf("SuggestedBackoff = %v, %v; want about %v", wait, ok, tt.want)
+			}
+			if len(reported) != 1 || reported[0] > tt.want || reported[0] < tt.want-2*time.Second {
+				t.Errorf("reported waits = %v, want one of about %v", reported, tt.want)
+			}
+			if _, ok := client.SuggestedBackoff("other.example.com"); ok {
+				t.Error("another host has a backoff")
+			}
+		})
+	}
+}
+
+func TestBackpressureLatestResponseWins(t *testing.T) {
+	server, host := backpressureServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithBackpressure(BackpressureConfig{})
+	ctx := context.Background()
+
+	for _, after := range []string{"60", "1"} {
+		if _, err := client.Do(ctx, GET("/"), WithQueryParam("status", "429"), WithQueryParam("after", after)); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if wait, ok := client.SuggestedBackoff(host); !ok || wait > time.Second {
+		t.Errorf("SuggestedBackoff = %v, %v; want the shorter, latest wait", wait, ok)
+	}
+}
+
+func TestBackpressureExpires(t *testing.T) {
+	server, host := backpressureServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithBackpressure(BackpressureConfig{DefaultWait: 20 * time.Millisecond})
+
+	if _, err := client.Do(context.Background(), GET("/"), WithQueryParam("status", "503")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if _, ok := client.SuggestedBackoff(host); !ok {
+		t.Fatal("no backoff right after a 503")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if wait, ok := client.SuggestedBackoff(host); ok {
+		t.Errorf("SuggestedBackoff = %v after the wait elapsed", wait)
+	}
+}
+
+func TestBackpressureCountsRetries(t *testing.T) {
+	server, _ := backpressureServer(t)
+	var reports atomic.Int32
+	client := NewClient(server.URL, 5*time.Second).WithBackpressure(BackpressureConfig{
+		OnBackpressure: func(string, time.Duration) { reports.Add(1) },
+	})
+
+	if _, err := client.Do(context.Background(), GET("/"), WithQueryParam("status", "503"), WithRetry(fastRetry(2))); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := reports.Load(); got != 3 {
+		t.Errorf("OnBackpressure calls = %d, want one per attempt", got)
+	}
+}
+
+func TestSuggestedBackoffDisabled(t *testing.T) {
+	if wait, ok := NewClient("http://localhost", time.Second).SuggestedBackoff("localhost"); ok || wait != 0 {
+		t.Errorf("SuggestedBackoff = %v, %v without WithBackpressure", wait, ok)
+	}
+}
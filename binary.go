@@ -0,0 +1,179 @@
+package reqws
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// binarySniffSize is how much of a body is inspected to tell binary from text.
+const binarySniffSize = 512
+
+// WithSafeResponseString makes Response.String() safe to log: binary bodies are
+// summarized as "<binary: N bytes, type X>", UTF-16 text is decoded, and invalid
+// UTF-8 is replaced with U+FFFD. Without it String() returns the raw bytes.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://files.example.com", 30*time.Second).
+//		WithSafeResponseString()
+func (c *Client) WithSafeResponseString() *Client {
+	c.safeString = true
+	return c
+}
+
+// IsBinary reports whether the body is binary rather than text. Binary
+// Content-Types (images, audio, video, fonts, PDF, archives, protobuf,
+// application/octet-stream) are binary; otherwise the first 512 bytes decide:
+// NUL bytes mean binary unless the body is UTF-16 text, and a body without a
+// text Content-Type must also be valid UTF-8.
+func (r *Response) IsBinary() bool {
+	return isBinaryBody(r.Headers.Get("Content-Type"), r.Body)
+}
+
+// Reader returns a reader over the body, e.g. for io.Copy or image.Decode.
+func (r *Response) Reader() io.Reader {
+	return bytes.NewReader(r.Body)
+}
+
+// SaveTo writes the body to the file at path, creating or truncating it.
+//
+// Example:
+//
+//	resp, err := client.Do(ctx, reqws.GET("/reports/42.pdf"))
+//	if err != nil {
+//		return err
+//	}
+//	err = resp.SaveTo("report.pdf")
+func (r *Response) SaveTo(path string) error {
+	if err := os.WriteFile(path, r.Body, 0o644); err != nil {
+		return fmt.Errorf("failed to save response body: %w", err)
+	}
+	return nil
+}
+
+// isBinaryBody reports whether body with the given Content-Type is binary.
+func isBinaryBody(contentType string, body []byte) bool {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if isBinaryMediaType(mediaType) {
+		return true
+	}
+	if isUTF16Text(params["charset"], body) {
+		return false
+	}
+	prefix := body[:min(len(body), binarySniffSize)]
+	if bytes.IndexByte(prefix, 0) >= 0 {
+		return true
+	}
+	if isTextMediaType(mediaType) {
+		// Text in a legacy charset renders with replacement characters
+		return false
+	}
+	if len(prefix) < len(body) {
+		// Keep a rune cut off by the prefix from looking invalid
+		for i := 1; i < utf8.UTFMax && i <= len(prefix); i++ {
+			if utf8.RuneStart(prefix[len(prefix)-i]) {
+				if !utf8.FullRune(prefix[len(prefix)-i:]) {
+					prefix = prefix[:len(prefix)-i]
+				}
+				break
+			}
+		}
+	}
+	return !utf8.Valid(prefix)
+}
+
+// renderBody returns body as printable text, or a summary if it is binary.
+func renderBody(contentType string, body []byte) string {
+	if isBinaryBody(contentType, body) {
+		return binarySummary(contentType, body)
+	}
+	_, params, _ := mime.ParseMediaType(contentType)
+	if isUTF16Text(params["charset"], body) {
+		return decodeUTF16(params["charset"], body)
+	}
+	return strings.ToValidUTF8(string(body), "\uFFFD")
+}
+
+// binarySummary describes a binary body without its contents.
+func binarySummary(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" {
+		mediaType = http.DetectContentType(body)
+	}
+	return fmt.Sprintf("<binary: %d bytes, type %s>", len(body), mediaType)
+}
+
+func isBinaryMediaType(mediaType string) bool {
+	major, minor, _ := strings.Cut(mediaType, "/")
+	switch major {
+	case "image":
+		return minor != "svg+xml"
+	case "audio", "video", "font":
+		return true
+	case "application":
+		switch minor {
+		case "octet-stream", "pdf", "zip", "gzip", "x-gzip", "x-tar", "x-7z-compressed",
+			"protobuf", "x-protobuf", "vnd.google.protobuf", "grpc", "grpc-web", "wasm", "msgpack", "x-msgpack", "cbor":
+			return true
+		}
+	}
+	return false
+}
+
+func isTextMediaType(mediaType string) bool {
+	major, minor, _ := strings.Cut(mediaType, "/")
+	switch {
+	case major == "text":
+		return true
+	case major != "application" && major != "image":
+		return false
+	case strings.HasSuffix(minor, "+json"), strings.HasSuffix(minor, "+xml"):
+		return true
+	}
+	switch minor {
+	case "json", "xml", "javascript", "ecmascript", "x-www-form-urlencoded", "x-ndjson", "yaml", "x-yaml", "graphql":
+		return true
+	}
+	return false
+}
+
+// isUTF16Text reports whether body is UTF-16 text, by its charset or byte order mark.
+func isUTF16Text(charset string, body []byte) bool {
+	switch strings.ToLower(charset) {
+	case "utf-16", "utf-16le", "utf-16be":
+		return true
+	}
+	return bytes.HasPrefix(body, []byte{0xFF, 0xFE}) || bytes.HasPrefix(body, []byte{0xFE, 0xFF})
+}
+
+// decodeUTF16 decodes UTF-16 text, big-endian unless a byte order mark or the
+// charset says otherwise.
+func decodeUTF16(charset string, body []byte) string {
+	littleEndian := strings.EqualFold(charset, "utf-16le")
+	switch {
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		littleEndian, body = true, body[2:]
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		littleEndian, body = false, body[2:]
+	}
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		if littleEndian {
+			units[i] = uint16(body[2*i]) | uint16(body[2*i+1])<<8
+		} else {
+			units[i] = uint16(body[2*i])<<8 | uint16(body[2*i+1])
+		}
+	}
+	text := string(utf16.Decode(units))
+	if len(body)%2 != 0 {
+		text += "\uFFFD"
+	}
+	return text
+}
@@ -0,0 +1,161 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngHeader is the start of a PNG image.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestIsBinaryBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        bool
+	}{
+		{"JSON", "application/json", []byte(`{"id":1}`), false},
+		{"vendor JSON", "application/vnd.api+json", []byte(`{}`), false},
+		{"SVG image", "image/svg+xml", []byte("<svg/>"), false},
+		{"PNG by type", "image/png", []byte("looks like text"), true},
+		{"protobuf by type", "application/x-protobuf; proto=Order", []byte("text"), true},
+		{"octet-stream", "application/octet-stream", nil, true},
+		{"NUL bytes without type", "", pngHeader, true},
+		{"NUL bytes in text type", "text/plain", []byte("a\x00b"), true},
+		{"UTF-16 charset", "text/plain; charset=UTF-16LE", []byte("h\x00i\x00"), false},
+		{"UTF-16 byte order mark", "", []byte("\xff\xfeh\x00i\x00"), false},
+		{"Latin-1 text type", "text/plain; charset=iso-8859-1", []byte("caf\xe9"), false},
+		{"invalid UTF-8 without type", "", []byte("caf\xe9"), true},
+		{"valid UTF-8 without type", "", []byte("café"), false},
+		{"rune cut by the sniffed prefix", "", append(bytes.Repeat([]byte("a"), binarySniffSize-1), "é and more"...), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinaryBody(tt.contentType, tt.body); got != tt.want {
+				t.Errorf("isBinaryBody(%q, %q) = %v, want %v", tt.contentType, tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+	}{
+		{"text", "text/plain", []byte("hello"), "hello"},
+		{"invalid UTF-8 replaced", "text/plain; charset=iso-8859-1", []byte("caf\xe9"), "caf�"},
+		{"binary with type", "image/png", pngHeader, "<binary: 16 bytes, type image/png>"},
+		{"binary sniffed", "", pngHeader, "<binary: 16 bytes, type image/png>"},
+		{"UTF-16LE byte order mark", "", []byte("\xff\xfeh\x00\xe9\x00"), "hé"},
+		{"UTF-16BE byte order mark", "", []byte("\xfe\xff\x00h\x00\xe9"), "hé"},
+		{"UTF-16 charset is big-endian", "text/plain; charset=utf-16", []byte("\x00h\x00i"), "hi"},
+		{"UTF-16LE charset", "text/plain; charset=utf-16le", []byte("h\x00i\x00"), "hi"},
+		{"odd UTF-16 length", "text/plain; charset=utf-16le", []byte("h\x00i"), "h�"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderBody(tt.contentType, tt.body); got != tt.want {
+				t.Errorf("renderBody = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// imageServer returns a server that answers with a PNG header.
+func imageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(pngHeader)
+	})
+	return server
+}
+
+func TestBinaryResponse(t *testing.T) {
+	server := imageServer(t)
+	ctx := context.Background()
+
+	resp, err := NewClient(server.URL, 5*time.Second).Do(ctx, GET("/logo.png"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if !resp.IsBinary() {
+		t.Error("IsBinary = false for a PNG")
+	}
+	if resp.String() != string(pngHeader) {
+		t.Errorf("String = %q, want the raw body without WithSafeResponseString", resp.String())
+	}
+	if data, _ := io.ReadAll(resp.Reader()); !bytes.Equal(data, pngHeader) {
+		t.Errorf("Reader = %q", data)
+	}
+	var v map[string]any
+	if err := resp.JSON(&v); err == nil || !strings.Contains(err.Error(), "body is <binary: 16 bytes, type image/png>") {
+		t.Errorf("JSON error = %v, want it to describe the binary body", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := resp.SaveTo(path); err != nil {
+		t.Fatalf("SaveTo: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, pngHeader) {
+		t.Errorf("saved file = %q", data)
+	}
+	if err := resp.SaveTo(filepath.Join(t.TempDir(), "missing", "logo.png")); err == nil {
+		t.Error("SaveTo into a missing directory succeeded")
+	}
+
+	resp, err = NewClient(server.URL, 5*time.Second).WithSafeResponseString().Do(ctx, GET("/logo.png"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got, want := resp.String(), "<binary: 16 bytes, type image/png>"; got != want {
+		t.Errorf("safe String = %q, want %q", got, want)
+	}
+}
+
+func TestTapPreviewSummarizesBinaryBody(t *testing.T) {
+	server := imageServer(t)
+	client := NewClient(server.URL, 5*time.Second).RegisterCodec("application/x-protobuf", rawCodec{})
+	events, stop := client.Tap(10)
+
+	ctx := context.Background()
+	if _, err := client.Do(ctx, PUT("/orders/1"), WithBodyAs("application/x-protobuf", pngHeader)); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if _, err := client.Do(ctx, PUT("/logo.png"), WithRawBody(pngHeader, "image/png")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	stop()
+
+	var previews []string
+	for event := range events {
+		if event.Type == TapRequest {
+			previews = append(previews, event.BodyPreview)
+		}
+	}
+	if len(previews) != 2 || previews[0] != "<binary: 16 bytes, type application/x-protobuf>" || previews[1] != "" {
+		t.Errorf("previews = %q, want a binary summary for the encoded body and none for the raw one", previews)
+	}
+}
+
+// rawCodec marshals byte slices as they are.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) { return v.([]byte), nil }
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,248 @@
+package reqws
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultResponseEstimate is reserved for a response body whose size is not yet known.
+const defaultResponseEstimate = 64 * 1024
+
+// BudgetMode controls what a request does when the memory budget is exhausted.
+type BudgetMode int
+
+const (
+	BudgetWait     BudgetMode = iota // Wait until enough memory is released or the context is done
+	BudgetFailFast                   // Fail immediately with *BudgetExceededError
+)
+
+// MemoryBudgetStats holds memory budget usage.
+type MemoryBudgetStats struct {
+	Max       int64  // Budget in bytes
+	InUse     int64  // Bytes currently reserved by requests in flight
+	HighWater int64  // Highest InUse seen
+	Waiting   int    // Requests waiting for memory
+	Rejected  uint64 // Requests that failed with *BudgetExceededError
+}
+
+// BudgetExceededError is returned when a request cannot reserve its memory footprint.
+type BudgetExceededError struct {
+	Requested int64 // Bytes the request needed
+	InUse     int64 // Bytes reserved by other requests at the time
+	Max       int64
+	Err       error // Context error if the request gave up waiting
+}
+
+func (e *BudgetExceededError) Error() string {
+	msg := fmt.Sprintf("memory budget exceeded: requested %d bytes, %d of %d in use", e.Requested, e.InUse, e.Max)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return e.Err
+}
+
+// WithMemoryBudget caps the memory held by all requests in flight on the client.
+//
+// Before sending, each attempt reserves its estimated footprint: the serialized
+// request body plus 64 KiB for the response (see WithExpectedResponseSize()).
+// Multipart uploads are streamed from their files and do not count. When the
+// response arrives, the reservation is adjusted to its Content-Length if known.
+// StreamLines and StreamCompletion reserve their maximum line or event buffer
+// instead, since they never hold the whole body. The reservation is released when
+// the response body is closed. WebSocket streams are not counted.
+//
+// When the budget is exhausted, BudgetWait blocks the request until memory is
+// released or its context is done, and BudgetFailFast fails it at once; both
+// return a *BudgetExceededError. A request larger than the whole budget always
+// fails. Budget errors are not retried.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithMemoryBudget(256<<20, reqws.BudgetWait)
+func (c *Client) WithMemoryBudget(maxBytes int64, mode BudgetMode) *Client {
+	c.budget = &memoryBudget{max: maxBytes, mode: mode}
+	return c
+}
+
+// MemoryBudgetStats returns the current memory budget usage.
+// Returns zero values if no budget is set.
+func (c *Client) MemoryBudgetStats() MemoryBudgetStats {
+	if c.budget == nil {
+		return MemoryBudgetStats{}
+	}
+	return c.budget.stats()
+}
+
+// WithExpectedResponseSize sets how many bytes the memory budget reserves for the
+// response body until its Content-Length is known. Only has an effect when the
+// client has a budget set via WithMemoryBudget().
+func WithExpectedResponseSize(n int64) RequestOption {
+	return func(c *requestConfig) {
+		c.expectedResponseSize = n
+	}
+}
+
+type memoryBudget struct {
+	max  int64
+	mode BudgetMode
+
+	mu       sync.Mutex
+	used     int64
+	peak     int64
+	rejected uint64
+	waiters  list.List // *budgetWaiter, first come first served
+}
+
+type budgetWaiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// acquire reserves n bytes.
+func (b *memoryBudget) acquire(ctx context.Context, n int64) error {
+	b.mu.Lock()
+	if n > b.max || (b.mode == BudgetFailFast && (b.used+n > b.max || b.waiters.Len() > 0)) {
+		b.rejected++
+		err := &BudgetExceededError{Requested: n, InUse: b.used, Max: b.max}
+		b.mu.Unlock()
+		return err
+	}
+	if b.used+n <= b.max && b.waiters.Len() == 0 {
+		b.reserveLocked(n)
+		b.mu.Unlock()
+		return nil
+	}
+	w := &budgetWaiter{n: n, ready: make(chan struct{})}
+	elem := b.waiters.PushBack(w)
+	b.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted while giving up; hand the memory back
+			b.used -= n
+		default:
+			b.waiters.Remove(elem)
+		}
+		b.rejected++
+		err := &BudgetExceededError{Requested: n, InUse: b.used, Max: b.max, Err: ctx.Err()}
+		b.grantLocked()
+		b.mu.Unlock()
+		return err
+	}
+}
+
+// release returns n bytes to the budget and wakes waiters that now fit.
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	b.grantLocked()
+	b.mu.Unlock()
+}
+
+// grantLocked reserves memory for waiters in order while they fit.
+func (b *memoryBudget) grantLocked() {
+	for elem := b.waiters.Front(); elem != nil; elem = b.waiters.Front() {
+		w := elem.Value.(*budgetWaiter)
+		if b.used+w.n > b.max {
+			return
+		}
+		b.reserveLocked(w.n)
+		b.waiters.Remove(elem)
+		close(w.ready)
+	}
+}
+
+func (b *memoryBudget) reserveLocked(n int64) {
+	b.used += n
+	if b.used > b.peak {
+		b.peak = b.used
+	}
+}
+
+func (b *memoryBudget) stats() MemoryBudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return MemoryBudgetStats{
+		Max:       b.max,
+		InUse:     b.used,
+		HighWater: b.peak,
+		Waiting:   b.waiters.Len(),
+		Rejected:  b.rejected,
+	}
+}
+
+// reserveRequest reserves the footprint of req before it is sent and returns
+// the number of bytes reserved.
+func (b *memoryBudget) reserveRequest(ctx context.Context, req *http.Request, config *requestConfig) (int64, error) {
+	n := config.responseEstimate() + bufferedBodySize(req, config)
+	return n, b.acquire(ctx, n)
+}
+
+// bufferedBodySize returns how much memory the request body takes; multipart
+// bodies are streamed from their files and take none.
+func bufferedBodySize(req *http.Request, config *requestConfig) int64 {
+	if config.isMultipart() || req.ContentLength < 0 {
+		return 0
+	}
+	return req.ContentLength
+}
+
+// trackResponse adjusts the reservation to the response's known size and releases
+// it when the body is closed. On error the body is closed and nothing stays reserved.
+func (b *memoryBudget) trackResponse(ctx context.Context, req *http.Request, resp *http.Response, config *requestConfig, reserved int64) (*http.Response, error) {
+	if config.streamBufferSize == 0 && resp.ContentLength >= 0 {
+		actual := resp.ContentLength + bufferedBodySize(req, config)
+		if actual < reserved {
+			b.release(reserved - actual)
+			reserved = actual
+		} else if actual > reserved {
+			// Release before waiting for the larger amount so requests never wait while holding memory
+			b.release(reserved)
+			if err := b.acquire(ctx, actual); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			reserved = actual
+		}
+	}
+	resp.Body = &budgetBody{ReadCloser: resp.Body, release: func() { b.release(reserved) }}
+	return resp, nil
+}
+
+// responseEstimate returns the bytes to reserve for the response before its size is known.
+func (config *requestConfig) responseEstimate() int64 {
+	if config.streamBufferSize > 0 {
+		return config.streamBufferSize
+	}
+	if config.expectedResponseSize > 0 {
+		return config.expectedResponseSize
+	}
+	return defaultResponseEstimate
+}
+
+// budgetBody releases the response's reservation when closed.
+type budgetBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *budgetBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
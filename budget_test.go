@@ -0,0 +1,155 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingServer returns a server that holds requests to /slow until release is
+// closed and records all requests.
+func blockingServer(t *testing.T) (*httptest.Server, chan struct{}, chan struct{}, *requestLog) {
+	t.Helper()
+	arrived, release := make(chan struct{}, 10), make(chan struct{})
+	server, hits := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			arrived <- struct{}{}
+			<-release
+		}
+		w.Write([]byte("ok"))
+	})
+	return server, arrived, release, hits
+}
+
+func TestMemoryBudgetFailFast(t *testing.T) {
+	server, arrived, release, hits := blockingServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithMemoryBudget(100_000, BudgetFailFast)
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Do(context.Background(), GET("/slow"), WithExpectedResponseSize(60_000))
+		done <- err
+	}()
+	<-arrived
+
+	_, err := client.Do(context.Background(), POST("/"), WithJSON(strings.Repeat("x", 50_000)), WithRetry(fastRetry(3)))
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("err = %v, want a BudgetExceededError", err)
+	}
+	if budgetErr.InUse != 60_000 || budgetErr.Max != 100_000 || budgetErr.Requested != defaultResponseEstimate+50_002 {
+		t.Errorf("BudgetExceededError = %+v", budgetErr)
+	}
+	if got := hits.Count(); got != 1 {
+		t.Errorf("server hits = %d, want the rejected request neither sent nor retried", got)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight request: %v", err)
+	}
+	stats := client.MemoryBudgetStats()
+	if stats.InUse != 0 || stats.HighWater != 60_000 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v, want nothing in use, high water 60000 and 1 rejection", stats)
+	}
+}
+
+func TestMemoryBudgetWait(t *testing.T) {
+	server, arrived, release, _ := blockingServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithMemoryBudget(100_000, BudgetWait)
+
+	first := make(chan error)
+	go func() {
+		_, err := client.Do(context.Background(), GET("/slow"), WithExpectedResponseSize(60_000))
+		first <- err
+	}()
+	<-arrived
+
+	// A waiter that gives up returns the context error
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.Do(ctx, GET("/"), WithExpectedResponseSize(60_000))
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want a BudgetExceededError wrapping the deadline", err)
+	}
+
+	second := make(chan error)
+	go func() {
+		_, err := client.Do(context.Background(), GET("/"), WithExpectedResponseSize(60_000))
+		second <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for client.MemoryBudgetStats().Waiting != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-second:
+		t.Fatalf("request did not wait for memory: %v", err)
+	default:
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if err := <-second; err != nil {
+		t.Fatalf("waiting request: %v", err)
+	}
+	if stats := client.MemoryBudgetStats(); stats.InUse != 0 || stats.Waiting != 0 {
+		t.Errorf("stats = %+v, want everything released", stats)
+	}
+}
+
+func TestMemoryBudgetRequestLargerThanBudget(t *testing.T) {
+	server, _, _, hits := blockingServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithMemoryBudget(1000, BudgetWait)
+	_, err := client.Do(context.Background(), GET("/"))
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) || budgetErr.Err != nil {
+		t.Errorf("err = %v, want an immediate BudgetExceededError", err)
+	}
+	if hits.Count() != 0 {
+		t.Error("request larger than the budget was sent")
+	}
+}
+
+func TestMemoryBudgetGrantsInOrder(t *testing.T) {
+	b := &memoryBudget{max: 100}
+	if err := b.acquire(context.Background(), 80); err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan int64, 2)
+	for i, n := range []int64{50, 10} {
+		go func() {
+			if b.acquire(context.Background(), n) == nil {
+				order <- n
+			}
+		}()
+		// Queue the waiters one after the other
+		for b.stats().Waiting != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	// The small request fits now but must not overtake the one queued first
+	select {
+	case n := <-order:
+		t.Fatalf("request for %d granted ahead of the queue", n)
+	case <-time.After(20 * time.Millisecond):
+	}
+	b.release(30)
+	if n := <-order; n != 50 {
+		t.Fatalf("granted %d first, want 50", n)
+	}
+	if stats := b.stats(); stats.InUse != 100 || stats.Waiting != 1 {
+		t.Errorf("stats = %+v, want the budget full and one waiter left", stats)
+	}
+	b.release(50)
+	if n := <-order; n != 10 {
+		t.Errorf("granted %d, want 10", n)
+	}
+}
@@ -0,0 +1,376 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxCacheBodySize limits the response body stored in the response cache.
+const maxCacheBodySize = 1 << 20
+
+// CachedResponse is a response stored in the response cache.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Vary       []string // Request headers the response varies on, canonicalized
+	StoredAt   time.Time
+}
+
+// CacheStore stores cached responses by key. Implementations must be safe for
+// concurrent use.
+type CacheStore interface {
+	// Get returns the response stored for key, or false if there is none or it expired.
+	Get(ctx context.Context, key string) (CachedResponse, bool, error)
+	// Set stores the response for key for ttl.
+	Set(ctx context.Context, key string, resp CachedResponse, ttl time.Duration) error
+}
+
+// WithResponseCache caches GET responses with status 200 in store, following
+// HTTP caching rules for a private cache.
+//
+// A response is stored for its Cache-Control max-age, or until its Expires date,
+// minus its Age; responses without either, with no-store or no-cache, with
+// "Vary: *" or with bodies over 1 MiB are not stored. Requests sending
+// Cache-Control no-cache or no-store (or Pragma: no-cache) skip the cache.
+//
+// Entries are keyed by method and URL plus, per the response's Vary header, the
+// values of the named request headers, so a response that varies on
+// Accept-Language is cached once per language. The key is computed from the
+// headers the request is sent with, and the Authorization and Cookie headers, an
+// API key header and the client's default headers are always part of it, so
+// credentials never share entries: whether they come from the request, from
+// SetDefaultHeaders(), from WithAPIKey() or SetAPIKey() or from the cookie jar.
+// Headers set by WithHeaderFunc() and hooks are not seen by the key and must not
+// be varied on.
+//
+// Cached responses are returned with Response.Cached set and an Age header.
+// Store errors are logged and the request is sent.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithResponseCache(reqws.NewMemoryCacheStore())
+func (c *Client) WithResponseCache(store CacheStore) *Client {
+	c.cache = store
+	return c
+}
+
+// executeCached serves GET requests from the response cache if one is configured.
+func (c *Client) executeCached(ctx context.Context, config *requestConfig) (*http.Response, error) {
+	if c.cache == nil || config.method != http.MethodGet {
+		return c.executeDedup(ctx, config)
+	}
+	spec := c.cacheSpec(config)
+	if spec == nil {
+		return c.executeDedup(ctx, config)
+	}
+	noStore, noCache := cacheDirectives(spec.Header)
+	noCache = noCache || strings.Contains(strings.ToLower(spec.Header.Get("Pragma")), "no-cache")
+
+	keyHeaders := c.cacheKeyHeaders(config)
+	primary := "cache:" + Fingerprint(spec, nil, FingerprintConfig{Headers: keyHeaders})
+	if !noStore && !noCache {
+		if cached, ok := c.cacheLookup(ctx, primary, spec, keyHeaders); ok {
+			if c.logger != nil {
+				c.logger.Debug("response served from cache", "method", config.method, "path", config.path)
+			}
+			config.cached = true
+			return cached.response(), nil
+		}
+	}
+
+	resp, err := c.executeDedup(ctx, config)
+	if err != nil || noStore || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	ttl, ok := cacheLifetime(resp.Header, time.Now())
+	vary := varyHeaders(resp.Header)
+	if !ok || (len(vary) == 1 && vary[0] == "*") || resp.ContentLength > maxCacheBodySize {
+		return resp, nil
+	}
+
+	body, err := readResponseBody(resp, config)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	// The callback already saw the body; don't replay it when the caller reads it
+	config.responseCallback = nil
+	if len(body) > maxCacheBodySize {
+		return resp, nil
+	}
+
+	entry := CachedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+		Vary:       vary,
+		StoredAt:   time.Now(),
+	}
+	key := primary
+	if len(vary) > 0 {
+		// The primary key holds only the Vary names; the response goes under its variant key
+		key = cacheVariantKey(spec, keyHeaders, vary)
+		if err := c.cache.Set(ctx, primary, CachedResponse{Vary: vary, StoredAt: entry.StoredAt}, ttl); err != nil {
+			c.logCacheError("cache write failed", err)
+			return resp, nil
+		}
+	}
+	if err := c.cache.Set(ctx, key, entry, ttl); err != nil {
+		c.logCacheError("cache write failed", err)
+	}
+	return resp, nil
+}
+
+// cacheLookup returns the cached response for the request, following the Vary
+// names stored under the primary key to the variant.
+func (c *Client) cacheLookup(ctx context.Context, primary string, spec *RequestSpec, keyHeaders []string) (CachedResponse, bool) {
+	entry, ok, err := c.cache.Get(ctx, primary)
+	if err != nil {
+		c.logCacheError("cache lookup failed, sending request", err)
+		return CachedResponse{}, false
+	}
+	if !ok || len(entry.Vary) == 0 {
+		return entry, ok
+	}
+	variant, ok, err := c.cache.Get(ctx, cacheVariantKey(spec, keyHeaders, entry.Vary))
+	if err != nil {
+		c.logCacheError("cache lookup failed, sending request", err)
+		return CachedResponse{}, false
+	}
+	return variant, ok
+}
+
+func (c *Client) logCacheError(msg string, err error) {
+	if c.logger != nil {
+		c.logger.Error(msg, "error", err)
+	}
+}
+
+// cacheSpec describes the request for cache keys with the headers it is sent with:
+// those set by setRequestHeaders() and the cookies of the cookie jar.
+// Returns nil if the request will fail to build.
+func (c *Client) cacheSpec(config *requestConfig) *RequestSpec {
+	var req *http.Request
+	if config.prepared != nil {
+		u := *config.prepared.URL
+		req = &http.Request{Method: config.method, URL: &u, Header: config.prepared.Header.Clone(), Host: config.prepared.Host}
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+	} else {
+		query, err := c.buildQuery(config)
+		if err != nil || config.pathErr != nil {
+			return nil
+		}
+		u, err := url.Parse(c.baseURL + config.path)
+		if err != nil {
+			return nil
+		}
+		u.RawQuery = query.Encode()
+		req = &http.Request{Method: config.method, URL: u, Header: make(http.Header)}
+	}
+	c.setRequestHeaders(req, config, "")
+
+	// http.Client adds the jar's cookies when sending
+	jar := c.client.Jar
+	if config.cookieJarSet {
+		jar = config.cookieJar
+	}
+	if jar != nil {
+		for _, cookie := range jar.Cookies(req.URL) {
+			req.AddCookie(cookie)
+		}
+	}
+	// Requests to one URL with different Host headers can reach different virtual hosts
+	if req.Host != "" {
+		req.Header.Set("Host", req.Host)
+	}
+	return &RequestSpec{Method: config.method, URL: req.URL.String(), Header: req.Header}
+}
+
+// cacheKeyHeaders returns the headers that are part of every cache key of the
+// request: the credentials, the header of the request's or client's API key, the
+// client's default headers and Host if the request overrides it.
+func (c *Client) cacheKeyHeaders(config *requestConfig) []string {
+	headers := []string{"Authorization", "Cookie"}
+	if config.host != "" || config.prepared != nil && config.prepared.Host != "" {
+		headers = append(headers, "Host")
+	}
+	for key := range c.headers {
+		headers = append(headers, key)
+	}
+	apiKey := config.apiKey
+	if apiKey == nil && c.apiKey != nil {
+		c.apiKey.mu.Lock()
+		cfg := c.apiKey.cfg
+		c.apiKey.mu.Unlock()
+		apiKey = &cfg
+	}
+	if apiKey != nil && apiKey.Placement == APIKeyInHeader {
+		headers = append(headers, apiKey.Name)
+	}
+	return headers
+}
+
+// cacheVariantKey returns the key of the response variant selected by the
+// request's values of the vary headers.
+func cacheVariantKey(spec *RequestSpec, keyHeaders, vary []string) string {
+	headers := append(keyHeaders[:len(keyHeaders):len(keyHeaders)], vary...)
+	return "cache-variant:" + Fingerprint(spec, nil, FingerprintConfig{Headers: headers})
+}
+
+// varyHeaders returns the canonical header names listed in the Vary header.
+func varyHeaders(header http.Header) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return []string{"*"}
+			}
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// cacheDirectives reports the no-store and no-cache Cache-Control directives.
+func cacheDirectives(header http.Header) (noStore, noCache bool) {
+	for _, directive := range cacheControl(header) {
+		switch directive.name {
+		case "no-store":
+			noStore = true
+		case "no-cache":
+			noCache = true
+		}
+	}
+	return noStore, noCache
+}
+
+type cacheDirective struct {
+	name, value string
+}
+
+// cacheControl parses the Cache-Control header into lower-cased directives.
+func cacheControl(header http.Header) []cacheDirective {
+	var directives []cacheDirective
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name != "" {
+				directives = append(directives, cacheDirective{strings.ToLower(name), strings.Trim(arg, `"`)})
+			}
+		}
+	}
+	return directives
+}
+
+// cacheLifetime returns how long a response stays fresh, from max-age or
+// Expires, minus its Age. Returns false if it must not be stored.
+func cacheLifetime(header http.Header, now time.Time) (time.Duration, bool) {
+	lifetime, known := time.Duration(0), false
+	for _, directive := range cacheControl(header) {
+		switch directive.name {
+		case "no-store", "no-cache":
+			return 0, false
+		case "max-age":
+			seconds, err := strconv.Atoi(directive.value)
+			if err != nil {
+				return 0, false
+			}
+			lifetime, known = time.Duration(seconds)*time.Second, true
+		}
+	}
+	if !known {
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			return 0, false
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = now
+		}
+		lifetime = expires.Sub(date)
+	}
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+		lifetime -= time.Duration(age) * time.Second
+	}
+	return lifetime, lifetime > 0
+}
+
+// response returns a synthetic response for the cached entry.
+func (r CachedResponse) response() *http.Response {
+	header := r.Header.Clone()
+	age := int(time.Since(r.StoredAt).Seconds())
+	if stored, err := strconv.Atoi(header.Get("Age")); err == nil {
+		age += stored
+	}
+	header.Set("Age", strconv.Itoa(age))
+	return &http.Response{
+		StatusCode:    r.StatusCode,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+	}
+}
+
+// MemoryCacheStore is an in-process CacheStore. Entries are lost on restart.
+type MemoryCacheStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryCacheEntry
+	lastPurge time.Time
+}
+
+type memoryCacheEntry struct {
+	resp    CachedResponse
+	expires time.Time
+}
+
+// NewMemoryCacheStore creates an empty in-memory cache store.
+func NewMemoryCacheStore() *MemoryCacheStore {
+	return &MemoryCacheStore{entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get returns the unexpired response stored for key.
+func (s *MemoryCacheStore) Get(ctx context.Context, key string) (CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return CachedResponse{}, false, nil
+	}
+	return entry.resp, true, nil
+}
+
+// Set stores the response for key for ttl, dropping expired entries at most once a minute.
+func (s *MemoryCacheStore) Set(ctx context.Context, key string, resp CachedResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > time.Minute {
+		for k, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPurge = now
+	}
+	s.entries[key] = memoryCacheEntry{resp: resp, expires: now.Add(ttl)}
+	return nil
+}
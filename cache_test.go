@@ -0,0 +1,309 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// credentialsHandler answers with a cacheable echo of the credentials the
+// request was sent with.
+func credentialsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "max-age=60")
+	w.Write([]byte(r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key") + "|" + r.Header.Get("Cookie")))
+}
+
+func TestResponseCacheSeparatesCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(server *httptest.Server, store CacheStore) (first, second *Client)
+		rotate func(c *Client) // Changes the credentials of first before its second request
+	}{
+		{
+			name: "default Authorization header",
+			setup: func(server *httptest.Server, store CacheStore) (*Client, *Client) {
+				a := NewClient(server.URL, 5*time.Second).WithResponseCache(store).
+					SetDefaultHeaders(http.Header{"Authorization": {"Bearer alice"}})
+				b := NewClient(server.URL, 5*time.Second).WithResponseCache(store).
+					SetDefaultHeaders(http.Header{"Authorization": {"Bearer bob"}})
+				return a, b
+			},
+		},
+		{
+			name: "client API key",
+			setup: func(server *httptest.Server, store CacheStore) (*Client, *Client) {
+				newClient := func(key string) *Client {
+					return NewClient(server.URL, 5*time.Second).WithResponseCache(store).
+						WithAPIKey(APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: key})
+				}
+				return newClient("alice"), newClient("bob")
+			},
+		},
+		{
+			name: "rotated API key",
+			setup: func(server *httptest.Server, store CacheStore) (*Client, *Client) {
+				c := NewClient(server.URL, 5*time.Second).WithResponseCache(store).
+					WithAPIKey(APIKeyConfig{Placement: APIKeyInHeader, Name: "X-Api-Key", Primary: "old"})
+				return c, c
+			},
+			rotate: func(c *Client) { c.SetAPIKey("new", "", time.Time{}) },
+		},
+		{
+			name: "cookie jar session",
+			setup: func(server *httptest.Server, store CacheStore) (*Client, *Client) {
+				u, _ := url.Parse(server.URL)
+				newClient := func(session string) *Client {
+					jar, _ := cookiejar.New(nil)
+					jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: session}})
+					return NewClient(server.URL, 5*time.Second).WithResponseCache(store).WithCookieJar(jar)
+				}
+				return newClient("alice"), newClient("bob")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := recordingServer(t, credentialsHandler)
+			first, second := tt.setup(server, NewMemoryCacheStore())
+			ctx := context.Background()
+
+			resp, err := first.Do(ctx, GET("/profile"))
+			if err != nil {
+				t.Fatalf("first Do: %v", err)
+			}
+			firstBody := string(resp.Body)
+			if tt.rotate != nil {
+				tt.rotate(first)
+			}
+			resp, err = second.Do(ctx, GET("/profile"))
+			if err != nil {
+				t.Fatalf("second Do: %v", err)
+			}
+			if resp.Cached || string(resp.Body) == firstBody {
+				t.Errorf("second credentials got the first response %q (cached: %v)", resp.Body, resp.Cached)
+			}
+			if got := hits.Count(); got != 2 {
+				t.Errorf("server hits = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestResponseCacheReusesEntryForSameCredentials(t *testing.T) {
+	server, hits := recordingServer(t, credentialsHandler)
+	client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore()).
+		SetDefaultHeaders(http.Header{"Authorization": {"Bearer alice"}})
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, GET("/profile")); err != nil {
+		t.Fatalf("first Do: %v", err)
+	}
+	resp, err := client.Do(ctx, GET("/profile"))
+	if err != nil {
+		t.Fatalf("second Do: %v", err)
+	}
+	if !resp.Cached {
+		t.Error("second response was not served from the cache")
+	}
+	if got, want := string(resp.Body), "Bearer alice||"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if got := hits.Count(); got != 1 {
+		t.Errorf("server hits = %d, want 1", got)
+	}
+}
+
+func TestResponseCacheSeparatesHosts(t *testing.T) {
+	server, hits := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(r.Host))
+	})
+	client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore())
+	ctx := context.Background()
+
+	steps := []struct {
+		name       string
+		do         func() (*Response, error)
+		wantBody   string
+		wantCached bool
+	}{
+		{"first host", func() (*Response, error) { return client.Do(ctx, GET("/page"), WithHost("a.example")) }, "a.example", false},
+		{"second host", func() (*Response, error) { return client.Do(ctx, GET("/page"), WithHost("b.example")) }, "b.example", false},
+		{"first host again", func() (*Response, error) { return client.Do(ctx, GET("/page"), WithHost("a.example")) }, "a.example", true},
+		{"URL host", func() (*Response, error) { return client.Do(ctx, GET("/page")) }, strings.TrimPrefix(server.URL, "http://"), false},
+		{"http.Request Host", func() (*Response, error) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/page", nil)
+			if err != nil {
+				return nil, err
+			}
+			req.Host = "b.example"
+			return client.DoRequest(ctx, req)
+		}, "b.example", true},
+	}
+	for _, step := range steps {
+		resp, err := step.do()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if string(resp.Body) != step.wantBody || resp.Cached != step.wantCached {
+			t.Errorf("%s: body %q, cached %v; want %q, %v", step.name, resp.Body, resp.Cached, step.wantBody, step.wantCached)
+		}
+	}
+	if got := hits.Count(); got != 3 {
+		t.Errorf("server hits = %d, want one per host", got)
+	}
+}
+
+func TestResponseCacheSkipsNoStore(t *testing.T) {
+	server, hits := recordingServer(t, credentialsHandler)
+	client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore())
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(ctx, GET("/profile"), WithHeader("Cache-Control", "no-store"))
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if resp.Cached {
+			t.Error("no-store request was served from the cache")
+		}
+	}
+	if got := hits.Count(); got != 2 {
+		t.Errorf("server hits = %d, want 2", got)
+	}
+}
+
+func TestResponseCacheVary(t *testing.T) {
+	server, hits := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Add("Vary", "accept-language")
+		w.Header().Add("Vary", "Accept-Language, X-Tenant")
+		w.Write([]byte(r.Header.Get("Accept-Language") + "|" + r.Header.Get("X-Tenant")))
+	})
+	client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore())
+
+	steps := []struct {
+		lang, tenant string
+		wantCached   bool
+	}{
+		{"en", "a", false},
+		{"de", "a", false},
+		{"en", "a", true},
+		{"de", "a", true},
+		{"en", "b", false},
+		{"en", "b", true},
+	}
+	for i, step := range steps {
+		resp, err := client.Do(context.Background(), GET("/greeting"),
+			WithHeader("Accept-Language", step.lang), WithHeader("X-Tenant", step.tenant))
+		if err != nil {
+			t.Fatalf("step %d: Do: %v", i, err)
+		}
+		if got, want := string(resp.Body), step.lang+"|"+step.tenant; got != want {
+			t.Errorf("step %d: body = %q, want %q", i, got, want)
+		}
+		if resp.Cached != step.wantCached {
+			t.Errorf("step %d: cached = %v, want %v", i, resp.Cached, step.wantCached)
+		}
+		if step.wantCached && resp.Headers.Get("Age") == "" {
+			t.Errorf("step %d: cached response without an Age header", i)
+		}
+	}
+	if got := hits.Count(); got != 3 {
+		t.Errorf("server hits = %d, want one per variant", got)
+	}
+}
+
+func TestResponseCacheSkipsUncacheableResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{"Vary star", http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"Accept, *"}}, http.StatusOK},
+		{"no freshness", http.Header{}, http.StatusOK},
+		{"no-cache", http.Header{"Cache-Control": {"max-age=60, no-cache"}}, http.StatusOK},
+		{"non-200 status", http.Header{"Cache-Control": {"max-age=60"}}, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				w.WriteHeader(tt.status)
+			})
+			client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore())
+
+			for i := 0; i < 2; i++ {
+				if _, err := client.Do(context.Background(), GET("/")); err != nil {
+					t.Fatalf("Do: %v", err)
+				}
+			}
+			if got := hits.Count(); got != 2 {
+				t.Errorf("server hits = %d, want 2", got)
+			}
+		})
+	}
+}
+
+func TestResponseCacheRequestNoCacheRevalidates(t *testing.T) {
+	server, hits := recordingServer(t, credentialsHandler)
+	client := NewClient(server.URL, 5*time.Second).WithResponseCache(NewMemoryCacheStore())
+	ctx := context.Background()
+
+	for _, opt := range []RequestOption{
+		WithHeader("Cache-Control", "no-cache"),
+		WithHeader("Pragma", "no-cache"),
+	} {
+		resp, err := client.Do(ctx, GET("/profile"), opt)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if resp.Cached {
+			t.Error("no-cache request was served from the cache")
+		}
+	}
+	// The last no-cache response was stored
+	resp, err := client.Do(ctx, GET("/profile"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if !resp.Cached || hits.Count() != 2 {
+		t.Errorf("cached = %v after %d hits, want the stored response", resp.Cached, hits.Count())
+	}
+}
+
+func TestCacheLifetime(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+		wantOK bool
+	}{
+		{"max-age", http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute, true},
+		{"max-age minus Age", http.Header{"Cache-Control": {"max-age=60"}, "Age": {"20"}}, 40 * time.Second, true},
+		{"aged out", http.Header{"Cache-Control": {"max-age=60"}, "Age": {"60"}}, 0, false},
+		{"max-age wins over Expires", http.Header{"Cache-Control": {"max-age=5"}, "Expires": {now.Add(time.Hour).Format(http.TimeFormat)}}, 5 * time.Second, true},
+		{"Expires from Date", http.Header{"Date": {now.Add(-time.Hour).Format(http.TimeFormat)}, "Expires": {now.Format(http.TimeFormat)}}, time.Hour, true},
+		{"Expires without Date", http.Header{"Expires": {now.Add(2 * time.Minute).Format(http.TimeFormat)}}, 2 * time.Minute, true},
+		{"invalid Expires", http.Header{"Expires": {"0"}}, 0, false},
+		{"invalid max-age", http.Header{"Cache-Control": {`max-age="soon"`}}, 0, false},
+		{"no-store", http.Header{"Cache-Control": {"max-age=60, No-Store"}}, 0, false},
+		{"nothing", http.Header{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cacheLifetime(tt.header, now)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("cacheLifetime = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCapabilitiesTTL is how long discovered capabilities are cached by default.
+const defaultCapabilitiesTTL = 5 * time.Minute
+
+// Capabilities describes what an endpoint supports, as advertised by its
+// Allow and Accept-Patch headers.
+type Capabilities struct {
+	Methods           map[string]bool // Allowed methods (upper case)
+	PatchContentTypes []string        // Media types accepted by PATCH
+	FetchedAt         time.Time       // When the capabilities were discovered
+
+	notAllowed map[string]bool // Methods rejected with 405 without an Allow header
+}
+
+// Supports reports whether the endpoint allows the given method.
+func (c Capabilities) Supports(method string) bool {
+	return c.Methods[strings.ToUpper(method)]
+}
+
+// rejects reports whether the endpoint is known not to allow the method.
+func (c Capabilities) rejects(method string) bool {
+	return (len(c.Methods) > 0 && !c.Supports(method)) || c.notAllowed[method]
+}
+
+type capabilitiesCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]Capabilities
+}
+
+// WithCapabilitiesTTL sets how long results of Capabilities() are cached per path (default: 5m).
+func (c *Client) WithCapabilitiesTTL(ttl time.Duration) *Client {
+	c.capabilities().ttl = ttl
+	return c
+}
+
+// Capabilities discovers the methods supported by path with an OPTIONS request.
+// Results are cached per path (see WithCapabilitiesTTL).
+//
+// Example:
+//
+//	caps, err := client.Capabilities(ctx, "/documents/42")
+//	if err == nil && caps.Supports("PATCH") {
+//		// send a partial update
+//	}
+func (c *Client) Capabilities(ctx context.Context, path string) (Capabilities, error) {
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	cache := c.capabilities()
+	if caps, ok := cache.get(path); ok {
+		return caps, nil
+	}
+
+	resp, err := c.Do(ctx, OPTIONS(path))
+	if err != nil {
+		return Capabilities{}, err
+	}
+	if !resp.IsSuccess() {
+		return Capabilities{}, NewHTTPError(resp.StatusCode, resp.Body)
+	}
+
+	caps := parseCapabilities(resp.Headers)
+	cache.set(path, caps)
+	return caps, nil
+}
+
+// WithFallbackMethod switches the request from primary to fallback (e.g. PATCH to PUT)
+// when the endpoint does not allow primary. The decision uses the cached result of
+// Capabilities(); if the server still answers 405 Method Not Allowed, the cache is
+// updated and the request is sent once more with the fallback method.
+//
+// Only applies when the request method equals primary.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.PATCH("/documents/42"),
+//		reqws.WithJSON(changes),
+//		reqws.WithFallbackMethod("PATCH", "PUT"),
+//	)
+func WithFallbackMethod(primary, fallback string) RequestOption {
+	return func(c *requestConfig) {
+		c.fallbackPrimary = strings.ToUpper(primary)
+		c.fallbackMethod = strings.ToUpper(fallback)
+	}
+}
+
+// execute runs the request, applying WithFallbackMethod() if configured.
+func (c *Client) execute(ctx context.Context, config *requestConfig) (*http.Response, error) {
+	if config.fallbackMethod == "" || config.method != config.fallbackPrimary {
+		return c.executeWithRetry(ctx, config)
+	}
+
+	if caps, err := c.Capabilities(ctx, config.path); err == nil && caps.rejects(config.method) {
+		config.method = config.fallbackMethod
+		return c.executeWithRetry(ctx, config)
+	}
+
+	resp, err := c.executeWithRetry(ctx, config)
+	if err != nil || resp.StatusCode != http.StatusMethodNotAllowed {
+		return resp, err
+	}
+
+	// Learn from the 405 and retry once with the fallback method
+	c.capabilities().markUnsupported(config.path, config.method, resp.Header)
+	resp.Body.Close()
+	if c.logger != nil {
+		c.logger.Info("method not allowed, retrying with fallback method",
+			"method", config.method,
+			"fallback", config.fallbackMethod,
+			"path", config.path,
+		)
+	}
+	config.method = config.fallbackMethod
+	return c.executeWithRetry(ctx, config)
+}
+
+// capabilities returns the client's capabilities cache, creating it on first use.
+func (c *Client) capabilities() *capabilitiesCache {
+	c.capsOnce.Do(func() {
+		c.capsCache = &capabilitiesCache{
+			ttl:     defaultCapabilitiesTTL,
+			entries: make(map[string]Capabilities),
+		}
+	})
+	return c.capsCache
+}
+
+func (cc *capabilitiesCache) get(path string) (Capabilities, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	caps, ok := cc.entries[path]
+	if !ok || time.Since(caps.FetchedAt) > cc.ttl {
+		return Capabilities{}, false
+	}
+	return caps, true
+}
+
+func (cc *capabilitiesCache) set(path string, caps Capabilities) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.entries[path] = caps
+}
+
+// markUnsupported records a 405 for method, preferring the Allow header of the
+// 405 response when the server sent one.
+func (cc *capabilitiesCache) markUnsupported(path, method string, header http.Header) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if header.Get("Allow") != "" {
+		cc.entries[path] = parseCapabilities(header)
+		return
+	}
+
+	// Copy the maps, earlier results may still be in use by callers
+	old := cc.entries[path]
+	caps := Capabilities{
+		Methods:           make(map[string]bool, len(old.Methods)),
+		PatchContentTypes: old.PatchContentTypes,
+		FetchedAt:         time.Now(),
+		notAllowed:        map[string]bool{method: true},
+	}
+	for m := range old.Methods {
+		if m != method {
+			caps.Methods[m] = true
+		}
+	}
+	for m := range old.notAllowed {
+		caps.notAllowed[m] = true
+	}
+	cc.entries[path] = caps
+}
+
+// parseCapabilities reads the Allow and Accept-Patch headers.
+func parseCapabilities(header http.Header) Capabilities {
+	caps := Capabilities{
+		Methods:   make(map[string]bool),
+		FetchedAt: time.Now(),
+	}
+	for _, value := range header.Values("Allow") {
+		for _, method := range strings.Split(value, ",") {
+			if method = strings.TrimSpace(method); method != "" {
+				caps.Methods[strings.ToUpper(method)] = true
+			}
+		}
+	}
+	for _, value := range header.Values("Accept-Patch") {
+		for _, mediaType := range strings.Split(value, ",") {
+			if mediaType = strings.TrimSpace(mediaType); mediaType != "" {
+				caps.PatchContentTypes = append(caps.PatchContentTypes, mediaType)
+			}
+		}
+	}
+	return caps
+}
@@ -0,0 +1,129 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// allowMethods returns a handler that allows the given methods, answering
+// OPTIONS with an Allow header when advertise is set and 404 otherwise.
+func allowMethods(advertise bool, allowed ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			if !advertise {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			w.Header().Set("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+			return
+		}
+		for _, method := range allowed {
+			if r.Method == method {
+				return
+			}
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// sentMethods returns the methods of the requests in log, comma separated.
+func sentMethods(log *requestLog) string {
+	var methods []string
+	for _, req := range log.All() {
+		methods = append(methods, req.Method)
+	}
+	return strings.Join(methods, ",")
+}
+
+func TestCapabilities(t *testing.T) {
+	server, log := recordingServer(t, allowMethods(true, "GET", "patch"))
+	client := NewClient(server.URL, 5*time.Second)
+
+	caps, err := client.Capabilities(context.Background(), "documents/42")
+	if err != nil {
+		t.Fatalf("Capabilities: %v", err)
+	}
+	if !caps.Supports("get") || !caps.Supports("PATCH") || caps.Supports("PUT") {
+		t.Errorf("Methods = %v, want GET and PATCH", caps.Methods)
+	}
+	if len(caps.PatchContentTypes) != 2 || caps.PatchContentTypes[0] != "application/merge-patch+json" {
+		t.Errorf("PatchContentTypes = %v", caps.PatchContentTypes)
+	}
+
+	if _, err := client.Capabilities(context.Background(), "/documents/42"); err != nil {
+		t.Fatalf("cached Capabilities: %v", err)
+	}
+	if got := sentMethods(log); got != "OPTIONS" {
+		t.Errorf("requests = %s, want a single OPTIONS", got)
+	}
+}
+
+func TestCapabilitiesTTL(t *testing.T) {
+	server, log := recordingServer(t, allowMethods(true, "GET"))
+	client := NewClient(server.URL, 5*time.Second).WithCapabilitiesTTL(10 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Capabilities(context.Background(), "/"); err != nil {
+			t.Fatalf("Capabilities: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := sentMethods(log); got != "OPTIONS,OPTIONS" {
+		t.Errorf("requests = %s, want the expired result fetched again", got)
+	}
+}
+
+func TestCapabilitiesError(t *testing.T) {
+	server, _ := recordingServer(t, allowMethods(false))
+	if _, err := NewClient(server.URL, 5*time.Second).Capabilities(context.Background(), "/"); err == nil {
+		t.Fatal("expected an error for a 404 OPTIONS response")
+	}
+}
+
+func TestFallbackMethod(t *testing.T) {
+	tests := []struct {
+		name      string
+		advertise bool
+		want      string
+	}{
+		{"advertised", true, "OPTIONS,PUT,PUT"},
+		{"learned from 405", false, "OPTIONS,PATCH,PUT,PUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, allowMethods(tt.advertise, "GET", "PUT"))
+			client := NewClient(server.URL, 5*time.Second)
+
+			for i := 0; i < 2; i++ {
+				resp, err := client.Do(context.Background(), PATCH("/documents/42"),
+					WithJSON(map[string]string{"title": "draft"}),
+					WithFallbackMethod("patch", "put"))
+				if err != nil {
+					t.Fatalf("Do: %v", err)
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Errorf("status = %d, want 200", resp.StatusCode)
+				}
+			}
+			if got := sentMethods(log); got != tt.want {
+				t.Errorf("requests = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackMethodOnlyForPrimary(t *testing.T) {
+	server, log := recordingServer(t, allowMethods(true, "POST"))
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/"),
+		WithFallbackMethod("PATCH", "PUT"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := sentMethods(log); got != "POST" {
+		t.Errorf("requests = %s, want the POST sent without discovery", got)
+	}
+}
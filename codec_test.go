@@ -0,0 +1,170 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// linesCodec encodes a []string as newline-separated lines.
+type linesCodec struct {
+	marshals *atomic.Int32
+}
+
+func (c linesCodec) Marshal(v interface{}) ([]byte, error) {
+	if c.marshals != nil {
+		c.marshals.Add(1)
+	}
+	lines, ok := v.([]string)
+	if !ok {
+		return nil, errors.New("not a []string")
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func (linesCodec) Unmarshal(data []byte, v interface{}) error {
+	lines, ok := v.(*[]string)
+	if !ok {
+		return errors.New("not a *[]string")
+	}
+	*lines = strings.Split(string(data), "\n")
+	return nil
+}
+
+// codecServer returns a server that echoes the request body with its Content-Type.
+func codecServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		io.Copy(w, r.Body)
+	})
+	return server
+}
+
+func TestWithBodyAs(t *testing.T) {
+	server := codecServer(t)
+	client := NewClient(server.URL, 5*time.Second).RegisterCodec("Application/X-Lines", linesCodec{})
+	tests := []struct {
+		name        string
+		contentType string
+		body        interface{}
+		wantType    string
+		wantBody    string
+		wantErr     string
+	}{
+		{"registered codec", "application/x-lines; charset=utf-8", []string{"a", "b"}, "application/x-lines; charset=utf-8", "a\nb", ""},
+		{"unregistered type is JSON", "application/x-other", []string{"a"}, "application/json", `["a"]`, ""},
+		{"codec error", "application/x-lines", 42, "", "", "failed to marshal application/x-lines body: not a []string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Do(context.Background(), POST("/"), WithBodyAs(tt.contentType, tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := resp.Headers.Get("Content-Type"); got != tt.wantType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantType)
+			}
+			if got := resp.String(); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestWithBodyAsEncodesEveryAttempt(t *testing.T) {
+	server, attempts := recordingServer(t, failFirst(1))
+	var marshals atomic.Int32
+	client := NewClient(server.URL, 5*time.Second).RegisterCodec("application/x-lines", linesCodec{marshals: &marshals})
+
+	if _, err := client.Do(context.Background(), POST("/"), WithBodyAs("application/x-lines", []string{"a"}), WithRetry(fastRetry(1))); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := marshals.Load(); got != 2 {
+		t.Errorf("Marshal calls = %d, want one per attempt", got)
+	}
+	for _, req := range attempts.All() {
+		if req.Body != "a" {
+			t.Errorf("attempt body = %q", req.Body)
+		}
+	}
+}
+
+func TestDecodeAs(t *testing.T) {
+	server := codecServer(t)
+	client := NewClient(server.URL, 5*time.Second).RegisterCodec("application/x-lines", linesCodec{})
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, POST("/"), WithRawBody([]byte("x\ny"), "application/x-lines; charset=utf-8"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	var lines []string
+	if err := resp.DecodeAs("", &lines); err != nil || strings.Join(lines, ",") != "x,y" {
+		t.Errorf("DecodeAs by Content-Type = %q, %v", lines, err)
+	}
+	var m map[string]int
+	if err := resp.DecodeAs("application/x-lines", &m); err == nil || !strings.Contains(err.Error(), "failed to decode application/x-lines body") {
+		t.Errorf("DecodeAs error = %v, want the codec's error", err)
+	}
+
+	resp, err = client.Do(ctx, POST("/"), WithRawBody([]byte(`{"a":1}`), "application/x-unknown"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if err := resp.DecodeAs("", &m); err != nil || m["a"] != 1 {
+		t.Errorf("DecodeAs without a codec = %v, %v; want JSON", m, err)
+	}
+	// An explicit type overrides the response's
+	if err := resp.DecodeAs("application/x-lines", &lines); err != nil || lines[0] != `{"a":1}` {
+		t.Errorf("DecodeAs with an explicit type = %q, %v", lines, err)
+	}
+}
+
+func TestRegisterCodecReplacesJSON(t *testing.T) {
+	server := codecServer(t)
+	client := NewClient(server.URL, 5*time.Second).RegisterCodec("application/json", linesCodec{})
+
+	resp, err := client.Do(context.Background(), POST("/"), WithBodyAs("application/json", []string{"a", "b"}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	var lines []string
+	if err := resp.DecodeAs("", &lines); err != nil || strings.Join(lines, ",") != "a,b" {
+		t.Errorf("round trip = %q, %v", lines, err)
+	}
+}
+
+func TestRegisterCodecWhileInFlight(t *testing.T) {
+	server := codecServer(t)
+	client := NewClient(server.URL, 5*time.Second)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.RegisterCodec("application/x-lines", linesCodec{})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := client.Do(context.Background(), POST("/"), WithBodyAs("application/x-lines", []string{"a"})); err != nil {
+				t.Errorf("Do: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
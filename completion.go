@@ -0,0 +1,178 @@
+package reqws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// completionDone is the sentinel that ends an OpenAI-compatible completion stream.
+const completionDone = "[DONE]"
+
+// maxCompletionEventSize limits the size of a single server-sent event line.
+const maxCompletionEventSize = 1 << 20
+
+// CompletionResult holds metadata collected from a streamed completion.
+type CompletionResult struct {
+	FinishReason string          // finish_reason of the last choice that reported one
+	Usage        json.RawMessage // usage object from the stream, nil if the provider sent none
+	Chunks       int             // Number of data chunks received
+	Done         bool            // True if the stream ended with the [DONE] sentinel
+	Duration     time.Duration   // Time from sending the request to the end of the stream
+}
+
+// CompletionError is returned when the provider reports an error inside the stream
+// after the HTTP response has already started.
+type CompletionError struct {
+	Message string
+	Body    json.RawMessage // The raw error object
+}
+
+func (e *CompletionError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("completion stream error: %s", e.Message)
+	}
+	return fmt.Sprintf("completion stream error: %s", e.Body)
+}
+
+// completionChunk is the subset of a streamed chunk read by StreamCompletion.
+type completionChunk struct {
+	Choices []struct {
+		Delta        json.RawMessage `json:"delta"`
+		FinishReason *string         `json:"finish_reason"`
+	} `json:"choices"`
+	Usage json.RawMessage `json:"usage"`
+	Error json.RawMessage `json:"error"`
+}
+
+// StreamCompletion POSTs reqBody as JSON to an OpenAI-compatible streaming endpoint and
+// calls onDelta with the delta of the first choice of every chunk until the [DONE] sentinel.
+// The request body must ask for streaming itself (e.g. "stream": true).
+//
+// Non-2xx responses are returned as *HTTPError with the provider's JSON error body.
+// An error object inside the stream is returned as *CompletionError. If onDelta returns
+// an error or ctx is cancelled, the connection is closed so the provider stops generating,
+// and that error is returned along with the metadata collected so far.
+//
+// The client timeout covers the whole stream; use a client without a timeout (or a
+// longer one) for long generations and bound them with ctx instead.
+//
+// Example:
+//
+//	result, err := client.StreamCompletion(ctx, map[string]interface{}{
+//		"model":    "gpt-4o-mini",
+//		"stream":   true,
+//		"messages": messages,
+//	}, func(delta json.RawMessage) error {
+//		var d struct{ Content string `json:"content"` }
+//		if err := json.Unmarshal(delta, &d); err != nil {
+//			return err
+//		}
+//		fmt.Print(d.Content)
+//		return nil
+//	}, reqws.WithPath("/v1/chat/completions"), reqws.WithBearerToken(apiKey))
+func (c *Client) StreamCompletion(ctx context.Context, reqBody interface{}, onDelta func(delta json.RawMessage) error, opts ...RequestOption) (*CompletionResult, error) {
+	defaults := []RequestOption{WithJSON(reqBody), WithHeader("Accept", "text/event-stream")}
+	config := newRequestConfig(http.MethodPost, append(defaults, opts...))
+	config.streamBufferSize = maxCompletionEventSize
+
+	start := time.Now()
+	result := &CompletionResult{}
+
+	resp, err := c.execute(ctx, config)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return result, err
+		}
+		return result, NewHTTPError(resp.StatusCode, body)
+	}
+
+	err = readCompletionStream(ctx, resp.Body, result, onDelta)
+	result.Duration = time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	return result, err
+}
+
+// readCompletionStream parses server-sent events from body until the [DONE] sentinel or EOF.
+func readCompletionStream(ctx context.Context, body io.Reader, result *CompletionResult, onDelta func(json.RawMessage) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCompletionEventSize)
+
+	var data bytes.Buffer
+	for {
+		more := scanner.Scan()
+		line := scanner.Bytes()
+
+		// Lines starting with "data:" are accumulated until a blank line ends the event
+		if more && len(line) > 0 {
+			if value, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.Write(bytes.TrimPrefix(value, []byte(" ")))
+			}
+			continue
+		}
+
+		if data.Len() > 0 {
+			if bytes.Equal(data.Bytes(), []byte(completionDone)) {
+				result.Done = true
+				return nil
+			}
+			if err := handleCompletionChunk(data.Bytes(), result, onDelta); err != nil {
+				return err
+			}
+			data.Reset()
+		}
+
+		if !more {
+			return scanner.Err()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// handleCompletionChunk decodes one data chunk, records its metadata and passes the delta to onDelta.
+func handleCompletionChunk(data []byte, result *CompletionResult, onDelta func(json.RawMessage) error) error {
+	var chunk completionChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return fmt.Errorf("failed to decode completion chunk: %w", err)
+	}
+	result.Chunks++
+
+	if len(chunk.Error) > 0 && string(chunk.Error) != "null" {
+		var detail struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(chunk.Error, &detail)
+		return &CompletionError{Message: detail.Message, Body: chunk.Error}
+	}
+	if len(chunk.Usage) > 0 && string(chunk.Usage) != "null" {
+		result.Usage = chunk.Usage
+	}
+	for _, choice := range chunk.Choices {
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			result.FinishReason = *choice.FinishReason
+		}
+	}
+
+	if len(chunk.Choices) == 0 || len(chunk.Choices[0].Delta) == 0 {
+		return nil
+	}
+	return onDelta(chunk.Choices[0].Delta)
+}
@@ -0,0 +1,132 @@
+package reqws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// completionServer returns a server that checks the request is a streaming
+// completion request and answers with the given SSE stream.
+func completionServer(t *testing.T, stream string) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Accept") != "text/event-stream" || string(body) != `{"stream":true}` {
+			t.Errorf("request = %s %s Accept %q", r.Method, body, r.Header.Get("Accept"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, stream)
+	})
+	return server
+}
+
+func TestStreamCompletion(t *testing.T) {
+	server := completionServer(t, ": keep-alive\n\n"+
+		`data: {"choices":[{"delta":{"content":"Hel"}}]}`+"\n\n"+
+		`data: {"choices":[{"delta":{"content":"lo"},`+"\n"+`data: "finish_reason":"stop"}]}`+"\n\n"+
+		`data: {"choices":[],"usage":{"total_tokens":9}}`+"\n\n"+
+		"data: [DONE]\n\n"+
+		`data: {"choices":[{"delta":{"content":"ignored"}}]}`+"\n\n")
+
+	var text strings.Builder
+	result, err := NewClient(server.URL, 5*time.Second).StreamCompletion(context.Background(),
+		map[string]bool{"stream": true},
+		func(delta json.RawMessage) error {
+			var d struct{ Content string }
+			if err := json.Unmarshal(delta, &d); err != nil {
+				return err
+			}
+			text.WriteString(d.Content)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("StreamCompletion: %v", err)
+	}
+	if text.String() != "Hello" {
+		t.Errorf("text = %q, want Hello", text.String())
+	}
+	if !result.Done || result.Chunks != 3 || result.FinishReason != "stop" || string(result.Usage) != `{"total_tokens":9}` {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestStreamCompletionErrors(t *testing.T) {
+	stop := errors.New("enough")
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		onDelta func(json.RawMessage) error
+		check   func(t *testing.T, result *CompletionResult, err error)
+	}{
+		{
+			name: "HTTP error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"error":{"message":"bad key"}}`, http.StatusUnauthorized)
+			},
+			check: func(t *testing.T, _ *CompletionResult, err error) {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+					t.Errorf("err = %v, want a 401 HTTPError", err)
+				}
+			},
+		},
+		{
+			name: "error in stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `data: {"choices":[{"delta":{}}]}`+"\n\n"+`data: {"error":{"message":"overloaded"}}`+"\n\n")
+			},
+			check: func(t *testing.T, result *CompletionResult, err error) {
+				var completionErr *CompletionError
+				if !errors.As(err, &completionErr) || completionErr.Message != "overloaded" {
+					t.Errorf("err = %v, want a CompletionError", err)
+				}
+				if result.Chunks != 2 || result.Done {
+					t.Errorf("result = %+v", result)
+				}
+			},
+		},
+		{
+			name: "stopped by callback",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 3; i++ {
+					io.WriteString(w, `data: {"choices":[{"delta":{"content":"x"}}]}`+"\n\n")
+				}
+			},
+			onDelta: func(json.RawMessage) error { return stop },
+			check: func(t *testing.T, result *CompletionResult, err error) {
+				if !errors.Is(err, stop) || result.Chunks != 1 {
+					t.Errorf("err = %v after %d chunks, want the callback's error after 1", err, result.Chunks)
+				}
+			},
+		},
+		{
+			name: "ended without DONE",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, `data: {"choices":[{"delta":{}}]}`)
+			},
+			check: func(t *testing.T, result *CompletionResult, err error) {
+				if err != nil || result.Done || result.Chunks != 1 {
+					t.Errorf("result = %+v, err = %v; want 1 chunk without Done", result, err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testServer(t, tt.handler)
+			onDelta := tt.onDelta
+			if onDelta == nil {
+				onDelta = func(json.RawMessage) error { return nil }
+			}
+			result, err := NewClient(server.URL, 5*time.Second).StreamCompletion(context.Background(), map[string]bool{"stream": true}, onDelta)
+			tt.check(t, result, err)
+		})
+	}
+}
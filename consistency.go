@@ -0,0 +1,160 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// TokenInjector returns the request option that attaches a consistency token to a request.
+type TokenInjector func(token string) RequestOption
+
+// ConsistencyConfig defines how a ConsistencySession captures and propagates tokens.
+type ConsistencyConfig struct {
+	Extract CursorExtractor       // Extracts the token from responses (default: X-Consistency-Token header)
+	Inject  TokenInjector         // Attaches the token to reads (default: X-Consistency-Token header)
+	Compare func(a, b string) int // Orders tokens; the greater one is kept (default: latest wins)
+	TTL     time.Duration         // How long a captured token stays valid (0 = forever)
+}
+
+// TokenInHeader attaches the consistency token as a request header.
+func TokenInHeader(name string) TokenInjector {
+	return func(token string) RequestOption {
+		return func(c *requestConfig) {
+			c.headers.Set(name, token)
+		}
+	}
+}
+
+// TokenInQuery attaches the consistency token as a query parameter.
+func TokenInQuery(param string) TokenInjector {
+	return func(token string) RequestOption {
+		return func(c *requestConfig) {
+			c.addQuery(func(_ *Client, query url.Values) error {
+				query.Set(param, token)
+				return nil
+			})
+		}
+	}
+}
+
+// ConsistencySession provides read-your-writes consistency on top of a Client.
+// Tokens returned by successful responses are captured into the session and
+// attached to subsequent reads (GET, HEAD and OPTIONS requests) made through it.
+//
+// A ConsistencySession is safe for concurrent use.
+type ConsistencySession struct {
+	client *Client
+	config ConsistencyConfig
+
+	mu       sync.Mutex
+	token    string
+	captured time.Time
+}
+
+// ConsistencySession creates a new read-your-writes session backed by the client.
+//
+// Example:
+//
+//	sess := client.ConsistencySession(reqws.ConsistencyConfig{
+//		Extract: reqws.CursorFromJSONPath("meta.consistency_token"),
+//		Inject:  reqws.TokenInQuery("consistency_token"),
+//		TTL:     time.Minute,
+//	})
+//	sess.Do(ctx, reqws.POST("/orders"), reqws.WithJSON(order))
+//	sess.Do(ctx, reqws.GET("/orders")) // sees the new order
+func (c *Client) ConsistencySession(config ConsistencyConfig) *ConsistencySession {
+	if config.Extract == nil {
+		config.Extract = CursorFromHeader("X-Consistency-Token")
+	}
+	if config.Inject == nil {
+		config.Inject = TokenInHeader("X-Consistency-Token")
+	}
+	return &ConsistencySession{
+		client: c,
+		config: config,
+	}
+}
+
+// Token returns the current consistency token, or an empty string if there is
+// none or it has expired. Use it to propagate the session to other services.
+func (s *ConsistencySession) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.config.TTL > 0 && time.Since(s.captured) > s.config.TTL {
+		s.token = ""
+	}
+	return s.token
+}
+
+// SetToken offers a token received from elsewhere, e.g. another service.
+// It is kept following the same comparison rules as captured tokens.
+func (s *ConsistencySession) SetToken(token string) {
+	s.observe(token)
+}
+
+// Do executes the request like Client.Do, attaching the session token to reads
+// and capturing any token from successful responses.
+func (s *ConsistencySession) Do(ctx context.Context, opts ...RequestOption) (*Response, error) {
+	if token := s.Token(); token != "" && isReadRequest(opts) {
+		opts = append(opts[:len(opts):len(opts)], s.config.Inject(token))
+	}
+
+	resp, err := s.client.Do(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.IsSuccess() {
+		token, err := s.config.Extract(resp)
+		if err != nil {
+			return resp, err
+		}
+		s.observe(token)
+	}
+	return resp, nil
+}
+
+// Request executes the request like Client.Request, attaching the session token
+// to reads and capturing any token from successful responses.
+func (s *ConsistencySession) Request(ctx context.Context, opts ...RequestOption) ([]byte, error) {
+	resp, err := s.Do(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return resp.Body, s.client.decodeHTTPError(resp.StatusCode, resp.Body)
+	}
+	return resp.Body, nil
+}
+
+// observe stores the token if it is newer than the current one.
+func (s *ConsistencySession) observe(token string) {
+	if token == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired := s.config.TTL > 0 && time.Since(s.captured) > s.config.TTL
+	if s.token == "" || expired || s.config.Compare == nil || s.config.Compare(token, s.token) >= 0 {
+		s.token = token
+		s.captured = time.Now()
+	}
+}
+
+// isReadRequest reports whether the options describe a read-only request.
+func isReadRequest(opts []RequestOption) bool {
+	config := newRequestConfig(http.MethodGet, opts)
+
+	switch config.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// issueTokens returns a handler that answers every write with an increasing
+// consistency token.
+func issueTokens() http.HandlerFunc {
+	var version atomic.Int32
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("X-Consistency-Token", strconv.Itoa(int(version.Add(1))))
+		}
+	}
+}
+
+// readTokens returns the token each read in log was sent with.
+func readTokens(log *requestLog) []string {
+	var tokens []string
+	for _, req := range log.All() {
+		if req.Method == http.MethodGet {
+			query, _ := url.ParseQuery(req.Query)
+			tokens = append(tokens, req.Header.Get("X-Consistency-Token")+query.Get("token"))
+		}
+	}
+	return tokens
+}
+
+func TestConsistencySessionReadYourWrites(t *testing.T) {
+	server, log := recordingServer(t, issueTokens())
+	client := NewClient(server.URL, 5*time.Second)
+	sess := client.ConsistencySession(ConsistencyConfig{})
+	ctx := context.Background()
+
+	if _, err := sess.Do(ctx, GET("/orders")); err != nil {
+		t.Fatalf("read before write: %v", err)
+	}
+	if _, err := sess.Do(ctx, POST("/orders"), WithJSON(map[string]int{"id": 1})); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := sess.Do(ctx, GET("/orders")); err != nil {
+		t.Fatalf("read after write: %v", err)
+	}
+	// Requests made without the session are not affected
+	if _, err := client.Do(ctx, GET("/orders")); err != nil {
+		t.Fatalf("read outside the session: %v", err)
+	}
+
+	if got, want := strings.Join(readTokens(log), ","), ",1,"; got != want {
+		t.Errorf("tokens sent with reads = %q, want %q", got, want)
+	}
+	if got := sess.Token(); got != "1" {
+		t.Errorf("Token() = %q, want 1", got)
+	}
+}
+
+func TestConsistencySessionTokenInQuery(t *testing.T) {
+	server, log := recordingServer(t, issueTokens())
+	sess := NewClient(server.URL, 5*time.Second).ConsistencySession(ConsistencyConfig{
+		Inject: TokenInQuery("token"),
+	})
+	sess.SetToken("7")
+	if _, err := sess.Do(context.Background(), GET("/orders"), WithQueryParam("token", "stale")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := readTokens(log); len(got) != 1 || got[0] != "7" {
+		t.Errorf("token sent = %q, want 7 replacing the request's value", got)
+	}
+}
+
+func TestConsistencySessionCompareKeepsNewest(t *testing.T) {
+	sess := NewClient("http://unused", time.Second).ConsistencySession(ConsistencyConfig{
+		Compare: func(a, b string) int {
+			x, _ := strconv.Atoi(a)
+			y, _ := strconv.Atoi(b)
+			return x - y
+		},
+	})
+	for _, token := range []string{"5", "3", "9", "8"} {
+		sess.SetToken(token)
+	}
+	if got := sess.Token(); got != "9" {
+		t.Errorf("Token() = %q, want 9", got)
+	}
+}
+
+func TestConsistencySessionTokenExpires(t *testing.T) {
+	sess := NewClient("http://unused", time.Second).ConsistencySession(ConsistencyConfig{TTL: 20 * time.Millisecond})
+	sess.SetToken("1")
+	if got := sess.Token(); got != "1" {
+		t.Fatalf("Token() = %q, want 1", got)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if got := sess.Token(); got != "" {
+		t.Errorf("Token() after TTL = %q, want none", got)
+	}
+}
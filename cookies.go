@@ -0,0 +1,319 @@
+package reqws
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// CookieDuplicateMode controls which of several Set-Cookie headers for the same
+// cookie (name, domain and path) in one response is stored.
+type CookieDuplicateMode int
+
+const (
+	CookieDuplicateLast   CookieDuplicateMode = iota // Store the last one, like browsers (default)
+	CookieDuplicateFirst                             // Store the first one and ignore the rest
+	CookieDuplicateReject                            // Store none of them and report a violation
+)
+
+// CookiePolicy restricts which cookies the client's jar accepts. The zero value
+// accepts every cookie the jar would.
+type CookiePolicy struct {
+	RequireSecure     bool                // Reject cookies without the Secure attribute
+	RequireHTTPOnly   bool                // Reject cookies without the HttpOnly attribute
+	HostOnly          bool                // Reject cookies with a Domain attribute, which scopes them to a parent domain
+	AllowedDomains    []string            // Accept only cookies for these domains; ".example.com" also allows subdomains (default: any)
+	MaxCookiesPerHost int                 // Reject new cookie names beyond this many per host (0 = no cap)
+	MaxCookieSize     int                 // Reject cookies whose name and value exceed this many bytes (0 = no cap)
+	Duplicates        CookieDuplicateMode // Handling of repeated Set-Cookie headers in one response
+
+	// Debug logs every accepted and rejected cookie through the client's Logger.
+	// Only cookie names are logged, never values.
+	Debug bool
+
+	// OnViolation is called for every rejected cookie, e.g. for alerting.
+	OnViolation func(CookieViolation)
+}
+
+// CookieViolation describes a cookie rejected by the cookie policy.
+type CookieViolation struct {
+	URL    string // URL of the response that set the cookie
+	Name   string // Cookie name; the value is not included
+	Domain string // Domain the cookie would apply to
+	Reason string // e.g. "missing Secure"
+}
+
+// WithCookieJar stores cookies from responses and sends them on later requests.
+// Pass nil for an in-memory jar. A policy set with WithCookiePolicy() keeps
+// applying to the new jar.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://app.example.com", 30*time.Second).
+//		WithCookieJar(nil)
+func (c *Client) WithCookieJar(jar http.CookieJar) *Client {
+	if jar == nil {
+		jar, _ = cookiejar.New(nil)
+	}
+	if c.cookies != nil {
+		c.cookies.setJar(jar)
+		return c
+	}
+	c.client.Jar = jar
+	return c
+}
+
+// SetCookieJar sets the jar that stores cookies from responses and sends them on
+// later requests. Use a jar from net/http/cookiejar, e.g. cookiejar.New(
+// &cookiejar.Options{PublicSuffixList: publicsuffix.List}), unless you need
+// custom storage. Unlike Client.WithCookieJar(), it replaces a policy set with
+// WithCookiePolicy(), and nil removes the client's jar instead of creating one.
+//
+// Example:
+//
+//	jar, _ := cookiejar.New(nil)
+//	client := reqws.NewClient("https://shop.example.com", 30*time.Second).
+//		SetCookieJar(jar)
+//
+//	// The session cookie from the login response is sent with later requests
+//	_, err := client.Do(ctx, reqws.POST("/login"), reqws.WithFormBody(credentials))
+func (c *Client) SetCookieJar(jar http.CookieJar) *Client {
+	c.cookies = nil
+	c.client.Jar = jar
+	return c
+}
+
+// WithCookieJar uses jar for this request only, instead of the client's jar:
+// cookies from the response (and redirects) are stored in it and cookies from it
+// are sent. nil sends the request without any jar. A policy set with
+// Client.WithCookiePolicy() does not apply. Use a jar from net/http/cookiejar
+// unless you need custom storage.
+//
+// Example:
+//
+//	jar, _ := cookiejar.New(nil)
+//	_, err := client.Do(ctx, reqws.POST("/login"), reqws.WithFormBody(credentials), reqws.WithCookieJar(jar))
+//	if err != nil {
+//		return err
+//	}
+//	body, err := client.Request(ctx, reqws.GET("/orders"), reqws.WithCookieJar(jar))
+func WithCookieJar(jar http.CookieJar) RequestOption {
+	return func(c *requestConfig) {
+		c.cookieJar, c.cookieJarSet = jar, true
+	}
+}
+
+// WithCookie sends a cookie with this request, e.g. for APIs that take a token as
+// a cookie, without setting up a cookie jar. Each call adds one cookie; cookies
+// from the client's jar are sent as well.
+//
+// Example:
+//
+//	body, err := client.Request(ctx,
+//		reqws.GET("/account"),
+//		reqws.WithCookie("session_token", token),
+//	)
+func WithCookie(name, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.cookies = append(c.cookies, &http.Cookie{Name: name, Value: value})
+	}
+}
+
+// WithCookiePolicy filters the cookies stored in the client's jar, enabling an
+// in-memory jar if none is set. Rejected cookies are never stored, so they are
+// never resent, including across https-to-http redirects. Cookies set by
+// WebSocket handshakes are not stored.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://tenant-a.api.example.com", 30*time.Second).
+//		WithCookiePolicy(reqws.CookiePolicy{
+//			RequireSecure:  true,
+//			HostOnly:       true,
+//			AllowedDomains: []string{"tenant-a.api.example.com"},
+//			MaxCookieSize:  4096,
+//			OnViolation: func(v reqws.CookieViolation) {
+//				log.Printf("cookie %s rejected: %s", v.Name, v.Reason)
+//			},
+//		})
+func (c *Client) WithCookiePolicy(policy CookiePolicy) *Client {
+	jar := c.client.Jar
+	if c.cookies != nil {
+		jar = c.cookies.jar
+	}
+	if jar == nil {
+		jar, _ = cookiejar.New(nil)
+	}
+	c.cookies = &policyJar{
+		jar:    jar,
+		policy: policy,
+		logger: func() Logger { return c.logger },
+		names:  make(map[string]map[string]bool),
+	}
+	c.client.Jar = c.cookies
+	return c
+}
+
+// policyJar decorates a cookie jar with a CookiePolicy.
+type policyJar struct {
+	policy CookiePolicy
+	logger func() Logger
+
+	mu    sync.Mutex
+	jar   http.CookieJar
+	names map[string]map[string]bool // Cookie names stored per host, for MaxCookiesPerHost
+}
+
+func (j *policyJar) setJar(jar http.CookieJar) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = jar
+	j.names = make(map[string]map[string]bool)
+}
+
+// SetCookies stores the cookies that pass the policy.
+func (j *policyJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := strings.ToLower(u.Hostname())
+	cookies = j.dedupe(u, host, cookies)
+	accepted := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		domain := cookieDomain(cookie, host)
+		if reason := j.check(cookie, host, domain); reason != "" {
+			j.reject(u, cookie, domain, reason)
+			continue
+		}
+		j.track(host, cookie)
+		accepted = append(accepted, cookie)
+		if j.policy.Debug {
+			if logger := j.logger(); logger != nil {
+				logger.Debug("cookie accepted", "url", u.Redacted(), "name", cookie.Name, "domain", domain)
+			}
+		}
+	}
+	if len(accepted) > 0 {
+		j.jar.SetCookies(u, accepted)
+	}
+}
+
+// Cookies returns the cookies to send for u.
+func (j *policyJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	jar := j.jar
+	j.mu.Unlock()
+	return jar.Cookies(u)
+}
+
+// check returns why cookie is rejected, or "" if it is accepted.
+func (j *policyJar) check(cookie *http.Cookie, host, domain string) string {
+	p := j.policy
+	switch {
+	case p.RequireSecure && !cookie.Secure:
+		return "missing Secure"
+	case p.RequireHTTPOnly && !cookie.HttpOnly:
+		return "missing HttpOnly"
+	case p.HostOnly && cookie.Domain != "":
+		return "Domain attribute not allowed"
+	case len(p.AllowedDomains) > 0 && !cookieDomainAllowed(domain, p.AllowedDomains):
+		return "domain not allowed"
+	case p.MaxCookieSize > 0 && len(cookie.Name)+len(cookie.Value) > p.MaxCookieSize:
+		return "cookie too large"
+	case p.MaxCookiesPerHost > 0 && cookie.MaxAge >= 0 && !j.names[host][cookie.Name] &&
+		len(j.names[host]) >= p.MaxCookiesPerHost:
+		return "too many cookies for host"
+	}
+	return ""
+}
+
+// dedupe applies the Duplicates mode to repeated cookies in one response.
+func (j *policyJar) dedupe(u *url.URL, host string, cookies []*http.Cookie) []*http.Cookie {
+	type cookieID struct{ name, domain, path string }
+	idOf := func(cookie *http.Cookie) cookieID {
+		return cookieID{cookie.Name, cookieDomain(cookie, host), cookie.Path}
+	}
+	count := make(map[cookieID]int, len(cookies))
+	for _, cookie := range cookies {
+		count[idOf(cookie)]++
+	}
+	if len(count) == len(cookies) {
+		return cookies
+	}
+
+	kept := make([]*http.Cookie, 0, len(count))
+	seen := make(map[cookieID]int, len(count))
+	for _, cookie := range cookies {
+		id := idOf(cookie)
+		seen[id]++
+		switch {
+		case count[id] == 1:
+			kept = append(kept, cookie)
+		case j.policy.Duplicates == CookieDuplicateFirst:
+			if seen[id] == 1 {
+				kept = append(kept, cookie)
+			}
+		case j.policy.Duplicates == CookieDuplicateReject:
+			if seen[id] == 1 {
+				j.reject(u, cookie, id.domain, "duplicate Set-Cookie")
+			}
+		default:
+			if seen[id] == count[id] {
+				kept = append(kept, cookie)
+			}
+		}
+	}
+	return kept
+}
+
+// track records cookie's name for MaxCookiesPerHost; deletions forget it.
+func (j *policyJar) track(host string, cookie *http.Cookie) {
+	if cookie.MaxAge < 0 {
+		delete(j.names[host], cookie.Name)
+		return
+	}
+	if j.names[host] == nil {
+		j.names[host] = make(map[string]bool)
+	}
+	j.names[host][cookie.Name] = true
+}
+
+func (j *policyJar) reject(u *url.URL, cookie *http.Cookie, domain, reason string) {
+	if j.policy.Debug {
+		if logger := j.logger(); logger != nil {
+			logger.Debug("cookie rejected", "url", u.Redacted(), "name", cookie.Name, "domain", domain, "reason", reason)
+		}
+	}
+	if j.policy.OnViolation != nil {
+		j.policy.OnViolation(CookieViolation{URL: u.Redacted(), Name: cookie.Name, Domain: domain, Reason: reason})
+	}
+}
+
+// cookieDomain returns the domain a cookie applies to: its Domain attribute
+// without the leading dot, or the host that set it.
+func cookieDomain(cookie *http.Cookie, host string) string {
+	if cookie.Domain == "" {
+		return host
+	}
+	return strings.ToLower(strings.TrimPrefix(cookie.Domain, "."))
+}
+
+// cookieDomainAllowed reports whether domain matches an allowlist entry. Entries
+// starting with "." also match subdomains.
+func cookieDomainAllowed(domain string, allowed []string) bool {
+	for _, entry := range allowed {
+		entry = strings.ToLower(entry)
+		if strings.HasPrefix(entry, ".") {
+			if domain == entry[1:] || strings.HasSuffix(domain, entry) {
+				return true
+			}
+			continue
+		}
+		if domain == entry {
+			return true
+		}
+	}
+	return false
+}
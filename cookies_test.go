@@ -0,0 +1,134 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setCookieServer returns a server that answers every request with the given
+// Set-Cookie headers and echoes the Cookie header it received.
+func setCookieServer(t *testing.T, setCookies ...string) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, c := range setCookies {
+			w.Header().Add("Set-Cookie", c)
+		}
+		w.Write([]byte(r.Header.Get("Cookie")))
+	})
+	return server
+}
+
+// storedCookies returns the name=value pairs the client's jar holds for server,
+// including Secure cookies, sorted.
+func storedCookies(client *Client, server *httptest.Server) string {
+	u, _ := url.Parse(server.URL)
+	u.Scheme = "https"
+	var pairs []string
+	for _, c := range client.client.Jar.Cookies(u) {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "; ")
+}
+
+func TestCookiePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     CookiePolicy
+		setCookies []string
+		wantStored string
+		wantReason []string
+	}{
+		{"zero policy", CookiePolicy{}, []string{"a=1", "b=2; Secure"}, "a=1; b=2", nil},
+		{"require Secure", CookiePolicy{RequireSecure: true}, []string{"a=1", "b=2; Secure"}, "b=2", []string{"a: missing Secure"}},
+		{"require HttpOnly", CookiePolicy{RequireHTTPOnly: true}, []string{"a=1; HttpOnly", "b=2"}, "a=1", []string{"b: missing HttpOnly"}},
+		{"host only", CookiePolicy{HostOnly: true}, []string{"a=1", "b=2; Domain=127.0.0.1"}, "a=1", []string{"b: Domain attribute not allowed"}},
+		{"allowed domains", CookiePolicy{AllowedDomains: []string{".example.com"}}, []string{"a=1"}, "", []string{"a: domain not allowed"}},
+		{"allowed IP", CookiePolicy{AllowedDomains: []string{"127.0.0.1"}}, []string{"a=1"}, "a=1", nil},
+		{"size cap", CookiePolicy{MaxCookieSize: 4}, []string{"a=123", "b=1234"}, "a=123", []string{"b: cookie too large"}},
+		{"per-host cap", CookiePolicy{MaxCookiesPerHost: 2}, []string{"a=1", "b=2", "c=3"}, "a=1; b=2", []string{"c: too many cookies for host"}},
+		{"duplicates keep last", CookiePolicy{}, []string{"a=1", "a=2"}, "a=2", nil},
+		{"duplicates keep first", CookiePolicy{Duplicates: CookieDuplicateFirst}, []string{"a=1", "a=2", "b=3"}, "a=1; b=3", nil},
+		{"duplicates rejected", CookiePolicy{Duplicates: CookieDuplicateReject}, []string{"a=1", "a=2", "b=3"}, "b=3", []string{"a: duplicate Set-Cookie"}},
+		{"different paths are not duplicates", CookiePolicy{Duplicates: CookieDuplicateReject}, []string{"a=1; Path=/", "a=2; Path=/x"}, "a=1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := setCookieServer(t, tt.setCookies...)
+			var reasons []string
+			policy := tt.policy
+			policy.OnViolation = func(v CookieViolation) {
+				if v.Domain != "127.0.0.1" || !strings.HasPrefix(v.URL, server.URL) {
+					t.Errorf("violation = %+v", v)
+				}
+				reasons = append(reasons, v.Name+": "+v.Reason)
+			}
+			client := NewClient(server.URL, 5*time.Second).WithCookiePolicy(policy)
+			if _, err := client.Do(context.Background(), GET("/")); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := storedCookies(client, server); got != tt.wantStored {
+				t.Errorf("stored %q, want %q", got, tt.wantStored)
+			}
+			if strings.Join(reasons, ", ") != strings.Join(tt.wantReason, ", ") {
+				t.Errorf("violations = %v, want %v", reasons, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCookiePolicyCapForgetsDeletedCookies(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, c := range r.URL.Query()["set"] {
+			w.Header().Add("Set-Cookie", c)
+		}
+	})
+	client := NewClient(server.URL, 5*time.Second).WithCookiePolicy(CookiePolicy{MaxCookiesPerHost: 1})
+
+	for _, set := range []string{"a=1", "a=; Max-Age=0", "b=2"} {
+		if _, err := client.Do(context.Background(), GET("/"), WithQueryParam("set", set)); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if got := storedCookies(client, server); got != "b=2" {
+		t.Errorf("stored %q, want b=2 accepted after a was deleted", got)
+	}
+}
+
+func TestCookieJarSendsStoredCookies(t *testing.T) {
+	server := setCookieServer(t, "session=s1")
+	client := NewClient(server.URL, 5*time.Second).WithCookieJar(nil).WithCookiePolicy(CookiePolicy{})
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, GET("/login")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp, err := client.Do(ctx, GET("/orders"), WithCookie("token", "t1"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); !strings.Contains(got, "session=s1") || !strings.Contains(got, "token=t1") {
+		t.Errorf("Cookie = %q, want the stored and the request cookie", got)
+	}
+
+	// A request jar replaces the client's
+	resp, err = client.Do(ctx, GET("/orders"), WithCookieJar(nil))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "" {
+		t.Errorf("Cookie = %q, want none without a jar", got)
+	}
+
+	// SetCookieJar drops the policy jar
+	client.SetCookieJar(nil)
+	if client.client.Jar != nil || client.cookies != nil {
+		t.Error("SetCookieJar(nil) kept a jar")
+	}
+}
@@ -0,0 +1,133 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSResult is the server's answer to a CORS preflight, read from its
+// Access-Control-Allow-*, Access-Control-Expose-Headers and Access-Control-Max-Age
+// headers.
+type CORSResult struct {
+	StatusCode       int
+	AllowOrigin      string        // Access-Control-Allow-Origin: an origin, "*" or empty
+	AllowMethods     []string      // Access-Control-Allow-Methods, as sent
+	AllowHeaders     []string      // Access-Control-Allow-Headers, as sent
+	ExposeHeaders    []string      // Access-Control-Expose-Headers, as sent
+	AllowCredentials bool          // Access-Control-Allow-Credentials: true
+	MaxAge           time.Duration // Access-Control-Max-Age, zero if absent or invalid
+	Headers          http.Header   // All response headers
+
+	origin string
+	method string
+}
+
+// Preflight sends the CORS preflight a browser would send before a request
+// with method from origin to path: an OPTIONS request with the Origin and
+// Access-Control-Request-Method headers. opts are applied after, e.g. to add
+// Access-Control-Request-Headers with WithHeader().
+//
+// A rejected preflight is not an error: check the result with Allowed(),
+// OriginAllowed(), MethodAllowed() and HeaderAllowed(). Errors are only returned
+// if no response was received.
+//
+// Example:
+//
+//	result, err := client.Preflight(ctx, "/api/orders", "https://app.example.com", "PUT",
+//		reqws.WithHeader("Access-Control-Request-Headers", "Authorization"),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	if !result.Allowed() || !result.HeaderAllowed("Authorization") {
+//		t.Errorf("CORS rejects PUT from app.example.com: %+v", result)
+//	}
+func (c *Client) Preflight(ctx context.Context, path, origin, method string, opts ...RequestOption) (*CORSResult, error) {
+	method = strings.ToUpper(method)
+	preflight := []RequestOption{
+		OPTIONS(path),
+		WithHeader("Origin", origin),
+		WithHeader("Access-Control-Request-Method", method),
+	}
+	resp, err := c.Do(ctx, append(preflight, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCORSResult(resp, origin, method), nil
+}
+
+// Allowed reports whether a browser would accept the preflight for a request
+// without credentials: the status is 2xx and both the origin and the method are
+// allowed. Request headers are checked separately with HeaderAllowed().
+func (r *CORSResult) Allowed() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300 && r.OriginAllowed() && r.MethodAllowed(r.method)
+}
+
+// OriginAllowed reports whether Access-Control-Allow-Origin is "*" or exactly
+// the origin the preflight was sent from.
+func (r *CORSResult) OriginAllowed() bool {
+	return r.AllowOrigin == "*" || r.AllowOrigin == r.origin
+}
+
+// MethodAllowed reports whether method may be used: it is listed (method names
+// are case sensitive), the list is "*" or it is GET, HEAD or POST, which need no
+// permission.
+func (r *CORSResult) MethodAllowed(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return true
+	}
+	for _, allowed := range r.AllowMethods {
+		if allowed == method || allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderAllowed reports whether the request header name may be sent: it is
+// listed (case insensitively) or the list is "*", which does not cover
+// Authorization.
+func (r *CORSResult) HeaderAllowed(name string) bool {
+	for _, allowed := range r.AllowHeaders {
+		if strings.EqualFold(allowed, name) || (allowed == "*" && !strings.EqualFold(name, "Authorization")) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCORSResult reads the CORS headers of a preflight response.
+func parseCORSResult(resp *Response, origin, method string) *CORSResult {
+	result := &CORSResult{
+		StatusCode:       resp.StatusCode,
+		AllowOrigin:      strings.TrimSpace(resp.Headers.Get("Access-Control-Allow-Origin")),
+		AllowMethods:     corsList(resp.Headers, "Access-Control-Allow-Methods"),
+		AllowHeaders:     corsList(resp.Headers, "Access-Control-Allow-Headers"),
+		ExposeHeaders:    corsList(resp.Headers, "Access-Control-Expose-Headers"),
+		AllowCredentials: strings.TrimSpace(resp.Headers.Get("Access-Control-Allow-Credentials")) == "true",
+		Headers:          resp.Headers,
+		origin:           origin,
+		method:           method,
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(resp.Headers.Get("Access-Control-Max-Age"))); err == nil && seconds > 0 {
+		result.MaxAge = time.Duration(seconds) * time.Second
+	}
+	return result
+}
+
+// corsList returns the comma-separated values of every name header.
+func corsList(header http.Header, name string) []string {
+	var list []string
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
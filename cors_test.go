@@ -0,0 +1,101 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// corsServer returns a client for a server that answers every request with
+// status and headers, and the log of requests it received.
+func corsServer(t *testing.T, status int, headers http.Header) (*Client, *requestLog) {
+	t.Helper()
+	server, log := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for key, values := range headers {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(status)
+	})
+	return NewClient(server.URL, 5*time.Second), log
+}
+
+func TestPreflightRequest(t *testing.T) {
+	client, log := corsServer(t, http.StatusNoContent, http.Header{
+		"Access-Control-Allow-Origin":      {"https://app.example.com"},
+		"Access-Control-Allow-Methods":     {"GET, PUT", "DELETE"},
+		"Access-Control-Allow-Headers":     {"Authorization, X-Trace"},
+		"Access-Control-Expose-Headers":    {"ETag"},
+		"Access-Control-Allow-Credentials": {"true"},
+		"Access-Control-Max-Age":           {"600"},
+	})
+	result, err := client.Preflight(context.Background(), "/api/orders", "https://app.example.com", "put",
+		WithHeader("Access-Control-Request-Headers", "Authorization"))
+	if err != nil {
+		t.Fatalf("Preflight: %v", err)
+	}
+
+	req := log.Last(t)
+	if req.Method != http.MethodOptions || req.Path != "/api/orders" {
+		t.Errorf("sent %s %s, want OPTIONS /api/orders", req.Method, req.Path)
+	}
+	for key, want := range map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  "PUT",
+		"Access-Control-Request-Headers": "Authorization",
+	} {
+		if got := req.Header.Get(key); got != want {
+			t.Errorf("request %s = %q, want %q", key, got, want)
+		}
+	}
+
+	if !result.Allowed() || !result.HeaderAllowed("authorization") || result.HeaderAllowed("X-Other") {
+		t.Errorf("result = %+v, want PUT and Authorization allowed, X-Other not", result)
+	}
+	if !slices.Equal(result.AllowMethods, []string{"GET", "PUT", "DELETE"}) || !slices.Equal(result.ExposeHeaders, []string{"ETag"}) {
+		t.Errorf("AllowMethods = %q, ExposeHeaders = %q", result.AllowMethods, result.ExposeHeaders)
+	}
+	if !result.AllowCredentials || result.MaxAge != 10*time.Minute || result.StatusCode != http.StatusNoContent {
+		t.Errorf("credentials %v, max age %v, status %d", result.AllowCredentials, result.MaxAge, result.StatusCode)
+	}
+}
+
+func TestPreflightAllowed(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers http.Header
+		method  string
+		want    bool
+	}{
+		{"wildcard origin and method", http.StatusOK, http.Header{"Access-Control-Allow-Origin": {"*"}, "Access-Control-Allow-Methods": {"*"}}, "PATCH", true},
+		{"other origin", http.StatusOK, http.Header{"Access-Control-Allow-Origin": {"https://evil.example.com"}, "Access-Control-Allow-Methods": {"PUT"}}, "PUT", false},
+		{"method not listed", http.StatusOK, http.Header{"Access-Control-Allow-Origin": {"*"}, "Access-Control-Allow-Methods": {"GET"}}, "DELETE", false},
+		{"simple method needs no listing", http.StatusOK, http.Header{"Access-Control-Allow-Origin": {"*"}}, "POST", true},
+		{"rejected status", http.StatusForbidden, http.Header{"Access-Control-Allow-Origin": {"*"}, "Access-Control-Allow-Methods": {"PUT"}}, "PUT", false},
+		{"no CORS headers", http.StatusOK, nil, "GET", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := corsServer(t, tt.status, tt.headers)
+			result, err := client.Preflight(context.Background(), "/", "https://app.example.com", tt.method)
+			if err != nil {
+				t.Fatalf("Preflight: %v", err)
+			}
+			if got := result.Allowed(); got != tt.want {
+				t.Errorf("Allowed() = %v, want %v for %+v", got, tt.want, result)
+			}
+		})
+	}
+}
+
+func TestCORSHeaderAllowedWildcard(t *testing.T) {
+	result := &CORSResult{AllowHeaders: []string{"*"}}
+	if !result.HeaderAllowed("X-Trace") {
+		t.Error("wildcard does not allow X-Trace")
+	}
+	if result.HeaderAllowed("Authorization") {
+		t.Error("wildcard allows Authorization")
+	}
+}
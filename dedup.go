@@ -0,0 +1,323 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// maxDedupBodySize limits the response body kept for a deduplicated request.
+const maxDedupBodySize = 64 * 1024
+
+// DedupResult is the stored outcome of a completed request.
+type DedupResult struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // Response body, truncated to 64 KiB
+	Truncated  bool   // Body was truncated
+	StoredAt   time.Time
+}
+
+// DedupStore stores request outcomes by deduplication key.
+// Implementations must be safe for concurrent use. An external store (e.g. Redis)
+// lets deduplication survive restarts and span several processes.
+type DedupStore interface {
+	// Get returns the outcome stored for key, or false if there is none or it expired.
+	Get(ctx context.Context, key string) (DedupResult, bool, error)
+	// Set stores the outcome for key for ttl.
+	Set(ctx context.Context, key string, result DedupResult, ttl time.Duration) error
+}
+
+// DedupKeyFunc computes the deduplication key of a request. body is nil for
+// multipart uploads and prepared requests without GetBody. Returning "" sends the
+// request without deduplication.
+type DedupKeyFunc func(spec *RequestSpec, body []byte) string
+
+// WithOutboundDedup skips re-sending mutating requests (POST, PUT, PATCH, DELETE)
+// that already completed. Before sending, the client computes the request's key
+// with keyFn (default: Fingerprint() of method, URL, body and a Host set with
+// WithHost()) and looks it up in store.
+// If a 2xx outcome was stored within ttl, it is returned as a Response with
+// Deduplicated set instead of sending the request again. Otherwise the request is
+// sent and a 2xx outcome is stored.
+//
+// Identical requests in flight at the same time are collapsed: only one is sent and
+// the others receive its outcome. Store errors are logged and the request is sent.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://hooks.example.com", 30*time.Second).
+//		WithOutboundDedup(reqws.NewMemoryDedupStore(), func(spec *reqws.RequestSpec, body []byte) string {
+//			return spec.Header.Get("X-Event-ID")
+//		}, 24*time.Hour)
+func (c *Client) WithOutboundDedup(store DedupStore, keyFn DedupKeyFunc, ttl time.Duration) *Client {
+	if keyFn == nil {
+		keyFn = defaultDedupKey
+	}
+	c.dedup = &outboundDedup{
+		store: store,
+		keyFn: keyFn,
+		ttl:   ttl,
+		calls: make(map[string]*dedupCall),
+	}
+	return c
+}
+
+// defaultDedupKey fingerprints the method, URL, body and the Host header if the
+// request overrides it.
+func defaultDedupKey(spec *RequestSpec, body []byte) string {
+	var config FingerprintConfig
+	if spec.Header.Get("Host") != "" {
+		config.Headers = []string{"Host"}
+	}
+	return Fingerprint(spec, body, config)
+}
+
+type outboundDedup struct {
+	store DedupStore
+	keyFn DedupKeyFunc
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+}
+
+// dedupCall is a request in flight that identical requests wait for.
+type dedupCall struct {
+	done   chan struct{}
+	result DedupResult
+	err    error
+}
+
+// executeDedup runs the request through the outbound deduplication cache if one is configured.
+func (c *Client) executeDedup(ctx context.Context, config *requestConfig) (*http.Response, error) {
+	if c.dedup == nil || !isMutatingMethod(config.method) {
+		return c.execute(ctx, config)
+	}
+	key := c.dedupKey(config)
+	if key == "" {
+		return c.execute(ctx, config)
+	}
+	d := c.dedup
+
+	d.mu.Lock()
+	if call, ok := d.calls[key]; ok {
+		d.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		config.deduplicated = true
+		return call.result.response(), nil
+	}
+	call := &dedupCall{done: make(chan struct{})}
+	d.calls[key] = call
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		delete(d.calls, key)
+		d.mu.Unlock()
+		close(call.done)
+	}()
+
+	stored, ok, err := d.store.Get(ctx, key)
+	if err != nil && c.logger != nil {
+		c.logger.Error("dedup store lookup failed, sending request", "key", key, "error", err)
+	}
+	if err == nil && ok {
+		if c.logger != nil {
+			c.logger.Debug("request deduplicated", "method", config.method, "path", config.path, "key", key)
+		}
+		call.result = stored
+		config.deduplicated = true
+		return stored.response(), nil
+	}
+
+	resp, err := c.execute(ctx, config)
+	if err != nil {
+		call.err = err
+		return nil, err
+	}
+
+	// Buffer the body so waiting duplicates can share the outcome
+	body, err := readResponseBody(resp, config)
+	resp.Body.Close()
+	if err != nil {
+		call.err = err
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	call.result = newDedupResult(resp, body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := d.store.Set(ctx, key, call.result, d.ttl); err != nil && c.logger != nil {
+			c.logger.Error("dedup store write failed", "key", key, "error", err)
+		}
+	}
+	// The callback already saw the body; don't replay it when the caller reads it
+	config.responseCallback = nil
+	return resp, nil
+}
+
+// dedupKey builds the request spec and body and returns the key from the key function.
+func (c *Client) dedupKey(config *requestConfig) string {
+	if config.prepared != nil {
+		var body []byte
+		if config.prepared.GetBody != nil {
+			if rc, err := config.prepared.GetBody(); err == nil {
+				body, _ = io.ReadAll(rc)
+				rc.Close()
+			}
+		}
+		host := config.prepared.Host
+		if config.host != "" {
+			host = config.host
+		}
+		return c.dedup.keyFn(&RequestSpec{
+			Method: config.method,
+			URL:    config.prepared.URL.String(),
+			Header: withHostHeader(config.prepared.Header, host),
+		}, body)
+	}
+
+	query, err := c.buildQuery(config)
+	if err != nil {
+		// Sending fails with the same error
+		return ""
+	}
+	fullURL := c.baseURL + config.path
+	if encoded := query.Encode(); encoded != "" {
+		fullURL += "?" + encoded
+	}
+	var body []byte
+	if len(config.files) == 0 && config.rawBody != nil {
+		body = config.rawBody.data
+	} else if len(config.files) == 0 && config.formBody != nil {
+		body = []byte(config.formBody.Encode())
+	} else if len(config.files) == 0 && config.body != nil && config.bodyAs != "" {
+		if body, _, err = c.marshalBodyAs(config); err != nil {
+			// Sending fails with the same error
+			return ""
+		}
+	} else if raw, ok := verbatimJSON(config.body, config.headers.Get("Content-Type")); ok && len(config.files) == 0 && !config.canonicalJSON {
+		body = raw
+	} else if len(config.files) == 0 && config.body != nil {
+		if body, err = json.Marshal(c.jsonBodyValue(config.body)); err != nil {
+			// Sending fails with the same error
+			return ""
+		}
+	} else if len(config.files) == 0 && len(config.formFields) > 0 {
+		fields := url.Values{}
+		for k, v := range config.formFields {
+			fields.Set(k, v)
+		}
+		body = []byte(fields.Encode())
+	}
+	return c.dedup.keyFn(&RequestSpec{Method: config.method, URL: fullURL, Header: withHostHeader(config.headers, config.host)}, body)
+}
+
+// withHostHeader returns header with Host set to host, copying it first, or
+// header itself if host is empty.
+func withHostHeader(header http.Header, host string) http.Header {
+	if host == "" {
+		return header
+	}
+	header = header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("Host", host)
+	return header
+}
+
+// isMutatingMethod reports whether method changes state on the server.
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// newDedupResult captures the outcome of resp with its (possibly truncated) body.
+func newDedupResult(resp *http.Response, body []byte) DedupResult {
+	result := DedupResult{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		StoredAt:   time.Now(),
+	}
+	if len(body) > maxDedupBodySize {
+		body = body[:maxDedupBodySize]
+		result.Truncated = true
+	}
+	result.Body = append([]byte(nil), body...)
+	return result
+}
+
+// response returns a synthetic response replaying the stored outcome.
+func (r DedupResult) response() *http.Response {
+	return &http.Response{
+		StatusCode:    r.StatusCode,
+		Header:        r.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+	}
+}
+
+// MemoryDedupStore is an in-process DedupStore. Outcomes are lost on restart.
+type MemoryDedupStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryDedupEntry
+	lastPurge time.Time
+}
+
+type memoryDedupEntry struct {
+	result  DedupResult
+	expires time.Time
+}
+
+// NewMemoryDedupStore creates an empty in-memory dedup store.
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{entries: make(map[string]memoryDedupEntry)}
+}
+
+// Get returns the unexpired outcome stored for key.
+func (s *MemoryDedupStore) Get(ctx context.Context, key string) (DedupResult, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return DedupResult{}, false, nil
+	}
+	return entry.result, true, nil
+}
+
+// Set stores the outcome for key for ttl, dropping expired entries at most once a minute.
+func (s *MemoryDedupStore) Set(ctx context.Context, key string, result DedupResult, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) > time.Minute {
+		for k, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastPurge = now
+	}
+	s.entries[key] = memoryDedupEntry{result: result, expires: now.Add(ttl)}
+	return nil
+}
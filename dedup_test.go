@@ -0,0 +1,164 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dedupHandler answers with the request's method and body, failing paths under
+// /fail with 500.
+func dedupHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if strings.HasPrefix(r.URL.Path, "/fail") {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write([]byte(r.Method + " " + string(body)))
+}
+
+func TestOutboundDedup(t *testing.T) {
+	server, hits := recordingServer(t, dedupHandler)
+	client := NewClient(server.URL, 5*time.Second).WithOutboundDedup(NewMemoryDedupStore(), nil, time.Minute)
+	ctx := context.Background()
+
+	steps := []struct {
+		name      string
+		opts      []RequestOption
+		wantHits  int
+		wantDedup bool
+	}{
+		{"first", []RequestOption{POST("/events"), WithJSON(map[string]int{"id": 1})}, 1, false},
+		{"repeat", []RequestOption{POST("/events"), WithJSON(map[string]int{"id": 1})}, 1, true},
+		{"other body", []RequestOption{POST("/events"), WithJSON(map[string]int{"id": 2})}, 2, false},
+		{"other query", []RequestOption{POST("/events"), WithQueryParam("v", "2"), WithJSON(map[string]int{"id": 1})}, 3, false},
+		{"other host", []RequestOption{POST("/events"), WithHost("b.example"), WithJSON(map[string]int{"id": 1})}, 4, false},
+		{"other host again", []RequestOption{POST("/events"), WithHost("b.example"), WithJSON(map[string]int{"id": 1})}, 4, true},
+		{"GET is not deduplicated", []RequestOption{GET("/events")}, 5, false},
+		{"GET again", []RequestOption{GET("/events")}, 6, false},
+		{"failure", []RequestOption{POST("/fail")}, 7, false},
+		{"failure is not stored", []RequestOption{POST("/fail")}, 8, false},
+	}
+	for _, step := range steps {
+		resp, err := client.Do(ctx, step.opts...)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := hits.Count(); got != step.wantHits {
+			t.Errorf("%s: server hits = %d, want %d", step.name, got, step.wantHits)
+		}
+		if resp.Deduplicated != step.wantDedup {
+			t.Errorf("%s: Deduplicated = %v, want %v", step.name, resp.Deduplicated, step.wantDedup)
+		}
+		if step.name == "repeat" && string(resp.Body) != `POST {"id":1}` {
+			t.Errorf("%s: Body = %q, want the stored body", step.name, resp.Body)
+		}
+	}
+}
+
+func TestOutboundDedupCollapsesInFlight(t *testing.T) {
+	release := make(chan struct{})
+	server, hits := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("sent once"))
+	})
+	client := NewClient(server.URL, 5*time.Second).WithOutboundDedup(NewMemoryDedupStore(), nil, time.Minute)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Do(context.Background(), POST("/charge"), WithJSON("order-7"))
+			if err != nil {
+				t.Errorf("Do: %v", err)
+				return
+			}
+			bodies[i] = string(resp.Body)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := hits.Count(); got != 1 {
+		t.Errorf("server hits = %d, want 1", got)
+	}
+	for i, body := range bodies {
+		if body != "sent once" {
+			t.Errorf("request %d: body = %q", i, body)
+		}
+	}
+}
+
+func TestOutboundDedupKeyFunc(t *testing.T) {
+	server, hits := recordingServer(t, dedupHandler)
+	client := NewClient(server.URL, 5*time.Second).WithOutboundDedup(NewMemoryDedupStore(),
+		func(spec *RequestSpec, body []byte) string {
+			return spec.Header.Get("X-Event-ID")
+		}, time.Minute)
+
+	for _, opts := range [][]RequestOption{
+		{POST("/a"), WithHeader("X-Event-ID", "e1"), WithJSON(1)},
+		{POST("/b"), WithHeader("X-Event-ID", "e1"), WithJSON(2)}, // Same key, different request
+		{POST("/a")}, // No key, not deduplicated
+		{POST("/a")},
+	} {
+		if _, err := client.Do(context.Background(), opts...); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if got := hits.Count(); got != 3 {
+		t.Errorf("server hits = %d, want 3", got)
+	}
+}
+
+// failingDedupStore is a DedupStore whose backend is down.
+type failingDedupStore struct{}
+
+func (failingDedupStore) Get(context.Context, string) (DedupResult, bool, error) {
+	return DedupResult{}, false, errors.New("store unavailable")
+}
+
+func (failingDedupStore) Set(context.Context, string, DedupResult, time.Duration) error {
+	return errors.New("store unavailable")
+}
+
+func TestOutboundDedupStoreFailure(t *testing.T) {
+	server, hits := recordingServer(t, dedupHandler)
+	client := NewClient(server.URL, 5*time.Second).WithOutboundDedup(failingDedupStore{}, nil, time.Minute)
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(context.Background(), POST("/events"), WithJSON(1)); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	if got := hits.Count(); got != 2 {
+		t.Errorf("server hits = %d, want every request sent while the store fails", got)
+	}
+}
+
+func TestMemoryDedupStore(t *testing.T) {
+	store := NewMemoryDedupStore()
+	ctx := context.Background()
+	store.Set(ctx, "short", DedupResult{StatusCode: 201}, 10*time.Millisecond)
+	store.Set(ctx, "long", DedupResult{StatusCode: 202}, time.Minute)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok, _ := store.Get(ctx, "short"); ok {
+		t.Error("expired entry returned")
+	}
+	if result, ok, _ := store.Get(ctx, "long"); !ok || result.StatusCode != 202 {
+		t.Errorf("Get = %+v, %v", result, ok)
+	}
+
+	body := make([]byte, maxDedupBodySize+1)
+	result := newDedupResult(&http.Response{StatusCode: 200, Header: http.Header{}}, body)
+	if !result.Truncated || len(result.Body) != maxDedupBodySize {
+		t.Errorf("stored %d bytes, truncated %v; want %d, true", len(result.Body), result.Truncated, maxDedupBodySize)
+	}
+}
@@ -0,0 +1,291 @@
+package reqws
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Dialect bundles the conventions of an API style: default media types, a response
+// transform for its envelope and a decoder for its error documents.
+// Every field is optional; copy a preset and change a field to override one piece:
+//
+//	dialect := reqws.DialectJSONAPI
+//	dialect.Transform = nil // keep the "data" envelope
+//	client.WithDialect(dialect)
+type Dialect struct {
+	Accept       string            // Accept header for requests that do not set one
+	ContentType  string            // Content-Type for JSON bodies that do not set one
+	Transform    ResponseTransform // Runs on 2xx bodies before the client transforms
+	ErrorDecoder ErrorDecoder      // Decodes non-2xx bodies in Request(), Paginate() and ConsistencySession
+}
+
+// ErrorDecoder converts a non-2xx response into an error.
+// Returning nil falls back to *HTTPError.
+type ErrorDecoder func(statusCode int, body []byte) error
+
+var (
+	// DialectPlainJSON sends and accepts application/json.
+	DialectPlainJSON = Dialect{
+		Accept:      "application/json",
+		ContentType: "application/json",
+	}
+	// DialectJSONAPI speaks JSON:API (application/vnd.api+json). Successful bodies
+	// are unwrapped to their "data" member and error documents become *JSONAPIError.
+	DialectJSONAPI = Dialect{
+		Accept:       jsonAPIMediaType,
+		ContentType:  jsonAPIMediaType,
+		Transform:    unwrapJSONAPI,
+		ErrorDecoder: decodeJSONAPIError,
+	}
+	// DialectHAL accepts application/hal+json. Bodies are left as they are; use
+	// Response.Links() and Response.Embedded() to read "_links" and "_embedded".
+	DialectHAL = Dialect{
+		Accept:      "application/hal+json",
+		ContentType: "application/json",
+	}
+)
+
+const jsonAPIMediaType = "application/vnd.api+json"
+
+// WithDialect sets the API dialect used by every request made by the client.
+// Per-request headers set with WithHeader() take precedence over the dialect's
+// Accept and Content-Type, and the dialect transform runs before transforms added
+// with WithResponseTransform().
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithDialect(reqws.DialectJSONAPI)
+func (c *Client) WithDialect(dialect Dialect) *Client {
+	c.dialect = dialect
+	return c
+}
+
+// WithErrorDecoder sets the decoder for non-2xx responses, replacing the one
+// from the dialect.
+func (c *Client) WithErrorDecoder(decoder ErrorDecoder) *Client {
+	c.errorDecoder = decoder
+	return c
+}
+
+// decodeHTTPError converts a non-2xx response with the configured error decoder,
+// falling back to *HTTPError.
+func (c *Client) decodeHTTPError(statusCode int, body []byte) error {
+	decoder := c.errorDecoder
+	if decoder == nil {
+		decoder = c.dialect.ErrorDecoder
+	}
+	if decoder != nil {
+		if err := decoder(statusCode, body); err != nil {
+			return err
+		}
+	}
+	return NewHTTPError(statusCode, body)
+}
+
+// jsonContentType returns the Content-Type for a JSON request body.
+func (c *Client) jsonContentType(config *requestConfig) string {
+	if contentType := config.headers.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	if c.dialect.ContentType != "" {
+		return c.dialect.ContentType
+	}
+	return "application/json"
+}
+
+// JSONAPIError is a JSON:API error document. It unwraps to *HTTPError when it
+// came from a non-2xx response.
+type JSONAPIError struct {
+	StatusCode int // 0 if the error document arrived with a 2xx status
+	Errors     []JSONAPIErrorObject
+	Body       []byte
+}
+
+// JSONAPIErrorObject is a single entry of a JSON:API "errors" array.
+type JSONAPIErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *JSONAPIErrorSource    `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// JSONAPIErrorSource points to the part of the request that caused an error.
+type JSONAPIErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+func (e *JSONAPIError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, obj := range e.Errors {
+		message := obj.Title
+		if obj.Detail != "" {
+			if message != "" {
+				message += ": "
+			}
+			message += obj.Detail
+		}
+		if message == "" {
+			message = obj.Code
+		}
+		if message != "" {
+			messages = append(messages, message)
+		}
+	}
+	if len(messages) == 0 {
+		messages = append(messages, "error document without details")
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, strings.Join(messages, "; "))
+	}
+	return "json:api error: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the *HTTPError for the response, if there was a non-2xx status.
+func (e *JSONAPIError) Unwrap() error {
+	if e.StatusCode == 0 {
+		return nil
+	}
+	return NewHTTPError(e.StatusCode, e.Body)
+}
+
+// parseJSONAPIErrors returns the error document in body, or nil if there is none.
+func parseJSONAPIErrors(statusCode int, body []byte) *JSONAPIError {
+	var document struct {
+		Errors []JSONAPIErrorObject `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil || len(document.Errors) == 0 {
+		return nil
+	}
+	return &JSONAPIError{StatusCode: statusCode, Errors: document.Errors, Body: body}
+}
+
+// decodeJSONAPIError is the JSON:API ErrorDecoder.
+func decodeJSONAPIError(statusCode int, body []byte) error {
+	if apiErr := parseJSONAPIErrors(statusCode, body); apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
+// unwrapJSONAPI replaces a JSON:API document with its "data" member. Bodies
+// without "data" (empty, meta-only) are left as they are.
+func unwrapJSONAPI(body []byte) ([]byte, error) {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return body, nil
+	}
+	if apiErr := parseJSONAPIErrors(0, body); apiErr != nil {
+		return nil, &TransformError{Transform: "JSONAPI", Err: apiErr}
+	}
+
+	var document struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, &TransformError{Transform: "JSONAPI", Err: err}
+	}
+	if document.Data == nil {
+		return body, nil
+	}
+	return document.Data, nil
+}
+
+// Link is a hypermedia link from a HAL "_links" or JSON:API "links" object.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// Links returns the links of the response document by relation. It reads HAL
+// "_links" and falls back to JSON:API "links"; single links and arrays of links
+// are both returned as slices. The untransformed body is used, so links are still
+// found after an envelope was unwrapped. Returns nil if there are no links.
+//
+// Example:
+//
+//	if next := resp.Links()["next"]; len(next) > 0 {
+//		resp, err = client.Do(ctx, reqws.GET(next[0].Href))
+//	}
+func (r *Response) Links() map[string][]Link {
+	var document struct {
+		HAL     map[string]json.RawMessage `json:"_links"`
+		JSONAPI map[string]json.RawMessage `json:"links"`
+	}
+	if err := json.Unmarshal(r.documentBody(), &document); err != nil {
+		return nil
+	}
+	raw := document.HAL
+	if raw == nil {
+		raw = document.JSONAPI
+	}
+	if raw == nil {
+		return nil
+	}
+
+	links := make(map[string][]Link, len(raw))
+	for rel, value := range raw {
+		if parsed := parseLinks(value); len(parsed) > 0 {
+			links[rel] = parsed
+		}
+	}
+	return links
+}
+
+// Embedded returns the HAL "_embedded" resources by relation, or nil if there are none.
+//
+// Example:
+//
+//	var orders []Order
+//	err := json.Unmarshal(resp.Embedded()["orders"], &orders)
+func (r *Response) Embedded() map[string]json.RawMessage {
+	var document struct {
+		Embedded map[string]json.RawMessage `json:"_embedded"`
+	}
+	if err := json.Unmarshal(r.documentBody(), &document); err != nil {
+		return nil
+	}
+	return document.Embedded
+}
+
+// documentBody returns the body before response transforms.
+func (r *Response) documentBody() []byte {
+	if r.RawBody != nil {
+		return r.RawBody
+	}
+	return r.Body
+}
+
+// parseLinks decodes a link value: a link object, an array of link objects, or a
+// plain URL string as allowed by JSON:API.
+func parseLinks(value json.RawMessage) []Link {
+	var href string
+	if err := json.Unmarshal(value, &href); err == nil {
+		if href == "" {
+			return nil
+		}
+		return []Link{{Href: href}}
+	}
+
+	var link Link
+	if err := json.Unmarshal(value, &link); err == nil {
+		if link.Href == "" {
+			return nil
+		}
+		return []Link{link}
+	}
+
+	var links []Link
+	if err := json.Unmarshal(value, &links); err != nil {
+		return nil
+	}
+	return links
+}
@@ -0,0 +1,145 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// dialectServer returns a server that answers each path with a fixed status and
+// body and echoes the request's Accept and Content-Type headers.
+func dialectServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("X-Accept", r.Header.Get("Accept"))
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+		switch r.URL.Path {
+		case "/article":
+			w.Write([]byte(`{"data":{"id":"1","type":"articles"},"links":{"self":"/article"}}`))
+		case "/meta":
+			w.Write([]byte(`{"meta":{"count":0}}`))
+		case "/error-in-200":
+			w.Write([]byte(`{"errors":[{"title":"Partial failure"}]}`))
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"errors":[{"title":"Invalid title","detail":"too short","source":{"pointer":"/data/attributes/title"}}]}`))
+		case "/plain-error":
+			http.Error(w, "boom", http.StatusInternalServerError)
+		case "/orders":
+			w.Write([]byte(`{"_links":{"self":{"href":"/orders"},"next":"/orders?page=2"},"_embedded":{"orders":[{"id":1}]}}`))
+		}
+	})
+	return server
+}
+
+func TestDialectJSONAPI(t *testing.T) {
+	server := dialectServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithDialect(DialectJSONAPI)
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, POST("/article"), WithJSON(map[string]string{"title": "x"}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != `{"id":"1","type":"articles"}` {
+		t.Errorf("Body = %s, want the data member", got)
+	}
+	if resp.Headers.Get("X-Accept") != jsonAPIMediaType || resp.Headers.Get("X-Content-Type") != jsonAPIMediaType {
+		t.Errorf("Accept = %q, Content-Type = %q, want %s", resp.Headers.Get("X-Accept"), resp.Headers.Get("X-Content-Type"), jsonAPIMediaType)
+	}
+	if links := resp.Links(); len(links["self"]) != 1 || links["self"][0].Href != "/article" {
+		t.Errorf("Links = %v, want self read from the unwrapped document", links)
+	}
+
+	resp, err = client.Do(ctx, POST("/article"), WithHeader("Accept", "application/json"),
+		WithHeader("Content-Type", "application/json"), WithJSON(map[string]string{}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.Headers.Get("X-Accept") != "application/json" || resp.Headers.Get("X-Content-Type") != "application/json" {
+		t.Errorf("request headers did not take precedence over the dialect: %v", resp.Headers)
+	}
+
+	body, err := client.Request(ctx, GET("/meta"))
+	if err != nil || string(body) != `{"meta":{"count":0}}` {
+		t.Errorf("meta-only body = %s, %v; want it unchanged", body, err)
+	}
+}
+
+func TestDialectJSONAPIErrors(t *testing.T) {
+	server := dialectServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithDialect(DialectJSONAPI)
+	ctx := context.Background()
+
+	_, err := client.Request(ctx, GET("/invalid"))
+	var apiErr *JSONAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want a JSONAPIError", err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Errors[0].Source.Pointer != "/data/attributes/title" {
+		t.Errorf("JSONAPIError = %+v", apiErr)
+	}
+	if got := err.Error(); got != "HTTP 422: Invalid title: too short" {
+		t.Errorf("Error() = %q", got)
+	}
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("err = %v, want it to unwrap to the HTTPError", err)
+	}
+
+	_, err = client.Request(ctx, GET("/error-in-200"))
+	var transformErr *TransformError
+	if !errors.As(err, &transformErr) || !errors.As(err, &apiErr) || apiErr.StatusCode != 0 {
+		t.Errorf("err = %v, want a TransformError wrapping the error document", err)
+	}
+
+	_, err = client.Request(ctx, GET("/plain-error"))
+	if errors.As(err, &apiErr) || !errors.As(err, &httpErr) {
+		t.Errorf("err = %v, want a plain HTTPError for a body without an error document", err)
+	}
+}
+
+func TestWithErrorDecoder(t *testing.T) {
+	server := dialectServer(t)
+	custom := errors.New("server exploded")
+	client := NewClient(server.URL, 5*time.Second).WithDialect(DialectJSONAPI).
+		WithErrorDecoder(func(statusCode int, body []byte) error {
+			if statusCode == http.StatusInternalServerError {
+				return custom
+			}
+			return nil
+		})
+
+	if _, err := client.Request(context.Background(), GET("/plain-error")); !errors.Is(err, custom) {
+		t.Errorf("err = %v, want the decoder's error", err)
+	}
+	// The decoder replaces the dialect's, so JSON:API documents fall back to HTTPError
+	_, err := client.Request(context.Background(), GET("/invalid"))
+	var apiErr *JSONAPIError
+	if errors.As(err, &apiErr) {
+		t.Errorf("err = %v, want an HTTPError", err)
+	}
+}
+
+func TestDialectHAL(t *testing.T) {
+	server := dialectServer(t)
+	resp, err := NewClient(server.URL, 5*time.Second).WithDialect(DialectHAL).Do(context.Background(), GET("/orders"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.Headers.Get("X-Accept") != "application/hal+json" {
+		t.Errorf("Accept = %q", resp.Headers.Get("X-Accept"))
+	}
+	links := resp.Links()
+	if len(links["self"]) != 1 || links["self"][0].Href != "/orders" || len(links["next"]) != 1 || links["next"][0].Href != "/orders?page=2" {
+		t.Errorf("Links = %v", links)
+	}
+	if got := string(resp.Embedded()["orders"]); got != `[{"id":1}]` {
+		t.Errorf("Embedded orders = %s", got)
+	}
+}
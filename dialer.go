@@ -0,0 +1,292 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// IPFamilyPolicy selects the address families used to connect to hosts that
+// resolve to both IPv4 and IPv6 addresses.
+type IPFamilyPolicy int
+
+const (
+	IPFamilyAny        IPFamilyPolicy = iota // Both families, the resolver's preferred one first (default)
+	IPFamilyPreferIPv4                       // IPv4 first, IPv6 as the fallback
+	IPFamilyPreferIPv6                       // IPv6 first, IPv4 as the fallback
+	IPFamilyIPv4Only                         // IPv4 only
+	IPFamilyIPv6Only                         // IPv6 only
+)
+
+// IPFamily is the address family of the connection that served a request.
+type IPFamily string
+
+const (
+	IPv4 IPFamily = "ipv4"
+	IPv6 IPFamily = "ipv6"
+)
+
+// minDialAddrTimeout is the least time a dial to one of several addresses gets
+// before the next address is tried, as in package net.
+const minDialAddrTimeout = 2 * time.Second
+
+// WithDialFallbackDelay sets how long a connection to a dual-stack host waits for
+// the first address family before racing a connection over the other one (Happy
+// Eyeballs, RFC 8305). The default is 300ms. Lower it for networks where IPv6 is
+// published but broken, so requests fall back to IPv4 quickly instead of waiting
+// for the dial timeout. A negative delay disables the race: the other family is
+// only tried after every address of the first has failed.
+//
+// It applies to HTTP requests and WebSocket dials. Response.IPFamily reports the
+// family that served a request.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithDialFallbackDelay(50 * time.Millisecond)
+func (c *Client) WithDialFallbackDelay(d time.Duration) *Client {
+	c.dialer().fallbackDelay = d
+	return c
+}
+
+// WithIPFamily sets which address families the client connects over. The
+// preferred family of IPFamilyPreferIPv4 and IPFamilyPreferIPv6 is raced against
+// the other after the fallback delay (see WithDialFallbackDelay); the "only"
+// policies fail to connect to hosts without an address of their family.
+//
+// It applies to HTTP requests and WebSocket dials, including dials to a proxy.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithIPFamily(reqws.IPFamilyPreferIPv4)
+func (c *Client) WithIPFamily(policy IPFamilyPolicy) *Client {
+	c.dialer().family = policy
+	return c
+}
+
+// dialConfig is the client's dialer, shared by HTTP requests and WebSocket dials.
+type dialConfig struct {
+	timeout       time.Duration
+	keepAlive     time.Duration
+	fallbackDelay time.Duration
+	family        IPFamilyPolicy
+	lookup        func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// defaultDialConfig dials like http.DefaultTransport.
+var defaultDialConfig = &dialConfig{
+	timeout:   30 * time.Second,
+	keepAlive: 30 * time.Second,
+	lookup:    net.DefaultResolver.LookupIPAddr,
+}
+
+// dialer returns the client's dialer for changing it, installing it in the
+// client's transport on first use.
+func (c *Client) dialer() *dialConfig {
+	if c.dial == nil {
+		dial := *defaultDialConfig
+		c.dial = &dial
+		c.transport().DialContext = c.dial.dialContext
+	}
+	return c.dial
+}
+
+// dialConfig returns the client's dialer, or the default one if it was never set.
+func (c *Client) dialConfig() *dialConfig {
+	if c.dial == nil {
+		return defaultDialConfig
+	}
+	return c.dial
+}
+
+func (d *dialConfig) netDialer() *net.Dialer {
+	return &net.Dialer{Timeout: d.timeout, KeepAlive: d.keepAlive, FallbackDelay: d.fallbackDelay}
+}
+
+// dialContext is the DialContext of the client's transport.
+func (d *dialConfig) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	switch d.family {
+	case IPFamilyIPv4Only:
+		network = familyNetwork(network, "4")
+	case IPFamilyIPv6Only:
+		network = familyNetwork(network, "6")
+	case IPFamilyPreferIPv4, IPFamilyPreferIPv6:
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || network != "tcp" || net.ParseIP(host) != nil {
+			break
+		}
+		addrs, err := d.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		ips := make([]net.IP, 0, len(addrs))
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+		return d.dialIPs(ctx, network, ips, port)
+	}
+	return d.netDialer().DialContext(ctx, network, addr)
+}
+
+// dialIPs connects to the first address that answers: the addresses of the
+// preferred family one after another, raced after the fallback delay against the
+// others, like net.Dialer does for a host name. ips are resolved addresses, whose
+// family order is kept under IPFamilyAny.
+func (d *dialConfig) dialIPs(ctx context.Context, network string, ips []net.IP, port string) (net.Conn, error) {
+	primaries, fallbacks := d.partition(ips)
+	if len(primaries) == 0 {
+		primaries, fallbacks = fallbacks, nil
+	}
+	if len(primaries) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found"}
+	}
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	if len(fallbacks) == 0 || d.fallbackDelay < 0 {
+		return d.dialSerial(ctx, network, append(primaries, fallbacks...), port)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan dialResult, 2)
+	race := func(ips []net.IP) {
+		go func() {
+			conn, err := d.dialSerial(ctx, network, ips, port)
+			results <- dialResult{conn: conn, err: err}
+		}()
+	}
+	race(primaries)
+	delay := d.fallbackDelay
+	if delay == 0 {
+		delay = 300 * time.Millisecond
+	}
+	fallback := time.NewTimer(delay)
+	defer fallback.Stop()
+
+	pending, raced := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-fallback.C:
+			race(fallbacks)
+			pending, raced = pending+1, true
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				if pending > 0 {
+					// The other dial is cancelled; close its connection if it won anyway
+					go func() {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}()
+				}
+				return result.conn, nil
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			if !raced {
+				// The preferred family failed: try the other one right away
+				fallback.Stop()
+				race(fallbacks)
+				pending, raced = pending+1, true
+			} else if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// dialSerial dials ips in order, giving each an equal share of the time left
+// before ctx's deadline (at least minDialAddrTimeout), and returns the first
+// connection.
+func (d *dialConfig) dialSerial(ctx context.Context, network string, ips []net.IP, port string) (net.Conn, error) {
+	dialer := &net.Dialer{KeepAlive: d.keepAlive}
+	err := errors.New("no addresses to dial")
+	for i, ip := range ips {
+		dialCtx, cancel := ctx, context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && i < len(ips)-1 {
+			share := max(time.Until(deadline)/time.Duration(len(ips)-i), minDialAddrTimeout)
+			dialCtx, cancel = context.WithTimeout(ctx, share)
+		}
+		var conn net.Conn
+		conn, err = dialer.DialContext(dialCtx, network, net.JoinHostPort(ip.String(), port))
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, err
+}
+
+// partition splits ips into the preferred family, in order, and the other one.
+// Families the policy excludes are dropped.
+func (d *dialConfig) partition(ips []net.IP) (primaries, fallbacks []net.IP) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	preferV4 := ips[0].To4() != nil
+	switch d.family {
+	case IPFamilyPreferIPv4, IPFamilyIPv4Only:
+		preferV4 = true
+	case IPFamilyPreferIPv6, IPFamilyIPv6Only:
+		preferV4 = false
+	}
+	for _, ip := range ips {
+		if !d.allows(ip) {
+			continue
+		}
+		if (ip.To4() != nil) == preferV4 {
+			primaries = append(primaries, ip)
+		} else {
+			fallbacks = append(fallbacks, ip)
+		}
+	}
+	return primaries, fallbacks
+}
+
+// allows reports whether the IP family policy allows connecting to ip.
+func (d *dialConfig) allows(ip net.IP) bool {
+	switch d.family {
+	case IPFamilyIPv4Only:
+		return ip.To4() != nil
+	case IPFamilyIPv6Only:
+		return ip.To4() == nil
+	}
+	return true
+}
+
+// familyNetwork restricts a "tcp" or "udp" network to one family.
+func familyNetwork(network, family string) string {
+	if network == "tcp" || network == "udp" {
+		return network + family
+	}
+	return network
+}
+
+// addrFamily returns the family of a connection's address, or "" if it is not
+// an IP address.
+func addrFamily(addr net.Addr) IPFamily {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+	if tcpAddr.IP.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
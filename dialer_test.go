@@ -0,0 +1,123 @@
+package reqws
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDialPartition(t *testing.T) {
+	v4a, v4b := net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")
+	v6a, v6b := net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")
+	tests := []struct {
+		name                      string
+		family                    IPFamilyPolicy
+		ips                       []net.IP
+		wantPrimary, wantFallback []net.IP
+	}{
+		{"any keeps resolver order", IPFamilyAny, []net.IP{v6a, v4a, v6b}, []net.IP{v6a, v6b}, []net.IP{v4a}},
+		{"any IPv4 first", IPFamilyAny, []net.IP{v4a, v6a, v4b}, []net.IP{v4a, v4b}, []net.IP{v6a}},
+		{"prefer IPv4", IPFamilyPreferIPv4, []net.IP{v6a, v4a}, []net.IP{v4a}, []net.IP{v6a}},
+		{"prefer IPv6", IPFamilyPreferIPv6, []net.IP{v4a, v6a, v4b}, []net.IP{v6a}, []net.IP{v4a, v4b}},
+		{"IPv6 only", IPFamilyIPv6Only, []net.IP{v4a, v6a}, []net.IP{v6a}, nil},
+		{"IPv4 only without IPv4", IPFamilyIPv4Only, []net.IP{v6a, v6b}, nil, nil},
+		{"no addresses", IPFamilyAny, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &dialConfig{family: tt.family}
+			primary, fallback := d.partition(tt.ips)
+			equal := func(a, b []net.IP) bool { return slices.EqualFunc(a, b, net.IP.Equal) }
+			if !equal(primary, tt.wantPrimary) || !equal(fallback, tt.wantFallback) {
+				t.Errorf("partition = %v, %v; want %v, %v", primary, fallback, tt.wantPrimary, tt.wantFallback)
+			}
+		})
+	}
+}
+
+// dualStackServer returns a server on 127.0.0.1 and the URL of a host name that
+// resolves to an unreachable IPv6 address and the server's IPv4 address.
+func dualStackServer(t *testing.T) (string, func(ctx context.Context, host string) ([]net.IPAddr, error)) {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		// 100::1 is in the IPv6 discard prefix (RFC 6666)
+		return []net.IPAddr{{IP: net.ParseIP("100::1")}, {IP: net.ParseIP("127.0.0.1")}}, nil
+	}
+	return "http://dualstack.test:" + u.Port(), lookup
+}
+
+func TestDialFallsBackToOtherFamily(t *testing.T) {
+	tests := []struct {
+		name       string
+		family     IPFamilyPolicy
+		delay      time.Duration
+		maxElapsed time.Duration // Bounds the wait for the unreachable IPv6 address
+	}{
+		{"prefer IPv6 races IPv4", IPFamilyPreferIPv6, 20 * time.Millisecond, time.Second},
+		{"prefer IPv6 without race", IPFamilyPreferIPv6, -1, 2 * minDialAddrTimeout},
+		{"prefer IPv4", IPFamilyPreferIPv4, 0, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverURL, lookup := dualStackServer(t)
+			client := NewClient(serverURL, 10*time.Second).WithIPFamily(tt.family).WithDialFallbackDelay(tt.delay)
+			client.dialer().lookup = lookup
+			// Without the race, the discard address gets its share of the dial timeout
+			client.dialer().timeout = 2 * minDialAddrTimeout
+
+			events, stop := client.Tap(8)
+			defer stop()
+			start := time.Now()
+			resp, err := client.Do(context.Background(), GET("/"))
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > tt.maxElapsed {
+				t.Errorf("request took %v, want at most %v", elapsed, tt.maxElapsed)
+			}
+			if resp.IPFamily != IPv4 {
+				t.Errorf("Response.IPFamily = %q, want %q", resp.IPFamily, IPv4)
+			}
+			for {
+				select {
+				case event := <-events:
+					if event.Type != TapResponse {
+						continue
+					}
+					if event.IPFamily != IPv4 {
+						t.Errorf("TapEvent.IPFamily = %q, want %q", event.IPFamily, IPv4)
+					}
+				case <-time.After(time.Second):
+					t.Error("no response tap event")
+				}
+				break
+			}
+		})
+	}
+}
+
+func TestDialFamilyOnly(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	resp, err := NewClient(server.URL, 5*time.Second).WithIPFamily(IPFamilyIPv4Only).Do(context.Background(), GET("/"))
+	if err != nil {
+		t.Fatalf("IPv4 only: %v", err)
+	}
+	if resp.IPFamily != IPv4 {
+		t.Errorf("IPFamily = %q, want %q", resp.IPFamily, IPv4)
+	}
+
+	_, err = NewClient(server.URL, 5*time.Second).WithIPFamily(IPFamilyIPv6Only).Do(context.Background(), GET("/"))
+	if err == nil {
+		t.Error("IPv6 only connected to an IPv4 server")
+	}
+}
@@ -0,0 +1,182 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DownloadParallel downloads a file to destPath in chunks concurrent ranged GETs.
+//
+// It first sends a HEAD request. If the server answers with "Accept-Ranges: bytes"
+// and a Content-Length, the file is split into chunks byte ranges that are fetched
+// concurrently and written at their offsets, and the final size is verified.
+// Otherwise, including when HEAD is not allowed, the file is downloaded with a
+// single GET. Options apply to every request, so WithRetry() retries each chunk
+// on its own.
+//
+// The file is created or truncated; on error it is removed. A chunk answered with
+// anything but 206 Partial Content for its exact range fails the download.
+//
+// Example:
+//
+//	err := client.DownloadParallel(ctx, "/tmp/dataset.tar", 8,
+//		reqws.GET("/exports/dataset.tar"),
+//		reqws.WithDefaultRetry(),
+//	)
+func (c *Client) DownloadParallel(ctx context.Context, destPath string, chunks int, opts ...RequestOption) (err error) {
+	config := newRequestConfig(http.MethodGet, opts)
+
+	file, err := os.Create(destPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close file: %w", closeErr)
+		}
+		if err != nil {
+			os.Remove(destPath)
+		}
+	}()
+
+	size, ranged := c.probeRanges(ctx, config)
+	if !ranged || chunks <= 1 || size < int64(chunks) {
+		if c.logger != nil {
+			c.logger.Debug("downloading without ranges", "path", config.path, "size", size)
+		}
+		return c.downloadWhole(ctx, config, file)
+	}
+
+	if err := file.Truncate(size); err != nil {
+		return fmt.Errorf("failed to allocate file: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunkSize := (size + int64(chunks) - 1) / int64(chunks)
+	errs := make([]error, chunks)
+	var wg sync.WaitGroup
+	for i := 0; i < chunks; i++ {
+		start := int64(i) * chunkSize
+		end := min(start+chunkSize, size) - 1
+		if start > end {
+			break
+		}
+		wg.Add(1)
+		go func(i int, start, end int64) {
+			defer wg.Done()
+			if err := c.downloadRange(ctx, config, file, start, end, size); err != nil {
+				errs[i] = fmt.Errorf("chunk %d (bytes %d-%d): %w", i, start, end, err)
+				cancel()
+			}
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	// Report the chunk that failed first, not the ones it cancelled
+	var firstErr error
+	for _, err := range errs {
+		if err != nil && (firstErr == nil || errors.Is(firstErr, context.Canceled)) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to verify file: %w", err)
+	}
+	if info.Size() != size {
+		return fmt.Errorf("downloaded %d bytes, expected %d", info.Size(), size)
+	}
+	if c.logger != nil {
+		c.logger.Debug("parallel download finished", "path", config.path, "size", size, "chunks", chunks)
+	}
+	return nil
+}
+
+// probeRanges sends a HEAD request and reports the content length and whether the
+// server accepts byte ranges.
+func (c *Client) probeRanges(ctx context.Context, config *requestConfig) (int64, bool) {
+	head := *config
+	head.method = http.MethodHead
+	resp, err := c.execute(ctx, &head)
+	if err != nil {
+		return -1, false
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return -1, false
+	}
+	accepts := strings.EqualFold(strings.TrimSpace(resp.Header.Get("Accept-Ranges")), "bytes")
+	return resp.ContentLength, accepts && resp.ContentLength > 0
+}
+
+// downloadWhole streams the full response body into file.
+func (c *Client) downloadWhole(ctx context.Context, config *requestConfig, file *os.File) error {
+	resp, err := c.execute(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return c.decodeHTTPError(resp.StatusCode, body)
+	}
+	n, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if resp.ContentLength >= 0 && n != resp.ContentLength {
+		return fmt.Errorf("downloaded %d bytes, expected %d", n, resp.ContentLength)
+	}
+	return nil
+}
+
+// downloadRange fetches bytes start-end (inclusive) and writes them at their offset.
+func (c *Client) downloadRange(ctx context.Context, config *requestConfig, file *os.File, start, end, size int64) error {
+	chunk := *config
+	chunk.headers = config.headers.Clone()
+	if chunk.headers == nil {
+		chunk.headers = make(http.Header)
+	}
+	chunk.headers.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := c.execute(ctx, &chunk)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(resp.Body)
+			return c.decodeHTTPError(resp.StatusCode, body)
+		}
+		return fmt.Errorf("server ignored the range request (status %d)", resp.StatusCode)
+	}
+	want := fmt.Sprintf("bytes %d-%d/%d", start, end, size)
+	if got := resp.Header.Get("Content-Range"); got != want {
+		return fmt.Errorf("unexpected Content-Range %q, expected %q", got, want)
+	}
+
+	length := end - start + 1
+	n, err := io.Copy(io.NewOffsetWriter(file, start), io.LimitReader(resp.Body, length))
+	if err != nil {
+		return fmt.Errorf("failed to write chunk: %w", err)
+	}
+	if n != length {
+		return fmt.Errorf("received %d bytes, expected %d", n, length)
+	}
+	return nil
+}
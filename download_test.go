@@ -0,0 +1,184 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// downloadContent returns n bytes of distinct-looking content.
+func downloadContent(n int) []byte {
+	content := make([]byte, n)
+	for i := range content {
+		content[i] = byte('a' + i%26)
+	}
+	return content
+}
+
+// rangeServer serves content with byte range support, failing the GETs for which
+// fail returns true, and records every request.
+func rangeServer(t *testing.T, content []byte, fail func(r *http.Request) bool) (string, *requestLog) {
+	server, log := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail != nil && r.Method == http.MethodGet && fail(r) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	})
+	return server.URL, log
+}
+
+// requestedRanges returns the sorted Range headers of the recorded GETs, with ""
+// for a GET without one.
+func requestedRanges(log *requestLog) []string {
+	var ranges []string
+	for _, req := range log.All() {
+		if req.Method == http.MethodGet {
+			ranges = append(ranges, req.Header.Get("Range"))
+		}
+	}
+	slices.Sort(ranges)
+	return ranges
+}
+
+func TestDownloadParallelSplitsRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		chunks     int
+		wantRanges []string
+	}{
+		{"even", 1000, 4, []string{"bytes=0-249", "bytes=250-499", "bytes=500-749", "bytes=750-999"}},
+		{"uneven", 10, 4, []string{"bytes=0-2", "bytes=3-5", "bytes=6-8", "bytes=9-9"}},
+		{"last chunk empty", 9, 4, []string{"bytes=0-2", "bytes=3-5", "bytes=6-8"}},
+		{"one chunk", 1000, 1, []string{""}},
+		{"smaller than the chunk count", 3, 4, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := downloadContent(tt.size)
+			url, log := rangeServer(t, content, nil)
+			dest := filepath.Join(t.TempDir(), "file")
+
+			if err := NewClient(url, 5*time.Second).DownloadParallel(context.Background(), dest, tt.chunks, GET("/file")); err != nil {
+				t.Fatalf("DownloadParallel: %v", err)
+			}
+			if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+				t.Errorf("file = %q, want %q", got, content)
+			}
+			if got := requestedRanges(log); !slices.Equal(got, tt.wantRanges) {
+				t.Errorf("ranges = %q, want %q", got, tt.wantRanges)
+			}
+			if first := log.All()[0]; first.Method != http.MethodHead || first.Path != "/file" {
+				t.Errorf("first request = %s %s, want HEAD /file", first.Method, first.Path)
+			}
+		})
+	}
+}
+
+func TestDownloadParallelWithoutRanges(t *testing.T) {
+	content := downloadContent(1000)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"range header ignored", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(content)
+		}},
+		{"HEAD not allowed", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Write(content)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, tt.handler)
+			dest := filepath.Join(t.TempDir(), "file")
+
+			if err := NewClient(server.URL, 5*time.Second).DownloadParallel(context.Background(), dest, 4, GET("/file")); err != nil {
+				t.Fatalf("DownloadParallel: %v", err)
+			}
+			if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+				t.Errorf("file has %d bytes, want the %d byte content", len(got), len(content))
+			}
+			if got := requestedRanges(log); !slices.Equal(got, []string{""}) {
+				t.Errorf("ranges = %q, want a single GET without a range", got)
+			}
+		})
+	}
+}
+
+func TestDownloadParallelRemovesFileOnFailure(t *testing.T) {
+	content := downloadContent(1000)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{"chunk fails", nil, "chunk 2 (bytes 500-749)"},
+		{"ranges advertised but ignored", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Accept-Ranges", "bytes")
+			w.Write(content)
+		}, "server ignored the range request (status 200)"},
+		{"whole download fails", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}, "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var url string
+			if tt.handler == nil {
+				url, _ = rangeServer(t, content, func(r *http.Request) bool {
+					return strings.HasPrefix(r.Header.Get("Range"), "bytes=500-")
+				})
+			} else {
+				url = testServer(t, tt.handler).URL
+			}
+			// An existing file at the destination is replaced, and removed on failure
+			dest := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(dest, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := NewClient(url, 5*time.Second).DownloadParallel(context.Background(), dest, 4, GET("/file"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DownloadParallel error = %v, want %q", err, tt.wantErr)
+			}
+			if _, statErr := os.Stat(dest); !errors.Is(statErr, os.ErrNotExist) {
+				t.Errorf("destination after a failed download: %v, want it removed", statErr)
+			}
+		})
+	}
+}
+
+func TestDownloadParallelRetriesChunk(t *testing.T) {
+	content := downloadContent(1000)
+	var failed atomic.Bool
+	url, log := rangeServer(t, content, func(r *http.Request) bool {
+		return r.Header.Get("Range") == "bytes=250-499" && failed.CompareAndSwap(false, true)
+	})
+	dest := filepath.Join(t.TempDir(), "file")
+
+	if err := NewClient(url, 5*time.Second).DownloadParallel(context.Background(), dest, 4, GET("/file"), WithRetry(fastRetry(1))); err != nil {
+		t.Fatalf("DownloadParallel: %v", err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Error("file differs from the content after a retried chunk")
+	}
+	want := []string{"bytes=0-249", "bytes=250-499", "bytes=250-499", "bytes=500-749", "bytes=750-999"}
+	if got := requestedRanges(log); !slices.Equal(got, want) {
+		t.Errorf("ranges = %q, want only the failed chunk retried: %q", got, want)
+	}
+}
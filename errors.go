@@ -1,6 +1,14 @@
 package reqws
 
-import "fmt"
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
 
 // HTTPError represents an HTTP error response with a non-2xx status code.
 type HTTPError struct {
@@ -50,3 +58,92 @@ func NewWebSocketError(reason string, err error) *WebSocketError {
 		Err:    err,
 	}
 }
+
+// ErrStreamClosed is returned by WebSocketWriter.Send after the writer was closed,
+// the send side of the stream was closed or the stream ended.
+var ErrStreamClosed = errors.New("websocket stream closed")
+
+// ErrBodyNotRewindable is returned when a request whose body is streamed from a
+// reader that cannot seek (see WithBodyReader and WithFileReader) would have to
+// be sent again.
+var ErrBodyNotRewindable = errors.New("request body cannot be rewound for another attempt")
+
+// ErrInputModified is returned in race-enabled builds when a map, slice or body
+// passed to a request option was modified while the request was in flight.
+var ErrInputModified = errors.New("request option input modified while the request was in flight")
+
+// ErrEmptyBody is returned by Response.XML when the body is empty.
+var ErrEmptyBody = errors.New("response body is empty")
+
+// ErrEmptyArray is returned by Response.JSONFirst when the body is an empty JSON array.
+var ErrEmptyArray = errors.New("json array is empty")
+
+// ErrNoRateLimit is returned by Response.RateLimit when the response carries no
+// recognized rate limit headers.
+var ErrNoRateLimit = errors.New("response has no rate limit headers")
+
+// ConnectionCategory classifies a connection-level failure.
+type ConnectionCategory string
+
+const (
+	ConnectionDNS     ConnectionCategory = "dns"     // Host name could not be resolved
+	ConnectionConnect ConnectionCategory = "connect" // TCP connection refused or unreachable
+	ConnectionTLS     ConnectionCategory = "tls"     // TLS handshake or certificate verification failed
+	ConnectionReset   ConnectionCategory = "reset"   // Connection reset or closed by the peer mid-request
+	ConnectionTimeout ConnectionCategory = "timeout" // Dial, handshake or response timed out
+)
+
+// ConnectionError is returned when a request fails before a response was received
+// because of the connection, e.g. a DNS failure or a connection reset.
+//
+// Example:
+//
+//	var connErr *reqws.ConnectionError
+//	if errors.As(err, &connErr) && connErr.Category == reqws.ConnectionDNS {
+//		// fail over to another region
+//	}
+type ConnectionError struct {
+	Category ConnectionCategory
+	Err      error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("connection error (%s): %v", e.Category, e.Err)
+}
+
+// Unwrap returns the underlying error for error chain support.
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// classifyConnectionError wraps err in a *ConnectionError if it is a connection
+// failure, and returns it unchanged otherwise (e.g. for a cancelled context).
+func classifyConnectionError(err error) error {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var opErr *net.OpError
+
+	category := ConnectionCategory("")
+	switch {
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.As(err, &dnsErr):
+		category = ConnectionDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		category = ConnectionTimeout
+	case errors.As(err, &recordErr), errors.As(err, &certErr), errors.As(err, &alertErr):
+		category = ConnectionTLS
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		category = ConnectionReset
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ENETUNREACH),
+		errors.Is(err, syscall.EHOSTUNREACH), errors.As(err, &opErr) && opErr.Op == "dial":
+		category = ConnectionConnect
+	default:
+		return err
+	}
+	return &ConnectionError{Category: category, Err: err}
+}
@@ -0,0 +1,143 @@
+package reqws
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassifyConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ConnectionCategory // Empty if err is returned unchanged
+	}{
+		{"dns", &net.DNSError{Err: "no such host", Name: "api.invalid", IsNotFound: true}, ConnectionDNS},
+		{"connect refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ConnectionConnect},
+		{"tls verification", fmt.Errorf("handshake: %w", &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), ConnectionTLS},
+		{"reset", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, ConnectionReset},
+		{"unexpected eof", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), ConnectionReset},
+		{"timeout", fmt.Errorf("attempt: %w", context.DeadlineExceeded), ConnectionTimeout},
+		{"cancelled", fmt.Errorf("attempt: %w", context.Canceled), ""},
+		{"other", errors.New("boom"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyConnectionError(tt.err)
+			var connErr *ConnectionError
+			if !errors.As(err, &connErr) {
+				if tt.want != "" {
+					t.Fatalf("got %v, want a ConnectionError of category %q", err, tt.want)
+				}
+				if err != tt.err {
+					t.Errorf("unclassified error %v was changed to %v", tt.err, err)
+				}
+				return
+			}
+			if connErr.Category != tt.want {
+				t.Errorf("category = %q, want %q", connErr.Category, tt.want)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("ConnectionError does not unwrap to the original error")
+			}
+		})
+	}
+}
+
+func TestConnectionErrorFromRequests(t *testing.T) {
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refusedURL := refused.URL
+	refused.Close()
+
+	reset := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	})
+
+	slow := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+
+	untrusted := httptest.NewUnstartedServer(http.NotFoundHandler())
+	untrusted.Config.ErrorLog = log.New(io.Discard, "", 0) // Handshake errors are expected
+	untrusted.StartTLS()
+	defer untrusted.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		opts []RequestOption
+		want ConnectionCategory
+	}{
+		{"connect", refusedURL, nil, ConnectionConnect},
+		{"reset", reset.URL, nil, ConnectionReset},
+		{"timeout", slow.URL, []RequestOption{WithRequestTimeout(50 * time.Millisecond)}, ConnectionTimeout},
+		{"tls", untrusted.URL, nil, ConnectionTLS},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.url, 5*time.Second)
+			_, err := client.Do(context.Background(), append([]RequestOption{GET("/")}, tt.opts...)...)
+			var connErr *ConnectionError
+			if !errors.As(err, &connErr) {
+				t.Fatalf("err = %v, want a ConnectionError", err)
+			}
+			if connErr.Category != tt.want {
+				t.Errorf("category = %q, want %q (%v)", connErr.Category, tt.want, err)
+			}
+		})
+	}
+}
+
+func TestTLSFailuresRetried(t *testing.T) {
+	tests := []struct {
+		name         string
+		skipTLSRetry bool
+		wantAttempts int32
+	}{
+		{"retried by default", false, 3},
+		{"skipped when opted out", true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var handshakes atomic.Int32
+			server := httptest.NewUnstartedServer(http.NotFoundHandler())
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					handshakes.Add(1)
+				}
+			}
+			server.Config.ErrorLog = log.New(io.Discard, "", 0) // Handshake errors are expected
+			server.StartTLS()
+			defer server.Close()
+
+			client := NewClient(server.URL, 5*time.Second)
+			_, err := client.Do(context.Background(), GET("/"), WithRetry(RetryConfig{
+				MaxRetries:   2,
+				InitialDelay: time.Millisecond,
+				SkipTLSRetry: tt.skipTLSRetry,
+			}))
+			var connErr *ConnectionError
+			if !errors.As(err, &connErr) || connErr.Category != ConnectionTLS {
+				t.Fatalf("err = %v, want a TLS ConnectionError", err)
+			}
+			if got := handshakes.Load(); got != tt.wantAttempts {
+				t.Errorf("connections = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package reqws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// fingerprintVersion prefixes every fingerprint. Bump it whenever the canonical
+// form below changes, so keys stored by older versions never match by accident.
+const fingerprintVersion = "v1"
+
+// RequestSpec describes an outgoing request for computing fingerprints and
+// deduplication keys.
+type RequestSpec struct {
+	Method string
+	URL    string      // Full URL including the query string
+	Header http.Header // Includes Host when the request overrides it, e.g. with WithHost()
+}
+
+// FingerprintAlgorithm is the hash used for a fingerprint.
+type FingerprintAlgorithm string
+
+const (
+	FingerprintSHA256 FingerprintAlgorithm = "sha256"
+	FingerprintSHA512 FingerprintAlgorithm = "sha512"
+)
+
+// FingerprintConfig controls which parts of a request participate in its fingerprint.
+// The zero value fingerprints the method, URL with sorted query and body with SHA-256.
+type FingerprintConfig struct {
+	Headers            []string             // Headers that participate (default: none)
+	PreserveQueryOrder bool                 // Keep query parameters in the order sent instead of sorting by name
+	Algorithm          FingerprintAlgorithm // Hash algorithm (default: FingerprintSHA256)
+	ExcludeQuery       []string             // Query parameters left out, e.g. timestamps and nonces
+	ExcludeBodyFields  []string             // Dot-separated JSON body fields left out, e.g. "meta.requestedAt"
+}
+
+// Fingerprint returns a stable key for a request, formatted as
+// "v1:<algorithm>:<hex digest>". Requests with the same fingerprint are the same
+// request for caching, deduplication and replay purposes.
+//
+// Version 1 hashes the following lines, each ending in "\n":
+//
+//	v1
+//	METHOD                  upper-cased
+//	scheme://host/path      scheme and host lower-cased, no query or fragment
+//	query                   name=value pairs joined by "&", re-escaped, excluded names
+//	                        dropped, sorted by name (values of a name keep their order)
+//	Name:value,value        one line per participating header, sorted by canonical name
+//	body digest             hex digest of the body, or of the JSON re-encoded with
+//	                        sorted keys when ExcludeBodyFields removes fields from it
+//
+// Absent headers and an empty body still produce their lines, so every request
+// hashes the same number of lines.
+//
+// Example:
+//
+//	key := reqws.Fingerprint(&reqws.RequestSpec{
+//		Method: "POST",
+//		URL:    "https://api.example.com/orders?ts=1700000000&b=2&a=1",
+//	}, body, reqws.FingerprintConfig{ExcludeQuery: []string{"ts"}})
+//	// key == "v1:sha256:..."
+func Fingerprint(spec *RequestSpec, body []byte, cfg FingerprintConfig) string {
+	algorithm := cfg.Algorithm
+	if algorithm == "" {
+		algorithm = FingerprintSHA256
+	}
+
+	var canonical bytes.Buffer
+	canonical.WriteString(fingerprintVersion + "\n")
+	canonical.WriteString(strings.ToUpper(spec.Method) + "\n")
+
+	base, rawQuery := spec.URL, ""
+	if i := strings.IndexByte(base, '#'); i >= 0 {
+		base = base[:i]
+	}
+	if i := strings.IndexByte(base, '?'); i >= 0 {
+		base, rawQuery = base[:i], base[i+1:]
+	}
+	if u, err := url.Parse(base); err == nil {
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		base = u.String()
+	}
+	canonical.WriteString(base + "\n")
+	canonical.WriteString(fingerprintQuery(rawQuery, cfg) + "\n")
+
+	headers := make([]string, len(cfg.Headers))
+	for i, name := range cfg.Headers {
+		headers[i] = http.CanonicalHeaderKey(name)
+	}
+	sort.Strings(headers)
+	for _, name := range headers {
+		canonical.WriteString(name + ":" + strings.Join(spec.Header.Values(name), ",") + "\n")
+	}
+
+	if len(cfg.ExcludeBodyFields) > 0 {
+		body = redactJSONBody(body, cfg.ExcludeBodyFields)
+	}
+	bodyHash := newFingerprintHash(algorithm)
+	bodyHash.Write(body)
+	canonical.WriteString(hex.EncodeToString(bodyHash.Sum(nil)) + "\n")
+
+	h := newFingerprintHash(algorithm)
+	h.Write(canonical.Bytes())
+	return fingerprintVersion + ":" + string(algorithm) + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// newFingerprintHash returns the hash for algorithm, falling back to SHA-256 for unknown names.
+func newFingerprintHash(algorithm FingerprintAlgorithm) hash.Hash {
+	if algorithm == FingerprintSHA512 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// fingerprintQuery returns the canonical query string.
+func fingerprintQuery(rawQuery string, cfg FingerprintConfig) string {
+	excluded := make(map[string]bool, len(cfg.ExcludeQuery))
+	for _, name := range cfg.ExcludeQuery {
+		excluded[name] = true
+	}
+
+	type pair struct{ name, value string }
+	var pairs []pair
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		if !excluded[name] {
+			pairs = append(pairs, pair{name, value})
+		}
+	}
+	if !cfg.PreserveQueryOrder {
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].name < pairs[j].name })
+	}
+
+	encoded := make([]string, len(pairs))
+	for i, p := range pairs {
+		encoded[i] = url.QueryEscape(p.name) + "=" + url.QueryEscape(p.value)
+	}
+	return strings.Join(encoded, "&")
+}
+
+// redactJSONBody removes fields from a JSON body and re-encodes it with sorted keys.
+// Bodies that are not JSON are returned unchanged.
+func redactJSONBody(body []byte, fields []string) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return body
+	}
+	for _, field := range fields {
+		deleteJSONField(doc, strings.Split(field, "."))
+	}
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// deleteJSONField removes the field at path from doc. Arrays along the path apply
+// the rest of the path to every element.
+func deleteJSONField(doc interface{}, path []string) {
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(v, path[0])
+			return
+		}
+		if child, ok := v[path[0]]; ok {
+			deleteJSONField(child, path[1:])
+		}
+	case []interface{}:
+		for _, elem := range v {
+			deleteJSONField(elem, path)
+		}
+	}
+}
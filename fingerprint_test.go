@@ -0,0 +1,68 @@
+package reqws
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFingerprintEquivalence(t *testing.T) {
+	spec := func(method, url string, header http.Header) *RequestSpec {
+		return &RequestSpec{Method: method, URL: url, Header: header}
+	}
+	tests := []struct {
+		name  string
+		a, b  *RequestSpec
+		bodyA string
+		bodyB string
+		cfg   FingerprintConfig
+		equal bool
+	}{
+		{"method case", spec("post", "https://api.example.com/o", nil), spec("POST", "https://api.example.com/o", nil), "", "", FingerprintConfig{}, true},
+		{"host case", spec("GET", "HTTPS://API.example.com/o", nil), spec("GET", "https://api.example.com/o", nil), "", "", FingerprintConfig{}, true},
+		{"path case matters", spec("GET", "https://api.example.com/O", nil), spec("GET", "https://api.example.com/o", nil), "", "", FingerprintConfig{}, false},
+		{"fragment ignored", spec("GET", "https://api.example.com/o#top", nil), spec("GET", "https://api.example.com/o", nil), "", "", FingerprintConfig{}, true},
+		{"query sorted", spec("GET", "https://a.test/?b=2&a=1", nil), spec("GET", "https://a.test/?a=1&b=2", nil), "", "", FingerprintConfig{}, true},
+		{"query escaping", spec("GET", "https://a.test/?q=a%20b", nil), spec("GET", "https://a.test/?q=a+b", nil), "", "", FingerprintConfig{}, true},
+		{"repeated values keep order", spec("GET", "https://a.test/?a=1&a=2", nil), spec("GET", "https://a.test/?a=2&a=1", nil), "", "", FingerprintConfig{}, false},
+		{"query order preserved", spec("GET", "https://a.test/?b=2&a=1", nil), spec("GET", "https://a.test/?a=1&b=2", nil), "", "", FingerprintConfig{PreserveQueryOrder: true}, false},
+		{"excluded query", spec("GET", "https://a.test/?a=1&ts=1", nil), spec("GET", "https://a.test/?ts=2&a=1", nil), "", "", FingerprintConfig{ExcludeQuery: []string{"ts"}}, true},
+		{"headers ignored by default", spec("GET", "https://a.test/", http.Header{"X-Tenant": {"a"}}), spec("GET", "https://a.test/", nil), "", "", FingerprintConfig{}, true},
+		{"participating header", spec("GET", "https://a.test/", http.Header{"X-Tenant": {"a"}}), spec("GET", "https://a.test/", http.Header{"X-Tenant": {"b"}}), "", "", FingerprintConfig{Headers: []string{"x-tenant"}}, false},
+		{"body", spec("POST", "https://a.test/", nil), spec("POST", "https://a.test/", nil), `{"a":1}`, `{"a":2}`, FingerprintConfig{}, false},
+		{"excluded body field", spec("POST", "https://a.test/", nil), spec("POST", "https://a.test/", nil),
+			`{"a":1,"meta":{"at":"x","v":1}}`, `{"meta":{"v":1,"at":"y"},"a":1}`, FingerprintConfig{ExcludeBodyFields: []string{"meta.at"}}, true},
+		{"excluded field in arrays", spec("POST", "https://a.test/", nil), spec("POST", "https://a.test/", nil),
+			`{"items":[{"id":1,"at":1},{"id":2,"at":2}]}`, `{"items":[{"id":1,"at":3},{"id":2}]}`, FingerprintConfig{ExcludeBodyFields: []string{"items.at"}}, true},
+		{"large numbers kept exact", spec("POST", "https://a.test/", nil), spec("POST", "https://a.test/", nil),
+			`{"id":9007199254740993,"at":1}`, `{"id":9007199254740992,"at":1}`, FingerprintConfig{ExcludeBodyFields: []string{"at"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Fingerprint(tt.a, []byte(tt.bodyA), tt.cfg)
+			b := Fingerprint(tt.b, []byte(tt.bodyB), tt.cfg)
+			if (a == b) != tt.equal {
+				t.Errorf("fingerprints equal = %v, want %v\n%s\n%s", a == b, tt.equal, a, b)
+			}
+		})
+	}
+}
+
+func TestFingerprintFormat(t *testing.T) {
+	spec := &RequestSpec{Method: "POST", URL: "https://api.example.com/orders?b=2&a=1"}
+	sha256Key := Fingerprint(spec, []byte(`{"id":1}`), FingerprintConfig{})
+	sha512Key := Fingerprint(spec, []byte(`{"id":1}`), FingerprintConfig{Algorithm: FingerprintSHA512})
+	if !strings.HasPrefix(sha256Key, "v1:sha256:") || len(sha256Key) != len("v1:sha256:")+64 {
+		t.Errorf("SHA-256 fingerprint = %q", sha256Key)
+	}
+	if !strings.HasPrefix(sha512Key, "v1:sha512:") || len(sha512Key) != len("v1:sha512:")+128 {
+		t.Errorf("SHA-512 fingerprint = %q", sha512Key)
+	}
+
+	// Version 1 fingerprints must never change, stored keys depend on them. This is
+	// the SHA-256 of the canonical lines documented on Fingerprint.
+	const want = "v1:sha256:79a1262729593a7aba1772ed68b76e86f416c687d7c67283b03d2fd24835850d"
+	if sha256Key != want {
+		t.Errorf("fingerprint = %q, want the pinned v1 value %q", sha256Key, want)
+	}
+}
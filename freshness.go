@@ -0,0 +1,124 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// FreshnessItem is a resource to re-check with CheckFreshness, with the
+// validators stored from its last response.
+type FreshnessItem struct {
+	Path         string // Request path, relative to the base URL
+	ETag         string // Sent as If-None-Match if set
+	LastModified string // Sent as If-Modified-Since if set
+}
+
+// FreshnessStatus is the outcome of checking one FreshnessItem.
+type FreshnessStatus int
+
+const (
+	FreshnessError       FreshnessStatus = iota // The request failed or returned an unexpected status; see Err
+	FreshnessNotModified                        // 304 Not Modified: the stored copy is current
+	FreshnessModified                           // 2xx: Body holds the new content
+)
+
+// FreshnessResult is the outcome of checking one FreshnessItem.
+type FreshnessResult struct {
+	Item       FreshnessItem
+	Status     FreshnessStatus
+	StatusCode int    // Zero if no response was received
+	Body       []byte // New content, only for FreshnessModified
+
+	// Validators to store for the next sweep: the response's, falling back to
+	// the item's for a 304 that does not repeat them. Empty for FreshnessError.
+	ETag         string
+	LastModified string
+
+	Err error // Set for FreshnessError; a *HTTPError for unexpected statuses
+}
+
+// CheckFreshness re-checks many resources with conditional GET requests, sending
+// each item's stored validators as If-None-Match and If-Modified-Since, with at
+// most concurrency requests in flight (minimum 1). Results are in the order of
+// items. A 304 response is reported as FreshnessNotModified, a 2xx response as
+// FreshnessModified with the new body and validators, and anything else as
+// FreshnessError; one item's failure never stops the sweep.
+//
+// opts apply to every request, e.g. WithRetry() to retry each item on its own.
+// The returned error is ctx's error if ctx ended during the sweep; results are
+// returned either way, with the items that did not complete marked as errors.
+//
+// Example:
+//
+//	results, err := client.CheckFreshness(ctx, items, 16, reqws.WithDefaultRetry())
+//	for _, r := range results {
+//		switch r.Status {
+//		case reqws.FreshnessModified:
+//			store.Save(r.Item.Path, r.Body, r.ETag, r.LastModified)
+//		case reqws.FreshnessError:
+//			log.Printf("%s: %v", r.Item.Path, r.Err)
+//		}
+//	}
+func (c *Client) CheckFreshness(ctx context.Context, items []FreshnessItem, concurrency int, opts ...RequestOption) ([]FreshnessResult, error) {
+	results := make([]FreshnessResult, len(items))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = c.checkFreshness(ctx, items[i], opts)
+			}
+		}()
+	}
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// checkFreshness sends one conditional GET and classifies its response.
+func (c *Client) checkFreshness(ctx context.Context, item FreshnessItem, opts []RequestOption) FreshnessResult {
+	result := FreshnessResult{Item: item}
+	itemOpts := append(opts[:len(opts):len(opts)], GET(item.Path))
+	if item.ETag != "" {
+		itemOpts = append(itemOpts, WithHeader("If-None-Match", item.ETag))
+	}
+	if item.LastModified != "" {
+		itemOpts = append(itemOpts, WithHeader("If-Modified-Since", item.LastModified))
+	}
+
+	resp, err := c.Do(ctx, itemOpts...)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.StatusCode = resp.StatusCode
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		result.Status = FreshnessNotModified
+		result.ETag = firstNonEmpty(resp.Headers.Get("ETag"), item.ETag)
+		result.LastModified = firstNonEmpty(resp.Headers.Get("Last-Modified"), item.LastModified)
+	case resp.IsSuccess():
+		result.Status = FreshnessModified
+		result.Body = resp.Body
+		result.ETag = resp.Headers.Get("ETag")
+		result.LastModified = resp.Headers.Get("Last-Modified")
+	default:
+		result.Err = NewHTTPError(resp.StatusCode, resp.Body)
+	}
+	return result
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
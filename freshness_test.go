@@ -0,0 +1,135 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const lastModified = "Wed, 01 Jan 2025 00:00:00 GMT"
+
+// freshnessServer serves resources whose current ETag is "v2", except /gone,
+// and honors If-None-Match and If-Modified-Since.
+func freshnessServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/gone":
+			http.Error(w, "gone", http.StatusGone)
+		case r.Header.Get("If-None-Match") == `"v2"`:
+			w.WriteHeader(http.StatusNotModified)
+		case r.Header.Get("If-Modified-Since") == lastModified:
+			w.Header().Set("ETag", `"v2"`)
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Last-Modified", lastModified)
+			fmt.Fprintf(w, "content of %s", r.URL.Path)
+		}
+	})
+	return server
+}
+
+func TestCheckFreshness(t *testing.T) {
+	server := freshnessServer(t)
+	items := []FreshnessItem{
+		{Path: "/current", ETag: `"v2"`, LastModified: "stale date"},
+		{Path: "/changed", ETag: `"v1"`},
+		{Path: "/by-date", LastModified: lastModified},
+		{Path: "/gone", ETag: `"v1"`},
+		{Path: "/new"},
+	}
+	results, err := NewClient(server.URL, 5*time.Second).CheckFreshness(context.Background(), items, 3)
+	if err != nil {
+		t.Fatalf("CheckFreshness: %v", err)
+	}
+
+	want := []FreshnessResult{
+		{Status: FreshnessNotModified, StatusCode: 304, ETag: `"v2"`, LastModified: "stale date"},
+		{Status: FreshnessModified, StatusCode: 200, Body: []byte("content of /changed"), ETag: `"v2"`, LastModified: lastModified},
+		{Status: FreshnessNotModified, StatusCode: 304, ETag: `"v2"`, LastModified: lastModified},
+		{Status: FreshnessError, StatusCode: 410},
+		{Status: FreshnessModified, StatusCode: 200, Body: []byte("content of /new"), ETag: `"v2"`, LastModified: lastModified},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Item != items[i] {
+			t.Errorf("result %d is for %+v, want %+v", i, got.Item, items[i])
+		}
+		if got.Status != w.Status || got.StatusCode != w.StatusCode || string(got.Body) != string(w.Body) ||
+			got.ETag != w.ETag || got.LastModified != w.LastModified {
+			t.Errorf("result %d (%s) = %+v, want %+v", i, items[i].Path, got, w)
+		}
+	}
+	var httpErr *HTTPError
+	if !errors.As(results[3].Err, &httpErr) || httpErr.StatusCode != http.StatusGone {
+		t.Errorf("error for /gone = %v, want an HTTPError", results[3].Err)
+	}
+}
+
+func TestCheckFreshnessConcurrency(t *testing.T) {
+	tests := []struct {
+		concurrency int
+		wantPeak    int32
+	}{
+		{0, 1},
+		{1, 1},
+		{3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.concurrency), func(t *testing.T) {
+			var inFlight, peak atomic.Int32
+			server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(10 * time.Millisecond)
+			})
+
+			items := make([]FreshnessItem, 9)
+			for i := range items {
+				items[i].Path = fmt.Sprintf("/%d", i)
+			}
+			results, err := NewClient(server.URL, 5*time.Second).CheckFreshness(context.Background(), items, tt.concurrency)
+			if err != nil || len(results) != len(items) {
+				t.Fatalf("CheckFreshness = %d results, %v", len(results), err)
+			}
+			if got := peak.Load(); got != tt.wantPeak {
+				t.Errorf("peak concurrency = %d, want %d", got, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestCheckFreshnessContextCancelled(t *testing.T) {
+	server := freshnessServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items := []FreshnessItem{{Path: "/a"}, {Path: "/b"}}
+	results, err := NewClient(server.URL, 5*time.Second).CheckFreshness(ctx, items, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	for i, r := range results {
+		if r.Status != FreshnessError || r.Err == nil || r.Item != items[i] {
+			t.Errorf("result %d = %+v, want an error for the item", i, r)
+		}
+	}
+}
+
+func TestCheckFreshnessNoItems(t *testing.T) {
+	results, err := NewClient("http://localhost", time.Second).CheckFreshness(context.Background(), nil, 4)
+	if err != nil || len(results) != 0 {
+		t.Errorf("CheckFreshness = %v, %v; want no results", results, err)
+	}
+}
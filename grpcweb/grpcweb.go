@@ -0,0 +1,170 @@
+// Package grpcweb calls unary gRPC-Web methods using a reqws.Client.
+//
+// Messages are passed as already-encoded protobuf bytes, so the package does not
+// depend on a protobuf runtime:
+//
+//	req, _ := proto.Marshal(&pb.GetUserRequest{Id: 1})
+//	respBytes, err := grpcweb.Invoke(ctx, client, "/users.v1.UserService/GetUser", req)
+//	if err != nil {
+//		return err
+//	}
+//	var user pb.User
+//	err = proto.Unmarshal(respBytes, &user)
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	reqws "github.com/gurizzu/go-reqws"
+)
+
+// ContentType is the gRPC-Web content type for binary protobuf messages.
+const ContentType = "application/grpc-web+proto"
+
+const (
+	flagData       = 0x00
+	flagCompressed = 0x01
+	flagTrailer    = 0x80
+	headerLen      = 5
+)
+
+// StatusError is returned when the call completes with a non-OK grpc-status.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("grpc-web status %d: %s", e.Code, e.Message)
+}
+
+// Invoke calls a unary gRPC-Web method and returns the encoded response message.
+// The method is the full method name, e.g. "/package.Service/Method".
+// Additional options (headers, auth, retry, hooks) are applied to the request.
+//
+// Returns a *reqws.HTTPError for non-2xx HTTP responses and a *StatusError
+// for non-OK grpc-status values.
+func Invoke(ctx context.Context, client *reqws.Client, method string, message []byte, opts ...reqws.RequestOption) ([]byte, error) {
+	body := Frame(message)
+
+	// The framed body is attached by the first hook so user hooks (e.g. signing) see it
+	callOpts := append([]reqws.RequestOption{
+		reqws.POST(method),
+		reqws.WithHeader("Accept", ContentType),
+		reqws.WithHeader("X-Grpc-Web", "1"),
+		reqws.WithBeforeRequest(func(req *http.Request) error {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			req.ContentLength = int64(len(body))
+			req.Header.Set("Content-Type", ContentType)
+			return nil
+		}),
+	}, opts...)
+
+	resp, err := client.Do(ctx, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, reqws.NewHTTPError(resp.StatusCode, resp.Body)
+	}
+
+	return ParseResponse(resp.Body, resp.Headers)
+}
+
+// Frame encodes a message as a single uncompressed gRPC-Web data frame.
+func Frame(message []byte) []byte {
+	frame := make([]byte, headerLen+len(message))
+	frame[0] = flagData
+	binary.BigEndian.PutUint32(frame[1:headerLen], uint32(len(message)))
+	copy(frame[headerLen:], message)
+	return frame
+}
+
+// ParseResponse extracts the message from a gRPC-Web response body and checks
+// the grpc-status, taken from the trailer frame or, for trailers-only responses,
+// from the HTTP headers.
+func ParseResponse(body []byte, headers http.Header) ([]byte, error) {
+	var message []byte
+	trailer := http.Header{}
+
+	for len(body) > 0 {
+		if len(body) < headerLen {
+			return nil, errors.New("grpc-web: truncated frame header")
+		}
+		flag := body[0]
+		length := binary.BigEndian.Uint32(body[1:headerLen])
+		if uint64(len(body)-headerLen) < uint64(length) {
+			return nil, errors.New("grpc-web: truncated frame")
+		}
+		payload := body[headerLen : headerLen+int(length)]
+		body = body[headerLen+int(length):]
+
+		switch {
+		case flag&flagTrailer != 0:
+			parsed, err := parseTrailer(payload)
+			if err != nil {
+				return nil, err
+			}
+			trailer = parsed
+		case flag&flagCompressed != 0:
+			return nil, errors.New("grpc-web: compressed messages are not supported")
+		default:
+			message = payload
+		}
+	}
+
+	status := trailer.Get("Grpc-Status")
+	statusMessage := trailer.Get("Grpc-Message")
+	if status == "" {
+		status = headers.Get("Grpc-Status")
+		statusMessage = headers.Get("Grpc-Message")
+	}
+	if status == "" {
+		return nil, errors.New("grpc-web: missing grpc-status")
+	}
+
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return nil, fmt.Errorf("grpc-web: invalid grpc-status %q", status)
+	}
+	if code != 0 {
+		// grpc-message is percent-encoded on the wire
+		if decoded, err := url.PathUnescape(statusMessage); err == nil {
+			statusMessage = decoded
+		}
+		return nil, &StatusError{Code: code, Message: statusMessage}
+	}
+	if message == nil {
+		message = []byte{}
+	}
+	return message, nil
+}
+
+// parseTrailer parses the "key: value\r\n" lines of a trailer frame.
+func parseTrailer(payload []byte) (http.Header, error) {
+	trailer := http.Header{}
+	for _, line := range strings.Split(string(payload), "\r\n") {
+		if line == "" {
+			continue
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("grpc-web: malformed trailer line %q", line)
+		}
+		trailer.Add(textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(key)), strings.TrimSpace(value))
+	}
+	return trailer, nil
+}
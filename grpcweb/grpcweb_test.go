@@ -0,0 +1,141 @@
+package grpcweb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	reqws "github.com/gurizzu/go-reqws"
+)
+
+// trailerFrame encodes trailer lines as a gRPC-Web trailer frame.
+func trailerFrame(lines string) []byte {
+	frame := Frame([]byte(lines))
+	frame[0] = flagTrailer
+	return frame
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	for _, message := range [][]byte{{}, []byte("hello"), bytes.Repeat([]byte{0xff}, 70000)} {
+		frame := Frame(message)
+		if frame[0] != flagData || binary.BigEndian.Uint32(frame[1:headerLen]) != uint32(len(message)) {
+			t.Errorf("frame header = %x, want a data frame of length %d", frame[:headerLen], len(message))
+		}
+		body := append(frame, trailerFrame("grpc-status: 0\r\n")...)
+		got, err := ParseResponse(body, http.Header{})
+		if err != nil {
+			t.Fatalf("ParseResponse: %v", err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("message = %d bytes, want %d", len(got), len(message))
+		}
+	}
+}
+
+func TestParseResponseTrailers(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		headers     http.Header
+		want        string
+		wantCode    int
+		wantMessage string
+		wantErr     string
+	}{
+		{"ok", append(Frame([]byte("m")), trailerFrame("grpc-status: 0\r\ngrpc-message: \r\n")...), nil, "m", 0, "", ""},
+		{"error status with encoded message", trailerFrame("Grpc-Status: 5\r\nGrpc-Message: user%20not%20found\r\n"), nil, "", 5, "user not found", ""},
+		{"trailer keys are case-insensitive and trimmed", trailerFrame("GRPC-STATUS:  7 \r\ngrpc-message:denied"), nil, "", 7, "denied", ""},
+		{"trailers-only response", nil, http.Header{"Grpc-Status": {"16"}, "Grpc-Message": {"no token"}}, "", 16, "no token", ""},
+		{"trailer frame wins over headers", append(Frame([]byte("m")), trailerFrame("grpc-status: 0\r\n")...), http.Header{"Grpc-Status": {"13"}}, "m", 0, "", ""},
+		{"no message", trailerFrame("grpc-status: 0\r\n"), nil, "", 0, "", ""},
+		{"missing status", Frame([]byte("m")), nil, "", 0, "", "grpc-web: missing grpc-status"},
+		{"invalid status", trailerFrame("grpc-status: ok\r\n"), nil, "", 0, "", `grpc-web: invalid grpc-status "ok"`},
+		{"malformed trailer line", trailerFrame("grpc-status 0\r\n"), nil, "", 0, "", `grpc-web: malformed trailer line "grpc-status 0"`},
+		{"compressed message", append([]byte{flagCompressed, 0, 0, 0, 1, 'm'}, trailerFrame("grpc-status: 0\r\n")...), nil, "", 0, "", "grpc-web: compressed messages are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := tt.headers
+			if headers == nil {
+				headers = http.Header{}
+			}
+			got, err := ParseResponse(tt.body, headers)
+			var statusErr *StatusError
+			switch {
+			case tt.wantErr != "":
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			case tt.wantCode != 0:
+				if !errors.As(err, &statusErr) || statusErr.Code != tt.wantCode || statusErr.Message != tt.wantMessage {
+					t.Errorf("error = %v, want status %d %q", err, tt.wantCode, tt.wantMessage)
+				}
+			case err != nil || string(got) != tt.want || got == nil:
+				t.Errorf("ParseResponse = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResponseTruncated(t *testing.T) {
+	full := append(Frame([]byte("hello")), trailerFrame("grpc-status: 0\r\n")...)
+	tests := []struct {
+		name    string
+		body    []byte
+		wantErr string
+	}{
+		{"short header", full[:3], "grpc-web: truncated frame header"},
+		{"short payload", full[:headerLen+2], "grpc-web: truncated frame"},
+		{"short trailer", full[:len(full)-1], "grpc-web: truncated frame"},
+		{"length beyond body", []byte{flagData, 0xff, 0xff, 0xff, 0xff, 'm'}, "grpc-web: truncated frame"},
+		{"header after a frame", append(Frame([]byte("m")), flagTrailer, 0), "grpc-web: truncated frame header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResponse(tt.body, http.Header{"Grpc-Status": {"0"}})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseResponse = %q, %v; want error %q", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path != "/users.v1.UserService/GetUser" || r.Header.Get("Content-Type") != ContentType || r.Header.Get("X-Grpc-Web") != "1" {
+			t.Errorf("request %s %s, Content-Type %q", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		request, err := ParseResponse(append(body, trailerFrame("grpc-status: 0")...), http.Header{})
+		if err != nil {
+			t.Errorf("request body: %v", err)
+		}
+		w.Header().Set("Content-Type", ContentType)
+		if string(request) == "missing" {
+			w.Write(trailerFrame("grpc-status: 5\r\ngrpc-message: user%201%20not%20found\r\n"))
+			return
+		}
+		w.Write(Frame(append([]byte("user:"), request...)))
+		w.Write(trailerFrame("grpc-status: 0\r\n"))
+	}))
+	t.Cleanup(server.Close)
+	client := reqws.NewClient(server.URL, 5*time.Second)
+
+	got, err := Invoke(context.Background(), client, "/users.v1.UserService/GetUser", []byte("1"))
+	if err != nil || string(got) != "user:1" {
+		t.Errorf("Invoke = %q, %v; want %q", got, err, "user:1")
+	}
+
+	_, err = Invoke(context.Background(), client, "/users.v1.UserService/GetUser", []byte("missing"))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != 5 || !strings.Contains(statusErr.Message, "user 1 not found") {
+		t.Errorf("Invoke error = %v, want status 5", err)
+	}
+}
@@ -0,0 +1,125 @@
+package reqws
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithGzipBody compresses the request body with gzip and sets the
+// Content-Encoding: gzip header, for servers that accept compressed uploads.
+// It applies to every kind of body (JSON, raw, streamed, form and multipart) and
+// each attempt is compressed again, so it composes with retries. An empty body
+// is sent as is, without the header.
+//
+// Bodies held in memory are compressed before sending and keep an exact
+// Content-Length; streamed bodies are compressed as they are sent, chunked.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/telemetry"),
+//		reqws.WithJSON(batch),
+//		reqws.WithGzipBody(),
+//	)
+func WithGzipBody() RequestOption {
+	return WithGzipBodyLevel(gzip.DefaultCompression)
+}
+
+// WithGzipBodyLevel is WithGzipBody with a compression level from
+// gzip.HuffmanOnly to gzip.BestCompression; any other level fails the request.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/telemetry"),
+//		reqws.WithJSON(batch),
+//		reqws.WithGzipBodyLevel(gzip.BestSpeed),
+//	)
+func WithGzipBodyLevel(level int) RequestOption {
+	return func(c *requestConfig) {
+		c.gzipBody = true
+		c.gzipLevel = level
+	}
+}
+
+// gzipBody returns body compressed at level, and whether it compressed it.
+// Bodies with a length are compressed into a buffer, unless empty; streams are
+// compressed through a pipe as they are read. A stream that turns out empty is
+// returned as an empty reader.
+func gzipBody(body io.Reader, level int) (io.Reader, bool, error) {
+	if sized, ok := body.(interface{ Len() int }); ok {
+		if sized.Len() == 0 {
+			return body, false, nil
+		}
+		var buf bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid gzip level: %w", err)
+		}
+		_, err = io.Copy(zw, body)
+		if closer, ok := body.(io.Closer); ok {
+			// Returns a pooled buffer
+			closer.Close()
+		}
+		if err == nil {
+			err = zw.Close()
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+		}
+		return &buf, true, nil
+	}
+
+	if _, ok := body.(*io.PipeReader); !ok {
+		br := bufio.NewReader(body)
+		if _, err := br.Peek(1); err == io.EOF {
+			return strings.NewReader(""), false, nil
+		} else if err != nil {
+			return nil, false, fmt.Errorf("failed to read request body: %w", err)
+		}
+		body = readCloser{Reader: br, src: body}
+	}
+	compressed, err := gzipStream(body, level)
+	if err != nil {
+		return nil, false, err
+	}
+	return compressed, true, nil
+}
+
+// gzipStream compresses body through a pipe. Closing the returned reader stops
+// the compression, and body is closed when it ends, as the transport would have.
+func gzipStream(body io.Reader, level int) (*io.PipeReader, error) {
+	pr, pw := io.Pipe()
+	zw, err := gzip.NewWriterLevel(pw, level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gzip level: %w", err)
+	}
+	go func() {
+		_, err := io.Copy(zw, body)
+		if err == nil {
+			err = zw.Close()
+		}
+		if closer, ok := body.(io.Closer); ok {
+			closer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// readCloser reads through Reader and closes src, if it is an io.Closer.
+type readCloser struct {
+	io.Reader
+	src io.Reader
+}
+
+func (r readCloser) Close() error {
+	if closer, ok := r.src.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
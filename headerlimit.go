@@ -0,0 +1,56 @@
+package reqws
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxHeaderBytes is net/http's response header limit when
+// Transport.MaxResponseHeaderBytes is zero.
+const defaultMaxHeaderBytes = 10 << 20
+
+// HeaderTooLargeError is returned when a response's headers exceed the limit set
+// with Client.WithMaxHeaderBytes(). It is not retried.
+type HeaderTooLargeError struct {
+	Limit int64 // Header bytes allowed
+	Err   error // Transport error
+}
+
+func (e *HeaderTooLargeError) Error() string {
+	return fmt.Sprintf("response headers exceed %d bytes", e.Limit)
+}
+
+// Unwrap returns the underlying error for error chain support.
+func (e *HeaderTooLargeError) Unwrap() error {
+	return e.Err
+}
+
+// WithMaxHeaderBytes limits the size of response headers, including the status
+// line, to n bytes, guarding against untrusted servers that send enormous headers.
+// It sets the client transport's MaxResponseHeaderBytes; without it net/http
+// allows 10 MB. Responses over the limit are aborted while the headers are read
+// and fail with *HeaderTooLargeError.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://untrusted.example.com", 30*time.Second).
+//		WithMaxHeaderBytes(64 << 10)
+func (c *Client) WithMaxHeaderBytes(n int64) *Client {
+	c.transport().MaxResponseHeaderBytes = n
+	return c
+}
+
+// headerLimitError returns a *HeaderTooLargeError if err is the transport's
+// header limit error, and err unchanged otherwise.
+func (c *Client) headerLimitError(err error) error {
+	// net/http does not export the error, only its message
+	if !strings.Contains(err.Error(), "server response headers exceeded") {
+		return err
+	}
+	limit := int64(defaultMaxHeaderBytes)
+	if transport, ok := c.client.Transport.(*http.Transport); ok && transport.MaxResponseHeaderBytes > 0 {
+		limit = transport.MaxResponseHeaderBytes
+	}
+	return &HeaderTooLargeError{Limit: limit, Err: err}
+}
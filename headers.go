@@ -0,0 +1,291 @@
+package reqws
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeFormatter formats a timestamp for use in a header value.
+type TimeFormatter func(t time.Time) string
+
+// FormatUnix formats t as Unix seconds, e.g. "1700000000".
+func FormatUnix(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// FormatUnixMilli formats t as Unix milliseconds, e.g. "1700000000000".
+func FormatUnixMilli(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+// FormatRFC1123 formats t in the HTTP date format, e.g. "Tue, 14 Nov 2023 22:13:20 GMT".
+func FormatRFC1123(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
+
+// FormatISO8601 formats t as ISO 8601 in UTC, e.g. "2023-11-14T22:13:20Z".
+func FormatISO8601(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+// FormatISO8601Basic formats t as compact ISO 8601 in UTC as used by AWS SigV4, e.g. "20231114T221320Z".
+func FormatISO8601Basic(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// SetDefaultHeaders sets headers sent with every HTTP request of the client, e.g.
+// an application version or tenant ID. A header set on the request with
+// WithHeader() or WithHeaderExactCase() replaces the default of the same name
+// instead of adding to it. headers is copied; calling SetDefaultHeaders again
+// replaces the previous defaults, and nil removes them.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		SetDefaultHeaders(http.Header{
+//			"X-App-Version": {"2.4.1"},
+//			"X-Tenant-Id":   {tenantID},
+//			"Accept":        {"application/json"},
+//		})
+func (c *Client) SetDefaultHeaders(headers http.Header) *Client {
+	c.headers = headers.Clone()
+	return c
+}
+
+// setDefaultHeaders adds the client's default headers to header, except those
+// the request sets itself.
+func (c *Client) setDefaultHeaders(header http.Header, config *requestConfig) {
+	if len(c.headers) == 0 {
+		return
+	}
+	requestKeys := make(map[string]bool, len(config.headers))
+	for key := range config.headers {
+		requestKeys[http.CanonicalHeaderKey(key)] = true
+	}
+	for key, values := range c.headers {
+		if !requestKeys[http.CanonicalHeaderKey(key)] {
+			header[key] = append(header[key], values...)
+		}
+	}
+}
+
+// WithHeaderExactCase adds a header sent with exactly the given name casing, for
+// legacy servers that match header names case-sensitively (e.g. "SOAPAction").
+// http.Header canonicalizes names ("Soapaction"), so the value is written to the
+// header map under key as is, which net/http sends verbatim over HTTP/1.1.
+//
+// HTTP/2 lowercases all header names, so a request with this option is sent over
+// HTTP/1.1 even if the server supports HTTP/2. Hooks and interceptors must read
+// the header by its exact key, e.g. req.Header["SOAPAction"]; Header.Get() looks up
+// the canonical name and does not find it.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/device/control"),
+//		reqws.WithHeaderExactCase("SOAPAction", `"urn:device:SetPower"`),
+//		reqws.WithHeaderExactCase("X-DeviceID", deviceID),
+//		reqws.WithRawBody(envelope, "text/xml; charset=utf-8"),
+//	)
+func WithHeaderExactCase(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.headers[key] = append(c.headers[key], value)
+		c.forceHTTP1 = true
+	}
+}
+
+// WithHeaderFunc sets a header whose value is computed when each attempt is sent,
+// including retries. Header functions run before the before-request hooks, so a
+// signing hook sees the final values.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/orders"),
+//		reqws.WithHeaderFunc("X-Request-ID", newRequestID),
+//	)
+func WithHeaderFunc(key string, fn func() string) RequestOption {
+	return func(c *requestConfig) {
+		c.headerFuncs = append(c.headerFuncs, headerFunc{key: key, fn: fn})
+	}
+}
+
+// WithTimestampHeader sets a header to the current time in the given format,
+// computed fresh for every attempt.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/payments"),
+//		reqws.WithTimestampHeader("X-Amz-Date", reqws.FormatISO8601Basic),
+//		reqws.WithBeforeRequest(signRequest),
+//	)
+func WithTimestampHeader(key string, format TimeFormatter) RequestOption {
+	return WithHeaderFunc(key, func() string {
+		return format(time.Now())
+	})
+}
+
+// WithUnixTimestampHeader sets a header to the current Unix time in seconds,
+// computed fresh for every attempt.
+//
+// Example:
+//
+//	client.Request(ctx, reqws.GET("/data"), reqws.WithUnixTimestampHeader("X-Timestamp"))
+func WithUnixTimestampHeader(key string) RequestOption {
+	return WithTimestampHeader(key, FormatUnix)
+}
+
+// WithIdempotencyKey sets the Idempotency-Key header. If key is empty, a random
+// UUID is generated once per Request()/Do() call.
+//
+// The key stays the same on every retry attempt, while header functions and
+// before-request hooks run again for each attempt. Combined with
+// WithTimestampHeader() and a signing hook, each retry carries the same key but
+// a fresh timestamp and signature.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/payments"),
+//		reqws.WithJSON(payment),
+//		reqws.WithIdempotencyKey(""),
+//		reqws.WithUnixTimestampHeader("X-Timestamp"),
+//		reqws.WithBeforeRequest(signHMAC),
+//		reqws.WithDefaultRetry(),
+//	)
+func WithIdempotencyKey(key string) RequestOption {
+	return func(c *requestConfig) {
+		value := key
+		if value == "" {
+			value = newUUID()
+		}
+		c.headers.Set("Idempotency-Key", value)
+	}
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("reqws: failed to generate random UUID: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+type headerFunc struct {
+	key string
+	fn  func() string
+}
+
+// ForwardedParams is one element of the RFC 7239 Forwarded header, describing a
+// single proxy hop. Empty fields are omitted.
+type ForwardedParams struct {
+	For   string // Client address, e.g. "192.0.2.60", "2001:db8::1", "unknown" or "_hidden"
+	By    string // Interface the request came in on at the proxy
+	Host  string // Host header the proxy received
+	Proto string // Scheme the proxy received, e.g. "https"
+}
+
+// String formats the element, quoting values as RFC 7239 requires,
+// e.g. `for="[2001:db8::1]";proto=https`.
+func (p ForwardedParams) String() string {
+	var pairs []string
+	for _, pair := range [][2]string{{"for", p.For}, {"by", p.By}, {"host", p.Host}, {"proto", p.Proto}} {
+		if pair[1] == "" {
+			continue
+		}
+		value := pair[1]
+		if pair[0] == "for" || pair[0] == "by" {
+			value = forwardedNode(value)
+		}
+		pairs = append(pairs, pair[0]+"="+forwardedValue(value))
+	}
+	return strings.Join(pairs, ";")
+}
+
+// WithForwarded appends a hop to the RFC 7239 Forwarded header, after any value
+// already set by earlier options (e.g. one copied from the incoming request).
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/orders"),
+//		reqws.WithHeader("Forwarded", in.Header.Get("Forwarded")),
+//		reqws.WithForwarded(reqws.ForwardedParams{For: clientIP, Proto: "https", Host: in.Host}),
+//	)
+func WithForwarded(params ForwardedParams) RequestOption {
+	return func(c *requestConfig) {
+		appendHeaderList(c.headers, "Forwarded", params.String())
+	}
+}
+
+// WithForwardedFor appends clientIP to the X-Forwarded-For header, after any
+// addresses already set by earlier options.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/orders"),
+//		reqws.WithHeader("X-Forwarded-For", in.Header.Get("X-Forwarded-For")),
+//		reqws.WithForwardedFor(clientIP),
+//	)
+func WithForwardedFor(clientIP string) RequestOption {
+	return func(c *requestConfig) {
+		appendHeaderList(c.headers, "X-Forwarded-For", clientIP)
+	}
+}
+
+// WithForwardedProto sets the X-Forwarded-Proto header to the scheme the client
+// used, e.g. "https". Unlike X-Forwarded-For it holds a single value and is replaced.
+func WithForwardedProto(proto string) RequestOption {
+	return func(c *requestConfig) {
+		c.headers.Set("X-Forwarded-Proto", proto)
+	}
+}
+
+// appendHeaderList appends value to a comma-separated list header, merging
+// existing header lines into one and skipping empty ones.
+func appendHeaderList(h http.Header, key, value string) {
+	var values []string
+	for _, v := range h.Values(key) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	h.Set(key, strings.Join(append(values, value), ", "))
+}
+
+// forwardedNode brackets IPv6 addresses as RFC 7239 node identifiers require.
+func forwardedNode(node string) string {
+	if ip := net.ParseIP(node); ip != nil && ip.To4() == nil {
+		return "[" + node + "]"
+	}
+	return node
+}
+
+// forwardedValue returns value as a token, or as a quoted string if it contains
+// characters a token can't.
+func forwardedValue(value string) string {
+	for _, r := range value {
+		if !isTokenChar(r) {
+			return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+		}
+	}
+	return value
+}
+
+// isTokenChar reports whether r may appear in an RFC 7230 token.
+func isTokenChar(r rune) bool {
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
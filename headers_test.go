@@ -0,0 +1,266 @@
+package reqws
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	server, log := recordingServer(t, failFirst(2))
+	attempt := 0
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(),
+		POST("/payments"),
+		WithIdempotencyKey(""),
+		WithHeaderFunc("X-Attempt", func() string {
+			attempt++
+			return strconv.Itoa(attempt)
+		}),
+		WithBeforeRequest(func(req *http.Request) error {
+			// A signing hook sees the key and the per-attempt header
+			req.Header.Set("X-Signature", req.Header.Get("Idempotency-Key")+"/"+req.Header.Get("X-Attempt"))
+			return nil
+		}),
+		WithRetry(fastRetry(3)),
+	)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	requests := log.All()
+	if len(requests) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(requests))
+	}
+	key := requests[0].Header.Get("Idempotency-Key")
+	if !uuidV4.MatchString(key) {
+		t.Errorf("Idempotency-Key = %q, want a version 4 UUID", key)
+	}
+	for i, req := range requests {
+		if got := req.Header.Get("Idempotency-Key"); got != key {
+			t.Errorf("attempt %d: Idempotency-Key = %q, want %q", i+1, got, key)
+		}
+		if got, want := req.Header.Get("X-Signature"), key+"/"+strconv.Itoa(i+1); got != want {
+			t.Errorf("attempt %d: X-Signature = %q, want %q", i+1, got, want)
+		}
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+	for _, key := range []string{"", "", "order-7"} {
+		if _, err := client.Do(context.Background(), POST("/payments"), WithIdempotencyKey(key)); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+	}
+	requests := log.All()
+	if requests[0].Header.Get("Idempotency-Key") == requests[1].Header.Get("Idempotency-Key") {
+		t.Error("generated keys repeat across calls")
+	}
+	if got := requests[2].Header.Get("Idempotency-Key"); got != "order-7" {
+		t.Errorf("Idempotency-Key = %q, want the given key", got)
+	}
+}
+
+// rawHeaderServer returns the address of an HTTP/1.1 server that sends the
+// header lines of each request, exactly as received, on lines.
+func rawHeaderServer(t *testing.T) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	lines := make(chan []string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				var header []string
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line = strings.TrimRight(line, "\r\n"); line == "" {
+						break
+					}
+					header = append(header, line)
+				}
+				lines <- header[1:] // Without the request line
+				io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+			}()
+		}
+	}()
+	return "http://" + ln.Addr().String(), lines
+}
+
+func TestHeaderExactCase(t *testing.T) {
+	url, lines := rawHeaderServer(t)
+	var hookValue []string
+	_, err := NewClient(url, 5*time.Second).Do(context.Background(), POST("/device/control"),
+		WithHeaderExactCase("SOAPAction", `"urn:device:SetPower"`),
+		WithHeaderExactCase("X-DeviceID", "d1"),
+		WithHeaderExactCase("X-DeviceID", "d2"),
+		WithHeader("soapaction-version", "1"),
+		WithBeforeRequest(func(req *http.Request) error {
+			hookValue = req.Header["SOAPAction"]
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	header := strings.Join(<-lines, "\n")
+	for _, want := range []string{`SOAPAction: "urn:device:SetPower"`, "X-DeviceID: d1", "X-DeviceID: d2", "Soapaction-Version: 1"} {
+		if !strings.Contains(header, want) {
+			t.Errorf("request header lacks %q:\n%s", want, header)
+		}
+	}
+	if len(hookValue) != 1 || hookValue[0] != `"urn:device:SetPower"` {
+		t.Errorf("hook saw SOAPAction %q, want it under the exact key", hookValue)
+	}
+}
+
+func TestHeaderExactCaseForcesHTTP1(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	client := NewClient(server.URL, 5*time.Second)
+	client.client.Transport = server.Client().Transport
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, GET("/"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.String() != "HTTP/2.0" {
+		t.Fatalf("proto = %s, want HTTP/2.0 without the option", resp.String())
+	}
+	resp, err = client.Do(ctx, GET("/"), WithHeaderExactCase("SOAPAction", "x"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.String() != "HTTP/1.1" {
+		t.Errorf("proto = %s, want HTTP/1.1 with WithHeaderExactCase", resp.String())
+	}
+	// The client's own transport keeps HTTP/2
+	if protos := client.client.Transport.(*http.Transport).TLSClientConfig.NextProtos; !slices.Contains(protos, "h2") || slices.Contains(protos, "") {
+		t.Errorf("client's NextProtos = %q, want h2 kept", protos)
+	}
+	resp, err = client.Do(ctx, GET("/"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.String() != "HTTP/2.0" {
+		t.Errorf("proto = %s after an exact-case request, want HTTP/2.0", resp.String())
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	server, log := recordingServer(t, failFirst(1))
+	defaults := http.Header{"X-App-Version": {"2.4.1"}, "X-Tenant-Id": {"acme"}}
+	client := NewClient(server.URL, 5*time.Second).SetDefaultHeaders(defaults)
+	defaults.Set("X-App-Version", "mutated")
+
+	if _, err := client.Do(context.Background(), GET("/"), WithRetry(fastRetry(1))); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	requests := log.All()
+	if len(requests) != 2 {
+		t.Fatalf("got %d attempts, want 2", len(requests))
+	}
+	for i, req := range requests {
+		if got := req.Header.Values("X-App-Version"); !slices.Equal(got, []string{"2.4.1"}) {
+			t.Errorf("attempt %d: X-App-Version = %q, want the copied default once", i+1, got)
+		}
+		if got := req.Header.Values("X-Tenant-Id"); !slices.Equal(got, []string{"acme"}) {
+			t.Errorf("attempt %d: X-Tenant-Id = %q, want [acme]", i+1, got)
+		}
+	}
+}
+
+func TestDefaultHeadersOverriddenByRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []RequestOption
+		key  string
+		want []string
+	}{
+		{"no override", nil, "X-Tenant-Id", []string{"acme"}},
+		{"WithHeader", []RequestOption{WithHeader("x-tenant-id", "other")}, "X-Tenant-Id", []string{"other"}},
+		{"WithHeaderExactCase", []RequestOption{WithHeaderExactCase("x-tenant-id", "exact")}, "X-Tenant-Id", []string{"exact"}},
+		{"other headers kept", []RequestOption{WithHeader("X-Trace", "1")}, "X-App-Version", []string{"2.4.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second).SetDefaultHeaders(http.Header{
+				"X-App-Version": {"2.4.1"},
+				"X-Tenant-Id":   {"acme"},
+			})
+			if _, err := client.Do(context.Background(), append([]RequestOption{GET("/")}, tt.opts...)...); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Header.Values(tt.key); !slices.Equal(got, tt.want) {
+				t.Errorf("%s = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultHeadersAcceptAndDialect(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults http.Header
+		want     string
+	}{
+		{"default wins", http.Header{"Accept": {"application/xml"}}, "application/xml"},
+		{"dialect fallback", http.Header{"X-App-Version": {"2.4.1"}}, "application/hal+json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second).WithDialect(DialectHAL).SetDefaultHeaders(tt.defaults)
+			if _, err := client.Do(context.Background(), GET("/")); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Header.Values("Accept"); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("Accept = %q, want [%s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultHeadersRemoved(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second).
+		SetDefaultHeaders(http.Header{"X-Tenant-Id": {"acme"}}).
+		SetDefaultHeaders(nil)
+	if _, err := client.Do(context.Background(), GET("/")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := log.Last(t).Header.Get("X-Tenant-Id"); got != "" {
+		t.Errorf("X-Tenant-Id = %q after SetDefaultHeaders(nil), want none", got)
+	}
+}
@@ -0,0 +1,136 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// testServer starts a server running handler and closes it when the test ends.
+func testServer(t testing.TB, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recordedRequest is a request received by a recordingServer.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  string // Raw query
+	Header http.Header
+	Body   string
+}
+
+// requestLog is the requests a recordingServer received, in arrival order.
+type requestLog struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// Count returns how many requests were received.
+func (l *requestLog) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.requests)
+}
+
+// All returns a copy of the requests received so far.
+func (l *requestLog) All() []recordedRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]recordedRequest(nil), l.requests...)
+}
+
+// Last returns the last request received and fails the test if there is none.
+func (l *requestLog) Last(t *testing.T) recordedRequest {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.requests) == 0 {
+		t.Fatal("no request received")
+	}
+	return l.requests[len(l.requests)-1]
+}
+
+// recordingServer starts a server that records every request, including its
+// body, then answers it with handler, or with an empty 200 if handler is nil.
+// The handler can still read the request body.
+func recordingServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *requestLog) {
+	t.Helper()
+	log := &requestLog{}
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		log.mu.Lock()
+		log.requests = append(log.requests, recordedRequest{
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Query:  r.URL.RawQuery,
+			Header: r.Header.Clone(),
+			Body:   string(body),
+		})
+		log.mu.Unlock()
+		if handler != nil {
+			handler(w, r)
+		}
+	})
+	return server, log
+}
+
+// failFirst returns a handler that answers the first n requests with 503 and
+// later ones with an empty 200.
+func failFirst(n int) http.HandlerFunc {
+	var mu sync.Mutex
+	seen := 0
+	return func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen++
+		fail := seen <= n
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// fastRetry returns a RetryConfig with short delays for tests.
+func fastRetry(maxRetries int) RetryConfig {
+	return RetryConfig{MaxRetries: maxRetries, InitialDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Multiplier: 2}
+}
+
+// wsServer starts a WebSocket server running handler for each connection and
+// returns its ws:// URL.
+func wsServer(t testing.TB, handler func(conn *websocket.Conn)) string {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Errorf("Accept: %v", err)
+			return
+		}
+		handler(conn)
+	})
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// wsTestConn dials a server running handler and returns the client side of the
+// connection.
+func wsTestConn(t *testing.T, handler func(conn *websocket.Conn)) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.Dial(context.Background(), wsServer(t, handler), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return conn
+}
@@ -0,0 +1,281 @@
+package reqws
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// RetryPriority controls how retried attempts queue for a host slot under
+// Client.WithHostConcurrency().
+type RetryPriority int
+
+const (
+	RetryPriorityLow   RetryPriority = iota // Queue behind first attempts (default)
+	RetryPriorityEqual                      // Queue in arrival order with first attempts
+)
+
+// HostLimitConfig defines the configuration for the per-host concurrency limit.
+type HostLimitConfig struct {
+	MaxPerHost int           // Attempts in flight per host; further attempts queue. Zero or less is unlimited
+	RetryAging time.Duration // Queued retries older than this are served like first attempts (default: 1s)
+}
+
+// HostLimitStats holds per-host concurrency counters, summed over all hosts.
+type HostLimitStats struct {
+	InFlight        int    // Attempts holding a slot
+	Queued          int    // First attempts waiting for a slot
+	QueuedRetries   int    // Retried attempts waiting for a slot
+	RetriesDelayed  uint64 // Retries passed over by a later first attempt ("delayed by fairness")
+	RetriesPromoted uint64 // Retries served early after waiting RetryAging
+}
+
+// WithHostConcurrency limits the attempts in flight per host, from sending the
+// request until its response body is closed. Attempts beyond the limit wait in a
+// queue until a slot frees or their context is done.
+//
+// The queue is fair to user-facing traffic: a retried attempt, including one whose
+// backoff ends while the host is saturated, queues behind first attempts, so a
+// burst of retries cannot starve fresh requests. To keep retries from starving in
+// turn, a retry that has waited RetryAging is served next. Set
+// RetryConfig.RetryPriority to RetryPriorityEqual to queue retries in plain arrival
+// order instead.
+//
+// A MaxPerHost of zero or less puts no limit on the attempts in flight: they are
+// only counted in HostLimitStats(). WithAdaptiveConcurrency() replaces MaxPerHost
+// with its adaptive limit.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithHostConcurrency(reqws.HostLimitConfig{MaxPerHost: 8})
+func (c *Client) WithHostConcurrency(config HostLimitConfig) *Client {
+	if config.RetryAging <= 0 {
+		config.RetryAging = time.Second
+	}
+	limiter := &hostLimiter{config: config, hosts: make(map[string]*hostSlots)}
+	if c.hostLimit != nil {
+		// Keep adaptive limits set with WithAdaptiveConcurrency()
+		limiter.adaptive, limiter.limits = c.hostLimit.adaptive, c.hostLimit.limits
+	}
+	c.hostLimit = limiter
+	return c
+}
+
+// HostLimitStats returns the current per-host concurrency counters.
+// Returns zero values if no limit is set.
+func (c *Client) HostLimitStats() HostLimitStats {
+	if c.hostLimit == nil {
+		return HostLimitStats{}
+	}
+	return c.hostLimit.stats()
+}
+
+type hostLimiter struct {
+	config   HostLimitConfig
+	adaptive *AdaptiveConcurrencyConfig // Replaces MaxPerHost when set
+
+	mu       sync.Mutex
+	hosts    map[string]*hostSlots
+	limits   map[string]*adaptiveLimit // Adaptive state per host, kept while the host is idle
+	delayed  uint64
+	promoted uint64
+}
+
+type hostSlots struct {
+	inFlight int
+	first    list.List // *hostWaiter for first attempts and equal-priority retries
+	retries  list.List // *hostWaiter for low-priority retries
+}
+
+type hostWaiter struct {
+	queued  time.Time
+	delayed bool
+	ready   chan struct{}
+}
+
+// acquire takes a slot for host, waiting in the queue matching the attempt.
+// Under an adaptive limit, sheddable attempts fail instead of waiting.
+func (l *hostLimiter) acquire(ctx context.Context, host string, retry, sheddable bool) error {
+	l.mu.Lock()
+	slots := l.hosts[host]
+	if slots == nil {
+		slots = &hostSlots{}
+		l.hosts[host] = slots
+	}
+	// Slots are granted to waiters as they free, so a free slot means an empty queue
+	limit := l.limitLocked(host)
+	if slots.inFlight < limit {
+		slots.inFlight++
+		l.mu.Unlock()
+		return nil
+	}
+	if l.adaptive != nil && sheddable {
+		err := &ShedError{Reason: fmt.Sprintf("adaptive concurrency limit %d reached", limit), InFlight: slots.inFlight}
+		l.cleanupLocked(host, slots)
+		l.mu.Unlock()
+		return err
+	}
+	queue := &slots.first
+	if retry {
+		queue = &slots.retries
+	}
+	w := &hostWaiter{queued: time.Now(), ready: make(chan struct{})}
+	elem := queue.PushBack(w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		select {
+		case <-w.ready:
+			// Granted while giving up; hand the slot on
+			slots.inFlight--
+			l.grantLocked(host, slots)
+		default:
+			queue.Remove(elem)
+			l.cleanupLocked(host, slots)
+		}
+		l.mu.Unlock()
+		return fmt.Errorf("waiting for host slot: %w", ctx.Err())
+	}
+}
+
+// release frees a slot for host and hands it to the next waiter.
+func (l *hostLimiter) release(host string) {
+	l.mu.Lock()
+	if slots := l.hosts[host]; slots != nil {
+		slots.inFlight--
+		l.grantLocked(host, slots)
+	}
+	l.mu.Unlock()
+}
+
+// grantLocked hands free slots to waiters: aged retries first, then first
+// attempts, then the remaining retries.
+func (l *hostLimiter) grantLocked(host string, slots *hostSlots) {
+	limit := l.limitLocked(host)
+	for slots.inFlight < limit {
+		queue := &slots.first
+		if retry := slots.retries.Front(); retry != nil {
+			aged := time.Since(retry.Value.(*hostWaiter).queued) >= l.config.RetryAging
+			if aged && slots.first.Len() > 0 {
+				l.promoted++
+			}
+			if aged || slots.first.Len() == 0 {
+				queue = &slots.retries
+			}
+		}
+		elem := queue.Front()
+		if elem == nil {
+			break
+		}
+		if queue == &slots.first {
+			l.markDelayedLocked(slots, elem.Value.(*hostWaiter).queued)
+		}
+		queue.Remove(elem)
+		slots.inFlight++
+		close(elem.Value.(*hostWaiter).ready)
+	}
+	l.cleanupLocked(host, slots)
+}
+
+// limitLocked returns the number of attempts allowed in flight for host.
+func (l *hostLimiter) limitLocked(host string) int {
+	if l.adaptive == nil {
+		if l.config.MaxPerHost <= 0 {
+			return math.MaxInt
+		}
+		return l.config.MaxPerHost
+	}
+	return l.adaptiveLocked(host).stats.Limit
+}
+
+// adaptiveLocked returns the adaptive state of host, creating it on first use.
+func (l *hostLimiter) adaptiveLocked(host string) *adaptiveLimit {
+	a := l.limits[host]
+	if a == nil {
+		a = newAdaptiveLimit(l.adaptive)
+		l.limits[host] = a
+	}
+	return a
+}
+
+// observe feeds an attempt's latency into the adaptive limit of host and grants
+// slots if the limit grew.
+func (l *hostLimiter) observe(host string, latency time.Duration, failed bool) {
+	if l.adaptive == nil {
+		return
+	}
+	l.mu.Lock()
+	l.adaptiveLocked(host).update(l.adaptive, time.Now(), latency, failed)
+	if slots := l.hosts[host]; slots != nil {
+		l.grantLocked(host, slots)
+	}
+	l.mu.Unlock()
+}
+
+func (l *hostLimiter) adaptiveStats() map[string]AdaptiveLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make(map[string]AdaptiveLimitStats, len(l.limits))
+	for host, a := range l.limits {
+		stats[host] = a.stats
+	}
+	return stats
+}
+
+// markDelayedLocked counts the queued retries that a first attempt queued at
+// granted is served ahead of. Each retry is counted once.
+func (l *hostLimiter) markDelayedLocked(slots *hostSlots, granted time.Time) {
+	for elem := slots.retries.Front(); elem != nil; elem = elem.Next() {
+		w := elem.Value.(*hostWaiter)
+		if !w.delayed && w.queued.Before(granted) {
+			w.delayed = true
+			l.delayed++
+		}
+	}
+}
+
+// cleanupLocked forgets hosts with nothing in flight or queued.
+func (l *hostLimiter) cleanupLocked(host string, slots *hostSlots) {
+	if slots.inFlight == 0 && slots.first.Len() == 0 && slots.retries.Len() == 0 {
+		delete(l.hosts, host)
+	}
+}
+
+func (l *hostLimiter) stats() HostLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := HostLimitStats{RetriesDelayed: l.delayed, RetriesPromoted: l.promoted}
+	for _, slots := range l.hosts {
+		stats.InFlight += slots.inFlight
+		stats.Queued += slots.first.Len()
+		stats.QueuedRetries += slots.retries.Len()
+	}
+	return stats
+}
+
+// isLowPriorityRetry reports whether an attempt queues behind first attempts.
+func (config *requestConfig) isLowPriorityRetry(attempt int) bool {
+	return attempt > 1 && config.retryConfig != nil && config.retryConfig.RetryPriority == RetryPriorityLow
+}
+
+// hostSlotBody releases the attempt's host slot when closed.
+type hostSlotBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *hostSlotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
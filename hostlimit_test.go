@@ -0,0 +1,122 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyServer returns a server that holds each request until release is
+// closed and records the most requests it served at once.
+func concurrencyServer(t *testing.T, arrived chan<- struct{}, release <-chan struct{}) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var inFlight, peak atomic.Int32
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		arrived <- struct{}{}
+		<-release
+	})
+	return server, &peak
+}
+
+func TestHostConcurrencyZeroIsUnlimited(t *testing.T) {
+	const requests = 4
+	arrived, release := make(chan struct{}, requests), make(chan struct{})
+	server, peak := concurrencyServer(t, arrived, release)
+	client := NewClient(server.URL, 5*time.Second).WithHostConcurrency(HostLimitConfig{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Do(ctx, GET("/"))
+			errs <- err
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		select {
+		case <-arrived:
+		case <-ctx.Done():
+			t.Fatalf("only %d of %d requests reached the server", i, requests)
+		}
+	}
+	if got := client.HostLimitStats().InFlight; got != requests {
+		t.Errorf("InFlight = %d, want %d", got, requests)
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Do: %v", err)
+		}
+	}
+	if got := peak.Load(); got != requests {
+		t.Errorf("peak concurrency = %d, want %d", got, requests)
+	}
+}
+
+func TestHostConcurrencyLimitsInFlight(t *testing.T) {
+	const requests = 3
+	arrived, release := make(chan struct{}, requests), make(chan struct{})
+	server, peak := concurrencyServer(t, arrived, release)
+	client := NewClient(server.URL, 5*time.Second).WithHostConcurrency(HostLimitConfig{MaxPerHost: 1})
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.Do(context.Background(), GET("/")); err != nil {
+				t.Errorf("Do: %v", err)
+			}
+		}()
+	}
+	<-arrived
+	deadline := time.Now().Add(2 * time.Second)
+	for client.HostLimitStats().Queued != requests-1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := client.HostLimitStats(); stats.InFlight != 1 || stats.Queued != requests-1 {
+		t.Errorf("stats = %+v, want 1 in flight and %d queued", stats, requests-1)
+	}
+	close(release)
+	wg.Wait()
+	if got := peak.Load(); got != 1 {
+		t.Errorf("peak concurrency = %d, want 1", got)
+	}
+}
+
+func TestHostConcurrencyQueuedRequestHonorsContext(t *testing.T) {
+	arrived, release := make(chan struct{}, 1), make(chan struct{})
+	server, _ := concurrencyServer(t, arrived, release)
+	defer close(release)
+	client := NewClient(server.URL, 5*time.Second).WithHostConcurrency(HostLimitConfig{MaxPerHost: 1})
+
+	go client.Do(context.Background(), GET("/"))
+	<-arrived
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Do(ctx, GET("/")); err == nil {
+		t.Fatal("expected the queued request to fail when its context ends")
+	}
+	if got := client.HostLimitStats().Queued; got != 0 {
+		t.Errorf("Queued = %d after the waiter gave up, want 0", got)
+	}
+}
@@ -0,0 +1,117 @@
+package reqws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// inputCheck returns a description of a caller-provided input that changed after
+// its option was applied, or "" if it is unchanged.
+type inputCheck func() string
+
+// inputWatch snapshots a caller-provided input and returns the check against
+// that snapshot, or nil if the input cannot be checked.
+type inputWatch func() inputCheck
+
+// watch records an input and snapshots it now.
+func (c *requestConfig) watch(w inputWatch) {
+	c.inputWatches = append(c.inputWatches, w)
+	if check := w(); check != nil {
+		c.inputChecks = append(c.inputChecks, check)
+	}
+}
+
+// rewatchInputs snapshots the recorded inputs again, for a config executed long
+// after its options were applied. Inputs may legitimately change between
+// executions of a Prepared request, only not while one is in flight.
+func (c *requestConfig) rewatchInputs() {
+	if !checkOptionInputs || len(c.inputWatches) == 0 {
+		return
+	}
+	c.inputChecks = nil
+	for _, w := range c.inputWatches {
+		if check := w(); check != nil {
+			c.inputChecks = append(c.inputChecks, check)
+		}
+	}
+}
+
+// watchQueryParams records params passed to WithQueryParams in race-enabled builds.
+func (c *requestConfig) watchQueryParams(params url.Values) {
+	if !checkOptionInputs || params == nil {
+		return
+	}
+	c.watch(func() inputCheck {
+		snapshot := make(url.Values, len(params))
+		for key, values := range params {
+			snapshot[key] = append([]string(nil), values...)
+		}
+		return func() string {
+			if !reflect.DeepEqual(snapshot, params) {
+				return "url.Values passed to WithQueryParams"
+			}
+			return ""
+		}
+	})
+}
+
+// watchStrings records a string slice passed to an option in race-enabled builds.
+func (c *requestConfig) watchStrings(option string, values []string) {
+	if !checkOptionInputs || len(values) == 0 {
+		return
+	}
+	c.watch(func() inputCheck {
+		snapshot := append([]string(nil), values...)
+		return func() string {
+			if !reflect.DeepEqual(snapshot, values) {
+				return "slice passed to " + option
+			}
+			return ""
+		}
+	})
+}
+
+// watchBody records a map, slice or pointer body in race-enabled builds. The body is
+// encoded again on every attempt, so changing it would alter retried requests.
+func (c *requestConfig) watchBody(body interface{}) {
+	if !checkOptionInputs || body == nil {
+		return
+	}
+	switch reflect.TypeOf(body).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer:
+	default:
+		return
+	}
+	c.watch(func() inputCheck {
+		snapshot, err := json.Marshal(body)
+		if err != nil {
+			return nil
+		}
+		return func() string {
+			current, err := json.Marshal(body)
+			if err == nil && !bytes.Equal(snapshot, current) {
+				return "body passed to WithBody/WithJSON"
+			}
+			return ""
+		}
+	})
+}
+
+// verifyInputs returns an error wrapping ErrInputModified if a caller-provided
+// input was modified after it was applied. It only runs in race-enabled builds,
+// where it complements the race detector by catching modifications that happen
+// between attempts rather than concurrently.
+func (c *requestConfig) verifyInputs() error {
+	if !checkOptionInputs {
+		return nil
+	}
+	for _, check := range c.inputChecks {
+		if input := check(); input != "" {
+			return fmt.Errorf("%w: %s", ErrInputModified, input)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+//go:build race
+
+package reqws
+
+// checkOptionInputs verifies in race-enabled builds that maps, slices and bodies
+// passed to request options are not modified while the request is in flight.
+const checkOptionInputs = true
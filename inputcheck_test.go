@@ -0,0 +1,110 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSharedOptionsAcrossConcurrentRequests(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Request")))
+	})
+	client := NewClient(server.URL, 10*time.Second)
+
+	var sharedRuns atomic.Int32
+	shared := make([]RequestOption, 0, 8) // Spare capacity, as returned by a helper building options
+	shared = append(shared,
+		GET("/"),
+		WithHeader("X-Shared", "1"),
+		WithBeforeRequest(func(*http.Request) error {
+			sharedRuns.Add(1)
+			return nil
+		}),
+	)
+
+	const requests = 200
+	var mu sync.Mutex
+	runs := make(map[string]int, requests)
+	// Each session appends its own header and hook to the shared slice it is
+	// given, so an append into the spare capacity would hand them to other requests
+	inject := func(id string) RequestOption {
+		header := WithHeader("X-Request", id)
+		hook := WithBeforeRequest(func(req *http.Request) error {
+			if got := req.Header.Get("X-Request"); got != id {
+				t.Errorf("hook of request %s ran for request %s", id, got)
+			}
+			mu.Lock()
+			runs[id]++
+			mu.Unlock()
+			return nil
+		})
+		return func(c *requestConfig) {
+			header(c)
+			hook(c)
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		id := strconv.Itoa(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session := client.ConsistencySession(ConsistencyConfig{Inject: inject})
+			session.SetToken(id)
+			resp, err := session.Do(context.Background(), shared...)
+			if err != nil {
+				t.Errorf("request %s: %v", id, err)
+				return
+			}
+			if string(resp.Body) != id {
+				t.Errorf("request %s got the response of request %s", id, resp.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < requests; i++ {
+		if n := runs[strconv.Itoa(i)]; n != 1 {
+			t.Errorf("hook of request %d ran %d times, want 1", i, n)
+		}
+	}
+	if got := sharedRuns.Load(); got != requests {
+		t.Errorf("shared hook ran %d times, want %d", got, requests)
+	}
+	if len(shared) != 3 || shared[:4][3] != nil {
+		t.Error("the shared slice was written to")
+	}
+}
+
+func TestModifiedInputFailsRequest(t *testing.T) {
+	if !checkOptionInputs {
+		t.Skip("input checks only run in race-enabled builds")
+	}
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	client := NewClient(server.URL, 5*time.Second)
+
+	body := map[string]string{"status": "draft"}
+	_, err := client.Do(context.Background(),
+		POST("/"),
+		WithJSON(body),
+		WithRetry(RetryConfig{MaxRetries: 3, InitialDelay: time.Millisecond}),
+		WithBeforeRequest(func(*http.Request) error {
+			body["status"] = "published" // Changes what a retry would send
+			return nil
+		}),
+	)
+	if !errors.Is(err, ErrInputModified) {
+		t.Fatalf("err = %v, want ErrInputModified", err)
+	}
+	if got := attempts.Count(); got != 1 {
+		t.Errorf("attempts = %d, want 1 (a modified input is not retried)", got)
+	}
+}
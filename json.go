@@ -0,0 +1,212 @@
+package reqws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FieldNameMapper maps a JSON object key to the key used for decoding.
+type FieldNameMapper func(key string) string
+
+// SnakeCaseKeys maps keys such as "userId", "UserID" and "user-id" to "user_id".
+func SnakeCaseKeys(key string) string {
+	var b strings.Builder
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			// Start a new word on lower->upper and on the last upper of an acronym ("IDName" -> "id_name")
+			if i > 0 && runes[i-1] != '_' && runes[i-1] != '-' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// LowerCaseKeys maps keys to lower case with separators removed, so that
+// "user_id", "userId" and "USER-ID" all become "userid".
+func LowerCaseKeys(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || r == ' ' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// mapJSONKeys recursively renames all object keys in a decoded JSON value.
+func mapJSONKeys(value interface{}, mapper FieldNameMapper) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(node))
+		for k, v := range node {
+			mapped[mapper(k)] = mapJSONKeys(v, mapper)
+		}
+		return mapped
+	case []interface{}:
+		for i, v := range node {
+			node[i] = mapJSONKeys(v, mapper)
+		}
+		return node
+	default:
+		return value
+	}
+}
+
+// lookupJSONPath follows keys through a decoded JSON value. Array elements are
+// addressed by their index. Returns nil if the path does not exist.
+func lookupJSONPath(value interface{}, keys []string) interface{} {
+	for _, key := range keys {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			value = node[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// decodeJSONValue decodes body keeping numbers as json.Number.
+func decodeJSONValue(body []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return value, nil
+}
+
+// canonicalJSON marshals v with object keys sorted at every depth, normalized
+// numbers and no HTML escaping. Custom MarshalJSON methods and struct tags apply
+// as with json.Marshal.
+func canonicalJSON(v interface{}) ([]byte, error) {
+	var marshaled bytes.Buffer
+	encoder := json.NewEncoder(&marshaled)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	value, err := decodeJSONValue(marshaled.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := writeCanonicalJSON(&out, enc, value); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeCanonicalJSON writes a value decoded by decodeJSONValue in canonical form.
+// enc writes strings into out without HTML escaping.
+func writeCanonicalJSON(out *bytes.Buffer, enc *json.Encoder, value interface{}) error {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for k := range node {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			writeCanonicalString(out, enc, k)
+			out.WriteByte(':')
+			if err := writeCanonicalJSON(out, enc, node[k]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case []interface{}:
+		out.WriteByte('[')
+		for i, elem := range node {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(out, enc, elem); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case string:
+		writeCanonicalString(out, enc, node)
+	case json.Number:
+		// Integers keep every digit; anything else takes float64's shortest form
+		if !strings.ContainsAny(string(node), ".eE") {
+			out.WriteString(string(node))
+			return nil
+		}
+		f, err := node.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", node, err)
+		}
+		number, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		out.Write(number)
+	default:
+		// bool and nil
+		encoded, err := json.Marshal(node)
+		if err != nil {
+			return err
+		}
+		out.Write(encoded)
+	}
+	return nil
+}
+
+// writeCanonicalString writes s as a JSON string with enc, which writes into out.
+func writeCanonicalString(out *bytes.Buffer, enc *json.Encoder, s string) {
+	enc.Encode(s)
+	// Drop the newline Encode appends
+	out.Truncate(out.Len() - 1)
+}
+
+// verbatimJSON returns a body that is already encoded JSON: a json.RawMessage,
+// or a []byte sent with a JSON Content-Type. Marshaling would compact and
+// HTML-escape the former and base64-encode the latter.
+func verbatimJSON(body interface{}, contentType string) ([]byte, bool) {
+	switch b := body.(type) {
+	case json.RawMessage:
+		return b, true
+	case *json.RawMessage:
+		if b != nil {
+			return *b, true
+		}
+	case []byte:
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return b, true
+		}
+	}
+	return nil, false
+}
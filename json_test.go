@@ -0,0 +1,142 @@
+package reqws
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type canonicalPayment struct {
+	To     string                 `json:"to"`
+	Amount json.Number            `json:"amount"`
+	Memo   string                 `json:"memo,omitempty"`
+	Meta   map[string]interface{} `json:"meta"`
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+		want string
+	}{
+		{"struct fields sorted", canonicalPayment{To: "acct_1", Amount: "100", Meta: map[string]interface{}{"z": 1, "a": []interface{}{true, nil}}},
+			`{"amount":100,"meta":{"a":[true,null],"z":1},"to":"acct_1"}`},
+		{"no HTML escaping", map[string]string{"q": "<a & b>"}, `{"q":"<a & b>"}`},
+		{"numbers normalized", map[string]interface{}{"f": json.Number("1.50"), "e": json.Number("1e3"), "n": json.Number("-0.0")}, `{"e":1000,"f":1.5,"n":-0}`},
+		{"large integers exact", map[string]interface{}{"id": json.Number("12345678901234567890")}, `{"id":12345678901234567890}`},
+		{"nested keys sorted", []interface{}{map[string]int{"b": 1, "a": 2}}, `[{"a":2,"b":1}]`},
+		{"line separators escaped", map[string]string{"s": "\u2028é"}, `{"s":"\u2028é"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := canonicalJSON(tt.body)
+			if err != nil {
+				t.Fatalf("canonicalJSON: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCanonicalJSON(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	var signed []byte
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(),
+		POST("/transfers"),
+		WithCanonicalJSON(map[string]interface{}{"to": "acct_1", "amount": 100, "note": "<ok>"}),
+		WithBeforeRequest(func(req *http.Request) error {
+			// A signing hook reads the exact bytes that are sent
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			signed, err = io.ReadAll(body)
+			return err
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	const want = `{"amount":100,"note":"<ok>","to":"acct_1"}`
+	if sent := log.Last(t).Body; sent != want || string(signed) != want {
+		t.Errorf("sent %s, signed %s; want %s", sent, signed, want)
+	}
+
+	// A later WithJSON turns canonical encoding off again
+	_, err = NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/"),
+		WithCanonicalJSON(map[string]string{"b": "<"}), WithJSON(map[string]string{"q": "<"}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := log.Last(t).Body; got != `{"q":"\u003c"}` {
+		t.Errorf("body = %s, want encoding/json's escaping", got)
+	}
+}
+
+func TestRawJSONBody(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	raw := json.RawMessage(`{ "html": "<b>", "n": 1.50 }`)
+
+	tests := []struct {
+		name    string
+		opts    []RequestOption
+		want    string
+		wantErr bool
+	}{
+		{"RawMessage", []RequestOption{WithBody(raw)}, `application/json { "html": "<b>", "n": 1.50 }`, false},
+		{"RawMessage pointer", []RequestOption{WithJSON(&raw)}, `application/json { "html": "<b>", "n": 1.50 }`, false},
+		{"bytes with JSON type", []RequestOption{WithBody([]byte(`[1, 2]`)), WithHeader("Content-Type", "application/vnd.api+json")}, `application/vnd.api+json [1, 2]`, false},
+		{"bytes without JSON type", []RequestOption{WithBody([]byte(`[1]`))}, `application/json "WzFd"`, false},
+		{"canonical RawMessage", []RequestOption{WithCanonicalJSON(raw)}, `application/json {"html":"<b>","n":1.5}`, false},
+		{"invalid RawMessage", []RequestOption{WithBody(json.RawMessage(`{"a":`))}, "", true},
+	}
+	for _, tt := range tests {
+		for _, pooled := range []bool{false, true} {
+			client := NewClient(server.URL, 5*time.Second)
+			if pooled {
+				client.WithBufferPool()
+			}
+			_, err := client.Do(context.Background(), append([]RequestOption{POST("/documents")}, tt.opts...)...)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+					t.Errorf("%s (pooled %v): err = %v, want invalid JSON", tt.name, pooled, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s (pooled %v): Do: %v", tt.name, pooled, err)
+			}
+			req := log.Last(t)
+			if got := req.Header.Get("Content-Type") + " " + req.Body; got != tt.want {
+				t.Errorf("%s (pooled %v): sent %s, want %s", tt.name, pooled, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestRawJSONBodyDedupKey(t *testing.T) {
+	// The key is the body the key function sees
+	client := NewClient("http://localhost", time.Second).WithOutboundDedup(NewMemoryDedupStore(),
+		func(spec *RequestSpec, body []byte) string { return string(body) }, time.Minute)
+	tests := []struct {
+		name string
+		opt  RequestOption
+		want string
+	}{
+		{"RawMessage", WithBody(json.RawMessage(`{"a": 1}`)), `{"a": 1}`},
+		{"bytes without JSON type", WithBody([]byte(`{"a": 1}`)), `"eyJhIjogMX0="`},
+		{"canonical RawMessage", WithCanonicalJSON(json.RawMessage(`{"a": 1}`)), `{"a":1}`},
+	}
+	for _, tt := range tests {
+		config := newRequestConfig(http.MethodPost, []RequestOption{WithPath("/documents"), tt.opt})
+		if got := client.dedupKey(config); got != tt.want {
+			t.Errorf("%s: dedup key body = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
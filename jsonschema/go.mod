@@ -0,0 +1,16 @@
+module github.com/gurizzu/go-reqws/jsonschema
+
+go 1.23
+
+require (
+	github.com/gurizzu/go-reqws v0.0.0
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2
+)
+
+require (
+	github.com/coder/websocket v1.8.14 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+// The module is developed alongside the root module
+replace github.com/gurizzu/go-reqws => ../
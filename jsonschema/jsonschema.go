@@ -0,0 +1,109 @@
+// Package jsonschema validates reqws responses against JSON Schema (draft 2020-12
+// by default) using github.com/santhosh-tekuri/jsonschema. It is a separate
+// module, so only programs that import it depend on the validator:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithSchemaValidation(reqws.SchemaValidationConfig{Compiler: jsonschema.Compile})
+//
+//	resp, err := client.Do(ctx, reqws.GET("/v1/orders/42"), reqws.WithResponseSchema(orderSchema))
+//	var violation *reqws.SchemaViolationError
+//	if errors.As(err, &violation) {
+//		for _, v := range violation.Violations {
+//			log.Printf("%s: %s", v.InstancePath, v.Message)
+//		}
+//	}
+package jsonschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	reqws "github.com/gurizzu/go-reqws"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// schemaURL is the location the schema is registered under; $ref to other
+// documents is not supported.
+const schemaURL = "reqws://response-schema.json"
+
+// Compile compiles a JSON Schema document. Schemas without $schema are treated as
+// draft 2020-12. Compile is a reqws.SchemaCompiler.
+func Compile(schema []byte) (reqws.SchemaValidator, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, err
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, err
+	}
+	return &validator{schema: compiled}, nil
+}
+
+type validator struct {
+	schema *jsonschema.Schema
+}
+
+// Validate returns one violation per failed keyword, with JSON pointer paths.
+func (v *validator) Validate(body []byte) ([]reqws.SchemaViolation, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	err = v.schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+	var violations []reqws.SchemaViolation
+	collectViolations(validationErr, &violations)
+	return violations, nil
+}
+
+// collectViolations appends the leaves of the error tree, which name the keywords
+// that actually failed.
+func collectViolations(err *jsonschema.ValidationError, violations *[]reqws.SchemaViolation) {
+	if len(err.Causes) > 0 {
+		for _, cause := range err.Causes {
+			collectViolations(cause, violations)
+		}
+		return
+	}
+	unit := err.BasicOutput()
+	message := ""
+	if unit.Error != nil {
+		message = unit.Error.String()
+	}
+	*violations = append(*violations, reqws.SchemaViolation{
+		InstancePath: jsonPointer(err.InstanceLocation),
+		KeywordPath:  schemaPointer(err.SchemaURL) + jsonPointer(err.ErrorKind.KeywordPath()),
+		Message:      message,
+	})
+}
+
+// schemaPointer returns the JSON pointer fragment of a schema location, e.g.
+// "/$defs/item/properties/price" for a schema reached through $ref.
+func schemaPointer(location string) string {
+	_, fragment, _ := strings.Cut(location, "#")
+	return fragment
+}
+
+// jsonPointer formats path tokens as an RFC 6901 JSON pointer.
+func jsonPointer(tokens []string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package jsonschema
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	reqws "github.com/gurizzu/go-reqws"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["id", "items"],
+	"properties": {
+		"id": {"type": "integer"},
+		"items": {"type": "array", "items": {"$ref": "#/$defs/item"}}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"properties": {"price": {"type": "number"}}
+		}
+	}
+}`
+
+func TestCompileAndValidate(t *testing.T) {
+	validator, err := Compile([]byte(orderSchema))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	tests := []struct {
+		name           string
+		body           string
+		wantInstance   string
+		wantKeyword    string
+		wantViolations int
+	}{
+		{"valid", `{"id":1,"items":[{"price":2.5}]}`, "", "", 0},
+		{"wrong type through ref", `{"id":1,"items":[{"price":1},{"price":"free"}]}`, "/items/1/price", "/$defs/item/properties/price/type", 1},
+		{"missing required", `{"items":[]}`, "", "/required", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := validator.Validate([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if len(violations) != tt.wantViolations {
+				t.Fatalf("violations = %+v, want %d", violations, tt.wantViolations)
+			}
+			if tt.wantViolations == 0 {
+				return
+			}
+			if v := violations[0]; v.InstancePath != tt.wantInstance || v.KeywordPath != tt.wantKeyword || v.Message == "" {
+				t.Errorf("violation = %+v, want instance %q and keyword %q", v, tt.wantInstance, tt.wantKeyword)
+			}
+		})
+	}
+
+	if _, err := validator.Validate([]byte("{")); err == nil {
+		t.Error("expected an error for a body that is not JSON")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, schema := range []string{`{`, `{"type": 5}`} {
+		if _, err := Compile([]byte(schema)); err == nil {
+			t.Errorf("Compile(%s) succeeded, want an error", schema)
+		}
+	}
+}
+
+func TestClientValidation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"42","items":[]}`))
+	}))
+	defer server.Close()
+
+	client := reqws.NewClient(server.URL, 5*time.Second).
+		WithSchemaValidation(reqws.SchemaValidationConfig{Compiler: Compile})
+	_, err := client.Do(context.Background(), reqws.GET("/v1/orders/42"), reqws.WithResponseSchema([]byte(orderSchema)))
+	var violation *reqws.SchemaViolationError
+	if !errors.As(err, &violation) {
+		t.Fatalf("err = %v, want a SchemaViolationError", err)
+	}
+	if len(violation.Violations) != 1 || violation.Violations[0].InstancePath != "/id" {
+		t.Errorf("violations = %+v, want one at /id", violation.Violations)
+	}
+}
@@ -4,11 +4,19 @@ import "net/http"
 
 // RequestHook is a function that runs before a request is sent.
 // It receives the prepared http.Request and can modify it or return an error to abort the request.
+// Errors stop any retries unless wrapped with RetryableHookError().
 type RequestHook func(req *http.Request) error
 
+// RequestInterceptor runs before a request is sent and may return a different request
+// to send instead. Returning a nil request keeps the original one.
+// Errors stop any retries unless wrapped with RetryableHookError().
+type RequestInterceptor func(req *http.Request) (*http.Request, error)
+
 // ResponseHook is a function that runs after a response is received.
 // It receives both the original request and the response.
-// Return an error to treat the response as failed.
+// Return an error to treat the response as failed. The error is permanent and stops
+// any retries unless wrapped with RetryableHookError().
+// Use RetryAttempt(req.Context()) to get the current attempt number.
 type ResponseHook func(req *http.Request, resp *http.Response) error
 
 // ErrorHook is a function that runs when an error occurs during the request.
@@ -31,9 +39,37 @@ func WithBeforeRequest(hook RequestHook) RequestOption {
 	}
 }
 
+// WithRequestInterceptor adds an interceptor that can replace the assembled request,
+// e.g. to wrap the body or reroute it to another URL. Multiple interceptors run in the
+// order they were added, each receiving the request returned by the previous one.
+// If any interceptor returns an error, the request is aborted.
+//
+// Interceptors run once per attempt, after all headers are set and before the
+// before-request hooks, so signing hooks see the final request. Build the new request
+// from the one passed in (req.Clone, req.WithContext) to keep its context, which
+// carries the retry attempt and cancellation.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.GET("/orders"),
+//		reqws.WithRequestInterceptor(func(req *http.Request) (*http.Request, error) {
+//			routed := req.Clone(req.Context())
+//			routed.URL.Host = router.HostFor(req.URL.Path)
+//			routed.Host = ""
+//			return routed, nil
+//		}),
+//	)
+func WithRequestInterceptor(interceptor RequestInterceptor) RequestOption {
+	return func(c *requestConfig) {
+		c.requestInterceptors = append(c.requestInterceptors, interceptor)
+	}
+}
+
 // WithAfterResponse adds a hook that runs after receiving the HTTP response.
 // Multiple hooks can be added and will be executed in the order they were added.
-// If any hook returns an error, the response is treated as failed.
+// If any hook returns an error, the response is treated as failed and not retried,
+// unless the error is wrapped with RetryableHookError().
 //
 // Use cases:
 // - Log response details
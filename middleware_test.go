@@ -0,0 +1,125 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHookErrorsArePermanent(t *testing.T) {
+	rejected := errors.New("rejected by hook")
+	tests := []struct {
+		name         string
+		hook         RequestOption
+		wantAttempts int
+	}{
+		{"before request", WithBeforeRequest(func(*http.Request) error { return rejected }), 0},
+		{"after response", WithAfterResponse(func(*http.Request, *http.Response) error { return rejected }), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+
+			var errorHookCalls int
+			_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(),
+				GET("/"),
+				WithRetry(fastRetry(3)),
+				tt.hook,
+				WithOnError(func(*http.Request, error) { errorHookCalls++ }),
+			)
+			if !errors.Is(err, rejected) {
+				t.Fatalf("err = %v, want the hook's error", err)
+			}
+			if got := attempts.Count(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if errorHookCalls != 1 {
+				t.Errorf("error hook calls = %d, want 1", errorHookCalls)
+			}
+		})
+	}
+}
+
+func TestRetryableHookErrorIsRetried(t *testing.T) {
+	var attempts *requestLog
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Count() < 3 {
+			w.Header().Set("X-Shard-State", "rebalancing")
+		}
+	})
+
+	var seen []int
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(),
+		GET("/"),
+		WithRetry(fastRetry(5)),
+		WithAfterResponse(func(req *http.Request, resp *http.Response) error {
+			seen = append(seen, RetryAttempt(req.Context()))
+			if resp.Header.Get("X-Shard-State") == "rebalancing" {
+				return RetryableHookError(errors.New("shard rebalancing"))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d", resp.StatusCode)
+	}
+	if len(seen) != 3 || seen[0] != 1 || seen[1] != 2 || seen[2] != 3 {
+		t.Errorf("attempts seen by the hook = %v, want [1 2 3]", seen)
+	}
+}
+
+func TestRetryAttemptWithoutRetries(t *testing.T) {
+	server := testServer(t, func(http.ResponseWriter, *http.Request) {})
+
+	attempt := -1
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"),
+		WithBeforeRequest(func(req *http.Request) error {
+			attempt = RetryAttempt(req.Context())
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if attempt != 1 {
+		t.Errorf("RetryAttempt = %d, want 1", attempt)
+	}
+	if got := RetryAttempt(context.Background()); got != 0 {
+		t.Errorf("RetryAttempt outside a request = %d, want 0", got)
+	}
+}
+
+func TestHooksRunInOrder(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Order")))
+	})
+
+	var order []string
+	hook := func(name string) RequestOption {
+		return WithBeforeRequest(func(req *http.Request) error {
+			order = append(order, name)
+			req.Header.Add("X-Order", name)
+			return nil
+		})
+	}
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"), hook("a"), hook("b"),
+		WithAfterResponse(func(*http.Request, *http.Response) error {
+			order = append(order, "after")
+			return nil
+		}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "a" {
+		t.Errorf("server saw X-Order %q, want the first value a", got)
+	}
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "after" {
+		t.Errorf("hook order = %v", order)
+	}
+}
@@ -0,0 +1,161 @@
+package reqws
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"os"
+	"slices"
+	"strings"
+)
+
+// isMultipart reports whether the body is multipart/form-data: there are files,
+// or WithForm() fields and no other body.
+func (config *requestConfig) isMultipart() bool {
+	if len(config.files) > 0 {
+		return true
+	}
+	return len(config.formFields) > 0 && config.rawBody == nil && config.formBody == nil && config.body == nil
+}
+
+// multipartBody opens the request's files and returns a reader that streams the
+// multipart body as it is read, so files are never held in memory. The size is
+// the body's length in bytes, or -1 if a file's size is unknown.
+//
+// The body is written by a goroutine that exits when the body has been read to
+// the end or closed.
+func (config *requestConfig) multipartBody(boundary string) (io.ReadCloser, int64, error) {
+	files := make([]io.ReadCloser, 0, len(config.files))
+	closeFiles := func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}
+	size := int64(0)
+	for _, f := range config.files {
+		file, fileSize, err := f.open()
+		if err != nil {
+			closeFiles()
+			return nil, 0, fmt.Errorf("failed to open file: %w", err)
+		}
+		files = append(files, file)
+		if fileSize < 0 || size < 0 {
+			size = -1
+		} else {
+			size += fileSize
+		}
+	}
+	if size >= 0 {
+		// The framing is the body with every file empty
+		framing := &byteCounter{}
+		if err := config.writeMultipart(framing, boundary, nil); err != nil {
+			closeFiles()
+			return nil, 0, err
+		}
+		size += framing.n
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		err := config.writeMultipart(pw, boundary, files)
+		closeFiles()
+		pw.CloseWithError(err)
+	}()
+	return pr, size, nil
+}
+
+// writeMultipart writes the form fields sorted by name, then the files in the
+// order they were added, with content from files, or empty if files is nil.
+func (config *requestConfig) writeMultipart(w io.Writer, boundary string, files []io.ReadCloser) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return fmt.Errorf("invalid multipart boundary: %w", err)
+	}
+	for _, k := range slices.Sorted(maps.Keys(config.formFields)) {
+		if err := writer.WriteField(k, config.formFields[k]); err != nil {
+			return fmt.Errorf("failed to write form field: %w", err)
+		}
+	}
+	for i, f := range config.files {
+		sanitizedFilename := strings.ReplaceAll(f.filename, " ", "_")
+		part, err := writer.CreateFormFile(f.field, sanitizedFilename)
+		if err != nil {
+			return fmt.Errorf("failed to create form file: %w", err)
+		}
+		if files == nil {
+			continue
+		}
+		if _, err = io.Copy(part, files[i]); err != nil {
+			return fmt.Errorf("failed to copy file to request body: %w", err)
+		}
+	}
+	return writer.Close()
+}
+
+// open returns the file's content for one attempt and its size, or -1 if the
+// size is unknown.
+func (f filePart) open() (io.ReadCloser, int64, error) {
+	switch {
+	case f.header != nil:
+		file, err := f.header.Open()
+		if err != nil {
+			return nil, 0, err
+		}
+		return file, f.header.Size, nil
+	case f.stream != nil:
+		r, err := f.stream.reader()
+		if err != nil {
+			return nil, 0, err
+		}
+		return io.NopCloser(r), remainingSize(r), nil
+	default:
+		file, err := os.Open(f.path)
+		if err != nil {
+			return nil, 0, err
+		}
+		info, err := file.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return file, -1, nil
+		}
+		return file, info.Size(), nil
+	}
+}
+
+// remainingSize returns how many bytes are left to read from r, or -1 if that
+// cannot be told without reading.
+func remainingSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case *bytes.Reader:
+		return int64(r.Len())
+	case *bytes.Buffer:
+		return int64(r.Len())
+	case *strings.Reader:
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
+
+// byteCounter is a writer that only counts the bytes written to it.
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,395 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// multipartServer returns a server that answers with the request's
+// Content-Type and its multipart parts in order, one "field[:filename]=content"
+// line each, and the request's Content-Length in an X-Content-Length header. It
+// fails the test if the body is not valid multipart or its length is wrong.
+func multipartServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		_, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Errorf("Content-Type %q: %v", contentType, err)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.ContentLength >= 0 && r.ContentLength != int64(len(body)) {
+			t.Errorf("Content-Length = %d, body is %d bytes", r.ContentLength, len(body))
+		}
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+		var parts []string
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("reading part: %v", err)
+				return
+			}
+			content, _ := io.ReadAll(part)
+			name := part.FormName()
+			if part.FileName() != "" {
+				name += ":" + part.FileName()
+			}
+			parts = append(parts, name+"="+string(content))
+		}
+		io.WriteString(w, contentType+"\n"+strings.Join(parts, "\n"))
+	})
+	return server
+}
+
+func TestMultipartContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		boundary string
+		want     string // With the body's boundary for "B"
+	}{
+		{"default", "", "", "multipart/form-data; boundary=B"},
+		{"related without boundary", "multipart/related; type=\"application/json\"", "", `multipart/related; boundary=B; type="application/json"`},
+		{"form-data with boundary", "multipart/form-data; boundary=fixed-boundary", "fixed-boundary", "multipart/form-data; boundary=fixed-boundary"},
+		{"mixed, fixed boundary", "multipart/mixed", "fixed-boundary", "multipart/mixed; boundary=fixed-boundary"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := multipartServer(t)
+			opts := []RequestOption{POST("/upload"), WithFileReader("doc", "a.txt", strings.NewReader("hello"))}
+			if tt.header != "" {
+				opts = append(opts, WithHeader("Content-Type", tt.header))
+			}
+			if tt.boundary != "" {
+				opts = append(opts, WithMultipartBoundary(tt.boundary))
+			}
+			resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), opts...)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			contentType, parts, _ := strings.Cut(resp.String(), "\n")
+			_, params, _ := mime.ParseMediaType(contentType)
+			if want := strings.Replace(tt.want, "=B", "="+params["boundary"], 1); contentType != want {
+				t.Errorf("Content-Type = %q, want %q", contentType, want)
+			}
+			if parts != "doc:a.txt=hello" {
+				t.Errorf("parts = %q", parts)
+			}
+		})
+	}
+}
+
+func TestMultipartContentTypeKeepsOtherTypes(t *testing.T) {
+	writer := multipart.NewWriter(&bytes.Buffer{})
+	for _, userType := range []string{"application/octet-stream", "not a media type;;"} {
+		if got := multipartContentType(userType, writer); got != userType {
+			t.Errorf("multipartContentType(%q) = %q, want it unchanged", userType, got)
+		}
+	}
+}
+
+// fileHeader returns a multipart file header holding content, as a server
+// handler receives it.
+func fileHeader(t *testing.T, filename, content string) *multipart.FileHeader {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("f", filename)
+	io.WriteString(part, content)
+	writer.Close()
+	form, err := multipart.NewReader(&buf, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("ReadForm: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["f"][0]
+}
+
+func TestMultipartStreamsFieldsThenFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("from disk"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		file      RequestOption
+		wantPart  string
+		wantSized bool
+	}{
+		{"path", WithFileFromPath("doc", path), "doc:notes.txt=from disk", true},
+		{"seekable reader", WithFileReader("doc", "my file.csv", strings.NewReader("a,b")), "doc:my_file.csv=a,b", true},
+		{"file header", WithFile("", fileHeader(t, "h.bin", "header")), "file:h.bin=header", true},
+		{"reader of unknown size", WithFileReader("doc", "s.txt", io.MultiReader(strings.NewReader("stream"))), "doc:s.txt=stream", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := multipartServer(t)
+			resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/upload"),
+				tt.file,
+				WithFileReader("extra", "b.txt", strings.NewReader("second")),
+				WithForm("title", "T"),
+				WithForm("author", "A"),
+			)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			_, parts, _ := strings.Cut(resp.String(), "\n")
+			if want := "author=A\ntitle=T\n" + tt.wantPart + "\nextra:b.txt=second"; parts != want {
+				t.Errorf("parts =\n%s\nwant sorted fields, then files in order:\n%s", parts, want)
+			}
+			if sized := resp.Headers.Get("X-Content-Length") != "-1"; sized != tt.wantSized {
+				t.Errorf("Content-Length = %s, want known %v", resp.Headers.Get("X-Content-Length"), tt.wantSized)
+			}
+		})
+	}
+}
+
+func TestMultipartRetryRewindsFiles(t *testing.T) {
+	parts := multipartServer(t)
+	// Each request's first attempt is an odd one and fails
+	var attempts *requestLog
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Count()%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		parts.Config.Handler.ServeHTTP(w, r)
+	})
+
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/upload"),
+		WithFileReader("doc", "a.txt", strings.NewReader("content")), WithRetry(fastRetry(1)))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if _, got, _ := strings.Cut(resp.String(), "\n"); got != "doc:a.txt=content" {
+		t.Errorf("retried parts = %q, want the full file again", got)
+	}
+
+	// A reader that cannot seek is sent once, and the failed attempt returned
+	resp, err = NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/upload"),
+		WithFileReader("doc", "a.txt", io.MultiReader(strings.NewReader("content"))), WithRetry(fastRetry(1)))
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Do = %v, %v; want the 503 without a retry", resp, err)
+	}
+	if got := attempts.Count(); got != 3 {
+		t.Errorf("attempts = %d, want 1 after the first request's 2", got)
+	}
+}
+
+func TestMultipartMissingFile(t *testing.T) {
+	server, hits := recordingServer(t, nil)
+
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/upload"),
+		WithFileFromPath("doc", filepath.Join(t.TempDir(), "missing.txt")))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want the open error", err)
+	}
+	if hits.Count() != 0 {
+		t.Error("the request was sent")
+	}
+}
+
+func TestMultipartUploadNotCountedInMemoryBudget(t *testing.T) {
+	server := multipartServer(t)
+	client := NewClient(server.URL, 5*time.Second).WithMemoryBudget(128<<10, BudgetFailFast)
+	content := strings.Repeat("x", 1<<20)
+
+	resp, err := client.Do(context.Background(), POST("/upload"),
+		WithFileReader("doc", "big.txt", strings.NewReader(content)))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := resp.Headers.Get("X-Content-Length"); got == "-1" {
+		t.Error("upload of known size sent chunked")
+	}
+}
+
+func TestRemainingSize(t *testing.T) {
+	seeked := strings.NewReader("abcdef")
+	seeked.Seek(2, io.SeekStart)
+	file, err := os.Open("multipart.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, _ := file.Stat()
+	file.Seek(10, io.SeekStart)
+
+	tests := []struct {
+		name string
+		r    io.Reader
+		want int64
+	}{
+		{"bytes.Reader", bytes.NewReader([]byte("abc")), 3},
+		{"bytes.Buffer", bytes.NewBufferString("abcd"), 4},
+		{"partly read strings.Reader", seeked, 4},
+		{"seeker", file, info.Size() - 10},
+		{"plain reader", io.MultiReader(), -1},
+	}
+	for _, tt := range tests {
+		if got := remainingSize(tt.r); got != tt.want {
+			t.Errorf("%s: remainingSize = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+	if pos, _ := file.Seek(0, io.SeekCurrent); pos != 10 {
+		t.Errorf("file offset = %d after remainingSize, want it restored", pos)
+	}
+}
+
+func TestFormFieldsWithoutFiles(t *testing.T) {
+	server := multipartServer(t)
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), POST("/profile"),
+		WithForm("name", "Ada"), WithForm("bio", "line one\nline two"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	contentType, parts, _ := strings.Cut(resp.String(), "\n")
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+	if parts != "bio=line one\nline two\nname=Ada" {
+		t.Errorf("parts = %q", parts)
+	}
+	if got := resp.Headers.Get("X-Content-Length"); got == "-1" {
+		t.Error("fields-only body sent chunked")
+	}
+}
+
+func TestFormFieldsLoseToOtherBodies(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, r.Header.Get("Content-Type")+" "+string(body))
+	})
+	tests := []struct {
+		name string
+		body RequestOption
+		want string
+	}{
+		{"JSON", WithJSON(map[string]int{"a": 1}), `application/json {"a":1}`},
+		{"URL-encoded form", WithFormBody(url.Values{"a": {"1"}}), "application/x-www-form-urlencoded a=1"},
+		{"raw", WithRawBody([]byte("raw"), "text/plain"), "text/plain raw"},
+	}
+	for _, tt := range tests {
+		for _, order := range [][]RequestOption{{WithForm("field", "x"), tt.body}, {tt.body, WithForm("field", "x")}} {
+			resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), append([]RequestOption{POST("/")}, order...)...)
+			if err != nil {
+				t.Fatalf("%s: Do: %v", tt.name, err)
+			}
+			if got := resp.String(); got != tt.want {
+				t.Errorf("%s: sent %q, want %q", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestFormFieldsDedupKey(t *testing.T) {
+	client := NewClient("http://localhost", time.Second).WithOutboundDedup(NewMemoryDedupStore(),
+		func(spec *RequestSpec, body []byte) string { return string(body) }, time.Minute)
+	key := func(opts ...RequestOption) string {
+		return client.dedupKey(newRequestConfig(http.MethodPost, append([]RequestOption{WithPath("/profile")}, opts...)))
+	}
+	if got := key(WithForm("b", "2"), WithForm("a", "1")); got != "a=1&b=2" {
+		t.Errorf("dedup key body = %q, want the encoded fields", got)
+	}
+	if got := key(WithForm("a", "1"), WithFileReader("f", "f.txt", strings.NewReader("x"))); got != "" {
+		t.Errorf("dedup key body with a file = %q, want none", got)
+	}
+}
+
+// patternData is synthetic file content of any size that is generated on read.
+type patternData struct{}
+
+func (patternData) ReadAt(p []byte, off int64) (int, error) {
+	for i := range p {
+		p[i] = byte((off + int64(i)) % 251)
+	}
+	return len(p), nil
+}
+
+// patternChecker is a writer that verifies it receives patternData from offset 0.
+type patternChecker struct {
+	n   int64
+	bad bool
+}
+
+func (c *patternChecker) Write(p []byte) (int, error) {
+	for i, b := range p {
+		if b != byte((c.n+int64(i))%251) {
+			c.bad = true
+		}
+	}
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func TestMultipartStreamsLargeUpload(t *testing.T) {
+	if testing.Short() {
+		t.Skip("uploads 100MB")
+	}
+	const size = 100 << 20
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			t.Errorf("MultipartReader: %v", err)
+			return
+		}
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Errorf("NextPart: %v", err)
+			return
+		}
+		checker := &patternChecker{}
+		if _, err := io.Copy(checker, part); err != nil || checker.n != size || checker.bad {
+			t.Errorf("received %d bytes (corrupted %v, error %v), want %d intact", checker.n, checker.bad, err, size)
+		}
+		w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+	})
+
+	tests := []struct {
+		name      string
+		file      io.Reader
+		wantSized bool
+	}{
+		{"known size", io.NewSectionReader(patternData{}, 0, size), true},
+		{"unknown size", io.LimitReader(io.NewSectionReader(patternData{}, 0, size), size), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			resp, err := NewClient(server.URL, time.Minute).Do(context.Background(), POST("/upload"),
+				WithFileReader("doc", "big.bin", tt.file))
+			runtime.ReadMemStats(&after)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if sized := resp.Headers.Get("X-Content-Length") != "-1"; sized != tt.wantSized {
+				t.Errorf("Content-Length = %s, want known %v", resp.Headers.Get("X-Content-Length"), tt.wantSized)
+			}
+			// Client and server together allocate far less than the file while it streams
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > size/10 {
+				t.Errorf("allocated %d MB during a %d MB upload, want the body streamed", allocated>>20, size>>20)
+			}
+		})
+	}
+}
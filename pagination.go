@@ -0,0 +1,170 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CursorExtractor extracts the cursor for the next page from a response.
+// It returns an empty string when there are no more pages.
+type CursorExtractor func(resp *Response) (string, error)
+
+// PageHandler is called by Paginate for every page received.
+// Return an error to stop pagination; the error is returned from Paginate.
+type PageHandler func(resp *Response) error
+
+// CursorFromJSONPath extracts the next cursor from a dot-separated path in the JSON body.
+// Array elements can be addressed by their index (e.g. "data.0.id").
+// A missing or null value means there are no more pages.
+//
+// Example:
+//
+//	reqws.CursorFromJSONPath("meta.next_cursor")
+func CursorFromJSONPath(path string) CursorExtractor {
+	keys := strings.Split(path, ".")
+	return func(resp *Response) (string, error) {
+		if len(bytes.TrimSpace(resp.Body)) == 0 {
+			return "", nil
+		}
+
+		current, err := decodeJSONValue(resp.Body)
+		if err != nil {
+			return "", err
+		}
+
+		switch value := lookupJSONPath(current, keys).(type) {
+		case nil:
+			return "", nil
+		case string:
+			return value, nil
+		case json.Number:
+			return value.String(), nil
+		default:
+			return "", fmt.Errorf("cursor at %q is not a string or number", path)
+		}
+	}
+}
+
+// CursorFromHeader extracts the next cursor from a response header.
+// An absent or empty header means there are no more pages.
+//
+// Example:
+//
+//	reqws.CursorFromHeader("X-Next-Cursor")
+func CursorFromHeader(name string) CursorExtractor {
+	return func(resp *Response) (string, error) {
+		return resp.Headers.Get(name), nil
+	}
+}
+
+// CursorFromLinkRel extracts the next cursor from the RFC 8288 Link header.
+// It finds the link with the given rel (usually "next") and returns the value
+// of the param query parameter from its target URL.
+//
+// Example:
+//
+//	// Link: <https://api.example.com/items?cursor=abc>; rel="next"
+//	reqws.CursorFromLinkRel("next", "cursor")
+func CursorFromLinkRel(rel, param string) CursorExtractor {
+	return func(resp *Response) (string, error) {
+		for _, header := range resp.Headers.Values("Link") {
+			for _, link := range strings.Split(header, ",") {
+				target, ok := parseLinkRel(link, rel)
+				if !ok {
+					continue
+				}
+				u, err := url.Parse(target)
+				if err != nil {
+					return "", fmt.Errorf("invalid Link URL: %w", err)
+				}
+				return u.Query().Get(param), nil
+			}
+		}
+		return "", nil
+	}
+}
+
+// parseLinkRel returns the target URL of a single Link header entry if it has the given rel.
+func parseLinkRel(link, rel string) (string, bool) {
+	parts := strings.Split(link, ";")
+	target := strings.TrimSpace(parts[0])
+	if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+		return "", false
+	}
+
+	for _, attr := range parts[1:] {
+		key, value, found := strings.Cut(strings.TrimSpace(attr), "=")
+		if !found || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+			continue
+		}
+		// rel may contain several space-separated relation types
+		for _, r := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+			if strings.EqualFold(r, rel) {
+				return target[1 : len(target)-1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// Paginate fetches pages until the extractor returns an empty cursor.
+// The first request is built from opts; each following request sets the
+// cursorParam query parameter to the extracted cursor.
+//
+// Returns an HTTPError (or the client's ErrorDecoder error) for non-2xx pages.
+// Pagination also stops if the extractor returns the same cursor twice in a row.
+//
+// Example:
+//
+//	err := client.Paginate(ctx, "cursor", reqws.CursorFromJSONPath("meta.next_cursor"),
+//		func(resp *reqws.Response) error {
+//			var page ItemsPage
+//			if err := resp.JSON(&page); err != nil {
+//				return err
+//			}
+//			items = append(items, page.Items...)
+//			return nil
+//		},
+//		reqws.GET("/items"),
+//		reqws.WithQueryParam("limit", "100"),
+//	)
+func (c *Client) Paginate(ctx context.Context, cursorParam string, extract CursorExtractor, handle PageHandler, opts ...RequestOption) error {
+	cursor := ""
+	for {
+		pageOpts := opts
+		if cursor != "" {
+			next := cursor
+			pageOpts = append(opts[:len(opts):len(opts)], func(c *requestConfig) {
+				c.addQuery(func(_ *Client, query url.Values) error {
+					query.Set(cursorParam, next)
+					return nil
+				})
+			})
+		}
+
+		resp, err := c.Do(ctx, pageOpts...)
+		if err != nil {
+			return err
+		}
+		if !resp.IsSuccess() {
+			return c.decodeHTTPError(resp.StatusCode, resp.Body)
+		}
+
+		if err := handle(resp); err != nil {
+			return err
+		}
+
+		nextCursor, err := extract(resp)
+		if err != nil {
+			return fmt.Errorf("failed to extract cursor: %w", err)
+		}
+		if nextCursor == "" || nextCursor == cursor {
+			return nil
+		}
+		cursor = nextCursor
+	}
+}
@@ -0,0 +1,182 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// pagedHandler serves pages "a", "b" and "c" of /items, chained by the cursor
+// query parameter; write sets the link to the next page.
+func pagedHandler(write func(w http.ResponseWriter, next string)) http.HandlerFunc {
+	next := map[string]string{"": "b", "b": "c", "c": ""}
+	return func(w http.ResponseWriter, r *http.Request) {
+		write(w, next[r.URL.Query().Get("cursor")])
+	}
+}
+
+func TestPaginateExtractors(t *testing.T) {
+	tests := []struct {
+		name    string
+		extract CursorExtractor
+		write   func(w http.ResponseWriter, next string)
+	}{
+		{
+			name:    "json path",
+			extract: CursorFromJSONPath("meta.next"),
+			write: func(w http.ResponseWriter, next string) {
+				if next == "" {
+					fmt.Fprint(w, `{"meta":{"next":null}}`)
+					return
+				}
+				fmt.Fprintf(w, `{"meta":{"next":%q}}`, next)
+			},
+		},
+		{
+			name:    "header",
+			extract: CursorFromHeader("X-Next-Cursor"),
+			write: func(w http.ResponseWriter, next string) {
+				w.Header().Set("X-Next-Cursor", next)
+			},
+		},
+		{
+			name:    "link rel",
+			extract: CursorFromLinkRel("next", "cursor"),
+			write: func(w http.ResponseWriter, next string) {
+				if next != "" {
+					w.Header().Set("Link", `</items?cursor=`+next+`>; rel="next", </items>; rel="first"`)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, pagedHandler(tt.write))
+			client := NewClient(server.URL, 5*time.Second)
+			pages := 0
+			err := client.Paginate(context.Background(), "cursor", tt.extract, func(*Response) error {
+				pages++
+				return nil
+			}, GET("/items"), WithQueryParam("limit", "2"))
+			if err != nil {
+				t.Fatalf("Paginate: %v", err)
+			}
+			if pages != 3 {
+				t.Errorf("pages = %d, want 3", pages)
+			}
+			var cursors []string
+			for _, req := range log.All() {
+				query, _ := url.ParseQuery(req.Query)
+				cursors = append(cursors, query.Get("cursor"))
+			}
+			if got := fmt.Sprint(cursors); got != "[ b c]" {
+				t.Errorf("cursors = %s, want [ b c]", got)
+			}
+		})
+	}
+}
+
+func TestPaginateStopsOnRepeatedCursor(t *testing.T) {
+	server, log := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Next-Cursor", "same")
+	})
+
+	client := NewClient(server.URL, 5*time.Second)
+	err := client.Paginate(context.Background(), "cursor", CursorFromHeader("X-Next-Cursor"),
+		func(*Response) error { return nil }, GET("/items"))
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if got := log.Count(); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestPaginateCursorReplacesQueryParam(t *testing.T) {
+	server, log := recordingServer(t, pagedHandler(func(w http.ResponseWriter, next string) {
+		w.Header().Set("X-Next-Cursor", next)
+	}))
+	client := NewClient(server.URL, 5*time.Second)
+	var queries []string
+	err := client.Paginate(context.Background(), "cursor", CursorFromHeader("X-Next-Cursor"),
+		func(*Response) error { return nil },
+		GET("/items"),
+		WithQueryParam("cursor", ""),
+		WithBeforeRequest(func(req *http.Request) error {
+			queries = append(queries, req.URL.RawQuery)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Paginate: %v", err)
+	}
+	if got := fmt.Sprint(queries); got != "[cursor= cursor=b cursor=c]" {
+		t.Errorf("queries = %s", got)
+	}
+	if log.Count() != 3 {
+		t.Errorf("requests = %d, want 3", log.Count())
+	}
+}
+
+func TestPaginateStopsOnHandlerError(t *testing.T) {
+	server, log := recordingServer(t, pagedHandler(func(w http.ResponseWriter, next string) {
+		w.Header().Set("X-Next-Cursor", next)
+	}))
+	client := NewClient(server.URL, 5*time.Second)
+	stop := errors.New("stop")
+	err := client.Paginate(context.Background(), "cursor", CursorFromHeader("X-Next-Cursor"),
+		func(*Response) error { return stop }, GET("/items"))
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v, want the handler's error", err)
+	}
+	if log.Count() != 1 {
+		t.Errorf("requests = %d, want 1", log.Count())
+	}
+}
+
+func TestPaginateReturnsHTTPError(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			w.Header().Set("X-Next-Cursor", "b")
+			return
+		}
+		http.Error(w, "gone", http.StatusGone)
+	})
+
+	client := NewClient(server.URL, 5*time.Second)
+	pages := 0
+	err := client.Paginate(context.Background(), "cursor", CursorFromHeader("X-Next-Cursor"),
+		func(*Response) error { pages++; return nil }, GET("/items"))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusGone {
+		t.Fatalf("err = %v, want an HTTPError with status 410", err)
+	}
+	if pages != 1 {
+		t.Errorf("pages = %d, want 1", pages)
+	}
+}
+
+func TestCursorFromJSONPath(t *testing.T) {
+	tests := []struct {
+		body    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{`{"meta":{"next":"abc"}}`, "meta.next", "abc", false},
+		{`{"data":[{"id":7}]}`, "data.0.id", "7", false},
+		{`{"meta":{}}`, "meta.next", "", false},
+		{``, "meta.next", "", false},
+		{`{"meta":{"next":{"a":1}}}`, "meta.next", "", true},
+	}
+	for _, tt := range tests {
+		got, err := CursorFromJSONPath(tt.path)(&Response{Body: []byte(tt.body)})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CursorFromJSONPath(%q) on %s = %q, %v; want %q, error %v", tt.path, tt.body, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package reqws
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// bufferSizeClasses are the capacities of pooled response slices.
+// Bodies larger than the last class are allocated normally and never pooled.
+var bufferSizeClasses = []int{4 << 10, 32 << 10, 256 << 10, 1 << 20}
+
+// bufferPool manages reusable buffers for request bodies and response reading.
+type bufferPool struct {
+	buffers sync.Pool   // *bytes.Buffer for request bodies
+	slices  []sync.Pool // *[]byte per size class for response bodies
+}
+
+func newBufferPool() *bufferPool {
+	return &bufferPool{
+		buffers: sync.Pool{New: func() interface{} { return new(bytes.Buffer) }},
+		slices:  make([]sync.Pool, len(bufferSizeClasses)),
+	}
+}
+
+// WithBufferPool enables buffer reuse for this client to reduce GC pressure under high load.
+// JSON request bodies are assembled in pooled buffers that are returned once the
+// transport has finished sending them. Response bodies are only
+// pooled for requests that opt in with WithPooledResponse().
+//
+// Pooled request bodies are not replayed on 307/308 redirects.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithBufferPool()
+func (c *Client) WithBufferPool() *Client {
+	c.buffers = newBufferPool()
+	return c
+}
+
+// responsePool holds released Response objects for reuse by WithPooledResponse().
+var responsePool = sync.Pool{New: func() interface{} { return new(Response) }}
+
+// WithPooledResponse makes Do() take the Response from a pool and hand over the
+// response headers without cloning them. On a client created with WithBufferPool(),
+// the body is also read into a pooled buffer.
+//
+// The caller MUST call Response.Release() once done with the response and must
+// not use the Response, its Headers, or Body/RawBody (or any slice of them)
+// afterwards. In builds with the race detector enabled, released buffers are
+// overwritten to surface use-after-release bugs.
+//
+// Example:
+//
+//	resp, err := client.Do(ctx, reqws.GET("/items"), reqws.WithPooledResponse())
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Release()
+func WithPooledResponse() RequestOption {
+	return func(c *requestConfig) {
+		c.pooledResponse = true
+	}
+}
+
+// Release returns a pooled response and its body to their pools.
+// For responses not obtained with WithPooledResponse() it only clears the fields.
+// The Response must not be used after Release.
+func (r *Response) Release() {
+	if r.pool != nil && r.pooled != nil {
+		r.pool.putSlice(r.pooled)
+	}
+	fromPool := r.fromPool
+	*r = Response{}
+	if fromPool {
+		responsePool.Put(r)
+	}
+}
+
+// getBuffer returns an empty pooled buffer.
+func (p *bufferPool) getBuffer() *bytes.Buffer {
+	buf := p.buffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool.
+func (p *bufferPool) putBuffer(buf *bytes.Buffer) {
+	if poisonReleasedBuffers {
+		poison(buf.Bytes())
+	}
+	p.buffers.Put(buf)
+}
+
+// getSlice returns a zero-length slice from the given size class.
+func (p *bufferPool) getSlice(class int) *[]byte {
+	if s, ok := p.slices[class].Get().(*[]byte); ok {
+		*s = (*s)[:0]
+		return s
+	}
+	s := make([]byte, 0, bufferSizeClasses[class])
+	return &s
+}
+
+// putSlice returns a slice to the pool of its size class.
+func (p *bufferPool) putSlice(s *[]byte) {
+	if poisonReleasedBuffers {
+		poison((*s)[:cap(*s)])
+	}
+	for class, size := range bufferSizeClasses {
+		if cap(*s) == size {
+			p.slices[class].Put(s)
+			return
+		}
+	}
+}
+
+// marshalJSON encodes v into a pooled buffer.
+// The returned body releases the buffer when closed.
+func (p *bufferPool) marshalJSON(v interface{}) (*pooledBody, error) {
+	buf := p.getBuffer()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		p.putBuffer(buf)
+		return nil, err
+	}
+	// Match json.Marshal output, which has no trailing newline
+	buf.Truncate(buf.Len() - 1)
+	return p.newBody(buf), nil
+}
+
+// newBody wraps a pooled buffer as a request body.
+func (p *bufferPool) newBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{Reader: bytes.NewReader(buf.Bytes()), pool: p, buf: buf}
+}
+
+// readAll reads r into pooled size-classed slices, growing to the next class as needed.
+// The returned pointer is nil if the body outgrew the largest class and is
+// therefore not pooled. fn, if set, receives each chunk as it is read.
+func (p *bufferPool) readAll(r io.Reader, fn func(chunk []byte) error) ([]byte, *[]byte, error) {
+	class := 0
+	pooled := p.getSlice(class)
+	buf := *pooled
+	for {
+		if len(buf) == cap(buf) {
+			buf, pooled, class = p.grow(buf, pooled, class)
+		}
+
+		n, err := r.Read(buf[len(buf):cap(buf)])
+		if n > 0 && fn != nil {
+			if cbErr := fn(buf[len(buf) : len(buf)+n]); cbErr != nil {
+				p.release(pooled)
+				return nil, nil, fmt.Errorf("response callback failed: %w", cbErr)
+			}
+		}
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			if pooled != nil {
+				*pooled = buf
+			}
+			return buf, pooled, nil
+		}
+		if err != nil {
+			p.release(pooled)
+			return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
+
+// grow moves buf into the next size class, or into a regular allocation once
+// it outgrows the largest class.
+func (p *bufferPool) grow(buf []byte, pooled *[]byte, class int) ([]byte, *[]byte, int) {
+	if pooled != nil && class+1 < len(bufferSizeClasses) {
+		next := p.getSlice(class + 1)
+		*next = append(*next, buf...)
+		p.putSlice(pooled)
+		return *next, next, class + 1
+	}
+
+	grown := make([]byte, len(buf), 2*cap(buf))
+	copy(grown, buf)
+	p.release(pooled)
+	return grown, nil, class
+}
+
+// release returns s to the pool if it is not nil.
+func (p *bufferPool) release(s *[]byte) {
+	if s != nil {
+		p.putSlice(s)
+	}
+}
+
+// pooledBody is a request body backed by a pooled buffer.
+// The transport closes request bodies once it is done with them, which returns the buffer.
+type pooledBody struct {
+	*bytes.Reader
+	pool *bufferPool
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(func() {
+		b.pool.putBuffer(b.buf)
+	})
+	return nil
+}
+
+// poison overwrites a released buffer so that stale reads return obvious garbage.
+func poison(b []byte) {
+	for i := range b {
+		b[i] = 0xDE
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package reqws
+
+// poisonReleasedBuffers overwrites released buffers in race-enabled builds
+// to make use-after-release bugs visible.
+const poisonReleasedBuffers = false
@@ -0,0 +1,234 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// isPoisoned reports whether b was overwritten by poison.
+func isPoisoned(b []byte) bool {
+	return len(b) > 0 && bytes.Count(b, []byte{0xDE}) == len(b)
+}
+
+func TestPooledResponseBodyHeldUntilRelease(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// Same length for every path, so all bodies use the same size class
+		w.Write(bytes.Repeat([]byte(r.URL.Path[1:2]), 3000))
+	})
+	client := NewClient(server.URL, 5*time.Second).WithBufferPool()
+
+	held, err := client.Do(context.Background(), GET("/a"), WithPooledResponse())
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	want := strings.Repeat("a", 3000)
+	body := held.Body
+
+	// Later pooled requests must not reuse the buffer of a response that is still held
+	for _, path := range []string{"/b", "/c", "/d"} {
+		resp, err := client.Do(context.Background(), GET(path), WithPooledResponse())
+		if err != nil {
+			t.Fatalf("Do %s: %v", path, err)
+		}
+		if got := string(resp.Body); got != strings.Repeat(path[1:], 3000) {
+			t.Errorf("%s body = %.10q..., want its own content", path, got)
+		}
+		resp.Release()
+		if string(body) != want {
+			t.Fatalf("held body changed to %.10q... after %s was released", body, path)
+		}
+	}
+
+	held.Release()
+	if held.Body != nil || held.Headers != nil || held.StatusCode != 0 {
+		t.Errorf("released response = %+v, want its fields cleared", held)
+	}
+	if poisonReleasedBuffers && !isPoisoned(body) {
+		t.Errorf("released body = %.10q..., want it poisoned", body)
+	}
+}
+
+func TestReleaseWithoutPooledBody(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	})
+
+	// Without WithBufferPool the response comes from the pool but its body does not,
+	// and without WithPooledResponse neither does
+	tests := []struct {
+		name string
+		opts []RequestOption
+	}{
+		{"pooled response", []RequestOption{WithPooledResponse()}},
+		{"regular response", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), append(tt.opts, GET("/"))...)
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			body := resp.Body
+			resp.Release()
+			if string(body) != "body" {
+				t.Errorf("body = %q after Release, want it left alone", body)
+			}
+			if resp.Body != nil || resp.fromPool {
+				t.Errorf("released response = %+v, want its fields cleared", resp)
+			}
+		})
+	}
+}
+
+func TestBufferPoolReadAll(t *testing.T) {
+	pool := newBufferPool()
+	tests := []struct {
+		name    string
+		size    int
+		wantCap int // 0 if the body is not pooled
+	}{
+		{"empty", 0, 4 << 10},
+		{"fills the first class", 4 << 10, 32 << 10},
+		{"second class", 5000, 32 << 10},
+		{"third class", 100 << 10, 256 << 10},
+		{"larger than every class", 2 << 20, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := bytes.Repeat([]byte{'x'}, tt.size)
+			var chunks int
+			got, pooled, err := pool.readAll(bytes.NewReader(data), func(chunk []byte) error {
+				chunks += len(chunk)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("readAll: %v", err)
+			}
+			if !bytes.Equal(got, data) || chunks != tt.size {
+				t.Errorf("read %d bytes with %d in callbacks, want %d", len(got), chunks, tt.size)
+			}
+			switch {
+			case tt.wantCap == 0 && pooled != nil:
+				t.Errorf("body of %d bytes pooled with capacity %d, want it unpooled", tt.size, cap(*pooled))
+			case tt.wantCap != 0 && (pooled == nil || cap(*pooled) != tt.wantCap || len(*pooled) != tt.size):
+				t.Errorf("pooled = %v, want a slice of %d bytes with capacity %d", pooled != nil, tt.size, tt.wantCap)
+			}
+			pool.release(pooled)
+		})
+	}
+}
+
+func TestBufferPoolResetsReusedBuffers(t *testing.T) {
+	pool := newBufferPool()
+	for i := 0; i < 10; i++ {
+		s := pool.getSlice(0)
+		if len(*s) != 0 || cap(*s) != bufferSizeClasses[0] {
+			t.Fatalf("getSlice = len %d cap %d, want an empty slice of the first class", len(*s), cap(*s))
+		}
+		*s = append(*s, "stale"...)
+		pool.putSlice(s)
+
+		buf := pool.getBuffer()
+		if buf.Len() != 0 {
+			t.Fatalf("getBuffer returned %q, want an empty buffer", buf.Bytes())
+		}
+		buf.WriteString("stale")
+		pool.putBuffer(buf)
+	}
+
+	// A slice of a size the pool does not manage is dropped
+	odd := make([]byte, 0, 1000)
+	pool.putSlice(&odd)
+}
+
+func TestBufferPoolReleasesOnCallbackError(t *testing.T) {
+	pool := newBufferPool()
+	cbErr := errors.New("stop")
+	_, pooled, err := pool.readAll(strings.NewReader("data"), func([]byte) error { return cbErr })
+	if !errors.Is(err, cbErr) || pooled != nil {
+		t.Errorf("readAll = %v, %v; want the callback error and no buffer", pooled, err)
+	}
+}
+
+func TestPooledRequestBodyReleasedOnce(t *testing.T) {
+	pool := newBufferPool()
+	body, err := pool.marshalJSON(map[string]int{"id": 1})
+	if err != nil {
+		t.Fatalf("marshalJSON: %v", err)
+	}
+	if got := body.buf.String(); got != `{"id":1}` {
+		t.Errorf("marshalJSON = %q, want it without a trailing newline", got)
+	}
+	data := body.buf.Bytes()
+	body.Close()
+	body.Close()
+	if poisonReleasedBuffers && !isPoisoned(data) {
+		t.Errorf("released request body = %q, want it poisoned", data)
+	}
+
+	// The buffer was put back once, so two gets cannot return the same buffer
+	if a, b := pool.getBuffer(), pool.getBuffer(); a == b {
+		t.Error("buffer returned to the pool twice")
+	}
+}
+
+// benchmarkDo runs a realistic request mix against a local server: a JSON POST
+// with a small response and a GET with a larger one. It reports GC pause time
+// per operation next to the allocation counts.
+func benchmarkDo(b *testing.B, bufferPool bool, opts ...RequestOption) {
+	payload := bytes.Repeat([]byte(`{"id":1,"name":"item","tags":["a","b"]},`), 200)
+	server := testServer(b, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"ok":true}`))
+			return
+		}
+		w.Write(payload)
+	})
+	client := NewClient(server.URL, 5*time.Second)
+	if bufferPool {
+		client.WithBufferPool()
+	}
+	order := map[string]interface{}{"id": 42, "items": []string{"a", "b", "c"}, "note": strings.Repeat("n", 512)}
+	ctx := context.Background()
+	get := append([]RequestOption{GET("/items")}, opts...)
+	post := append([]RequestOption{POST("/orders"), WithJSON(order)}, opts...)
+
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reqOpts := get
+		if i%2 == 0 {
+			reqOpts = post
+		}
+		resp, err := client.Do(ctx, reqOpts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		resp.Release()
+	}
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+func BenchmarkDo(b *testing.B) {
+	benchmarkDo(b, false)
+}
+
+func BenchmarkDoBufferPool(b *testing.B) {
+	benchmarkDo(b, true)
+}
+
+func BenchmarkDoPooledResponse(b *testing.B) {
+	benchmarkDo(b, true, WithPooledResponse())
+}
@@ -0,0 +1,39 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+)
+
+// Prepared is a request whose options have been applied once, for sending the
+// same request many times, e.g. in polling loops or load generation.
+// A Prepared is immutable and safe to execute concurrently.
+type Prepared struct {
+	config *requestConfig
+}
+
+// Prepare applies opts once and returns a request to execute with DoPrepared().
+//
+// The body is still serialized on every execution, so a pointer passed to WithJSON()
+// is sent with its current contents. Values computed by options are fixed when
+// Prepare is called: WithIdempotencyKey("") generates one key shared by every
+// execution, so use WithHeaderFunc() for values that must change per call.
+//
+// Example:
+//
+//	poll := reqws.Prepare(reqws.GET("/v1/jobs/42"), reqws.WithBearerToken(token))
+//	for {
+//		resp, err := client.DoPrepared(ctx, poll)
+//		...
+//	}
+func Prepare(opts ...RequestOption) *Prepared {
+	return &Prepared{config: newRequestConfig(http.MethodGet, opts)}
+}
+
+// DoPrepared executes a request built with Prepare() and returns it like Do().
+func (c *Client) DoPrepared(ctx context.Context, p *Prepared) (*Response, error) {
+	// Execution records per-call state on the config, so each call gets its own copy
+	config := *p.config
+	config.rewatchInputs()
+	return c.do(ctx, &config)
+}
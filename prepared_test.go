@@ -0,0 +1,140 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoPreparedSendsSameRequest(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+
+	type job struct {
+		ID    int    `json:"id"`
+		State string `json:"state"`
+	}
+	body := &job{ID: 42, State: "queued"}
+	var calls atomic.Int32
+	p := Prepare(
+		PUT("/jobs/42"),
+		WithQueryParam("v", "2"),
+		WithHeader("X-Team", "core"),
+		WithIdempotencyKey(""),
+		WithHeaderFunc("X-Call", func() string { return strconv.Itoa(int(calls.Add(1))) }),
+		WithJSON(body),
+	)
+
+	for _, state := range []string{"queued", "running", "done"} {
+		body.State = state
+		if _, err := client.DoPrepared(context.Background(), p); err != nil {
+			t.Fatalf("DoPrepared: %v", err)
+		}
+	}
+
+	sent := log.All()
+	if len(sent) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(sent))
+	}
+	key := sent[0].Header.Get("Idempotency-Key")
+	for i, req := range sent {
+		// The body is serialized per call, header funcs run per call, and values
+		// computed by options are fixed at Prepare
+		want := `{"id":42,"state":"` + []string{"queued", "running", "done"}[i] + `"}`
+		if req.Method != http.MethodPut || req.Path != "/jobs/42" || req.Query != "v=2" || req.Header.Get("X-Team") != "core" || req.Body != want {
+			t.Errorf("request %d = %+v, want the prepared request with body %s", i+1, req, want)
+		}
+		if got := req.Header.Get("Idempotency-Key"); key == "" || got != key {
+			t.Errorf("request %d Idempotency-Key = %q, want %q shared by every call", i+1, got, key)
+		}
+		if got := req.Header.Get("X-Call"); got != strconv.Itoa(i+1) {
+			t.Errorf("request %d X-Call = %q, want %d", i+1, got, i+1)
+		}
+	}
+}
+
+func TestDoPreparedDefaultsToGET(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	if _, err := NewClient(server.URL, 5*time.Second).DoPrepared(context.Background(), Prepare(WithPath("/status"))); err != nil {
+		t.Fatalf("DoPrepared: %v", err)
+	}
+	if got := log.Last(t); got.Method != http.MethodGet || got.Path != "/status" {
+		t.Errorf("sent %s %s, want GET /status", got.Method, got.Path)
+	}
+}
+
+func TestDoPreparedConcurrently(t *testing.T) {
+	// Every third request fails, so executions also carry retry state
+	var attempts *requestLog
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Count()%3 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	client := NewClient(server.URL, 5*time.Second)
+	p := Prepare(POST("/events"), WithJSON(map[string]string{"type": "ping"}), WithRetry(fastRetry(10)))
+
+	const calls = 20
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.DoPrepared(context.Background(), p)
+			if err != nil || resp.StatusCode != http.StatusOK {
+				t.Errorf("DoPrepared = %v, %v; want 200", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, req := range attempts.All() {
+		if req.Body != `{"type":"ping"}` {
+			t.Errorf("body = %q, want the prepared body", req.Body)
+		}
+	}
+}
+
+// preparedBenchOpts are the options of a typical authenticated polling request.
+func preparedBenchOpts() []RequestOption {
+	return []RequestOption{
+		GET("/v1/jobs/42"),
+		WithBearerToken("token"),
+		WithHeader("Accept", "application/json"),
+		WithHeader("X-Client", "poller"),
+		WithQueryParam("include", "steps"),
+		WithQueryParam("fields", "id,state,progress"),
+		WithRetry(fastRetry(2)),
+	}
+}
+
+func BenchmarkDoOptions(b *testing.B) {
+	server := testServer(b, func(w http.ResponseWriter, r *http.Request) {})
+	client := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+	opts := preparedBenchOpts()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Do(ctx, opts...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDoPrepared(b *testing.B) {
+	server := testServer(b, func(w http.ResponseWriter, r *http.Request) {})
+	client := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+	p := Prepare(preparedBenchOpts()...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.DoPrepared(ctx, p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
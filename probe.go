@@ -0,0 +1,141 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeIdleCycles is how many consecutive idle intervals the prober keeps running
+// before it stops. It restarts on the next request.
+const probeIdleCycles = 10
+
+// ProbeStats holds idle connection probe counters.
+type ProbeStats struct {
+	Probes    uint64 // Probe requests sent
+	Failures  uint64 // Probes that failed
+	Evictions uint64 // Times idle connections were closed after a failed probe
+}
+
+// WithIdleConnProbe keeps pooled connections healthy while the client is idle.
+// Every interval without user traffic, the client sends a HEAD request to probePath
+// over the idle pool. If the probe fails, all idle connections are closed so the
+// next real request dials a fresh connection instead of stalling on a dead one
+// (e.g. after a NAT timeout).
+//
+// To avoid creating load on a client nobody uses, probing stops after 10 idle
+// intervals and resumes with the next request. Use Timeouts.IdleConn to also drop
+// idle connections sooner than the transport default.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithIdleConnProbe(30*time.Second, "/healthz")
+func (c *Client) WithIdleConnProbe(interval time.Duration, probePath string) *Client {
+	if !strings.HasPrefix(probePath, "/") {
+		probePath = "/" + probePath
+	}
+	c.prober = &idleProber{
+		client:   c,
+		interval: interval,
+		path:     probePath,
+	}
+	return c
+}
+
+// ProbeStats returns the idle connection probe counters.
+// Returns zero values if probing is not enabled.
+func (c *Client) ProbeStats() ProbeStats {
+	if c.prober == nil {
+		return ProbeStats{}
+	}
+	c.prober.mu.Lock()
+	defer c.prober.mu.Unlock()
+	return c.prober.stats
+}
+
+type idleProber struct {
+	client   *Client
+	interval time.Duration
+	path     string
+
+	mu       sync.Mutex
+	running  bool
+	lastUsed time.Time
+	stats    ProbeStats
+}
+
+// touch records user traffic and starts the probe loop if it is not running.
+func (p *idleProber) touch() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastUsed = time.Now()
+	if !p.running {
+		p.running = true
+		go p.run()
+	}
+}
+
+// run probes the pool on every idle interval until the client stays idle for too long.
+func (p *idleProber) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	idleCycles := 0
+	for range ticker.C {
+		p.mu.Lock()
+		idle := time.Since(p.lastUsed) >= p.interval
+		if !idle {
+			idleCycles = 0
+			p.mu.Unlock()
+			continue
+		}
+		idleCycles++
+		if idleCycles > probeIdleCycles {
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+
+		p.probe()
+	}
+}
+
+// probe sends a HEAD request and evicts idle connections if it fails.
+func (p *idleProber) probe() {
+	timeout := p.interval / 2
+	if timeout > 5*time.Second {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	failed := true
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, p.client.baseURL+p.path, nil)
+	if err == nil {
+		resp, err := p.client.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			failed = false
+		}
+	}
+
+	p.mu.Lock()
+	p.stats.Probes++
+	if failed {
+		p.stats.Failures++
+		p.stats.Evictions++
+	}
+	p.mu.Unlock()
+
+	if failed {
+		if p.client.logger != nil {
+			p.client.logger.Info("idle connection probe failed, closing idle connections", "path", p.path)
+		}
+		p.client.client.CloseIdleConnections()
+	}
+}
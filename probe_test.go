@@ -0,0 +1,68 @@
+package reqws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdleConnProbe(t *testing.T) {
+	var probes atomic.Int32
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead && r.URL.Path == "/healthz" {
+			probes.Add(1)
+		}
+	})
+
+	const interval = 10 * time.Millisecond
+	client := NewClient(server.URL, 5*time.Second).WithIdleConnProbe(interval, "healthz")
+	if stats := client.ProbeStats(); stats.Probes != 0 {
+		t.Fatalf("probes before any request = %d, want 0", stats.Probes)
+	}
+	if _, err := client.Do(context.Background(), GET("/")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+
+	// Probing stops on its own after probeIdleCycles idle intervals
+	time.Sleep(interval * (probeIdleCycles + 10))
+	stats := client.ProbeStats()
+	if stats.Probes == 0 || stats.Probes > probeIdleCycles {
+		t.Errorf("probes = %d, want between 1 and %d", stats.Probes, probeIdleCycles)
+	}
+	if stats.Failures != 0 || stats.Evictions != 0 {
+		t.Errorf("stats = %+v, want no failures", stats)
+	}
+	if got := probes.Load(); uint64(got) != stats.Probes {
+		t.Errorf("server saw %d HEAD /healthz probes, stats report %d", got, stats.Probes)
+	}
+	time.Sleep(interval * 5)
+	if got := client.ProbeStats().Probes; got != stats.Probes {
+		t.Errorf("probes grew from %d to %d after the prober went idle", stats.Probes, got)
+	}
+}
+
+func TestIdleConnProbeFailureEvicts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	client := NewClient(server.URL, 5*time.Second).WithIdleConnProbe(10*time.Millisecond, "/healthz")
+	if _, err := client.Do(context.Background(), GET("/")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	server.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.ProbeStats().Failures == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stats := client.ProbeStats(); stats.Failures == 0 || stats.Evictions != stats.Failures {
+		t.Errorf("stats = %+v, want failed probes each followed by an eviction", stats)
+	}
+}
+
+func TestProbeStatsWithoutProbing(t *testing.T) {
+	if stats := NewClient("http://unused", time.Second).ProbeStats(); stats != (ProbeStats{}) {
+		t.Errorf("stats = %+v, want zero values", stats)
+	}
+}
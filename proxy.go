@@ -0,0 +1,196 @@
+package reqws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyAuthProvider returns the Proxy-Authorization header value for a proxy,
+// e.g. "Basic dXNlcjpwYXNz" or "Bearer <token>".
+// It is called for every new proxy connection, so it may return rotating credentials.
+// Use IsProxyAuthRefresh(ctx) to detect that cached credentials were rejected.
+type ProxyAuthProvider func(ctx context.Context, proxyURL *url.URL) (string, error)
+
+// ProxyAuthError is returned when the proxy rejects the client's credentials
+// with 407 Proxy Authentication Required, or the ProxyAuthProvider fails.
+// It is distinct from HTTPError so that origin 401s are not mistaken for proxy failures.
+type ProxyAuthError struct {
+	Err error
+}
+
+func (e *ProxyAuthError) Error() string {
+	return fmt.Sprintf("proxy authentication failed: %v", e.Err)
+}
+
+// Unwrap returns the underlying error for error chain support.
+func (e *ProxyAuthError) Unwrap() error {
+	return e.Err
+}
+
+type proxyAuthRefreshKey struct{}
+
+// IsProxyAuthRefresh reports whether a ProxyAuthProvider is being called again
+// because the proxy rejected the previous credentials with 407.
+// Providers that cache credentials should fetch fresh ones when this is true.
+func IsProxyAuthRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(proxyAuthRefreshKey{}).(bool)
+	return refresh
+}
+
+// WithProxyAuth sets a provider for proxy credentials.
+// The provider's result is sent as Proxy-Authorization on CONNECT requests for
+// HTTPS and WSS targets, and on plain HTTP requests sent through the proxy.
+// The proxy itself is still taken from the environment (HTTP_PROXY, HTTPS_PROXY,
+// NO_PROXY) unless the transport was configured otherwise.
+//
+// A 407 response triggers one credential refresh and retry; if that also fails
+// the request returns a *ProxyAuthError.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithProxyAuth(func(ctx context.Context, proxyURL *url.URL) (string, error) {
+//			token, err := tokenSource.Token(ctx, reqws.IsProxyAuthRefresh(ctx))
+//			if err != nil {
+//				return "", err
+//			}
+//			return "Bearer " + token, nil
+//		})
+func (c *Client) WithProxyAuth(provider ProxyAuthProvider) *Client {
+	c.proxyAuth = provider
+
+	transport := c.transport()
+	if transport.Proxy == nil {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	transport.GetProxyConnectHeader = func(ctx context.Context, proxyURL *url.URL, target string) (http.Header, error) {
+		header, err := provider(ctx, proxyURL)
+		if err != nil {
+			return nil, &ProxyAuthError{Err: err}
+		}
+		if header == "" {
+			return nil, nil
+		}
+		return http.Header{"Proxy-Authorization": []string{header}}, nil
+	}
+	return c
+}
+
+// WithProxy sends this request through the proxy at proxyURL, e.g.
+// "http://proxy.corp.example:3128" or "socks5://127.0.0.1:1080", overriding the
+// client's proxy settings for this request only. Credentials in the URL are sent
+// to the proxy; WithProxyAuth() also applies.
+//
+// Requests through the same proxy share a transport derived from the client's
+// (see WithInsecureSkipVerify for how it is created). A proxyURL without a scheme and host fails the request when it is built.
+// WebSocket dials are not affected.
+//
+// Example:
+//
+//	body, err := client.Request(ctx,
+//		reqws.GET("/partners/quotes"),
+//		reqws.WithProxy("http://proxy.corp.example:3128"),
+//	)
+func WithProxy(proxyURL string) RequestOption {
+	return func(c *requestConfig) {
+		parsed, err := url.Parse(proxyURL)
+		if err == nil && (parsed.Scheme == "" || parsed.Host == "") {
+			err = fmt.Errorf("missing scheme or host in %q", proxyURL)
+		}
+		if err != nil {
+			c.proxy, c.proxyErr = nil, fmt.Errorf("invalid proxy URL: %w", err)
+			return
+		}
+		c.proxy, c.proxyErr = parsed, nil
+	}
+}
+
+// doWithProxyAuth sends req, adding proxy credentials for plain HTTP proxying and
+// refreshing them once if the proxy answers 407.
+func (c *Client) doWithProxyAuth(httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	if err := c.setProxyAuthHeader(httpClient, req); err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if !isProxyAuthFailure(resp, err) {
+		return resp, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	// Retry once with refreshed credentials if the body can be replayed
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return nil, &ProxyAuthError{Err: proxyAuthCause(resp, err)}
+	}
+	retryReq := req.Clone(context.WithValue(req.Context(), proxyAuthRefreshKey{}, true))
+	if req.GetBody != nil {
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return nil, &ProxyAuthError{Err: proxyAuthCause(resp, err)}
+		}
+		retryReq.Body = body
+	}
+	if c.logger != nil {
+		c.logger.Info("proxy rejected credentials, retrying with refreshed credentials", "url", req.URL.String())
+	}
+	if err := c.setProxyAuthHeader(httpClient, retryReq); err != nil {
+		return nil, err
+	}
+
+	resp, err = httpClient.Do(retryReq)
+	if isProxyAuthFailure(resp, err) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, &ProxyAuthError{Err: proxyAuthCause(resp, err)}
+	}
+	return resp, err
+}
+
+// setProxyAuthHeader adds Proxy-Authorization to plain HTTP requests that go through a proxy.
+// HTTPS requests get their credentials on the CONNECT request instead.
+func (c *Client) setProxyAuthHeader(httpClient *http.Client, req *http.Request) error {
+	if req.URL.Scheme != "http" {
+		return nil
+	}
+	transport, ok := httpClient.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		return nil
+	}
+	proxyURL, err := transport.Proxy(req)
+	if err != nil || proxyURL == nil {
+		return nil
+	}
+
+	header, err := c.proxyAuth(req.Context(), proxyURL)
+	if err != nil {
+		return &ProxyAuthError{Err: err}
+	}
+	if header != "" {
+		req.Header.Set("Proxy-Authorization", header)
+	}
+	return nil
+}
+
+// isProxyAuthFailure reports whether the proxy rejected the credentials, either as a
+// 407 response (plain HTTP proxying) or a failed CONNECT (HTTPS tunneling).
+func isProxyAuthFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		// net/http reports a non-200 CONNECT response only through its status text
+		return strings.Contains(err.Error(), http.StatusText(http.StatusProxyAuthRequired))
+	}
+	return resp != nil && resp.StatusCode == http.StatusProxyAuthRequired
+}
+
+// proxyAuthCause returns the error describing a proxy authentication failure.
+func proxyAuthCause(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return NewHTTPError(resp.StatusCode, nil)
+}
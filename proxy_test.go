@@ -0,0 +1,138 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// authProxy returns a forward proxy that accepts requests carrying the
+// Proxy-Authorization value "Bearer good", answering plain HTTP requests itself
+// and tunneling CONNECT requests.
+func authProxy(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var rejected atomic.Int32
+	proxy := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Bearer good" {
+			rejected.Add(1)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		if r.Method != http.MethodConnect {
+			w.Write([]byte("proxied " + r.URL.String()))
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			target.Close()
+			return
+		}
+		go func() {
+			io.Copy(target, buf)
+			target.Close()
+		}()
+		io.Copy(conn, target)
+		conn.Close()
+	})
+	return proxy, &rejected
+}
+
+// rotatingCredentials returns a provider that answers with a stale token until
+// asked to refresh.
+func rotatingCredentials(refreshes *atomic.Int32) ProxyAuthProvider {
+	return func(ctx context.Context, proxyURL *url.URL) (string, error) {
+		if IsProxyAuthRefresh(ctx) {
+			refreshes.Add(1)
+			return "Bearer good", nil
+		}
+		return "Bearer stale", nil
+	}
+}
+
+func TestProxyAuthRefreshesRejectedCredentials(t *testing.T) {
+	proxy, rejected := authProxy(t)
+	var refreshes atomic.Int32
+	client := NewClient("http://api.example", 5*time.Second).WithProxyAuth(rotatingCredentials(&refreshes))
+
+	resp, err := client.Do(context.Background(), GET("/orders"), WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "proxied http://api.example/orders" {
+		t.Errorf("body = %q", got)
+	}
+	if rejected.Load() != 1 || refreshes.Load() != 1 {
+		t.Errorf("rejected = %d, refreshes = %d; want 1 each", rejected.Load(), refreshes.Load())
+	}
+}
+
+func TestProxyAuthOnConnect(t *testing.T) {
+	origin := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tunneled"))
+	}))
+	defer origin.Close()
+	proxy, rejected := authProxy(t)
+	var refreshes atomic.Int32
+	client := NewClient(origin.URL, 5*time.Second).WithProxyAuth(rotatingCredentials(&refreshes))
+
+	resp, err := client.Do(context.Background(), GET("/"), WithProxy(proxy.URL), WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if string(resp.Body) != "tunneled" {
+		t.Errorf("body = %q", resp.Body)
+	}
+	if rejected.Load() != 1 || refreshes.Load() != 1 {
+		t.Errorf("rejected = %d, refreshes = %d; want 1 each", rejected.Load(), refreshes.Load())
+	}
+}
+
+func TestProxyAuthError(t *testing.T) {
+	proxy, _ := authProxy(t)
+	providerErr := errors.New("vault unavailable")
+	tests := []struct {
+		name     string
+		provider ProxyAuthProvider
+		wantErr  error
+	}{
+		{"rejected twice", func(context.Context, *url.URL) (string, error) { return "Bearer bad", nil }, nil},
+		{"provider failure", func(context.Context, *url.URL) (string, error) { return "", providerErr }, providerErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient("http://api.example", 5*time.Second).WithProxyAuth(tt.provider)
+			_, err := client.Do(context.Background(), GET("/"), WithProxy(proxy.URL))
+			var authErr *ProxyAuthError
+			if !errors.As(err, &authErr) {
+				t.Fatalf("err = %v, want a ProxyAuthError", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want it to wrap %v", err, tt.wantErr)
+			}
+			var httpErr *HTTPError
+			if tt.wantErr == nil && (!errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusProxyAuthRequired) {
+				t.Errorf("err = %v, want it to wrap the 407", err)
+			}
+		})
+	}
+}
+
+func TestWithProxyInvalidURL(t *testing.T) {
+	client := NewClient("http://api.example", 5*time.Second)
+	if _, err := client.Do(context.Background(), GET("/"), WithProxy("proxy.corp.example:3128")); err == nil {
+		t.Fatal("expected an error for a proxy URL without a scheme")
+	}
+}
@@ -0,0 +1,185 @@
+package reqws
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryArrayStyle selects how WithQueryParamSlice() encodes a list of values.
+type QueryArrayStyle int
+
+const (
+	QueryArrayDefault        QueryArrayStyle = iota // The client's style (see WithQueryArrayStyle), QueryArrayRepeat unless set
+	QueryArrayRepeat                                // ids=1&ids=2
+	QueryArrayRepeatBrackets                        // ids[]=1&ids[]=2
+	QueryArrayCommaJoined                           // ids=1,2; values containing commas are rejected
+	QueryArrayIndexed                               // ids[0]=1&ids[1]=2
+)
+
+// WithQueryArrayStyle sets the encoding style used by WithQueryParamSlice() when
+// it is passed QueryArrayDefault.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://legacy.example.com", 30*time.Second).
+//		WithQueryArrayStyle(reqws.QueryArrayRepeatBrackets)
+func (c *Client) WithQueryArrayStyle(style QueryArrayStyle) *Client {
+	c.queryArrayStyle = style
+	return c
+}
+
+// SetDefaultQueryParams sets query parameters sent with every HTTP request of the
+// client, e.g. an API version. A parameter the request sets with WithQueryParam()
+// or another query option replaces the default of the same key instead of adding
+// to it. params is copied; calling SetDefaultQueryParams again replaces the
+// previous defaults, and nil removes them.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		SetDefaultQueryParams(url.Values{"api_version": {"2"}})
+func (c *Client) SetDefaultQueryParams(params url.Values) *Client {
+	c.queryParams = cloneValues(params)
+	return c
+}
+
+// cloneValues returns a deep copy of values, or nil if values is nil.
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+	clone := make(url.Values, len(values))
+	for key, v := range values {
+		clone[key] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// WithQueryParamSlice adds a list of values for key, encoded in the given style.
+// Values are URL-encoded individually like any other parameter, and brackets
+// in keys are percent-encoded ("ids%5B%5D=1"), which servers decode as usual.
+// Under QueryArrayCommaJoined a value containing a comma cannot be told apart from
+// two values, so the request fails without being sent. An empty slice adds nothing.
+//
+// Example:
+//
+//	// ?ids=1,2,3
+//	client.Request(ctx,
+//		reqws.GET("/users"),
+//		reqws.WithQueryParamSlice("ids", []string{"1", "2", "3"}, reqws.QueryArrayCommaJoined),
+//	)
+func WithQueryParamSlice(key string, values []string, style QueryArrayStyle) RequestOption {
+	return func(c *requestConfig) {
+		qs := querySlice{key: key, values: append([]string(nil), values...), style: style}
+		c.addQuery(func(client *Client, query url.Values) error {
+			return qs.add(query, client.queryArrayStyle)
+		})
+	}
+}
+
+type querySlice struct {
+	key    string
+	values []string
+	style  QueryArrayStyle
+}
+
+// add adds the values to query in the slice's style, or in fallback for
+// QueryArrayDefault.
+func (q querySlice) add(query url.Values, fallback QueryArrayStyle) error {
+	if len(q.values) == 0 {
+		return nil
+	}
+	style := q.style
+	if style == QueryArrayDefault {
+		style = fallback
+	}
+	switch style {
+	case QueryArrayRepeatBrackets:
+		query[q.key+"[]"] = append(query[q.key+"[]"], q.values...)
+	case QueryArrayCommaJoined:
+		for _, value := range q.values {
+			if strings.Contains(value, ",") {
+				return fmt.Errorf("query parameter %q: value %q contains a comma, which comma-joined encoding cannot represent", q.key, value)
+			}
+		}
+		query.Add(q.key, strings.Join(q.values, ","))
+	case QueryArrayIndexed:
+		for i, value := range q.values {
+			query.Add(q.key+"["+strconv.Itoa(i)+"]", value)
+		}
+	default:
+		query[q.key] = append(query[q.key], q.values...)
+	}
+	return nil
+}
+
+// WithQueryParamAny adds a query parameter from a scalar value, added after any
+// values key already has:
+//   - strings and fmt.Stringer values as they are
+//   - integers in decimal, e.g. "42"
+//   - floats in the shortest decimal form without an exponent, e.g. "0.25"
+//   - bools as "true" or "false"
+//   - time.Time like WithQueryParamTime(), in the client's query time format
+//
+// Pointers are dereferenced, except to a type whose String method has a
+// pointer receiver, and a nil value adds nothing. For several values
+// use WithQueryParamSlice(); other types make the request fail without being
+// sent, see WithQueryParamEncoded() for them.
+//
+// Example:
+//
+//	// ?limit=50&active=true&min_score=0.5
+//	client.Request(ctx,
+//		reqws.GET("/users"),
+//		reqws.WithQueryParamAny("limit", 50),
+//		reqws.WithQueryParamAny("active", true),
+//		reqws.WithQueryParamAny("min_score", 0.5),
+//	)
+func WithQueryParamAny(key string, value interface{}) RequestOption {
+	return func(c *requestConfig) {
+		v := reflect.ValueOf(value)
+		for v.Kind() == reflect.Pointer && !v.IsNil() {
+			// A String method with a pointer receiver is lost by dereferencing
+			if s, ok := v.Interface().(fmt.Stringer); ok && !v.Elem().Type().Implements(stringerType) {
+				WithQueryParamEncoded(key, s, formatQueryValue)(c)
+				return
+			}
+			v = v.Elem()
+		}
+		if !v.IsValid() || v.Kind() == reflect.Pointer {
+			return
+		}
+		if t, ok := v.Interface().(time.Time); ok {
+			WithQueryParamTime(key, t)(c)
+			return
+		}
+		WithQueryParamEncoded(key, v.Interface(), formatQueryValue)(c)
+	}
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// formatQueryValue formats a scalar for WithQueryParamAny().
+func formatQueryValue(value interface{}) (string, error) {
+	if s, ok := value.(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	}
+	return "", fmt.Errorf("unsupported type %T", value)
+}
@@ -0,0 +1,223 @@
+package reqws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryOptionsKeepCallOrder(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts []RequestOption
+		want string
+	}{
+		{
+			name: "param, any, param",
+			opts: []RequestOption{WithQueryParam("id", "1"), WithQueryParamAny("id", 2), WithQueryParam("id", "3")},
+			want: "id=1&id=2&id=3",
+		},
+		{
+			name: "slice before param",
+			opts: []RequestOption{WithQueryParamSlice("id", []string{"2"}, QueryArrayRepeat), WithQueryParam("id", "1")},
+			want: "id=2&id=1",
+		},
+		{
+			name: "time and encoded between params",
+			opts: []RequestOption{
+				WithQueryParamEncoded("v", []int{1}, JSONQueryEncoder),
+				WithQueryParam("v", "a"),
+				WithQueryParamTime("v", when),
+				WithQueryParams(url.Values{"v": {"b", "c"}}),
+			},
+			want: "v=%5B1%5D&v=a&v=2024-01-02T03%3A04%3A05Z&v=b&v=c",
+		},
+		{
+			name: "any with pointer, nil and bool",
+			opts: []RequestOption{WithQueryParamAny("x", (*int)(nil)), WithQueryParamAny("x", true), WithQueryParamAny("x", 1.5)},
+			want: "x=true&x=1.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second)
+			if _, err := client.Do(context.Background(), append([]RequestOption{GET("/")}, tt.opts...)...); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Query; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// orderID formats itself with a pointer receiver.
+type orderID struct{ n int }
+
+func (id *orderID) String() string { return fmt.Sprintf("ord-%d", id.n) }
+
+// region formats itself with a value receiver.
+type region int
+
+func (r region) String() string { return [...]string{"eu", "us"}[r] }
+
+func TestQueryParamAnyStringer(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	us := region(1)
+	usPtr := &us
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"pointer receiver", &orderID{n: 7}, "v=ord-7"},
+		{"pointer to pointer receiver", func() **orderID { id := &orderID{n: 8}; return &id }(), "v=ord-8"},
+		{"value receiver", region(0), "v=eu"},
+		{"pointer to value receiver", &us, "v=us"},
+		{"pointer to pointer to value receiver", &usPtr, "v=us"},
+		{"nil pointer receiver", (*orderID)(nil), ""},
+		{"time pointer", &when, "v=2024-01-02T03%3A04%3A05Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second)
+			if _, err := client.Do(context.Background(), GET("/"), WithQueryParamAny("v", tt.value)); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Query; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryParamAnyUnsupportedType(t *testing.T) {
+	server, _ := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+	_, err := client.Do(context.Background(), GET("/"), WithQueryParamAny("x", []int{1}))
+	if err == nil {
+		t.Fatal("expected an error for a slice value")
+	}
+}
+
+func TestDefaultQueryParamsReplacedByRequest(t *testing.T) {
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name string
+		opts []RequestOption
+		want string
+	}{
+		{"defaults only", nil, "lang=en&v=1"},
+		{"WithQueryParamAny", []RequestOption{WithQueryParamAny("v", 2)}, "lang=en&v=2"},
+		{"WithQueryParam repeated", []RequestOption{WithQueryParam("v", "2"), WithQueryParam("v", "3")}, "lang=en&v=2&v=3"},
+		{"WithQueryParamSlice", []RequestOption{WithQueryParamSlice("v", []string{"2", "3"}, QueryArrayCommaJoined)}, "lang=en&v=2%2C3"},
+		{"WithQueryParamTime", []RequestOption{WithQueryParamTime("v", when)}, "lang=en&v=2024-01-02T03%3A04%3A05Z"},
+		{"other key", []RequestOption{WithQueryParam("page", "2")}, "lang=en&page=2&v=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second)
+			client.SetDefaultQueryParams(url.Values{"v": {"1"}, "lang": {"en"}})
+			if _, err := client.Do(context.Background(), append([]RequestOption{GET("/")}, tt.opts...)...); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Query; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultQueryParamsCopiedAndRemoved(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+	params := url.Values{"v": {"1"}}
+	client.SetDefaultQueryParams(params)
+	params["v"][0] = "mutated"
+	params.Set("extra", "1")
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(context.Background(), GET("/")); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		if got, want := log.Last(t).Query, "v=1"; got != want {
+			t.Errorf("call %d: query = %q, want %q", i, got, want)
+		}
+	}
+
+	client.SetDefaultQueryParams(nil)
+	if _, err := client.Do(context.Background(), GET("/")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := log.Last(t).Query; got != "" {
+		t.Errorf("query = %q after SetDefaultQueryParams(nil), want none", got)
+	}
+}
+
+func TestPreparedQueryIsRebuiltPerCall(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+	poll := Prepare(GET("/"), WithQueryParam("id", "1"), WithQueryParamAny("id", 2))
+	for i := 0; i < 2; i++ {
+		if _, err := client.DoPrepared(context.Background(), poll); err != nil {
+			t.Fatalf("DoPrepared: %v", err)
+		}
+		if got, want := log.Last(t).Query, "id=1&id=2"; got != want {
+			t.Errorf("call %d: query = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestQueryParamSliceStyles(t *testing.T) {
+	values := []string{"1", "a b", "é"}
+	tests := []struct {
+		name        string
+		clientStyle QueryArrayStyle
+		style       QueryArrayStyle
+		values      []string
+		want        string
+	}{
+		{"default is repeat", QueryArrayDefault, QueryArrayDefault, values, "ids=1&ids=a+b&ids=%C3%A9"},
+		{"repeat", QueryArrayIndexed, QueryArrayRepeat, values, "ids=1&ids=a+b&ids=%C3%A9"},
+		{"brackets", QueryArrayDefault, QueryArrayRepeatBrackets, values, "ids%5B%5D=1&ids%5B%5D=a+b&ids%5B%5D=%C3%A9"},
+		{"comma joined", QueryArrayDefault, QueryArrayCommaJoined, values, "ids=1%2Ca+b%2C%C3%A9"},
+		{"indexed", QueryArrayDefault, QueryArrayIndexed, values, "ids%5B0%5D=1&ids%5B1%5D=a+b&ids%5B2%5D=%C3%A9"},
+		{"client style for default", QueryArrayRepeatBrackets, QueryArrayDefault, values, "ids%5B%5D=1&ids%5B%5D=a+b&ids%5B%5D=%C3%A9"},
+		{"empty slice", QueryArrayDefault, QueryArrayCommaJoined, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, log := recordingServer(t, nil)
+			client := NewClient(server.URL, 5*time.Second)
+			client.WithQueryArrayStyle(tt.clientStyle)
+			if _, err := client.Do(context.Background(), GET("/"), WithQueryParamSlice("ids", tt.values, tt.style)); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Query; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryParamSliceCommaJoinedRejectsCommas(t *testing.T) {
+	var hits int
+	server := testServer(t, func(http.ResponseWriter, *http.Request) { hits++ })
+
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"),
+		WithQueryParamSlice("tags", []string{"a", "b,c"}, QueryArrayCommaJoined))
+	if err == nil || !strings.Contains(err.Error(), `value "b,c" contains a comma`) {
+		t.Errorf("err = %v, want the comma rejected", err)
+	}
+	if hits != 0 {
+		t.Errorf("server hits = %d, want the request not sent", hits)
+	}
+}
@@ -0,0 +1,177 @@
+package reqws
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitInfo is the rate limit state reported in a response's headers.
+type RateLimitInfo struct {
+	Limit     int       // Requests allowed per window, -1 if not reported
+	Remaining int       // Requests left in the current window, -1 if not reported
+	Reset     time.Time // When the window resets, zero if not reported
+}
+
+// RateLimit parses the response's rate limit headers, so callers can slow down
+// before they are throttled. The first of these conventions present is used:
+//
+//   - RateLimit: limit=100, remaining=50, reset=30 (IETF draft up to -07), or
+//     RateLimit: "default";r=50;t=30 with RateLimit-Policy: "default";q=100;w=60
+//     (IETF draft -08 and later)
+//   - RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset (IETF draft -00 to -06)
+//   - X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (GitHub, GitLab
+//     and many others), with X-RateLimit-Reset-After as an alternative reset
+//   - X-Rate-Limit-Limit, X-Rate-Limit-Remaining and X-Rate-Limit-Reset (Twitter)
+//
+// Reset values are seconds until the reset in the IETF headers. In the X- headers
+// they may also be a Unix timestamp in seconds or milliseconds (values of at least
+// 1e9 and 1e12), as GitHub sends. Delays count from now.
+//
+// Returns ErrNoRateLimit if none of the headers is present, and an error naming
+// the header if a value cannot be parsed.
+//
+// Example:
+//
+//	if rl, err := resp.RateLimit(); err == nil && rl.Remaining == 0 {
+//		time.Sleep(time.Until(rl.Reset))
+//	}
+func (r *Response) RateLimit() (*RateLimitInfo, error) {
+	return parseRateLimit(r.Headers, time.Now())
+}
+
+func parseRateLimit(h http.Header, now time.Time) (*RateLimitInfo, error) {
+	if field := h.Get("RateLimit"); field != "" {
+		return parseRateLimitField(field, h.Get("RateLimit-Policy"), now)
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-", "X-Rate-Limit-"} {
+		if h.Get(prefix+"Limit") == "" && h.Get(prefix+"Remaining") == "" && h.Get(prefix+"Reset") == "" {
+			continue
+		}
+		info := &RateLimitInfo{Limit: -1, Remaining: -1}
+		var err error
+		if info.Limit, err = rateLimitCount(h, prefix+"Limit"); err != nil {
+			return nil, err
+		}
+		if info.Remaining, err = rateLimitCount(h, prefix+"Remaining"); err != nil {
+			return nil, err
+		}
+		// Only the IETF headers are specified as delay seconds
+		timestamps := prefix != "RateLimit-"
+		if info.Reset, err = rateLimitReset(h.Get(prefix+"Reset"), timestamps, now); err != nil {
+			return nil, fmt.Errorf("invalid %sReset header: %w", prefix, err)
+		}
+		if info.Reset.IsZero() && prefix == "X-RateLimit-" {
+			if info.Reset, err = rateLimitReset(h.Get("X-RateLimit-Reset-After"), false, now); err != nil {
+				return nil, fmt.Errorf("invalid X-RateLimit-Reset-After header: %w", err)
+			}
+		}
+		return info, nil
+	}
+	return nil, ErrNoRateLimit
+}
+
+// parseRateLimitField parses the RateLimit header of the IETF drafts, either as
+// "limit=100, remaining=50, reset=30" or as a list of policies such as
+// "default";r=50;t=30 with the quota from the matching RateLimit-Policy entry.
+func parseRateLimitField(field, policy string, now time.Time) (*RateLimitInfo, error) {
+	info := &RateLimitInfo{Limit: -1, Remaining: -1}
+	params, name := rateLimitParams(field)
+	for key, value := range params {
+		switch key {
+		case "limit", "q", "remaining", "r", "reset", "t":
+		default:
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(n >= 0) || math.IsInf(n, 1) {
+			return nil, fmt.Errorf("invalid RateLimit header: parameter %s=%q", key, value)
+		}
+		switch key {
+		case "limit", "q":
+			info.Limit = int(n)
+		case "remaining", "r":
+			info.Remaining = int(n)
+		case "reset", "t":
+			info.Reset = now.Add(time.Duration(n * float64(time.Second)))
+		}
+	}
+	if info.Limit < 0 && name != "" {
+		// The quota is in the RateLimit-Policy item of the same name
+		for _, entry := range strings.Split(policy, ",") {
+			policyParams, policyName := rateLimitParams(entry)
+			q, ok := policyParams["q"]
+			if !ok || policyName != name {
+				continue
+			}
+			n, err := strconv.Atoi(q)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid RateLimit-Policy header: parameter q=%q", q)
+			}
+			info.Limit = n
+			break
+		}
+	}
+	return info, nil
+}
+
+// rateLimitParams returns the parameters of a RateLimit or RateLimit-Policy
+// header. In the named form ("default";r=50;t=30, "burst";r=5) only the first
+// item is read and its name is returned; otherwise every key=value pair is.
+func rateLimitParams(field string) (map[string]string, string) {
+	params := make(map[string]string)
+	name := ""
+	first, _, _ := strings.Cut(field, ";")
+	if first = strings.TrimSpace(first); !strings.Contains(first, "=") {
+		name = strings.Trim(first, `"`)
+		field, _, _ = strings.Cut(field, ",")
+	}
+	for _, part := range strings.FieldsFunc(field, func(r rune) bool { return r == ';' || r == ',' }) {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return params, name
+}
+
+// rateLimitCount parses a count header, or returns -1 if it is absent.
+func rateLimitCount(h http.Header, name string) (int, error) {
+	value := strings.TrimSpace(h.Get(name))
+	if value == "" {
+		return -1, nil
+	}
+	// Some servers send "100, 100;w=60" with the policy appended
+	value, _, _ = strings.Cut(value, ",")
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s header %q", name, value)
+	}
+	return n, nil
+}
+
+// rateLimitReset parses a reset value as seconds from now or, if timestamps is
+// set and the value is large enough, as a Unix time in seconds or milliseconds.
+// Returns the zero time if value is empty.
+func rateLimitReset(value string, timestamps bool, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(n >= 0) || math.IsInf(n, 1) {
+		return time.Time{}, fmt.Errorf("not a number of seconds: %q", value)
+	}
+	switch {
+	case timestamps && n >= 1e12:
+		return time.UnixMilli(int64(n)), nil
+	case timestamps && n >= 1e9:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)), nil
+	}
+	return now.Add(time.Duration(n * float64(time.Second))), nil
+}
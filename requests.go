@@ -3,14 +3,24 @@ package reqws
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,6 +37,44 @@ type Client struct {
 	client  *http.Client
 	baseURL string
 	logger  Logger
+	shedder *loadShedder
+	buffers *bufferPool
+
+	proxyAuth ProxyAuthProvider
+	prober    *idleProber
+	wsDialer  *wsDialer
+
+	queryTimeFormat *TimeFormat
+	bodyTimeFormat  *TimeFormat
+	omitZeroTime    bool
+	queryArrayStyle QueryArrayStyle
+
+	capsOnce  sync.Once
+	capsCache *capabilitiesCache
+
+	variantsOnce sync.Once
+	variants     *transportVariants
+
+	responseTransforms []ResponseTransform
+	taps               tapHub
+
+	dialect      Dialect
+	errorDecoder ErrorDecoder
+	dedup        *outboundDedup
+	budget       *memoryBudget
+	schemas      *schemaRegistry
+	apiKey       *apiKeyState
+	hostLimit    *hostLimiter
+	cookies      *policyJar
+	cache        CacheStore
+	safeString   bool
+	backpressure *backpressureTracker
+	codecs       codecRegistry
+	headers      http.Header // Default headers, see SetDefaultHeaders
+	queryParams  url.Values  // Default query parameters, see SetDefaultQueryParams
+
+	activeStreams atomic.Int64 // WebSocket streams not fully ended, see ActiveStreams
+	dial          *dialConfig  // Dialer set by WithIPFamily, WithDialFallbackDelay or WithTimeouts
 }
 
 // Requests is deprecated. Use Client instead.
@@ -34,25 +82,88 @@ type Client struct {
 type Requests = Client
 
 type requestConfig struct {
-	method              string
-	path                string
-	queryParams         url.Values
-	body                interface{}
-	headers             http.Header
-	auth                string
-	file                *multipart.FileHeader
-	formFieldName       string
-	formFields          map[string]string
-	insecureSkipVerify  bool
-	retryConfig         *RetryConfig
-	wsConfig            *WebSocketConfig
-	beforeRequestHooks  []RequestHook
-	afterResponseHooks  []ResponseHook
-	errorHooks          []ErrorHook
+	method               string
+	path                 string
+	query                []queryOp // Query options in the order given, see requestQuery
+	body                 interface{}
+	canonicalJSON        bool
+	bodyAs               string // WithBodyAs() content type, selecting the codec for body
+	formBody             url.Values
+	rawBody              *rawBody
+	gzipBody             bool
+	gzipLevel            int
+	pathParams           map[string]string
+	pathErr              error // Unresolved path placeholders, reported when the request is built
+	headers              http.Header
+	auth                 string
+	files                []filePart
+	formFields           map[string]string
+	host                 string
+	requestTimeout       time.Duration
+	proxy                *url.URL
+	proxyErr             error // Invalid WithProxy URL, reported when the request is built
+	insecureSkipVerify   bool
+	forceHTTP1           bool
+	cookieJar            http.CookieJar
+	cookies              []*http.Cookie
+	cookieJarSet         bool
+	retryConfig          *RetryConfig
+	wsConfig             *WebSocketConfig
+	wsSendContext        context.Context
+	wsHalfClose          bool
+	wsController         *WebSocketController
+	wsKeepReceive        bool
+	wsReceiveBuffer      int
+	wsStream             *wsStream
+	sseConfig            *SSEConfig
+	sheddable            bool
+	responseCallback     func(chunk []byte) error
+	responseTransforms   []ResponseTransform
+	requestTransforms    []func([]byte) ([]byte, error)
+	preserveAuth         bool
+	preserveAuthHosts    []string
+	pooledResponse       bool
+	headerFuncs          []headerFunc
+	apiKey               *APIKeyConfig
+	expectedResponseSize int64
+	streamBufferSize     int64
+	responseSchema       []byte
+	retryObserver        func(RetryInfo)
+	multipartBoundary    string
+	fallbackPrimary      string
+	fallbackMethod       string
+	inputWatches         []inputWatch
+	inputChecks          []inputCheck
+	wsGzip               bool
+	prepared             *http.Request
+	preparedBodyUsed     bool
+	returnRedirects      bool
+	deduplicated         bool
+	cached               bool
+	ipFamily             IPFamily // Of the connection that served the last attempt
+	requestInterceptors  []RequestInterceptor
+	beforeRequestHooks   []RequestHook
+	afterResponseHooks   []ResponseHook
+	errorHooks           []ErrorHook
 }
 
 type RequestOption func(*requestConfig)
 
+// newRequestConfig creates a fresh config for one call and applies opts to it.
+// Every call gets its own headers, query values and hook slices, so options that
+// append to them never share backing storage between concurrent requests.
+func newRequestConfig(method string, opts []RequestOption) *requestConfig {
+	config := &requestConfig{
+		method:  method,
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	config.path, config.pathErr = expandPathParams(config.path, config.pathParams)
+	return config
+}
+
 // NewClient creates a new HTTP client with the specified base URL and timeout.
 //
 // The baseURL should not include a trailing slash. All request paths will be
@@ -71,6 +182,36 @@ func NewClient(baseURL string, timeout time.Duration) *Client {
 	}
 }
 
+// NewClientWithTransport creates a client like NewClient that sends its requests
+// through transport, so several clients, e.g. one per tenant base URL, share one
+// connection pool and reuse connections to the same host. A nil transport is the
+// same as NewClient.
+//
+// The caller owns transport: the clients never close it, so call
+// transport.CloseIdleConnections() once no client uses it any more. Client
+// methods that configure the transport (WithTimeouts, WithMaxHeaderBytes,
+// WithProxyAuth, WithIPFamily, WithDialFallbackDelay, Warmup) change it for
+// every client sharing it, and
+// WithIdleConnProbe closes idle connections for all of them; configure the shared
+// transport directly instead.
+//
+// Example:
+//
+//	shared := http.DefaultTransport.(*http.Transport).Clone()
+//	shared.MaxIdleConnsPerHost = 32
+//	defer shared.CloseIdleConnections()
+//
+//	for _, tenant := range tenants {
+//		clients[tenant.ID] = reqws.NewClientWithTransport(tenant.BaseURL, 30*time.Second, shared)
+//	}
+func NewClientWithTransport(baseURL string, timeout time.Duration, transport *http.Transport) *Client {
+	c := NewClient(baseURL, timeout)
+	if transport != nil {
+		c.client.Transport = transport
+	}
+	return c
+}
+
 // NewRequests is deprecated. Use NewClient instead.
 // Kept for backward compatibility.
 func NewRequests(baseURL string, timeout time.Duration) *Client {
@@ -79,76 +220,75 @@ func NewRequests(baseURL string, timeout time.Duration) *Client {
 
 // buildAndExecuteRequest is a helper method that builds and executes an HTTP request.
 // It returns the raw http.Response which can be processed by the caller.
+//
+// It runs once per attempt. Headers are applied in a fixed order so retries stay
+// consistent: static headers (including the idempotency key), then Content-Type,
+// Authorization and the API key, then per-attempt header functions. Request interceptors may then
+// replace the request, and finally before-request hooks run, which therefore see
+// every header and the final URL when signing.
 func (c *Client) buildAndExecuteRequest(ctx context.Context, config *requestConfig) (*http.Response, error) {
-	// Build full URL with query parameters
-	fullURL, err := url.Parse(c.baseURL + config.path)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+	if err := config.verifyInputs(); err != nil {
+		return nil, err
+	}
+	if config.pathErr != nil {
+		return nil, config.pathErr
+	}
+	if config.proxyErr != nil {
+		return nil, config.proxyErr
+	}
+	if c.prober != nil {
+		c.prober.touch()
 	}
-	fullURL.RawQuery = config.queryParams.Encode()
 
+	// A successful response hands the cancel function to its body instead,
+	// since the deadline also covers reading it
+	cancel := func() {}
+	if config.requestTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, config.requestTimeout)
+	}
+	defer func() { cancel() }()
+
+	var req *http.Request
 	var reqBody io.Reader
 	var contentType string
-
-	// Handle file upload with multipart form data
-	if config.file != nil {
-		bodyBuffer := &bytes.Buffer{}
-		writer := multipart.NewWriter(bodyBuffer)
-
-		// Add form fields
-		for k, v := range config.formFields {
-			if err := writer.WriteField(k, v); err != nil {
-				return nil, fmt.Errorf("failed to write form field: %w", err)
+	var err error
+	if config.prepared != nil {
+		req, reqBody, err = config.preparedRequest(ctx)
+	} else {
+		req, reqBody, contentType, err = c.newRequest(ctx, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The transport closes a streamed multipart body; close it here if the
+	// attempt ends before the request is sent, which stops its writer
+	sent := false
+	if body, ok := reqBody.(*io.PipeReader); ok {
+		defer func() {
+			if !sent {
+				body.Close()
 			}
-		}
-
-		// Add file
-		sanitizedFilename := strings.ReplaceAll(config.file.Filename, " ", "_")
-		part, err := writer.CreateFormFile(config.formFieldName, sanitizedFilename)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create form file: %w", err)
-		}
-
-		file, err := config.file.Open()
-		if err != nil {
-			return nil, fmt.Errorf("failed to open file: %w", err)
-		}
-		defer file.Close()
-
-		if _, err = io.Copy(part, file); err != nil {
-			return nil, fmt.Errorf("failed to copy file to buffer: %w", err)
-		}
-		writer.Close()
-
-		reqBody = bodyBuffer
-		contentType = writer.FormDataContentType()
-	} else if config.body != nil {
-		// Handle JSON body
-		jsonBody, err := json.Marshal(config.body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal JSON body: %w", err)
-		}
-		reqBody = bytes.NewBuffer(jsonBody)
-		contentType = "application/json"
+		}()
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, config.method, fullURL.String(), reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	c.setRequestHeaders(req, config, contentType)
+	for _, hf := range config.headerFuncs {
+		req.Header.Set(hf.key, hf.fn())
 	}
 
-	// Set headers
-	for key, values := range config.headers {
-		for _, value := range values {
-			req.Header.Add(key, value)
+	// Execute request interceptors
+	for _, interceptor := range config.requestInterceptors {
+		replaced, err := interceptor(req)
+		if err != nil {
+			// Call error hooks
+			for _, errHook := range config.errorHooks {
+				errHook(req, err)
+			}
+			return nil, fmt.Errorf("request interceptor failed: %w", &hookError{err: err})
+		}
+		if replaced != nil {
+			req = replaced
 		}
-	}
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
-	}
-	if config.auth != "" {
-		req.Header.Set("Authorization", config.auth)
 	}
 
 	// Execute before-request hooks
@@ -158,24 +298,137 @@ func (c *Client) buildAndExecuteRequest(ctx context.Context, config *requestConf
 			for _, errHook := range config.errorHooks {
 				errHook(req, err)
 			}
-			return nil, fmt.Errorf("before-request hook failed: %w", err)
+			return nil, fmt.Errorf("before-request hook failed: %w", &hookError{err: err})
 		}
 	}
 
 	// Log request if logger is available
 	if c.logger != nil {
-		c.logger.Debug("requesting to API", "method", config.method, "url", fullURL.String())
+		c.logger.Debug("requesting to API", "method", req.Method, "url", req.URL.String())
+	}
+
+	// Re-add Authorization on redirects only for this request
+	httpClient := c.client
+	if config.preserveAuth {
+		redirectClient := *c.client
+		redirectClient.CheckRedirect = preserveAuthOnRedirect(config.preserveAuthHosts)
+		httpClient = &redirectClient
+	}
+	// AsRoundTripper leaves redirects to the http.Client using it
+	if config.returnRedirects {
+		redirectClient := *httpClient
+		redirectClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		httpClient = &redirectClient
+	}
+	httpClient = c.requestClient(httpClient, config)
+	if config.cookieJarSet {
+		jarClient := *httpClient
+		jarClient.Jar = config.cookieJar
+		httpClient = &jarClient
+	}
+
+	if c.tapping() {
+		c.tap(TapEvent{
+			Type:        TapRequest,
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			Attempt:     RetryAttempt(ctx),
+			BodySize:    req.ContentLength,
+			BodyPreview: c.tapPreview(reqBody, req.Header.Get("Content-Type")),
+		})
+	}
+
+	// Take a host slot; retries queue behind first attempts
+	releaseHost := func() {}
+	if c.hostLimit != nil {
+		host := req.URL.Host
+		if err := c.hostLimit.acquire(ctx, host, config.isLowPriorityRetry(RetryAttempt(ctx)), config.sheddable); err != nil {
+			return nil, err
+		}
+		releaseHost = func() { c.hostLimit.release(host) }
 	}
 
+	// Reserve the attempt's memory footprint
+	var reserved int64
+	if c.budget != nil {
+		if reserved, err = c.budget.reserveRequest(ctx, req, config); err != nil {
+			releaseHost()
+			if c.logger != nil {
+				c.logger.Debug("request rejected by memory budget", "method", req.Method, "url", req.URL.String(), "error", err)
+			}
+			return nil, err
+		}
+	}
+
+	// Record the address family of the connection that serves the attempt
+	var family IPFamily
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			family = addrFamily(info.Conn.RemoteAddr())
+		},
+	}))
+
 	// Execute request
-	resp, err := c.client.Do(req)
+	start := time.Now()
+	var resp *http.Response
+	sent = true
+	if c.proxyAuth != nil {
+		resp, err = c.doWithProxyAuth(httpClient, req)
+	} else {
+		resp, err = httpClient.Do(req)
+	}
+	if c.shedder != nil {
+		c.shedder.observe(time.Since(start))
+	}
+	if c.hostLimit != nil {
+		failed := (err != nil && ctx.Err() == nil) || (err == nil && (resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests))
+		c.hostLimit.observe(req.URL.Host, time.Since(start), failed)
+	}
+	if c.backpressure != nil && err == nil {
+		c.backpressure.observe(req.URL.Host, resp)
+	}
+	if c.tapping() {
+		event := TapEvent{
+			Type:     TapResponse,
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			Attempt:  RetryAttempt(ctx),
+			Duration: time.Since(start),
+		}
+		if err != nil {
+			event.Type = TapError
+			event.Error = err.Error()
+		} else {
+			event.StatusCode = resp.StatusCode
+			event.IPFamily = family
+			if resp.ContentLength > 0 {
+				event.BodySize = resp.ContentLength
+			}
+		}
+		c.tap(event)
+	}
 	if err != nil {
+		if c.budget != nil {
+			c.budget.release(reserved)
+		}
+		releaseHost()
+		err = c.headerLimitError(classifyConnectionError(err))
 		// Call error hooks
 		for _, errHook := range config.errorHooks {
 			errHook(req, err)
 		}
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	config.ipFamily = family
+	if c.budget != nil {
+		if resp, err = c.budget.trackResponse(ctx, req, resp, config, reserved); err != nil {
+			releaseHost()
+			return nil, err
+		}
+	}
+	if c.hostLimit != nil {
+		resp.Body = &hostSlotBody{ReadCloser: resp.Body, release: releaseHost}
+	}
 
 	// Execute after-response hooks
 	for _, hook := range config.afterResponseHooks {
@@ -185,13 +438,258 @@ func (c *Client) buildAndExecuteRequest(ctx context.Context, config *requestConf
 				errHook(req, err)
 			}
 			resp.Body.Close()
-			return nil, fmt.Errorf("after-response hook failed: %w", err)
+			return nil, fmt.Errorf("after-response hook failed: %w", &hookError{err: err})
 		}
 	}
 
+	if config.requestTimeout > 0 {
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		cancel = func() {}
+	}
 	return resp, nil
 }
 
+// cancelBody releases the attempt's timeout context when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// preparedRequest returns the caller's request from DoRequest for one attempt.
+// The first attempt sends the original body; later attempts get a fresh one from GetBody.
+func (config *requestConfig) preparedRequest(ctx context.Context) (*http.Request, io.Reader, error) {
+	req := config.prepared.Clone(ctx)
+	req.Method = config.method
+	if req.Body != nil && req.Body != http.NoBody {
+		if config.preparedBodyUsed {
+			if req.GetBody == nil {
+				return nil, nil, errors.New("request body cannot be re-read: req.GetBody is nil")
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to re-read request body: %w", err)
+			}
+			req.Body = body
+		}
+		config.preparedBodyUsed = true
+	}
+	return req, req.Body, nil
+}
+
+// newRequest builds the request for one attempt from the options: URL, query and
+// JSON or multipart body. It returns the body reader and the Content-Type to set.
+func (c *Client) newRequest(ctx context.Context, config *requestConfig) (*http.Request, io.Reader, string, error) {
+	// Build full URL with query parameters
+	fullURL, err := url.Parse(c.baseURL + config.path)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("invalid URL: %w", err)
+	}
+	query, err := c.buildQuery(config)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	fullURL.RawQuery = query.Encode()
+
+	var reqBody io.Reader
+	var contentType string
+
+	// Handle file upload with multipart form data
+	var multipartSize int64
+	var boundary string
+	if config.isMultipart() {
+		writer := multipart.NewWriter(io.Discard)
+		if config.multipartBoundary != "" {
+			// SetBoundary enforces the RFC 2046 length and character rules
+			if err := writer.SetBoundary(config.multipartBoundary); err != nil {
+				return nil, nil, "", fmt.Errorf("invalid multipart boundary: %w", err)
+			}
+		}
+		boundary = writer.Boundary()
+
+		// Stream form fields, then files in the order they were added
+		body, size, err := config.multipartBody(boundary)
+		if err != nil {
+			// The request is not built yet; error hooks get it without headers or body
+			if hookReq, reqErr := http.NewRequestWithContext(ctx, config.method, fullURL.String(), nil); reqErr == nil {
+				for _, errHook := range config.errorHooks {
+					errHook(hookReq, err)
+				}
+			}
+			return nil, nil, "", err
+		}
+		reqBody, multipartSize = body, size
+		contentType = multipartContentType(config.headers.Get("Content-Type"), writer)
+	} else if config.rawBody != nil {
+		// Handle pre-encoded body, sent as is
+		if reqBody, err = config.rawBody.reader(); err != nil {
+			return nil, nil, "", err
+		}
+		contentType = config.rawBody.contentType
+		if contentType == "" {
+			contentType = config.headers.Get("Content-Type")
+		}
+		if contentType == "" {
+			contentType = config.rawBody.defaultType
+		}
+	} else if config.formBody != nil {
+		// Handle URL-encoded form body
+		reqBody = strings.NewReader(config.formBody.Encode())
+		contentType = config.headers.Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+	} else if config.body != nil && config.bodyAs != "" {
+		// Handle body encoded by a registered codec
+		data, bodyType, err := c.marshalBodyAs(config)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		reqBody = bytes.NewBuffer(data)
+		contentType = bodyType
+	} else if config.body != nil && config.canonicalJSON {
+		// Handle canonical JSON body, byte-identical for logically equal values
+		jsonBody, err := canonicalJSON(c.jsonBodyValue(config.body))
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to marshal JSON body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+		contentType = c.jsonContentType(config)
+	} else if raw, ok := verbatimJSON(config.body, config.headers.Get("Content-Type")); ok {
+		// Handle already encoded JSON body, sent byte for byte
+		if !json.Valid(raw) {
+			return nil, nil, "", errors.New("failed to marshal JSON body: raw JSON body is not valid JSON")
+		}
+		reqBody = bytes.NewBuffer(raw)
+		contentType = c.jsonContentType(config)
+	} else if config.body != nil && c.buffers != nil {
+		// Handle JSON body in a pooled buffer
+		pooledBody, err := c.buffers.marshalJSON(c.jsonBodyValue(config.body))
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to marshal JSON body: %w", err)
+		}
+		reqBody = pooledBody
+		contentType = c.jsonContentType(config)
+	} else if config.body != nil {
+		// Handle JSON body
+		jsonBody, err := json.Marshal(c.jsonBodyValue(config.body))
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to marshal JSON body: %w", err)
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+		contentType = c.jsonContentType(config)
+	}
+
+	// Transform the encoded body, e.g. to encrypt it
+	transformed := false
+	if len(config.requestTransforms) > 0 && reqBody != nil {
+		data, err := transformRequestBody(reqBody, config.requestTransforms)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		reqBody, transformed = bytes.NewBuffer(data), true
+	}
+
+	// Compress the body for this attempt
+	gzipped := false
+	if config.gzipBody && reqBody != nil {
+		compressed, ok, err := gzipBody(reqBody, config.gzipLevel)
+		if err != nil {
+			if body, isPipe := reqBody.(*io.PipeReader); isPipe {
+				body.Close()
+			}
+			return nil, nil, "", err
+		}
+		reqBody, gzipped = compressed, ok
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, config.method, fullURL.String(), reqBody)
+	if err != nil {
+		if body, ok := reqBody.(*io.PipeReader); ok {
+			body.Close()
+		}
+		return nil, nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if pooledBody, ok := reqBody.(*pooledBody); ok {
+		req.ContentLength = int64(pooledBody.Len())
+	}
+	if boundary != "" && !transformed {
+		// Unknown file sizes and compressed bodies are sent chunked
+		if !gzipped {
+			req.ContentLength = max(multipartSize, 0)
+		}
+		if config.bodyRewindable() {
+			// Redirects and proxy authentication replay the body from the files
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, _, err := config.multipartBody(boundary)
+				if err != nil || !gzipped {
+					return body, err
+				}
+				compressed, err := gzipStream(body, config.gzipLevel)
+				if err != nil {
+					body.Close()
+					return nil, err
+				}
+				return compressed, nil
+			}
+		}
+	}
+	if gzipped {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	return req, reqBody, contentType, nil
+}
+
+// setRequestHeaders sets the headers of req from the request's options, the
+// client's default headers and dialect, cookies, credentials and the API key, which
+// may add a query parameter instead. Headers from WithHeaderFunc() are computed per
+// attempt and not set here.
+func (c *Client) setRequestHeaders(req *http.Request, config *requestConfig, contentType string) {
+	// Keep the casing of WithHeaderExactCase() keys
+	for key, values := range config.headers {
+		req.Header[key] = append(req.Header[key], values...)
+	}
+	c.setDefaultHeaders(req.Header, config)
+	if c.dialect.Accept != "" && req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", c.dialect.Accept)
+	}
+	for _, cookie := range config.cookies {
+		req.AddCookie(cookie)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if config.auth != "" {
+		req.Header.Set("Authorization", config.auth)
+	}
+	req.URL.RawQuery = c.applyAPIKey(config, req.Header, req.URL.RawQuery)
+	if config.host != "" {
+		req.Host = config.host
+	}
+}
+
+// multipartContentType returns the Content-Type of a multipart body: the one set
+// with WithHeader(), given the writer's boundary if it has none, or
+// multipart/form-data with the boundary.
+func multipartContentType(userType string, writer *multipart.Writer) string {
+	if userType == "" {
+		return writer.FormDataContentType()
+	}
+	mediaType, params, err := mime.ParseMediaType(userType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] != "" {
+		return userType
+	}
+	params["boundary"] = writer.Boundary()
+	return mime.FormatMediaType(mediaType, params)
+}
+
 // Request executes an HTTP request and returns only the response body as bytes.
 // This is the simple method for most use cases - it automatically fails on non-2xx status codes.
 //
@@ -205,32 +703,27 @@ func (c *Client) buildAndExecuteRequest(ctx context.Context, config *requestConf
 //		reqws.WithBearerToken("token"),
 //	)
 func (c *Client) Request(ctx context.Context, opts ...RequestOption) ([]byte, error) {
-	config := &requestConfig{
-		method:      http.MethodGet,
-		queryParams: url.Values{},
-		headers:     http.Header{},
-	}
-
-	for _, opt := range opts {
-		opt(config)
-	}
+	config := newRequestConfig(http.MethodGet, opts)
 
-	resp, err := c.executeWithRetry(ctx, config)
+	resp, err := c.executeCached(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return respBody, NewHTTPError(resp.StatusCode, respBody)
+		return respBody, c.decodeHTTPError(resp.StatusCode, respBody)
+	}
+	if err := c.validateResponseSchema(config, resp.StatusCode, respBody); err != nil {
+		return nil, err
 	}
 
-	return respBody, nil
+	return c.applyResponseTransforms(respBody, config)
 }
 
 // HTTP Method Shortcuts
@@ -334,7 +827,7 @@ func HEAD(path string) RequestOption {
 }
 
 // OPTIONS creates an OPTIONS request to the specified path.
-// Useful for CORS preflight requests.
+// Useful for CORS preflight requests; see Client.Preflight() to send and check one.
 //
 // Example:
 //
@@ -385,6 +878,77 @@ func WithPath(path string) RequestOption {
 	}
 }
 
+// WithPathParam replaces the placeholder {key} in the request path with value,
+// escaped with url.PathEscape, so values containing slashes or spaces stay one
+// path segment. Call it once per placeholder; it works with every method shortcut.
+// The request fails before it is sent if a placeholder is left without a value or
+// a parameter matches no placeholder, with an error listing the names.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/users/{id}/posts/{postId}"),
+//		reqws.WithPathParam("id", "42"),
+//		reqws.WithPathParam("postId", "hello world"),
+//	)
+//	// GET /users/42/posts/hello%20world
+func WithPathParam(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		if c.pathParams == nil {
+			c.pathParams = make(map[string]string)
+		}
+		c.pathParams[key] = value
+	}
+}
+
+// expandPathParams replaces {name} placeholders in path with their escaped values.
+// The error lists placeholders without a value.
+// Parameters without a placeholder and a { without a closing } are an error too.
+func expandPathParams(path string, params map[string]string) (string, error) {
+	if len(params) == 0 && !strings.Contains(path, "{") {
+		return path, nil
+	}
+	var expanded strings.Builder
+	var missing []string
+	used := make(map[string]bool, len(params))
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			break
+		}
+		length := strings.IndexByte(path[start:], '}')
+		if length < 0 {
+			return expanded.String() + path, fmt.Errorf("unterminated path placeholder: %s", path[start:])
+		}
+		end := start + length
+		expanded.WriteString(path[:start])
+		name := path[start+1 : end]
+		if value, ok := params[name]; ok {
+			expanded.WriteString(url.PathEscape(value))
+			used[name] = true
+		} else {
+			expanded.WriteString(path[start : end+1])
+			missing = append(missing, path[start:end+1])
+		}
+		path = path[end+1:]
+	}
+	expanded.WriteString(path)
+	if len(missing) > 0 {
+		return expanded.String(), fmt.Errorf("unresolved path parameters: %s", strings.Join(missing, ", "))
+	}
+	var unused []string
+	for name := range params {
+		if !used[name] {
+			unused = append(unused, "{"+name+"}")
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return expanded.String(), fmt.Errorf("path parameters without a placeholder: %s", strings.Join(unused, ", "))
+	}
+	return expanded.String(), nil
+}
+
 // WithQueryParam adds a single query parameter to the request URL.
 // Can be called multiple times to add multiple parameters.
 //
@@ -397,12 +961,17 @@ func WithPath(path string) RequestOption {
 //	)
 func WithQueryParam(key, value string) RequestOption {
 	return func(c *requestConfig) {
-		c.queryParams.Add(key, value)
+		c.addQuery(func(_ *Client, query url.Values) error {
+			query.Add(key, value)
+			return nil
+		})
 	}
 }
 
 // WithBody sets the request body.
 // The body will be automatically marshaled to JSON.
+// A json.RawMessage, or a []byte with a JSON Content-Type header, is already
+// encoded and is sent byte for byte.
 //
 // For more explicit JSON handling, consider using WithJSON() instead.
 //
@@ -412,6 +981,11 @@ func WithQueryParam(key, value string) RequestOption {
 func WithBody(body interface{}) RequestOption {
 	return func(c *requestConfig) {
 		c.body = body
+		c.bodyAs = ""
+		c.canonicalJSON = false
+		c.formBody = nil
+		c.rawBody = nil
+		c.watchBody(body)
 	}
 }
 
@@ -432,6 +1006,59 @@ func WithJSON(body interface{}) RequestOption {
 	return WithBody(body)
 }
 
+// WithCanonicalJSON sets the request body as canonical JSON: object keys sorted
+// at every depth (struct fields included), numbers in one normalized form and no
+// HTML escaping, so logically equal bodies are sent as identical bytes. Use it when
+// an upstream signs or deduplicates the exact payload, e.g. HMAC signatures
+// computed in a before-request hook, which can read the bytes with req.GetBody().
+//
+// The body is marshaled, decoded and re-encoded, which costs about five times the
+// CPU and several times the allocations of WithJSON() for a typical small object;
+// keep WithJSON() for everything else.
+// Integers are kept digit for digit; other numbers are re-encoded from float64.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/transfers"),
+//		reqws.WithCanonicalJSON(map[string]interface{}{"amount": 100, "to": "acct_1"}),
+//		reqws.WithBeforeRequest(signHMAC),
+//	)
+func WithCanonicalJSON(body interface{}) RequestOption {
+	return func(c *requestConfig) {
+		c.body = body
+		c.bodyAs = ""
+		c.canonicalJSON = true
+		c.formBody = nil
+		c.rawBody = nil
+		c.watchBody(body)
+	}
+}
+
+// WithXML sets the request body as XML, marshaled with encoding/xml (values
+// implementing xml.Marshaler encode themselves), with Content-Type
+// application/xml unless a Content-Type header is set. The body is marshaled when
+// the option is applied; a marshaling error fails the request. It replaces a JSON,
+// form or raw body set before.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/orders"),
+//		reqws.WithXML(Order{ID: 42, Items: items}),
+//	)
+func WithXML(body interface{}) RequestOption {
+	return func(c *requestConfig) {
+		c.body = nil
+		c.formBody = nil
+		data, err := xml.Marshal(body)
+		if err != nil {
+			err = fmt.Errorf("failed to marshal XML body: %w", err)
+		}
+		c.rawBody = &rawBody{data: data, err: err, defaultType: "application/xml"}
+	}
+}
+
 // WithHeader adds a custom HTTP header to the request.
 // Can be called multiple times to add multiple headers.
 //
@@ -448,6 +1075,25 @@ func WithHeader(key, value string) RequestOption {
 	}
 }
 
+// WithPrefer sets the RFC 7240 Prefer header, used by APIs such as OData and FHIR
+// to shape the response. Multiple preferences are joined with ", ".
+// Check Response.PreferenceApplied() to see which preferences the server honored.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/Patient"),
+//		reqws.WithJSON(patient),
+//		reqws.WithPrefer("return=minimal"),
+//	)
+func WithPrefer(preferences ...string) RequestOption {
+	return func(c *requestConfig) {
+		if len(preferences) > 0 {
+			c.headers.Set("Prefer", strings.Join(preferences, ", "))
+		}
+	}
+}
+
 // WithAuth sets the Authorization header with the provided token.
 // The token should include the auth scheme (e.g., "Bearer xxx").
 //
@@ -496,7 +1142,10 @@ func WithBasicAuth(username, password string) RequestOption {
 }
 
 // WithForm adds a form field for multipart/form-data requests.
-// Use this together with WithFile() for file uploads.
+// Use this together with WithFile() for file uploads. Without files the fields
+// are still sent as multipart/form-data, unless the request has another body
+// (e.g. WithJSON()), which then wins. For application/x-www-form-urlencoded use
+// WithFormBody() instead.
 //
 // Example:
 //
@@ -515,23 +1164,286 @@ func WithForm(key, value string) RequestOption {
 	}
 }
 
-// WithFile adds a file to the request for multipart/form-data upload.
-// The formFieldName is the name of the form field (defaults to "file" if empty).
+// WithFormURLEncoded sets the request body to values encoded as
+// application/x-www-form-urlencoded, e.g. for OAuth token endpoints. It replaces
+// any body set before with WithJSON() or WithBody(), and a later one replaces it.
+// The body is encoded again on every retry attempt.
+//
+// For multipart/form-data, use WithForm() with WithFile() instead; a file upload
+// takes precedence over this body.
 //
 // Example:
 //
-//	client.Do(ctx,
-//		reqws.POST("/upload"),
-//		reqws.WithFile("avatar", fileHeader),
+//	client.Request(ctx,
+//		reqws.POST("/oauth/token"),
+//		reqws.WithFormURLEncoded(url.Values{
+//			"grant_type": {"client_credentials"},
+//			"scope":      {"read write"},
+//		}),
+//	)
+func WithFormURLEncoded(values url.Values) RequestOption {
+	return func(c *requestConfig) {
+		c.body = nil
+		c.rawBody = nil
+		c.formBody = make(url.Values, len(values))
+		for key, vals := range values {
+			c.formBody[key] = append([]string(nil), vals...)
+		}
+	}
+}
+
+// WithFormBody sets the request body to params encoded as
+// application/x-www-form-urlencoded.
+// This is an alias for WithFormURLEncoded(), named after WithBody().
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/oauth/token"),
+//		reqws.WithFormBody(url.Values{"grant_type": {"client_credentials"}}),
+//	)
+func WithFormBody(params url.Values) RequestOption {
+	return WithFormURLEncoded(params)
+}
+
+// WithFormField adds a field to an application/x-www-form-urlencoded body,
+// keeping fields added before, including by WithFormURLEncoded(). Like
+// WithFormURLEncoded(), it replaces a JSON body.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/login.php"),
+//		reqws.WithFormField("user", "alice"),
+//		reqws.WithFormField("remember", "1"),
+//	)
+func WithFormField(key, value string) RequestOption {
+	return func(c *requestConfig) {
+		c.body = nil
+		c.rawBody = nil
+		if c.formBody == nil {
+			c.formBody = url.Values{}
+		}
+		c.formBody.Add(key, value)
+	}
+}
+
+// WithRawBody sets a pre-encoded request body, sent as is with the given
+// Content-Type (default: the Content-Type header, or application/octet-stream).
+// Use it for protobuf, MessagePack, encrypted or already serialized payloads.
+// WithRawBody(), WithJSON(), WithXML() and the form options replace each other;
+// the last one applied wins.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.POST("/events"),
+//		reqws.WithRawBody(payload, "application/x-protobuf"),
+//	)
+func WithRawBody(data []byte, contentType string) RequestOption {
+	return func(c *requestConfig) {
+		c.body = nil
+		c.formBody = nil
+		c.rawBody = &rawBody{data: data, contentType: contentType, defaultType: "application/octet-stream"}
+	}
+}
+
+// WithBodyReader streams the request body from r with the given Content-Type,
+// like WithRawBody() without buffering it. The caller closes r, if needed, after
+// the request returns.
+//
+// A retry must send the body again: if r implements io.Seeker (e.g. *os.File or
+// *bytes.Reader) it is rewound to where the first attempt started reading.
+// Otherwise the request is not retried: a failed attempt is returned as is, and a
+// transport error is wrapped with ErrBodyNotRewindable.
+//
+// Example:
+//
+//	f, _ := os.Open("export.csv")
+//	defer f.Close()
+//	client.Request(ctx,
+//		reqws.PUT("/imports/42"),
+//		reqws.WithBodyReader(f, "text/csv"),
+//		reqws.WithDefaultRetry(),
+//	)
+func WithBodyReader(r io.Reader, contentType string) RequestOption {
+	return func(c *requestConfig) {
+		c.body = nil
+		c.formBody = nil
+		c.rawBody = &rawBody{stream: r, contentType: contentType, defaultType: "application/octet-stream"}
+	}
+}
+
+// rawBody is a request body set with WithRawBody(), WithBodyReader() or WithXML().
+type rawBody struct {
+	data        []byte
+	stream      io.Reader
+	err         error  // Encoding error, returned when the request is built
+	contentType string // Content-Type, overriding the header
+	defaultType string // Content-Type if neither contentType nor the header is set
+	start       int64  // Offset of a seekable stream when first read
+	used        bool
+}
+
+// reader returns the body for an attempt, rewinding the stream if an earlier
+// attempt read it.
+func (b *rawBody) reader() (io.Reader, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.stream == nil {
+		return bytes.NewReader(b.data), nil
+	}
+	seeker, seekable := b.stream.(io.Seeker)
+	if !b.used {
+		b.used = true
+		if seekable {
+			start, err := seeker.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read request body offset: %w", err)
+			}
+			b.start = start
+		}
+		return b.stream, nil
+	}
+	if !seekable {
+		return nil, ErrBodyNotRewindable
+	}
+	if _, err := seeker.Seek(b.start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind request body: %w", err)
+	}
+	return b.stream, nil
+}
+
+// rewindable reports whether the body can be sent again.
+func (b *rawBody) rewindable() bool {
+	if b == nil || b.stream == nil {
+		return true
+	}
+	_, ok := b.stream.(io.Seeker)
+	return ok
+}
+
+// WithFile adds a file to the request for multipart/form-data upload.
+// The formFieldName is the name of the form field (defaults to "file" if empty).
+// Calling it again adds another file; the same field name may be used more than once.
+// Form fields from WithForm() are written first, sorted by name, followed by the
+// files in the order they were added.
+// A Content-Type set with WithHeader() is kept; if it is a multipart type without
+// a boundary parameter, the body's boundary is added to it.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/contracts"),
+//		reqws.WithFile("document", documentHeader),
+//		reqws.WithFile("signature", signatureHeader),
 //	)
 func WithFile(formFieldName string, file *multipart.FileHeader) RequestOption {
+	return WithFiles(formFieldName, file)
+}
+
+// WithFiles adds several files under the same form field for multipart/form-data
+// upload, e.g. for servers that accept repeated "attachments" fields. The
+// formFieldName defaults to "file" if empty. Nil files are skipped.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/messages"),
+//		reqws.WithFiles("attachments", form.File["attachments"]...),
+//	)
+func WithFiles(formFieldName string, files ...*multipart.FileHeader) RequestOption {
 	return func(c *requestConfig) {
-		c.file = file
 		if formFieldName == "" {
-			c.formFieldName = "file"
-		} else {
-			c.formFieldName = formFieldName
+			formFieldName = "file"
+		}
+		for _, file := range files {
+			if file != nil {
+				c.files = append(c.files, filePart{field: formFieldName, filename: file.Filename, header: file})
+			}
+		}
+	}
+}
+
+// WithFileFromPath adds the file at path to the request for multipart/form-data
+// upload, named after its base name. The file is opened when the request is sent,
+// and again for each retry; if it cannot be opened the request fails and the error
+// hooks are called. The formFieldName defaults to "file" if empty.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/backups"),
+//		reqws.WithFileFromPath("archive", "/var/backups/db.tar.gz"),
+//	)
+func WithFileFromPath(formFieldName, path string) RequestOption {
+	return func(c *requestConfig) {
+		if formFieldName == "" {
+			formFieldName = "file"
 		}
+		c.files = append(c.files, filePart{field: formFieldName, filename: filepath.Base(path), path: path})
+	}
+}
+
+// WithFileReader adds content read from r to the request for multipart/form-data
+// upload as a file named filename. The formFieldName defaults to "file" if empty.
+// Retries rewind r if it is an io.Seeker (e.g. *bytes.Reader or *os.File);
+// otherwise the request is sent once and a failure that would be retried is
+// returned wrapped in ErrBodyNotRewindable.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/reports"),
+//		reqws.WithFileReader("report", "report.csv", bytes.NewReader(csvData)),
+//	)
+func WithFileReader(formFieldName, filename string, r io.Reader) RequestOption {
+	return func(c *requestConfig) {
+		if formFieldName == "" {
+			formFieldName = "file"
+		}
+		c.files = append(c.files, filePart{field: formFieldName, filename: filename, stream: &rawBody{stream: r}})
+	}
+}
+
+// filePart is a file to upload under a multipart form field. Its content comes
+// from header, stream or, if both are nil, the file at path.
+type filePart struct {
+	field    string
+	filename string
+	header   *multipart.FileHeader
+	stream   *rawBody
+	path     string
+}
+
+// bodyRewindable reports whether the request body can be sent again.
+func (config *requestConfig) bodyRewindable() bool {
+	for _, f := range config.files {
+		if !f.stream.rewindable() {
+			return false
+		}
+	}
+	return config.rawBody.rewindable()
+}
+
+// WithMultipartBoundary sets a fixed boundary for multipart/form-data requests
+// instead of a random one. Useful for reproducible requests and servers that
+// expect a pre-agreed boundary.
+//
+// The boundary must be 1-70 characters from the RFC 2046 set (letters, digits and
+// '()+_,-./:=? ) and must not end with a space; otherwise the request fails.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/upload"),
+//		reqws.WithFile("file", fileHeader),
+//		reqws.WithMultipartBoundary("reqws-fixed-boundary"),
+//	)
+func WithMultipartBoundary(boundary string) RequestOption {
+	return func(c *requestConfig) {
+		c.multipartBoundary = boundary
 	}
 }
 
@@ -546,26 +1458,230 @@ func WithFile(formFieldName string, file *multipart.FileHeader) RequestOption {
 //	client.Request(ctx, reqws.GET("/users"), reqws.WithQueryParams(params))
 func WithQueryParams(params url.Values) RequestOption {
 	return func(cfg *requestConfig) {
-		if cfg.queryParams == nil {
-			cfg.queryParams = url.Values{}
-		}
-		for key, values := range params {
-			for _, v := range values {
-				cfg.queryParams.Add(key, v)
+		added := cloneValues(params)
+		cfg.addQuery(func(_ *Client, query url.Values) error {
+			for key, values := range added {
+				query[key] = append(query[key], values...)
 			}
-		}
+			return nil
+		})
+		cfg.watchQueryParams(params)
 	}
 }
 
-// WithInsecureSkipVerify disables TLS certificate verification.
+// WithQueryParamEncoded adds a query parameter whose value is produced by encoder,
+// for APIs that expect a complex value in a single parameter, e.g. ?filter={"status":"active"}.
+// The encoder runs when the request is built; if it fails, the request fails
+// without being sent. The result is URL-encoded like any other parameter.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/orders"),
+//		reqws.WithQueryParamEncoded("filter", map[string]string{"status": "active"}, reqws.JSONQueryEncoder),
+//	)
+//
+//	// Custom delimited value: ?ids=1|2|3
+//	reqws.WithQueryParamEncoded("ids", ids, func(v interface{}) (string, error) {
+//		parts := make([]string, 0, len(ids))
+//		for _, id := range v.([]int) {
+//			parts = append(parts, strconv.Itoa(id))
+//		}
+//		return strings.Join(parts, "|"), nil
+//	})
+func WithQueryParamEncoded(key string, value interface{}, encoder func(interface{}) (string, error)) RequestOption {
+	return func(c *requestConfig) {
+		c.addQuery(func(_ *Client, query url.Values) error {
+			encoded, err := encoder(value)
+			if err != nil {
+				return fmt.Errorf("failed to encode query parameter %q: %w", key, err)
+			}
+			query.Add(key, encoded)
+			return nil
+		})
+	}
+}
+
+// JSONQueryEncoder encodes a query parameter value as compact JSON.
+// Use it with WithQueryParamEncoded().
+func JSONQueryEncoder(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WithHost sets the Host header independently of the URL, e.g. to reach one
+// virtual host through a load balancer IP or to test a specific backend behind a
+// shared address. The connection still goes to the URL's host. Also applies to
+// WebSocket dials.
+//
+// Only the Host header changes: TLS SNI and certificate verification still use
+// the URL's host, so an https URL with an IP address needs a certificate valid
+// for that IP. Use a host name in the URL that resolves to the backend (or a
+// custom transport with tls.Config.ServerName) to test SNI routing.
+//
+// Example:
+//
+//	client := reqws.NewClient("http://10.0.3.17:8080", 10*time.Second)
+//	body, err := client.Request(ctx,
+//		reqws.GET("/healthz"),
+//		reqws.WithHost("api.example.com"),
+//	)
+func WithHost(host string) RequestOption {
+	return func(c *requestConfig) {
+		c.host = host
+	}
+}
+
+// WithRequestTimeout sets a deadline for each attempt of this request, covering
+// the response and reading its body, e.g. for latency-sensitive health probes.
+// It can only tighten limits: a shorter deadline on ctx or the client timeout
+// from NewClient still applies. Each retry attempt gets a fresh timeout.
+//
+// Example:
+//
+//	body, err := client.Request(ctx,
+//		reqws.GET("/healthz"),
+//		reqws.WithRequestTimeout(500*time.Millisecond),
+//	)
+func WithRequestTimeout(d time.Duration) RequestOption {
+	return func(c *requestConfig) {
+		c.requestTimeout = d
+	}
+}
+
+// WithInsecureSkipVerify disables TLS certificate verification for this request,
+// both for HTTP requests and WebSocket dials.
 // WARNING: This should only be used for testing or development.
 // Using this in production makes your application vulnerable to man-in-the-middle attacks.
+//
+// HTTP requests with this option share a transport cloned from the client's
+// transport on first use, so configure the client before sending them. If the
+// client's transport is not an *http.Transport, a clone of http.DefaultTransport
+// is used.
 func WithInsecureSkipVerify() RequestOption {
 	return func(c *requestConfig) {
 		c.insecureSkipVerify = true
 	}
 }
 
+// WithResponseCallback sets a callback that receives the response body in chunks as it is read.
+// The body is still buffered and returned as usual, so this works with both Request() and Do().
+// If the callback returns an error, reading stops and the request fails with that error.
+//
+// The chunk slice is reused between calls; copy it if you need to keep it.
+//
+// Example:
+//
+//	var received int
+//	resp, err := client.Do(ctx,
+//		reqws.GET("/export"),
+//		reqws.WithResponseCallback(func(chunk []byte) error {
+//			received += len(chunk)
+//			fmt.Printf("downloaded %d bytes\n", received)
+//			return nil
+//		}),
+//	)
+func WithResponseCallback(fn func(chunk []byte) error) RequestOption {
+	return func(c *requestConfig) {
+		c.responseCallback = fn
+	}
+}
+
+// readResponseBody reads the full response body, passing each chunk to the
+// response callback if one is configured.
+func readResponseBody(resp *http.Response, config *requestConfig) ([]byte, error) {
+	if config.responseCallback == nil {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		return respBody, nil
+	}
+
+	var respBody bytes.Buffer
+	chunk := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(chunk)
+		if n > 0 {
+			respBody.Write(chunk[:n])
+			if cbErr := config.responseCallback(chunk[:n]); cbErr != nil {
+				return nil, fmt.Errorf("response callback failed: %w", cbErr)
+			}
+		}
+		if err == io.EOF {
+			return respBody.Bytes(), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+	}
+}
+
+// WithPreserveAuthOnRedirect keeps the Authorization header when following redirects.
+//
+// By default Go drops the Authorization header when a redirect points to a different
+// host, so credentials are not leaked to a server you did not intend to talk to.
+// Some APIs redirect within the same trust domain (e.g. api.example.com to
+// files.example.net) and need the header preserved.
+//
+// If allowedHosts is given, the header is only re-added for redirects to those
+// hostnames (compared case-insensitively, without port). Otherwise it is re-added for
+// every redirect target.
+//
+// WARNING: Without an allowlist, any redirect - including one injected by a
+// compromised or misconfigured server - receives your credentials. Always pass
+// allowedHosts unless you fully control every server in the redirect chain.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/reports/latest"),
+//		reqws.WithBearerToken(token),
+//		reqws.WithPreserveAuthOnRedirect("files.example.net"),
+//	)
+func WithPreserveAuthOnRedirect(allowedHosts ...string) RequestOption {
+	return func(c *requestConfig) {
+		c.preserveAuth = true
+		c.preserveAuthHosts = append(c.preserveAuthHosts, allowedHosts...)
+		c.watchStrings("WithPreserveAuthOnRedirect", allowedHosts)
+	}
+}
+
+// preserveAuthOnRedirect returns a CheckRedirect function that copies the original
+// Authorization header onto redirects to allowed hosts.
+func preserveAuthOnRedirect(allowedHosts []string) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		// Same limit as the default http.Client policy
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+
+		auth := via[0].Header.Get("Authorization")
+		if auth == "" || req.Header.Get("Authorization") != "" {
+			return nil
+		}
+
+		if len(allowedHosts) > 0 {
+			allowed := false
+			for _, host := range allowedHosts {
+				if strings.EqualFold(req.URL.Hostname(), host) {
+					allowed = true
+					break
+				}
+			}
+			if !allowed {
+				return nil
+			}
+		}
+
+		req.Header.Set("Authorization", auth)
+		return nil
+	}
+}
+
 // WithLogger sets a custom logger for the Client.
 // The logger will be used for all HTTP and WebSocket operations.
 // If no logger is provided, logging is disabled by default.
@@ -582,24 +1698,180 @@ func (c *Client) WithLogger(logger Logger) *Client {
 // Response represents an HTTP response with helper methods.
 type Response struct {
 	Body       []byte
+	RawBody    []byte // Body before any response transforms were applied
 	Headers    http.Header
 	StatusCode int
+	TLS        *tls.ConnectionState // Negotiated TLS state, nil for plain HTTP
+
+	// Deduplicated is true if the request was not sent because an identical request
+	// already completed (see Client.WithOutboundDedup). Body may then be truncated.
+	Deduplicated bool
+
+	// Cached is true if the response was served from the response cache
+	// (see Client.WithResponseCache).
+	Cached bool
+
+	// IPFamily is the address family of the connection that served the request,
+	// or of the connection to the proxy when one is used. Empty if the response
+	// was not received over a TCP connection, e.g. if it was cached.
+	IPFamily IPFamily
+
+	pool       *bufferPool
+	pooled     *[]byte
+	fromPool   bool
+	safeString bool
+	codecs     *codecRegistry
 }
 
 // JSON unmarshals the response body into the provided value.
 // The value should be a pointer to the target struct.
 func (r *Response) JSON(v interface{}) error {
 	if err := json.Unmarshal(r.Body, v); err != nil {
+		if r.IsBinary() {
+			return fmt.Errorf("failed to unmarshal JSON: body is %s: %w", binarySummary(r.Headers.Get("Content-Type"), r.Body), err)
+		}
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return nil
+}
+
+// XML unmarshals the response body into the provided value, with or without a
+// leading <?xml ...?> declaration. Besides UTF-8, declared ISO-8859-1 and US-ASCII
+// encodings are supported, which covers most legacy SOAP services. An empty body
+// returns an error wrapping ErrEmptyBody.
+//
+// Example:
+//
+//	var envelope struct {
+//		Body struct {
+//			Result string `xml:"GetQuoteResponse>Result"`
+//		} `xml:"Body"`
+//	}
+//	err := resp.XML(&envelope)
+func (r *Response) XML(v interface{}) error {
+	body := bytes.TrimPrefix(r.Body, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("failed to unmarshal XML: %w", ErrEmptyBody)
+	}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = xmlCharsetReader
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to unmarshal XML: %w", err)
+	}
+	return nil
+}
+
+// xmlCharsetReader converts the single-byte encodings of legacy XML APIs to UTF-8.
+func xmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "us-ascii", "ascii":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported XML charset %q", charset)
+}
+
+// JSONInto unmarshals the response body into v after renaming every object key
+// (at any depth) with mapper.
+//
+// Go's encoding/json already matches struct fields case-insensitively, so
+// "userId" and "USERID" both decode into a field tagged `json:"userid"`. It does
+// not bridge naming styles ("user_id" vs "userId") and does nothing for dynamic
+// keys decoded into maps. JSONInto covers those cases, e.g. decoding into a
+// map[string]interface{} with predictable keys.
+//
+// Example:
+//
+//	var data map[string]interface{}
+//	err := resp.JSONInto(&data, reqws.SnakeCaseKeys)
+func (r *Response) JSONInto(v interface{}, mapper FieldNameMapper) error {
+	raw, err := decodeJSONValue(r.Body)
+	if err != nil {
+		return err
+	}
+
+	mapped, err := json.Marshal(mapJSONKeys(raw, mapper))
+	if err != nil {
+		return fmt.Errorf("failed to remap JSON keys: %w", err)
+	}
+
+	if err := json.Unmarshal(mapped, v); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return nil
+}
+
+// JSONFirst unmarshals a body that may wrap a single object in an array. If the
+// body is a JSON array, its first element is decoded into v and any further
+// elements are ignored; otherwise the body is decoded directly like JSON().
+// An empty array returns ErrEmptyArray, which callers can treat as not found.
+//
+// Example:
+//
+//	var user User
+//	if err := resp.JSONFirst(&user); errors.Is(err, reqws.ErrEmptyArray) {
+//		return nil, ErrUserNotFound
+//	}
+func (r *Response) JSONFirst(v interface{}) error {
+	body := bytes.TrimLeft(r.Body, " \t\r\n")
+	if len(body) == 0 || body[0] != '[' {
+		return r.JSON(v)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	if !decoder.More() {
+		return ErrEmptyArray
+	}
+	if err := decoder.Decode(v); err != nil {
 		return fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
 	return nil
 }
 
-// String returns the response body as a string.
+// String returns the response body as a string. With
+// Client.WithSafeResponseString() binary bodies are summarized instead and text
+// is always valid UTF-8.
 func (r *Response) String() string {
+	if r.safeString {
+		return renderBody(r.Headers.Get("Content-Type"), r.Body)
+	}
 	return string(r.Body)
 }
 
+// PreferenceApplied returns the Preference-Applied header, which lists the
+// preferences from WithPrefer() that the server honored.
+func (r *Response) PreferenceApplied() string {
+	return strings.Join(r.Headers.Values("Preference-Applied"), ", ")
+}
+
+// PeerCertificates returns the certificate chain presented by the server, leaf
+// first, or nil for plain HTTP. The chains Go verified are in TLS.VerifiedChains.
+//
+// Example:
+//
+//	if resp.TLS != nil {
+//		log.Printf("%s %s", tls.VersionName(resp.TLS.Version), tls.CipherSuiteName(resp.TLS.CipherSuite))
+//	}
+//	for _, cert := range resp.PeerCertificates() {
+//		log.Printf("subject=%s issuer=%s expires=%s", cert.Subject, cert.Issuer, cert.NotAfter)
+//	}
+func (r *Response) PeerCertificates() []*x509.Certificate {
+	if r.TLS == nil {
+		return nil
+	}
+	return r.TLS.PeerCertificates
+}
+
 // IsSuccess returns true if the status code is 2xx (200-299).
 func (r *Response) IsSuccess() bool {
 	return r.StatusCode >= 200 && r.StatusCode < 300
@@ -627,6 +1899,9 @@ func (r *Response) IsServerError() bool {
 // You must manually check resp.IsSuccess() or resp.StatusCode.
 // Supports retry via WithRetry() or WithDefaultRetry() options.
 //
+// Do reads the whole body into memory. For large downloads or bodies that
+// arrive over time, use DoStream(), which returns the body unread.
+//
 // Example:
 //
 //	resp, err := client.Do(ctx,
@@ -642,30 +1917,98 @@ func (r *Response) IsServerError() bool {
 //	var user User
 //	resp.JSON(&user)
 func (c *Client) Do(ctx context.Context, opts ...RequestOption) (*Response, error) {
-	config := &requestConfig{
-		method:      http.MethodGet,
-		queryParams: url.Values{},
-		headers:     http.Header{},
-	}
+	return c.do(ctx, newRequestConfig(http.MethodGet, opts))
+}
 
-	for _, opt := range opts {
-		opt(config)
-	}
+// DoRequest executes a request built with net/http through the client's retry,
+// hook, logging and response pipeline, and returns it like Do().
+//
+// The method, URL, body and headers come from req; opts add behavior such as
+// WithRetry(), hooks, extra headers and auth. Options that build the URL or body
+// (GET(), WithPath(), WithQueryParam(), WithJSON(), WithFile(), ...) are ignored.
+// The client's base URL is not applied.
+//
+// Retries re-read the body with req.GetBody, which http.NewRequest sets for
+// *bytes.Buffer, *bytes.Reader and *strings.Reader bodies. If a retry is needed
+// and GetBody is nil, the attempt fails.
+//
+// Example:
+//
+//	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/orders", bytes.NewReader(payload))
+//	req.Header.Set("Content-Type", "application/json")
+//	resp, err := client.DoRequest(ctx, req, reqws.WithDefaultRetry())
+func (c *Client) DoRequest(ctx context.Context, req *http.Request, opts ...RequestOption) (*Response, error) {
+	config := newRequestConfig(req.Method, opts)
+	config.method = req.Method
+	config.path = req.URL.Path
+	config.prepared = req
+	return c.do(ctx, config)
+}
 
-	resp, err := c.executeWithRetry(ctx, config)
+// do executes the request described by config and reads the full response.
+func (c *Client) do(ctx context.Context, config *requestConfig) (*Response, error) {
+	resp, err := c.executeCached(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	var respBody []byte
+	var pooled *[]byte
+	if config.pooledResponse && c.buffers != nil {
+		respBody, pooled, err = c.buffers.readAll(resp.Body, config.responseCallback)
+	} else {
+		respBody, err = readResponseBody(resp, config)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
+	}
+
+	rawBody := respBody
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		err = c.validateResponseSchema(config, resp.StatusCode, respBody)
+		if err == nil {
+			respBody, err = c.applyResponseTransforms(respBody, config)
+		}
+		if err != nil {
+			if pooled != nil {
+				c.buffers.putSlice(pooled)
+			}
+			return nil, err
+		}
+	}
+
+	if config.pooledResponse {
+		// The http.Response is discarded, so its headers can be handed over without a clone
+		response := responsePool.Get().(*Response)
+		response.Body = respBody
+		response.RawBody = rawBody
+		response.Headers = resp.Header
+		response.StatusCode = resp.StatusCode
+		response.TLS = resp.TLS
+		response.Deduplicated = config.deduplicated
+		response.Cached = config.cached
+		response.IPFamily = config.ipFamily
+		response.fromPool = true
+		response.safeString = c.safeString
+		response.codecs = &c.codecs
+		if pooled != nil {
+			response.pool = c.buffers
+			response.pooled = pooled
+		}
+		return response, nil
 	}
 
 	return &Response{
-		Body:       respBody,
-		Headers:    resp.Header.Clone(),
-		StatusCode: resp.StatusCode,
+		Body:         respBody,
+		RawBody:      rawBody,
+		Headers:      resp.Header.Clone(),
+		StatusCode:   resp.StatusCode,
+		TLS:          resp.TLS,
+		Deduplicated: config.deduplicated,
+		Cached:       config.cached,
+		IPFamily:     config.ipFamily,
+		safeString:   c.safeString,
+		codecs:       &c.codecs,
 	}, nil
 }
@@ -0,0 +1,105 @@
+package reqws
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandPathParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		params  map[string]string
+		want    string
+		wantErr string
+	}{
+		{"no placeholders", "/users", nil, "/users", ""},
+		{"escaped value", "/users/{id}/posts", map[string]string{"id": "a/b c"}, "/users/a%2Fb%20c/posts", ""},
+		{"repeated placeholder", "/{v}/{v}", map[string]string{"v": "x"}, "/x/x", ""},
+		{"missing value", "/users/{id}", nil, "/users/{id}", "unresolved path parameters: {id}"},
+		{"unused param", "/users", map[string]string{"id": "1"}, "/users", "path parameters without a placeholder: {id}"},
+		{"unterminated", "/a/{id", map[string]string{"id": "1"}, "/a/{id", "unterminated path placeholder: {id"},
+		{"unterminated after a placeholder", "/{a}/{b", map[string]string{"a": "1"}, "/1/{b", "unterminated path placeholder: {b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandPathParams(tt.path, tt.params)
+			if got != tt.want {
+				t.Errorf("path = %q, want %q", got, tt.want)
+			}
+			if (err == nil) != (tt.wantErr == "") || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnterminatedPathParamFailsRequest(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/a/{id"), WithPathParam("id", "1"))
+	if err == nil || !strings.Contains(err.Error(), "unterminated path placeholder") {
+		t.Errorf("Do error = %v, want the unterminated placeholder", err)
+	}
+	if got := log.Count(); got != 0 {
+		t.Errorf("server received %d requests, want none", got)
+	}
+}
+
+func TestDoRequestReplaysBodyOnRetry(t *testing.T) {
+	server, log := recordingServer(t, failFirst(2))
+	payload := `{"id":1}`
+	var getBodyCalls int
+	// A body type http.NewRequest cannot rewind, so only the caller's GetBody can
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/orders?v=2", io.NopCloser(strings.NewReader(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		getBodyCalls++
+		return io.NopCloser(strings.NewReader(payload)), nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := NewClient("http://unused.invalid", 5*time.Second).DoRequest(context.Background(), req, WithRetry(fastRetry(3)))
+	if err != nil {
+		t.Fatalf("DoRequest: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200 after two 503s", resp.StatusCode)
+	}
+	sent := log.All()
+	if len(sent) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(sent))
+	}
+	for i, got := range sent {
+		if got.Method != http.MethodPost || got.Path != "/orders" || got.Query != "v=2" || got.Body != payload || got.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("attempt %d = %+v, want the caller's request with its body", i+1, got)
+		}
+	}
+	if getBodyCalls < 2 {
+		t.Errorf("GetBody called %d times, want at least once per retry", getBodyCalls)
+	}
+}
+
+func TestDoRequestRetryWithoutGetBody(t *testing.T) {
+	server, log := recordingServer(t, failFirst(1))
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("once")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GetBody != nil {
+		t.Fatal("http.NewRequest set GetBody for an opaque reader")
+	}
+
+	_, err = NewClient("", 5*time.Second).DoRequest(context.Background(), req, WithRetry(fastRetry(2)))
+	if err == nil {
+		t.Error("DoRequest succeeded, want the retry to fail without GetBody")
+	}
+	if got := log.Count(); got != 1 || log.Last(t).Body != "once" {
+		t.Errorf("server received %d requests, want only the first with its body", got)
+	}
+}
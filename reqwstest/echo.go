@@ -0,0 +1,154 @@
+// Package reqwstest provides in-process servers for testing code built on reqws
+// without depending on external services:
+//
+//	url, cleanup := reqwstest.NewEchoWSServer(reqwstest.WithDisconnectAfter(2))
+//	defer cleanup()
+//
+//	client := reqws.NewClient(url, 5*time.Second)
+//	recv, errs, err := client.OpenWebSocket(ctx, sendChan,
+//		reqws.WithPath("/"),
+//		reqws.WithWebSocketAutoReconnect(reqws.WebSocketConfig{
+//			AutoReconnect:  true,
+//			ReconnectDelay: 10 * time.Millisecond,
+//		}),
+//	)
+package reqwstest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/coder/websocket"
+)
+
+// EchoOption configures the server started by NewEchoWSServer.
+type EchoOption func(*echoConfig)
+
+type echoConfig struct {
+	disconnect      bool
+	disconnectAfter int
+	maxDisconnects  int
+	abrupt          bool
+	closeCode       websocket.StatusCode
+}
+
+// WithDisconnectAfter makes the server close each connection after echoing n
+// messages, to exercise reconnect logic. With n == 0 connections are closed
+// right after the handshake. The connection is closed with StatusGoingAway
+// unless WithCloseCode() or WithAbruptDisconnect() is used.
+func WithDisconnectAfter(n int) EchoOption {
+	return func(c *echoConfig) {
+		c.disconnect = true
+		c.disconnectAfter = n
+	}
+}
+
+// WithMaxDisconnects limits WithDisconnectAfter() to the first n connections;
+// later connections echo until the client closes them, so a test can check that
+// the client recovers. Zero (the default) drops every connection.
+func WithMaxDisconnects(n int) EchoOption {
+	return func(c *echoConfig) {
+		c.maxDisconnects = n
+	}
+}
+
+// WithCloseCode sets the close status sent when a connection is dropped
+// (default: StatusGoingAway).
+func WithCloseCode(code websocket.StatusCode) EchoOption {
+	return func(c *echoConfig) {
+		c.closeCode = code
+	}
+}
+
+// WithAbruptDisconnect drops connections without a close handshake, like a
+// network failure or a crashed server.
+func WithAbruptDisconnect() EchoOption {
+	return func(c *echoConfig) {
+		c.abrupt = true
+	}
+}
+
+// NewEchoWSServer starts a local WebSocket server that sends every message it
+// receives back unchanged, in the same frame type, so JSON sent through
+// reqws comes back as the same JSON. It accepts connections on any path.
+//
+// The returned URL has the ws:// scheme and can be passed to reqws.NewClient.
+// cleanup closes open connections and shuts the server down; call it when the
+// test ends.
+//
+// Example:
+//
+//	url, cleanup := reqwstest.NewEchoWSServer()
+//	t.Cleanup(cleanup)
+//	client := reqws.NewClient(url, 5*time.Second)
+func NewEchoWSServer(opts ...EchoOption) (url string, cleanup func()) {
+	config := echoConfig{closeCode: websocket.StatusGoingAway}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &echoServer{config: config, ctx: ctx}
+	server := httptest.NewServer(http.HandlerFunc(s.serve))
+
+	var once sync.Once
+	cleanup = func() {
+		once.Do(func() {
+			// Handlers block reading hijacked connections, which Close waits for
+			cancel()
+			server.Close()
+		})
+	}
+	return "ws" + strings.TrimPrefix(server.URL, "http"), cleanup
+}
+
+type echoServer struct {
+	config echoConfig
+	ctx    context.Context // Cancelled by cleanup
+
+	mu      sync.Mutex
+	dropped int // Connections chosen to be dropped so far
+}
+
+func (s *echoServer) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.CloseNow()
+
+	drop := s.shouldDrop()
+	for n := 0; ; n++ {
+		if drop && n == s.config.disconnectAfter {
+			if !s.config.abrupt {
+				conn.Close(s.config.closeCode, "reqwstest: simulated disconnect")
+			}
+			return
+		}
+		typ, data, err := conn.Read(s.ctx)
+		if err != nil {
+			return
+		}
+		if err := conn.Write(s.ctx, typ, data); err != nil {
+			return
+		}
+	}
+}
+
+// shouldDrop reports whether a new connection is to be dropped, counting it
+// against WithMaxDisconnects().
+func (s *echoServer) shouldDrop() bool {
+	if !s.config.disconnect {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.config.maxDisconnects > 0 && s.dropped >= s.config.maxDisconnects {
+		return false
+	}
+	s.dropped++
+	return true
+}
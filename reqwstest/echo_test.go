@@ -0,0 +1,99 @@
+package reqwstest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	reqws "github.com/gurizzu/go-reqws"
+)
+
+// receive returns the next message that is not a Closed notice, counting the
+// Closed notices skipped.
+func receive(t *testing.T, recv <-chan reqws.WebSocketResponse, closed *int) reqws.WebSocketResponse {
+	t.Helper()
+	for {
+		select {
+		case msg, ok := <-recv:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			if msg.Closed {
+				*closed++
+				continue
+			}
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message received")
+		}
+	}
+}
+
+func TestEchoWSServer(t *testing.T) {
+	url, cleanup := NewEchoWSServer()
+	t.Cleanup(cleanup)
+
+	sendChan := make(chan interface{})
+	defer close(sendChan)
+	recv, _, err := reqws.NewClient(url, 5*time.Second).OpenWebSocket(context.Background(), sendChan, reqws.WithPath("/any/path"))
+	if err != nil {
+		t.Fatalf("OpenWebSocket: %v", err)
+	}
+
+	var closed int
+	for n := 1; n <= 2; n++ {
+		sendChan <- map[string]int{"n": n}
+		msg := receive(t, recv, &closed)
+		if m, ok := msg.Data.(map[string]interface{}); !ok || m["n"] != float64(n) {
+			t.Errorf("echo = %+v, want n = %d", msg, n)
+		}
+	}
+	if closed != 0 {
+		t.Errorf("%d Closed notices, want none", closed)
+	}
+}
+
+func TestEchoWSServerDisconnects(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []EchoOption
+	}{
+		{"close handshake", []EchoOption{WithDisconnectAfter(1), WithMaxDisconnects(1)}},
+		{"abrupt", []EchoOption{WithDisconnectAfter(1), WithMaxDisconnects(1), WithAbruptDisconnect()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, cleanup := NewEchoWSServer(tt.opts...)
+			t.Cleanup(cleanup)
+
+			sendChan := make(chan interface{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			recv, errs, err := reqws.NewClient(url, 5*time.Second).OpenWebSocket(ctx, sendChan,
+				reqws.WithWebSocketAutoReconnect(reqws.WebSocketConfig{AutoReconnect: true, ReconnectDelay: 10 * time.Millisecond}))
+			if err != nil {
+				t.Fatalf("OpenWebSocket: %v", err)
+			}
+
+			// The first connection is dropped after one echo, the second one stays up
+			var closed int
+			for _, want := range []string{"first", "after reconnect"} {
+				sendChan <- map[string]string{"msg": want}
+				if msg := receive(t, recv, &closed); msg.Error != nil || msg.Data.(map[string]interface{})["msg"] != want {
+					t.Errorf("echo = %+v, want %q", msg, want)
+				}
+			}
+			if closed != 1 {
+				t.Errorf("%d Closed notices, want 1 for the dropped connection", closed)
+			}
+
+			cancel()
+			for range recv {
+			}
+			if err := <-errs; !errors.Is(err, context.Canceled) {
+				t.Errorf("stream error = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
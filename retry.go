@@ -1,17 +1,83 @@
 package reqws
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+// defaultRetryOnBodyMaxBytes is the default cap on how much of the body RetryOnBody sees.
+const defaultRetryOnBodyMaxBytes = 64 * 1024
+
 // RetryConfig defines the configuration for retry behavior.
 type RetryConfig struct {
 	MaxRetries   int           // Maximum number of retry attempts (default: 3)
 	InitialDelay time.Duration // Initial delay before first retry (default: 100ms)
 	MaxDelay     time.Duration // Maximum delay between retries (default: 5s)
 	Multiplier   float64       // Backoff multiplier (default: 2.0)
+
+	// RetryOnBody classifies a response as retryable based on its body, e.g. an
+	// upstream that returns 200 with {"status":"retry"}. Returning true retries
+	// the request even for 2xx responses.
+	//
+	// When set, every response body is read into memory (up to RetryOnBodyMaxBytes)
+	// before being handed to the caller, so only enable it for endpoints that need it.
+	RetryOnBody func(statusCode int, body []byte) bool
+
+	// RetryOnBodyMaxBytes caps how many body bytes are read for RetryOnBody (default: 64KB).
+	// Bodies larger than this are classified by their prefix only.
+	RetryOnBodyMaxBytes int64
+
+	// HonorRetryAfter waits for the duration in a response's Retry-After header
+	// (seconds or HTTP date) instead of the backoff delay, capped at MaxDelay.
+	HonorRetryAfter bool
+
+	// RetryPriority sets how retried attempts queue for a host slot when the client
+	// limits concurrency with WithHostConcurrency() (default: RetryPriorityLow).
+	RetryPriority RetryPriority
+
+	// SkipTLSRetry stops retrying requests that failed with a ConnectionError of
+	// category ConnectionTLS, e.g. for certificate errors that would only fail
+	// again. By default TLS failures are retried like other connection errors,
+	// since handshakes also fail transiently behind overloaded load balancers.
+	SkipTLSRetry bool
+}
+
+// RetryInfo describes the outcome of one attempt of a request sent with retries.
+type RetryInfo struct {
+	Attempt           int           // Attempt number, starting at 1
+	MaxRetries        int           // RetryConfig.MaxRetries
+	StatusCode        int           // Response status, 0 if no response was received
+	Err               error         // Attempt error, nil if a response was received
+	Retrying          bool          // Another attempt follows
+	Delay             time.Duration // Wait before the next attempt, 0 if not retrying
+	RetryAfterHonored bool          // Delay comes from the Retry-After header
+}
+
+// WithRetryObserver calls observer after every attempt of a request sent with
+// WithRetry() or WithDefaultRetry(), including the final one, e.g. to feed retry
+// dashboards. It runs synchronously in the retry loop, so keep it fast.
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/quotes"),
+//		reqws.WithDefaultRetry(),
+//		reqws.WithRetryObserver(func(info reqws.RetryInfo) {
+//			if info.Retrying {
+//				retryCounter.WithLabelValues(strconv.Itoa(info.StatusCode)).Inc()
+//			}
+//		}),
+//	)
+func WithRetryObserver(observer func(info RetryInfo)) RequestOption {
+	return func(c *requestConfig) {
+		c.retryObserver = observer
+	}
 }
 
 // DefaultRetryConfig returns a sensible default retry configuration.
@@ -27,7 +93,8 @@ func DefaultRetryConfig() RetryConfig {
 // WithRetry enables retry with custom configuration.
 func WithRetry(config RetryConfig) RequestOption {
 	return func(c *requestConfig) {
-		c.retryConfig = &config
+		cfg := config // Per-request copy, the option may be shared between requests
+		c.retryConfig = &cfg
 	}
 }
 
@@ -39,22 +106,92 @@ func WithRetry(config RetryConfig) RequestOption {
 func WithDefaultRetry() RequestOption {
 	config := DefaultRetryConfig()
 	return func(c *requestConfig) {
-		c.retryConfig = &config
+		cfg := config
+		c.retryConfig = &cfg
 	}
 }
 
+type retryAttemptKey struct{}
+
+// RetryAttempt returns the attempt number of the request being sent, starting at 1
+// for the first attempt. Hooks can read it from req.Context() to implement their own
+// give-up logic. Returns 0 if the context does not belong to a request.
+func RetryAttempt(ctx context.Context) int {
+	attempt, _ := ctx.Value(retryAttemptKey{}).(int)
+	return attempt
+}
+
+// RetryableHookError marks an error returned from a RequestHook or ResponseHook as
+// retryable. By default hook errors are permanent and stop the retry loop.
+//
+// Example:
+//
+//	reqws.WithAfterResponse(func(req *http.Request, resp *http.Response) error {
+//		if resp.Header.Get("X-Shard-State") == "rebalancing" {
+//			return reqws.RetryableHookError(errors.New("shard rebalancing"))
+//		}
+//		return nil
+//	})
+func RetryableHookError(err error) error {
+	return &retryableHookError{err: err}
+}
+
+type retryableHookError struct {
+	err error
+}
+
+func (e *retryableHookError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableHookError) Unwrap() error {
+	return e.err
+}
+
+// hookError wraps errors returned from hooks so the retry loop can tell them
+// apart from transport errors.
+type hookError struct {
+	err error
+}
+
+func (e *hookError) Error() string {
+	return e.err.Error()
+}
+
+func (e *hookError) Unwrap() error {
+	return e.err
+}
+
+// isPermanentHookError reports whether err came from a hook that did not mark it retryable.
+func isPermanentHookError(err error) bool {
+	var hookErr *hookError
+	if !errors.As(err, &hookErr) {
+		return false
+	}
+	var retryable *retryableHookError
+	return !errors.As(err, &retryable)
+}
+
 // shouldRetry determines if a request should be retried based on the response.
 // Returns true for:
-// - Network errors (no response)
+// - Network errors (no response), except TLS failures if skipTLS is set
 // - 5xx server errors
 // - 429 Too Many Requests
 // Returns false for:
 // - 2xx success
 // - 4xx client errors (except 429)
-func shouldRetry(resp *http.Response, err error) bool {
+func shouldRetry(resp *http.Response, err error, skipTLS bool) bool {
 	// Network error, should retry
 	if err != nil {
-		return true
+		// Memory budget rejections, oversized headers and modified option inputs
+		// would only fail again
+		var budgetErr *BudgetExceededError
+		var headerErr *HeaderTooLargeError
+		if errors.As(err, &budgetErr) || errors.As(err, &headerErr) || errors.Is(err, ErrInputModified) {
+			return false
+		}
+		var connErr *ConnectionError
+		return !skipTLS || !errors.As(err, &connErr) || connErr.Category != ConnectionTLS
 	}
 
 	// No response, should retry
@@ -83,9 +220,20 @@ func shouldRetry(resp *http.Response, err error) bool {
 
 // executeWithRetry wraps the request execution with retry logic.
 func (c *Client) executeWithRetry(ctx context.Context, config *requestConfig) (*http.Response, error) {
+	// Reject sheddable requests early when the upstream is degraded
+	if c.shedder != nil {
+		if err := c.shedder.admit(config.sheddable); err != nil {
+			if c.logger != nil {
+				c.logger.Debug("request shed", "method", config.method, "path", config.path, "error", err)
+			}
+			return nil, err
+		}
+		defer c.shedder.done()
+	}
+
 	// No retry config, execute once
 	if config.retryConfig == nil {
-		return c.buildAndExecuteRequest(ctx, config)
+		return c.buildAndExecuteRequest(context.WithValue(ctx, retryAttemptKey{}, 1), config)
 	}
 
 	var lastResp *http.Response
@@ -99,39 +247,80 @@ func (c *Client) executeWithRetry(ctx context.Context, config *requestConfig) (*
 		}
 
 		// Execute request
-		resp, err := c.buildAndExecuteRequest(ctx, config)
+		resp, err := c.buildAndExecuteRequest(context.WithValue(ctx, retryAttemptKey{}, attempt+1), config)
 
-		// Success - return immediately
-		if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return resp, nil
+		// Hook errors are permanent unless marked with RetryableHookError
+		if err != nil && isPermanentHookError(err) {
+			config.observeRetry(attempt+1, resp, err, false, 0, false)
+			return nil, err
+		}
+
+		// Classify by body if requested
+		retryOnBody := false
+		if err == nil && resp != nil && config.retryConfig.RetryOnBody != nil {
+			body, readErr := preReadBody(resp, config.retryConfig.RetryOnBodyMaxBytes)
+			if readErr != nil {
+				resp.Body.Close()
+				resp, err = nil, readErr
+			} else {
+				retryOnBody = config.retryConfig.RetryOnBody(resp.StatusCode, body)
+			}
 		}
 
-		// Check if we should retry
-		if !shouldRetry(resp, err) {
-			// Don't retry, return error immediately
-			return resp, err
+		if !retryOnBody {
+			// Success - return immediately
+			if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				config.observeRetry(attempt+1, resp, nil, false, 0, false)
+				return resp, nil
+			}
+
+			// Check if we should retry
+			if !shouldRetry(resp, err, config.retryConfig.SkipTLSRetry) {
+				// Don't retry, return error immediately
+				config.observeRetry(attempt+1, resp, err, false, 0, false)
+				return resp, err
+			}
+		}
+
+		// A streamed body that cannot be rewound is only sent once
+		if !config.bodyRewindable() {
+			config.observeRetry(attempt+1, resp, err, false, 0, false)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrBodyNotRewindable, err)
+			}
+			return resp, nil
 		}
 
 		// Store last response/error
 		lastResp = resp
 		lastErr = err
 
+		// Last attempt, don't sleep and keep the body readable for the caller
+		if attempt >= config.retryConfig.MaxRetries {
+			config.observeRetry(attempt+1, resp, err, false, 0, false)
+			break
+		}
+
+		// A Retry-After header replaces the backoff delay for this wait
+		wait, retryAfter := delay, false
+		if config.retryConfig.HonorRetryAfter && resp != nil {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait, retryAfter = min(d, config.retryConfig.MaxDelay), true
+			}
+		}
+		config.observeRetry(attempt+1, resp, err, true, wait, retryAfter)
+
 		// Close response body if exists (to avoid leaking connections)
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
 		}
 
-		// Last attempt, don't sleep
-		if attempt >= config.retryConfig.MaxRetries {
-			break
-		}
-
 		// Log retry attempt if logger available
 		if c.logger != nil {
 			c.logger.Info("retrying request",
 				"attempt", attempt+1,
 				"max_retries", config.retryConfig.MaxRetries,
-				"delay", delay,
+				"delay", wait,
 			)
 		}
 
@@ -139,7 +328,7 @@ func (c *Client) executeWithRetry(ctx context.Context, config *requestConfig) (*
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(delay):
+		case <-time.After(wait):
 			// Calculate next delay with exponential backoff
 			delay = time.Duration(float64(delay) * config.retryConfig.Multiplier)
 			if delay > config.retryConfig.MaxDelay {
@@ -159,3 +348,65 @@ func (c *Client) executeWithRetry(ctx context.Context, config *requestConfig) (*
 	// Return last response if no error (non-2xx status)
 	return lastResp, nil
 }
+
+// observeRetry reports an attempt's outcome to the retry observer, if any.
+func (config *requestConfig) observeRetry(attempt int, resp *http.Response, err error, retrying bool, delay time.Duration, retryAfter bool) {
+	if config.retryObserver == nil {
+		return
+	}
+	info := RetryInfo{
+		Attempt:           attempt,
+		MaxRetries:        config.retryConfig.MaxRetries,
+		Err:               err,
+		Retrying:          retrying,
+		Delay:             delay,
+		RetryAfterHonored: retryAfter,
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	config.retryObserver(info)
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// preReadBody reads up to maxBytes of the response body for classification and
+// replaces resp.Body so the caller still sees the complete, unconsumed body.
+func preReadBody(resp *http.Response, maxBytes int64) ([]byte, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultRetryOnBodyMaxBytes
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	resp.Body = &preReadBodyCloser{
+		Reader: io.MultiReader(bytes.NewReader(body), resp.Body),
+		Closer: resp.Body,
+	}
+	return body, nil
+}
+
+// preReadBodyCloser carries an already-read body prefix followed by the rest of
+// the original stream, closing the original body on Close.
+type preReadBodyCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,113 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryOnBody(t *testing.T) {
+	var attempts *requestLog
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Count() < 3 {
+			w.Write([]byte(`{"status":"retry"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+
+	retry := fastRetry(3)
+	retry.RetryOnBody = func(status int, body []byte) bool {
+		return bytes.Contains(body, []byte(`"retry"`))
+	}
+	var observed []RetryInfo
+	client := NewClient(server.URL, 5*time.Second)
+	resp, err := client.Do(context.Background(), GET("/"), WithRetry(retry),
+		WithRetryObserver(func(info RetryInfo) { observed = append(observed, info) }))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != `{"status":"ok"}` {
+		t.Errorf("body = %s", got)
+	}
+	if got := attempts.Count(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+	if len(observed) != 3 || !observed[0].Retrying || !observed[1].Retrying || observed[2].Retrying {
+		t.Errorf("observed attempts = %+v", observed)
+	}
+}
+
+func TestRetryOnBodyExhaustedReturnsLastBody(t *testing.T) {
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"retry"}`))
+	})
+
+	retry := fastRetry(2)
+	retry.RetryOnBody = func(int, []byte) bool { return true }
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"), WithRetry(retry))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != `{"status":"retry"}` {
+		t.Errorf("body = %q, want the last response's body", got)
+	}
+	if got := attempts.Count(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestRetryOnBodyReadsOnlyPrefix(t *testing.T) {
+	large := `{"status":"ok","data":"` + strings.Repeat("x", 1000) + `"}`
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(large))
+	})
+
+	retry := fastRetry(1)
+	retry.RetryOnBodyMaxBytes = 16
+	var seen int
+	retry.RetryOnBody = func(_ int, body []byte) bool {
+		seen = len(body)
+		return false
+	}
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"), WithRetry(retry))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if seen != 16 {
+		t.Errorf("classifier saw %d bytes, want 16", seen)
+	}
+	if string(resp.Body) != large {
+		t.Errorf("body was not returned complete: %d of %d bytes", len(resp.Body), len(large))
+	}
+}
+
+func TestRetryStatusClassification(t *testing.T) {
+	tests := []struct {
+		status       int
+		wantAttempts int
+	}{
+		{http.StatusOK, 1},
+		{http.StatusNotFound, 1},
+		{http.StatusTooManyRequests, 3},
+		{http.StatusBadGateway, 3},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			resp, _ := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/"), WithRetry(fastRetry(2)))
+			if resp == nil || resp.StatusCode != tt.status {
+				t.Fatalf("response = %+v, want status %d", resp, tt.status)
+			}
+			if got := attempts.Count(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
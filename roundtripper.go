@@ -0,0 +1,56 @@
+package reqws
+
+import "net/http"
+
+// AsRoundTripper returns an http.RoundTripper that sends requests through c, so
+// libraries that take an *http.Client or a RoundTripper (generated API clients,
+// golang.org/x/oauth2, ...) get the client's retries, hooks, logging, tap, host
+// limit and load shedding. defaults apply to every request, as in DoRequest().
+//
+// Each request is sent like DoRequest(): the method, absolute URL, headers and
+// body come from the request, the client's base URL is not applied, and options
+// that build the URL or body are ignored. The request's context bounds every
+// attempt, and retries re-read the body with req.GetBody. The response is
+// returned without reading its body, which streams to the caller like
+// DoStream(); RetryConfig.RetryOnBody reads up to its limit first.
+//
+// Redirects are returned to the caller instead of being followed, so the
+// http.Client using the RoundTripper applies its own redirect policy.
+//
+// Example:
+//
+//	api := reqws.NewClient("", 30*time.Second)
+//	httpClient := &http.Client{
+//		Transport: reqws.AsRoundTripper(api, reqws.WithDefaultRetry()),
+//	}
+//	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+//	token, err := oauthConfig.Exchange(ctx, code)
+func AsRoundTripper(c *Client, defaults ...RequestOption) http.RoundTripper {
+	return &clientTransport{client: c, defaults: defaults}
+}
+
+// clientTransport is the RoundTripper returned by AsRoundTripper.
+type clientTransport struct {
+	client   *Client
+	defaults []RequestOption
+}
+
+func (t *clientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	config := newRequestConfig(req.Method, t.defaults)
+	config.method = req.Method
+	config.path = req.URL.Path
+	config.prepared = req
+	config.streamBufferSize = streamReadSize
+	config.returnRedirects = true
+
+	resp, err := t.client.execute(req.Context(), config)
+	if err != nil {
+		// RoundTrip must close the body, also when it was never sent
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	resp.Request = req
+	return resp, nil
+}
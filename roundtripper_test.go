@@ -0,0 +1,115 @@
+package reqws
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAsRoundTripperRetriesWithBody(t *testing.T) {
+	fail := failFirst(1)
+	server, attempts := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fail(w, r)
+		w.Write([]byte("streamed"))
+	})
+
+	httpClient := &http.Client{
+		Transport: AsRoundTripper(NewClient("", 5*time.Second), WithRetry(fastRetry(2)), WithHeader("X-Default", "1")),
+	}
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/orders", strings.NewReader(`{"id":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Caller", "yes")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "streamed" {
+		t.Errorf("body = %q, %v; want %q", body, err, "streamed")
+	}
+	if resp.StatusCode != http.StatusOK || resp.Request != req {
+		t.Errorf("status %d, request %p; want 200 and the caller's request %p", resp.StatusCode, resp.Request, req)
+	}
+	sent := attempts.All()
+	if len(sent) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(sent))
+	}
+	for _, req := range sent {
+		if req.Body != `{"id":1}` || req.Header.Get("X-Default") != "1" || req.Header.Get("X-Caller") != "yes" {
+			t.Errorf("attempt = %+v, want the same body and headers on every attempt", req)
+		}
+	}
+}
+
+func TestAsRoundTripperLeavesRedirectsToHTTPClient(t *testing.T) {
+	var targetHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		targetHits.Add(1)
+	})
+	server := testServer(t, mux.ServeHTTP)
+	transport := AsRoundTripper(NewClient("", 5*time.Second))
+
+	tests := []struct {
+		name       string
+		check      func(*http.Request, []*http.Request) error
+		wantStatus int
+		wantHits   int32
+	}{
+		{"followed by default", nil, http.StatusOK, 1},
+		{"policy stops", func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }, http.StatusFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetHits.Store(0)
+			httpClient := &http.Client{Transport: transport, CheckRedirect: tt.check}
+			resp, err := httpClient.Get(server.URL + "/old")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || targetHits.Load() != tt.wantHits {
+				t.Errorf("status %d after %d target hits, want %d after %d", resp.StatusCode, targetHits.Load(), tt.wantStatus, tt.wantHits)
+			}
+		})
+	}
+}
+
+// closeTracker is a request body that records whether it was closed.
+type closeTracker struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (b *closeTracker) Close() error {
+	b.closed.Store(true)
+	return nil
+}
+
+func TestAsRoundTripperClosesBodyOnError(t *testing.T) {
+	// The hook fails the attempt before anything is sent
+	body := &closeTracker{Reader: strings.NewReader("payload")}
+	req, err := http.NewRequest(http.MethodPost, "http://127.0.0.1:1/", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hookErr := errors.New("denied")
+	transport := AsRoundTripper(NewClient("", 5*time.Second), WithBeforeRequest(func(*http.Request) error { return hookErr }))
+	if _, err := transport.RoundTrip(req); !errors.Is(err, hookErr) {
+		t.Errorf("RoundTrip error = %v, want the hook error", err)
+	}
+	if !body.closed.Load() {
+		t.Error("request body not closed after RoundTrip failed")
+	}
+}
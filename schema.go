@@ -0,0 +1,208 @@
+package reqws
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// defaultSchemaMaxBodyBytes is the largest response body validated by default.
+const defaultSchemaMaxBodyBytes = 1 << 20
+
+// SchemaValidator validates a JSON document against a compiled schema.
+type SchemaValidator interface {
+	// Validate returns the violations found in body, or an error if body is not JSON.
+	Validate(body []byte) ([]SchemaViolation, error)
+}
+
+// SchemaCompiler compiles a JSON Schema document. The jsonschema subpackage
+// provides one for draft 2020-12 (github.com/gurizzu/go-reqws/jsonschema).
+type SchemaCompiler func(schema []byte) (SchemaValidator, error)
+
+// SchemaMode controls what happens when a response violates its schema.
+type SchemaMode int
+
+const (
+	SchemaStrict  SchemaMode = iota // Fail the call with *SchemaViolationError
+	SchemaObserve                   // Report to OnViolation and return the response unchanged
+)
+
+// SchemaValidationConfig configures response schema validation.
+type SchemaValidationConfig struct {
+	Compiler     SchemaCompiler              // Required, e.g. jsonschema.Compile
+	Mode         SchemaMode                  // SchemaStrict or SchemaObserve
+	OnViolation  func(*SchemaViolationError) // Called for every violating response in either mode
+	MaxBodyBytes int                         // Larger bodies are not validated (default: 1MB)
+}
+
+// SchemaViolation is a single schema violation.
+type SchemaViolation struct {
+	InstancePath string // JSON pointer to the offending value, e.g. "/items/0/price"; "" is the whole body
+	KeywordPath  string // JSON pointer to the failed keyword in the schema document, e.g. "/$defs/item/properties/price/type"
+	Message      string
+}
+
+// SchemaViolationError is returned in SchemaStrict mode when a response body does
+// not match its schema.
+type SchemaViolationError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Violations []SchemaViolation
+	Body       []byte
+}
+
+func (e *SchemaViolationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		location := v.InstancePath
+		if location == "" {
+			location = "(root)"
+		}
+		parts[i] = fmt.Sprintf("%s: %s", location, v.Message)
+	}
+	return fmt.Sprintf("response of %s %s violates schema: %s", e.Method, e.Path, strings.Join(parts, "; "))
+}
+
+// WithSchemaValidation enables response schema validation, e.g. in staging to
+// catch upstream contract drift. Schemas are attached with WithResponseSchema()
+// or Client.WithResponseSchemas().
+//
+// 2xx responses of Request() and Do() are validated before response transforms run.
+// Compiled schemas are cached on the client. Bodies larger than MaxBodyBytes are
+// skipped, and a schema that fails to compile fails the call in either mode.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithSchemaValidation(reqws.SchemaValidationConfig{
+//			Compiler: jsonschema.Compile,
+//			Mode:     reqws.SchemaObserve,
+//			OnViolation: func(err *reqws.SchemaViolationError) {
+//				log.Printf("contract drift: %v", err)
+//			},
+//		}).
+//		WithResponseSchemas(map[string][]byte{"/v1/orders/*": orderSchema})
+func (c *Client) WithSchemaValidation(config SchemaValidationConfig) *Client {
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = defaultSchemaMaxBodyBytes
+	}
+	registry := &schemaRegistry{config: config, compiled: make(map[string]SchemaValidator)}
+	if c.schemas != nil {
+		registry.byPath = c.schemas.byPath
+	}
+	c.schemas = registry
+	return c
+}
+
+// WithResponseSchemas validates responses against the schema whose path pattern
+// matches the request path. Patterns use path.Match syntax, e.g. "/v1/orders/*";
+// the first match in lexical pattern order wins. Requires WithSchemaValidation().
+func (c *Client) WithResponseSchemas(schemas map[string][]byte) *Client {
+	if c.schemas == nil {
+		c.WithSchemaValidation(SchemaValidationConfig{})
+	}
+	c.schemas.byPath = schemas
+	return c
+}
+
+// WithResponseSchema validates the response against schema, replacing any schema
+// the client matched by path. Requires Client.WithSchemaValidation().
+func WithResponseSchema(schema []byte) RequestOption {
+	return func(c *requestConfig) {
+		c.responseSchema = schema
+	}
+}
+
+type schemaRegistry struct {
+	config SchemaValidationConfig
+	byPath map[string][]byte
+
+	mu       sync.Mutex
+	compiled map[string]SchemaValidator
+}
+
+// schemaFor returns the schema for the request, or nil if there is none.
+func (r *schemaRegistry) schemaFor(config *requestConfig) []byte {
+	if config.responseSchema != nil {
+		return config.responseSchema
+	}
+	var match string
+	for pattern := range r.byPath {
+		if ok, _ := path.Match(pattern, config.path); ok && (match == "" || pattern < match) {
+			match = pattern
+		}
+	}
+	if match == "" {
+		return nil
+	}
+	return r.byPath[match]
+}
+
+// validator compiles schema once and returns the cached validator afterwards.
+func (r *schemaRegistry) validator(schema []byte) (SchemaValidator, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := string(schema)
+	if v, ok := r.compiled[key]; ok {
+		return v, nil
+	}
+	if r.config.Compiler == nil {
+		return nil, fmt.Errorf("response schema set but no SchemaCompiler configured")
+	}
+	v, err := r.config.Compiler(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile response schema: %w", err)
+	}
+	r.compiled[key] = v
+	return v, nil
+}
+
+// validateResponseSchema validates a 2xx response body if a schema applies to the request.
+func (c *Client) validateResponseSchema(config *requestConfig, statusCode int, body []byte) error {
+	if c.schemas == nil {
+		return nil
+	}
+	schema := c.schemas.schemaFor(config)
+	if schema == nil {
+		return nil
+	}
+	if len(body) > c.schemas.config.MaxBodyBytes {
+		if c.logger != nil {
+			c.logger.Debug("response too large for schema validation", "path", config.path, "size", len(body))
+		}
+		return nil
+	}
+
+	validator, err := c.schemas.validator(schema)
+	if err != nil {
+		return err
+	}
+	violations, err := validator.Validate(body)
+	if err != nil {
+		violations = []SchemaViolation{{InstancePath: "", Message: err.Error()}}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	violationErr := &SchemaViolationError{
+		Method:     config.method,
+		Path:       config.path,
+		StatusCode: statusCode,
+		Violations: violations,
+		Body:       append([]byte(nil), body...),
+	}
+	if c.schemas.config.OnViolation != nil {
+		c.schemas.config.OnViolation(violationErr)
+	}
+	if c.schemas.config.Mode == SchemaObserve {
+		if c.logger != nil {
+			c.logger.Info("response violates schema", "method", config.method, "path", config.path, "violations", len(violations))
+		}
+		return nil
+	}
+	return violationErr
+}
@@ -0,0 +1,147 @@
+package reqws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// requiredFieldCompiler compiles schemas of the form "required:<field>" into a
+// validator that checks the field is present, and counts compilations.
+func requiredFieldCompiler(compiles *atomic.Int32) SchemaCompiler {
+	return func(schema []byte) (SchemaValidator, error) {
+		compiles.Add(1)
+		field, ok := strings.CutPrefix(string(schema), "required:")
+		if !ok {
+			return nil, errors.New("unknown schema")
+		}
+		return requiredField(field), nil
+	}
+}
+
+type requiredField string
+
+func (f requiredField) Validate(body []byte) ([]SchemaViolation, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if _, ok := doc[string(f)]; ok {
+		return nil, nil
+	}
+	return []SchemaViolation{{KeywordPath: "/required", Message: "missing " + string(f)}}, nil
+}
+
+// schemaServer returns a server answering /orders/* with an order, /users/* with
+// a user, /text with plain text and /error with a 500.
+func schemaServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/orders/"):
+			w.Write([]byte(`{"id":1,"total":5}`))
+		case strings.HasPrefix(r.URL.Path, "/users/"):
+			w.Write([]byte(`{"id":1}`))
+		case r.URL.Path == "/text":
+			w.Write([]byte("not json"))
+		default:
+			http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+		}
+	})
+	return server
+}
+
+func TestSchemaValidationStrict(t *testing.T) {
+	server := schemaServer(t)
+	var compiles atomic.Int32
+	client := NewClient(server.URL, 5*time.Second).
+		WithSchemaValidation(SchemaValidationConfig{Compiler: requiredFieldCompiler(&compiles)}).
+		WithResponseSchemas(map[string][]byte{
+			"/orders/*": []byte("required:total"),
+			"/users/*":  []byte("required:email"),
+			"/*":        []byte("required:anything"),
+		})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Request(ctx, GET("/orders/1")); err != nil {
+			t.Fatalf("valid response: %v", err)
+		}
+	}
+	if got := compiles.Load(); got != 1 {
+		t.Errorf("compilations = %d, want the schema cached", got)
+	}
+
+	_, err := client.Do(ctx, GET("/users/1"))
+	var violationErr *SchemaViolationError
+	if !errors.As(err, &violationErr) {
+		t.Fatalf("err = %v, want a SchemaViolationError", err)
+	}
+	if violationErr.Method != "GET" || violationErr.Path != "/users/1" || violationErr.StatusCode != 200 ||
+		string(violationErr.Body) != `{"id":1}` || violationErr.Violations[0].Message != "missing email" {
+		t.Errorf("SchemaViolationError = %+v", violationErr)
+	}
+	if got := err.Error(); got != "response of GET /users/1 violates schema: (root): missing email" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	// A request schema replaces the one matched by path
+	if _, err := client.Request(ctx, GET("/users/1"), WithResponseSchema([]byte("required:id"))); err != nil {
+		t.Errorf("request schema: %v", err)
+	}
+	// Non-JSON bodies are reported as a violation of the whole body
+	if _, err := client.Request(ctx, GET("/text"), WithResponseSchema([]byte("required:id"))); !errors.As(err, &violationErr) {
+		t.Errorf("err = %v, want a SchemaViolationError for a non-JSON body", err)
+	}
+	// Error responses are not validated
+	_, err = client.Request(ctx, GET("/error"))
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Errorf("err = %v, want the HTTPError", err)
+	}
+}
+
+func TestSchemaValidationObserve(t *testing.T) {
+	server := schemaServer(t)
+	var compiles atomic.Int32
+	var observed []*SchemaViolationError
+	client := NewClient(server.URL, 5*time.Second).
+		WithResponseSchemas(map[string][]byte{"/users/*": []byte("required:email")}).
+		WithSchemaValidation(SchemaValidationConfig{
+			Compiler:    requiredFieldCompiler(&compiles),
+			Mode:        SchemaObserve,
+			OnViolation: func(err *SchemaViolationError) { observed = append(observed, err) },
+		})
+
+	body, err := client.Request(context.Background(), GET("/users/1"))
+	if err != nil || string(body) != `{"id":1}` {
+		t.Fatalf("Request = %s, %v; want the response unchanged", body, err)
+	}
+	if len(observed) != 1 || observed[0].Path != "/users/1" {
+		t.Errorf("OnViolation calls = %v", observed)
+	}
+
+	// A schema that does not compile fails the call in either mode
+	if _, err := client.Request(context.Background(), GET("/users/1"), WithResponseSchema([]byte("bogus"))); err == nil {
+		t.Error("expected an error for a schema that fails to compile")
+	}
+}
+
+func TestSchemaValidationSkipsLargeBodies(t *testing.T) {
+	server := schemaServer(t)
+	var compiles atomic.Int32
+	client := NewClient(server.URL, 5*time.Second).
+		WithSchemaValidation(SchemaValidationConfig{Compiler: requiredFieldCompiler(&compiles), MaxBodyBytes: 4})
+	if _, err := client.Request(context.Background(), GET("/users/1"), WithResponseSchema([]byte("required:email"))); err != nil {
+		t.Errorf("Request: %v, want bodies over MaxBodyBytes skipped", err)
+	}
+	if compiles.Load() != 0 {
+		t.Error("schema compiled for a skipped body")
+	}
+}
@@ -0,0 +1,188 @@
+package reqws
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShedConfig defines the configuration for client-side load shedding.
+type ShedConfig struct {
+	LatencyThreshold  time.Duration // p95 latency above which sheddable requests are rejected
+	RecoveryThreshold time.Duration // p95 latency below which shedding stops (default: 80% of LatencyThreshold)
+	MaxInFlight       int           // In-flight requests above which sheddable requests are rejected (0 = no cap)
+	Window            time.Duration // Rolling window for latency samples (default: 10s)
+	MaxSamples        int           // Maximum latency samples kept in the window (default: 1000)
+}
+
+// ShedStats holds load shedding counters.
+type ShedStats struct {
+	Allowed  uint64        // Requests admitted
+	Shed     uint64        // Sheddable requests rejected
+	InFlight int           // Requests currently in flight
+	P95      time.Duration // Current rolling p95 latency
+	Shedding bool          // Whether latency-based shedding is active
+}
+
+// ShedError is returned when a sheddable request is rejected by load shedding.
+type ShedError struct {
+	Reason   string
+	InFlight int
+	P95      time.Duration
+}
+
+func (e *ShedError) Error() string {
+	return fmt.Sprintf("request shed: %s (in-flight: %d, p95: %s)", e.Reason, e.InFlight, e.P95)
+}
+
+// WithLoadShedding enables client-side load shedding.
+// The client tracks a rolling p95 latency and the number of in-flight requests.
+// While p95 exceeds LatencyThreshold, or in-flight exceeds MaxInFlight, requests
+// marked with WithSheddable() fail immediately with a *ShedError instead of adding
+// load to a degraded upstream. Other requests always pass.
+//
+// Latency shedding uses hysteresis: once active, it only stops after p95 drops
+// below RecoveryThreshold.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithLoadShedding(reqws.ShedConfig{
+//			LatencyThreshold: 2 * time.Second,
+//			MaxInFlight:      100,
+//		})
+func (c *Client) WithLoadShedding(config ShedConfig) *Client {
+	if config.RecoveryThreshold <= 0 {
+		config.RecoveryThreshold = config.LatencyThreshold * 8 / 10
+	}
+	if config.Window <= 0 {
+		config.Window = 10 * time.Second
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = 1000
+	}
+	c.shedder = &loadShedder{config: config}
+	return c
+}
+
+// ShedStats returns the current load shedding counters.
+// Returns zero values if load shedding is not enabled.
+func (c *Client) ShedStats() ShedStats {
+	if c.shedder == nil {
+		return ShedStats{}
+	}
+	return c.shedder.stats()
+}
+
+// WithSheddable marks the request as sheddable under load.
+// Only has an effect when the client has load shedding enabled via WithLoadShedding().
+func WithSheddable() RequestOption {
+	return func(c *requestConfig) {
+		c.sheddable = true
+	}
+}
+
+type latencySample struct {
+	at      time.Time
+	latency time.Duration
+}
+
+type loadShedder struct {
+	config ShedConfig
+
+	mu       sync.Mutex
+	samples  []latencySample
+	inFlight int
+	shedding bool
+	allowed  uint64
+	shed     uint64
+}
+
+// admit decides whether a request may proceed and counts it as in flight.
+// Callers must call done() once an admitted request finishes.
+func (s *loadShedder) admit(sheddable bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p95 := s.updateLocked(time.Now())
+
+	if sheddable {
+		reason := ""
+		if s.shedding {
+			reason = "latency above threshold"
+		} else if s.config.MaxInFlight > 0 && s.inFlight >= s.config.MaxInFlight {
+			reason = "too many in-flight requests"
+		}
+		if reason != "" {
+			s.shed++
+			return &ShedError{Reason: reason, InFlight: s.inFlight, P95: p95}
+		}
+	}
+
+	s.inFlight++
+	s.allowed++
+	return nil
+}
+
+// done marks an admitted request as finished.
+func (s *loadShedder) done() {
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+}
+
+// observe records the latency of a single HTTP round trip.
+func (s *loadShedder) observe(latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples = append(s.samples, latencySample{at: time.Now(), latency: latency})
+	if len(s.samples) > s.config.MaxSamples {
+		s.samples = s.samples[len(s.samples)-s.config.MaxSamples:]
+	}
+}
+
+func (s *loadShedder) stats() ShedStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p95 := s.updateLocked(time.Now())
+	return ShedStats{
+		Allowed:  s.allowed,
+		Shed:     s.shed,
+		InFlight: s.inFlight,
+		P95:      p95,
+		Shedding: s.shedding,
+	}
+}
+
+// updateLocked drops samples outside the window, recomputes p95 and
+// updates the shedding state with hysteresis. s.mu must be held.
+func (s *loadShedder) updateLocked(now time.Time) time.Duration {
+	cutoff := now.Add(-s.config.Window)
+	i := 0
+	for i < len(s.samples) && s.samples[i].at.Before(cutoff) {
+		i++
+	}
+	s.samples = s.samples[i:]
+
+	var p95 time.Duration
+	if len(s.samples) > 0 {
+		latencies := make([]time.Duration, len(s.samples))
+		for j, sample := range s.samples {
+			latencies[j] = sample.latency
+		}
+		sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+		p95 = latencies[(len(latencies)-1)*95/100]
+	}
+
+	if s.config.LatencyThreshold > 0 {
+		if !s.shedding && p95 > s.config.LatencyThreshold {
+			s.shedding = true
+		} else if s.shedding && p95 < s.config.RecoveryThreshold {
+			s.shedding = false
+		}
+	}
+	return p95
+}
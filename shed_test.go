@@ -0,0 +1,100 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLoadSheddingOnLatency(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+	})
+	client := NewClient(server.URL, 5*time.Second).WithLoadShedding(ShedConfig{LatencyThreshold: 10 * time.Millisecond})
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, GET("/slow"), WithSheddable()); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+
+	_, err := client.Do(ctx, GET("/"), WithSheddable())
+	var shedErr *ShedError
+	if !errors.As(err, &shedErr) {
+		t.Fatalf("sheddable request: err = %v, want a ShedError", err)
+	}
+	if shedErr.P95 < 30*time.Millisecond {
+		t.Errorf("ShedError.P95 = %v, want the slow request's latency", shedErr.P95)
+	}
+	if stats := client.ShedStats(); !stats.Shedding || stats.Shed != 1 || stats.Allowed != 1 {
+		t.Errorf("stats = %+v, want shedding with 1 shed and 1 allowed", stats)
+	}
+	if _, err := client.Do(ctx, GET("/")); err != nil {
+		t.Errorf("request that is not sheddable: %v", err)
+	}
+}
+
+func TestLoadSheddingOnInFlight(t *testing.T) {
+	arrived, release := make(chan struct{}), make(chan struct{})
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-release
+	})
+	client := NewClient(server.URL, 5*time.Second).WithLoadShedding(ShedConfig{MaxInFlight: 1})
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Do(context.Background(), GET("/"))
+		done <- err
+	}()
+	<-arrived
+
+	_, err := client.Do(context.Background(), GET("/"), WithSheddable())
+	var shedErr *ShedError
+	if !errors.As(err, &shedErr) || shedErr.InFlight != 1 {
+		t.Errorf("err = %v, want a ShedError with 1 request in flight", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("in-flight request: %v", err)
+	}
+	if got := client.ShedStats().InFlight; got != 0 {
+		t.Errorf("InFlight = %d after the request finished, want 0", got)
+	}
+}
+
+func TestLoadShedderHysteresis(t *testing.T) {
+	s := &loadShedder{config: ShedConfig{
+		LatencyThreshold:  100 * time.Millisecond,
+		RecoveryThreshold: 50 * time.Millisecond,
+		Window:            time.Hour,
+		MaxSamples:        1,
+	}}
+	steps := []struct {
+		latency  time.Duration
+		shedding bool
+	}{
+		{20 * time.Millisecond, false},
+		{150 * time.Millisecond, true},
+		{80 * time.Millisecond, true}, // Below the threshold but above recovery
+		{40 * time.Millisecond, false},
+		{80 * time.Millisecond, false},
+	}
+	for i, step := range steps {
+		s.observe(step.latency)
+		if got := s.stats().Shedding; got != step.shedding {
+			t.Errorf("step %d (p95 %v): shedding = %v, want %v", i, step.latency, got, step.shedding)
+		}
+	}
+}
+
+func TestLoadShedderDropsSamplesOutsideWindow(t *testing.T) {
+	s := &loadShedder{config: ShedConfig{LatencyThreshold: 10 * time.Millisecond, Window: time.Minute, MaxSamples: 10}}
+	s.samples = []latencySample{{at: time.Now().Add(-2 * time.Minute), latency: time.Second}}
+	if err := s.admit(true); err != nil {
+		t.Errorf("admit with only expired samples: %v", err)
+	}
+}
@@ -0,0 +1,405 @@
+package reqws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSSELineSize is the longest server-sent event line OpenSSE accepts.
+const maxSSELineSize = 1 << 20
+
+// defaultSSEBuffer is the buffer of the event channel returned by OpenSSE.
+const defaultSSEBuffer = 16
+
+// StreamState is a connection lifecycle state of a reconnecting stream.
+type StreamState string
+
+const (
+	StreamConnected    StreamState = "connected"    // A connection was established
+	StreamReconnecting StreamState = "reconnecting" // The connection dropped; a reconnect follows after the delay
+	StreamGaveUp       StreamState = "gave_up"      // MaxReconnectAttempts was reached; the stream ends
+)
+
+// SSEEvent is a server-sent event, or a lifecycle event if State is set.
+type SSEEvent struct {
+	ID    string // Last event ID, carried over from earlier events if this one had none
+	Event string // Event type ("message" if the server sent none)
+	Data  string // Data lines joined with "\n"
+
+	State   StreamState   // Lifecycle events only; ID, Event and Data are empty
+	Attempt int           // Reconnect attempt, for StreamReconnecting and StreamGaveUp
+	Delay   time.Duration // Wait before the reconnect, for StreamReconnecting
+	Err     error         // Why the connection dropped, for StreamReconnecting and StreamGaveUp
+}
+
+// SSEConfig defines reconnection of a server-sent event stream. The fields
+// mirror WebSocketConfig.
+type SSEConfig struct {
+	AutoReconnect        bool          // Enable automatic reconnection on disconnect
+	MaxReconnectAttempts int           // Maximum number of reconnection attempts (0 = infinite)
+	ReconnectDelay       time.Duration // Initial delay before reconnection; the server's retry: field replaces it
+	MaxReconnectDelay    time.Duration // Maximum delay between reconnections
+	ReconnectMultiplier  float64       // Backoff multiplier for reconnection delay
+	Jitter               float64       // Randomize each delay by up to this fraction, 0-1
+	StableAfter          time.Duration // A connection open this long resets the attempts and delay (default: 30s)
+	OnReconnect          func()        // Callback function called on each reconnection attempt
+}
+
+// DefaultSSEConfig returns the default SSE reconnection configuration:
+// - MaxReconnectAttempts: 10
+// - ReconnectDelay: 1s
+// - MaxReconnectDelay: 30s
+// - ReconnectMultiplier: 2.0 (exponential backoff)
+// - Jitter: 0.2
+// - StableAfter: 30s
+func DefaultSSEConfig() SSEConfig {
+	return SSEConfig{
+		AutoReconnect:        true,
+		MaxReconnectAttempts: 10,
+		ReconnectDelay:       1 * time.Second,
+		MaxReconnectDelay:    30 * time.Second,
+		ReconnectMultiplier:  2.0,
+		Jitter:               0.2,
+		StableAfter:          30 * time.Second,
+	}
+}
+
+// WithSSEReconnect enables reconnection of streams opened with OpenSSE.
+func WithSSEReconnect(config SSEConfig) RequestOption {
+	return func(c *requestConfig) {
+		if config.ReconnectMultiplier < 1 {
+			config.ReconnectMultiplier = 1
+		}
+		if config.MaxReconnectDelay <= 0 {
+			config.MaxReconnectDelay = 30 * time.Second
+		}
+		if config.StableAfter <= 0 {
+			config.StableAfter = 30 * time.Second
+		}
+		config.Jitter = min(max(config.Jitter, 0), 1)
+		c.sseConfig = &config
+	}
+}
+
+// SSEStoppedError is returned by OpenSSE when the server answers a connection
+// with 204 No Content, which tells the client to stop reconnecting.
+type SSEStoppedError struct {
+	LastEventID string // ID of the last event received before the server stopped the stream
+}
+
+func (e *SSEStoppedError) Error() string {
+	return "sse stream stopped by server (204 No Content)"
+}
+
+// errSSEContentType is returned for a response that is not an event stream.
+var errSSEContentType = errors.New("sse: unexpected content type")
+
+// errSSEEnded reports a connection the server closed normally, which reconnects.
+var errSSEEnded = errors.New("sse stream ended by server")
+
+// OpenSSE GETs a server-sent event stream and returns channels owned by the
+// library: events delivers received events and errs the error that ended the
+// stream. The first connection is made before OpenSSE returns, and its failure is
+// returned as err.
+//
+// With WithSSEReconnect(), dropped connections are reconnected with backoff,
+// starting from the server's retry: field if it sent one, and send the
+// Last-Event-ID header so the server can resume. A connection that stayed open
+// for StableAfter resets the attempt counter and delay. Lifecycle events with
+// State set report every connection, reconnect and giving up. Transport errors,
+// 5xx, 408 and 429 responses reconnect; other non-2xx responses end the stream
+// with *HTTPError, and a 204 response ends it with *SSEStoppedError.
+//
+// events is closed exactly once after the stream has permanently ended, then errs
+// delivers the terminal error, if any, and is closed. Without reconnection, a
+// stream the server ends normally delivers no error. events is buffered (16
+// events); once it is full the stream stops reading until it is drained.
+//
+// The client timeout covers each connection; use a client without a timeout for
+// long-lived streams and bound them with ctx instead.
+//
+// Example:
+//
+//	events, errs, err := client.OpenSSE(ctx,
+//		reqws.WithPath("/events"),
+//		reqws.WithSSEReconnect(reqws.DefaultSSEConfig()),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	for ev := range events {
+//		if ev.State == "" {
+//			handle(ev.Event, ev.Data)
+//		}
+//	}
+//	return <-errs
+func (c *Client) OpenSSE(ctx context.Context, opts ...RequestOption) (<-chan SSEEvent, <-chan error, error) {
+	config := newRequestConfig(http.MethodGet, opts)
+	config.streamBufferSize = maxSSELineSize
+
+	resp, err := c.sseConnect(ctx, config, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan SSEEvent, defaultSSEBuffer)
+	errs := make(chan error, 1)
+	go func() {
+		err := c.sseRun(ctx, config, resp, events)
+		close(events)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return events, errs, nil
+}
+
+// sseStream is the state carried across the connections of a stream.
+type sseStream struct {
+	lastEventID string
+	retry       time.Duration // From the server's retry: field, 0 if none was sent
+}
+
+// baseDelay returns the first reconnect delay: the server's retry: field, or ReconnectDelay.
+func (s *sseStream) baseDelay(rc *SSEConfig) time.Duration {
+	if s.retry > 0 {
+		return s.retry
+	}
+	return rc.ReconnectDelay
+}
+
+// sseRun serves connections until the stream permanently ends. resp is the
+// already established first connection.
+func (c *Client) sseRun(ctx context.Context, config *requestConfig, resp *http.Response, events chan<- SSEEvent) error {
+	rc := config.sseConfig
+	reconnect := rc != nil && rc.AutoReconnect
+	stream := &sseStream{}
+	attempt := 0
+	var delay time.Duration
+	if reconnect {
+		delay = rc.ReconnectDelay
+	}
+
+	for {
+		var err error
+		if resp == nil {
+			resp, err = c.sseConnect(ctx, config, stream.lastEventID)
+		}
+		if err == nil {
+			if !sseSend(ctx, events, SSEEvent{State: StreamConnected, Attempt: attempt}) {
+				resp.Body.Close()
+				return ctx.Err()
+			}
+			start := time.Now()
+			retry := stream.retry
+			err = c.sseRead(ctx, resp.Body, stream, events)
+			resp.Body.Close()
+
+			if reconnect {
+				// A stable connection starts over; a retry: field replaces the base delay
+				if time.Since(start) >= rc.StableAfter {
+					attempt = 0
+					delay = stream.baseDelay(rc)
+				} else if stream.retry != retry {
+					delay = stream.baseDelay(rc)
+				}
+			}
+		}
+		resp = nil
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !reconnect || !isSSERetryable(err) {
+			if errors.Is(err, errSSEEnded) {
+				return nil
+			}
+			return err
+		}
+
+		attempt++
+		if rc.MaxReconnectAttempts > 0 && attempt >= rc.MaxReconnectAttempts {
+			if c.logger != nil {
+				c.logger.Error("max SSE reconnection attempts reached", "attempts", attempt, "error", err)
+			}
+			sseSend(ctx, events, SSEEvent{State: StreamGaveUp, Attempt: attempt, Err: err})
+			return fmt.Errorf("sse: max reconnection attempts exceeded: %w", err)
+		}
+
+		wait := jitterDelay(delay, rc.Jitter)
+		if c.logger != nil {
+			c.logger.Info("SSE disconnected, will retry", "attempt", attempt, "delay", wait, "error", err)
+		}
+		if !sseSend(ctx, events, SSEEvent{State: StreamReconnecting, Attempt: attempt, Delay: wait, Err: err}) {
+			return ctx.Err()
+		}
+		if rc.OnReconnect != nil {
+			rc.OnReconnect()
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay = min(time.Duration(float64(delay)*rc.ReconnectMultiplier), rc.MaxReconnectDelay)
+	}
+}
+
+// sseConnect sends the stream request, resuming after lastEventID if set.
+func (c *Client) sseConnect(ctx context.Context, config *requestConfig, lastEventID string) (*http.Response, error) {
+	attempt := *config
+	attempt.headers = config.headers.Clone()
+	if attempt.headers == nil {
+		attempt.headers = make(http.Header)
+	}
+	attempt.headers.Set("Accept", "text/event-stream")
+	attempt.headers.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		attempt.headers.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.execute(ctx, &attempt)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		resp.Body.Close()
+		return nil, &SSEStoppedError{LastEventID: lastEventID}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, NewHTTPError(resp.StatusCode, body)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w %q", errSSEContentType, resp.Header.Get("Content-Type"))
+	}
+	return resp, nil
+}
+
+// sseRead parses events from body and delivers them until the connection ends.
+// Returns errSSEEnded if the server closed the stream.
+func (c *Client) sseRead(ctx context.Context, body io.Reader, stream *sseStream, events chan<- SSEEvent) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineSize)
+	scanner.Split(scanSSELines)
+
+	var eventType string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			// A blank line dispatches the event
+			if data.Len() > 0 {
+				ev := SSEEvent{ID: stream.lastEventID, Event: eventType, Data: strings.TrimSuffix(data.String(), "\n")}
+				if ev.Event == "" {
+					ev.Event = "message"
+				}
+				if !sseSend(ctx, events, ev) {
+					return ctx.Err()
+				}
+			}
+			eventType = ""
+			data.Reset()
+			continue
+		}
+		if line[0] == ':' {
+			continue // Comment, often a keep-alive
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				stream.lastEventID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				stream.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	// An event not ended by a blank line is discarded
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errSSEEnded
+}
+
+// scanSSELines splits lines ended by "\r\n", "\n" or "\r".
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// "\r" may be followed by "\n" in the next read
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// isSSERetryable reports whether a stream ended by err reconnects.
+func isSSERetryable(err error) bool {
+	var stopped *SSEStoppedError
+	if errors.As(err, &stopped) {
+		return false
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		code := httpErr.StatusCode
+		return code >= 500 || code == http.StatusRequestTimeout || code == http.StatusTooManyRequests
+	}
+	return !errors.Is(err, errSSEContentType)
+}
+
+// sseSend delivers ev unless ctx is done first.
+func sseSend(ctx context.Context, events chan<- SSEEvent, ev SSEEvent) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// jitterDelay randomizes delay by up to ±fraction.
+func jitterDelay(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || delay <= 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 + fraction*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,250 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sseServer returns a server that answers the nth stream connection (from 1)
+// with handler and records each connection request.
+func sseServer(t *testing.T, handler func(n int, w http.ResponseWriter)) (*httptest.Server, *requestLog) {
+	t.Helper()
+	var conns *requestLog
+	server, conns := recordingServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("Accept = %q", r.Header.Get("Accept"))
+		}
+		handler(conns.Count(), w)
+	})
+	return server, conns
+}
+
+// writeSSE answers with an event stream body.
+func writeSSE(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	io.WriteString(w, body)
+}
+
+// collectSSE drains events and returns them with the terminal error.
+func collectSSE(t *testing.T, events <-chan SSEEvent, errs <-chan error) ([]SSEEvent, error) {
+	t.Helper()
+	var got []SSEEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return got, <-errs
+			}
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatal("stream did not end")
+		}
+	}
+}
+
+// sseSummary formats events as "event:data" or "state/attempt" for comparison.
+func sseSummary(events []SSEEvent) string {
+	var parts []string
+	for _, ev := range events {
+		if ev.State != "" {
+			parts = append(parts, fmt.Sprintf("%s/%d", ev.State, ev.Attempt))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s:%s:%q", ev.ID, ev.Event, ev.Data))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestOpenSSEParsesEvents(t *testing.T) {
+	server, _ := sseServer(t, func(n int, w http.ResponseWriter) {
+		writeSSE(w, ": keep-alive\n"+
+			"data: first\n\n"+
+			"id: 7\r\nevent: update\r\ndata: line one\r\ndata:line two\r\n\r\n"+
+			"id: bad\x00id\rdata: carries id 7\r\r"+
+			"event: empty\n\n"+
+			"data: not dispatched")
+	})
+	events, errs, err := NewClient(server.URL, 5*time.Second).OpenSSE(context.Background(), WithPath("/events"))
+	if err != nil {
+		t.Fatalf("OpenSSE: %v", err)
+	}
+	got, err := collectSSE(t, events, errs)
+	if err != nil {
+		t.Errorf("terminal error = %v, want none for a normally ended stream", err)
+	}
+	want := `connected/0 :message:"first" 7:update:"line one\nline two" 7:message:"carries id 7"`
+	if summary := sseSummary(got); summary != want {
+		t.Errorf("events =\n%s\nwant\n%s", summary, want)
+	}
+}
+
+func TestOpenSSEConnectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter)
+		check   func(err error) bool
+	}{
+		{"HTTP error", func(w http.ResponseWriter) { http.Error(w, "gone", http.StatusNotFound) }, func(err error) bool {
+			var httpErr *HTTPError
+			return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+		}},
+		{"not an event stream", func(w http.ResponseWriter) { w.Header().Set("Content-Type", "application/json") }, func(err error) bool {
+			return errors.Is(err, errSSEContentType)
+		}},
+		{"204 stops", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNoContent) }, func(err error) bool {
+			var stopped *SSEStoppedError
+			return errors.As(err, &stopped)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := sseServer(t, func(_ int, w http.ResponseWriter) { tt.handler(w) })
+			events, _, err := NewClient(server.URL, 5*time.Second).OpenSSE(context.Background(),
+				WithSSEReconnect(SSEConfig{AutoReconnect: true, ReconnectDelay: time.Millisecond}))
+			if events != nil || !tt.check(err) {
+				t.Errorf("OpenSSE = %v, %v", events, err)
+			}
+		})
+	}
+}
+
+func TestOpenSSEReconnectResumes(t *testing.T) {
+	server, conns := sseServer(t, func(n int, w http.ResponseWriter) {
+		switch n {
+		case 1:
+			writeSSE(w, "retry: 10\nid: 1\ndata: a\n\n")
+		case 2:
+			writeSSE(w, "id: 2\ndata: b\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	var reconnects atomic.Int32
+	events, errs, err := NewClient(server.URL, 5*time.Second).OpenSSE(context.Background(),
+		WithSSEReconnect(SSEConfig{
+			AutoReconnect:  true,
+			ReconnectDelay: time.Minute, // Replaced by the server's retry: field
+			OnReconnect:    func() { reconnects.Add(1) },
+		}))
+	if err != nil {
+		t.Fatalf("OpenSSE: %v", err)
+	}
+	got, err := collectSSE(t, events, errs)
+
+	var stopped *SSEStoppedError
+	if !errors.As(err, &stopped) || stopped.LastEventID != "2" {
+		t.Errorf("terminal error = %v, want SSEStoppedError after event 2", err)
+	}
+	want := `connected/0 1:message:"a" reconnecting/1 connected/1 2:message:"b" reconnecting/2`
+	if summary := sseSummary(got); summary != want {
+		t.Errorf("events =\n%s\nwant\n%s", summary, want)
+	}
+	for _, ev := range got {
+		if ev.State == StreamReconnecting && (ev.Delay != 10*time.Millisecond || !errors.Is(ev.Err, errSSEEnded)) {
+			t.Errorf("reconnecting event = %+v, want the retry: delay after a normal end", ev)
+		}
+	}
+	var lastIDs []string
+	for _, req := range conns.All() {
+		lastIDs = append(lastIDs, req.Header.Get("Last-Event-ID"))
+	}
+	if got, want := strings.Join(lastIDs, ","), ",1,2"; got != want {
+		t.Errorf("Last-Event-ID per connection = %q, want %q", got, want)
+	}
+	if got := reconnects.Load(); got != 2 {
+		t.Errorf("OnReconnect calls = %d, want 2", got)
+	}
+}
+
+func TestOpenSSEGivesUp(t *testing.T) {
+	server, _ := sseServer(t, func(n int, w http.ResponseWriter) {
+		if n == 1 {
+			writeSSE(w, "data: a\n\n")
+			return
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	events, errs, err := NewClient(server.URL, 5*time.Second).OpenSSE(context.Background(),
+		WithSSEReconnect(SSEConfig{AutoReconnect: true, MaxReconnectAttempts: 3, ReconnectDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("OpenSSE: %v", err)
+	}
+	got, err := collectSSE(t, events, errs)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("terminal error = %v, want the last 503", err)
+	}
+	want := `connected/0 :message:"a" reconnecting/1 reconnecting/2 gave_up/3`
+	if summary := sseSummary(got); summary != want {
+		t.Errorf("events =\n%s\nwant\n%s", summary, want)
+	}
+}
+
+func TestOpenSSEStopsOnPermanentError(t *testing.T) {
+	server, _ := sseServer(t, func(n int, w http.ResponseWriter) {
+		if n == 1 {
+			writeSSE(w, "data: a\n\n")
+			return
+		}
+		http.Error(w, "expired", http.StatusUnauthorized)
+	})
+	events, errs, err := NewClient(server.URL, 5*time.Second).OpenSSE(context.Background(),
+		WithSSEReconnect(SSEConfig{AutoReconnect: true, ReconnectDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("OpenSSE: %v", err)
+	}
+	got, err := collectSSE(t, events, errs)
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("terminal error = %v, want the 401", err)
+	}
+	want := `connected/0 :message:"a" reconnecting/1`
+	if summary := sseSummary(got); summary != want {
+		t.Errorf("events =\n%s\nwant\n%s", summary, want)
+	}
+}
+
+func TestOpenSSEContextCancel(t *testing.T) {
+	server, _ := sseServer(t, func(n int, w http.ResponseWriter) {
+		writeSSE(w, "data: a\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs, err := NewClient(server.URL, 5*time.Second).OpenSSE(ctx,
+		WithSSEReconnect(SSEConfig{AutoReconnect: true, ReconnectDelay: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("OpenSSE: %v", err)
+	}
+	for ev := range events {
+		if ev.Data == "a" {
+			cancel()
+		}
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("terminal error = %v, want context.Canceled", err)
+	}
+}
+
+func TestJitterDelay(t *testing.T) {
+	if got := jitterDelay(time.Second, 0); got != time.Second {
+		t.Errorf("no jitter = %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := jitterDelay(time.Second, 0.2); got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("jittered delay %v outside ±20%%", got)
+		}
+	}
+}
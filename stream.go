@@ -0,0 +1,147 @@
+package reqws
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// maxStreamLineSize is the longest line StreamLines accepts.
+const maxStreamLineSize = 1 << 20
+
+// streamReadSize is the memory budget reserved for a DoStream body, which the
+// caller reads piece by piece.
+const streamReadSize = 32 << 10
+
+// StreamResponse is a response whose body is read as it arrives.
+// The caller must close Body.
+type StreamResponse struct {
+	Body       io.ReadCloser
+	Headers    http.Header
+	StatusCode int
+	TLS        *tls.ConnectionState // Negotiated TLS state, nil for plain HTTP
+	IPFamily   IPFamily             // Address family of the connection, see Response.IPFamily
+
+	resp *http.Response
+	eof  *atomic.Bool
+}
+
+// Trailers returns the trailers sent after the body, e.g. grpc-status or a
+// checksum. They are only known once Body has returned io.EOF; before that
+// Trailers returns nil.
+func (r *StreamResponse) Trailers() http.Header {
+	if !r.eof.Load() {
+		return nil
+	}
+	return r.resp.Trailer
+}
+
+// DoStream sends the request and returns the response without reading its body,
+// for large downloads or bodies that arrive over time. Like Do, it does not return
+// an error for non-2xx status codes. Retries only cover the attempt up to the
+// response headers.
+//
+// Example:
+//
+//	resp, err := client.DoStream(ctx, reqws.GET("/export"))
+//	if err != nil {
+//		return err
+//	}
+//	defer resp.Body.Close()
+//	if _, err := io.Copy(dst, resp.Body); err != nil {
+//		return err
+//	}
+//	checksum := resp.Trailers().Get("X-Checksum")
+func (c *Client) DoStream(ctx context.Context, opts ...RequestOption) (*StreamResponse, error) {
+	config := newRequestConfig(http.MethodGet, opts)
+	config.streamBufferSize = streamReadSize
+
+	resp, err := c.execute(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	eof := &atomic.Bool{}
+	return &StreamResponse{
+		Body:       &trailerBody{ReadCloser: resp.Body, eof: eof},
+		Headers:    resp.Header,
+		StatusCode: resp.StatusCode,
+		TLS:        resp.TLS,
+		IPFamily:   config.ipFamily,
+		resp:       resp,
+		eof:        eof,
+	}, nil
+}
+
+// trailerBody records when the body was read to EOF, after which the response
+// trailers are set.
+type trailerBody struct {
+	io.ReadCloser
+	eof *atomic.Bool
+}
+
+func (b *trailerBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.eof.Store(true)
+	}
+	return n, err
+}
+
+// StreamLines sends the request and emits each line of the response body to out as it
+// arrives, without the trailing newline (a trailing "\r" is removed as well).
+// It is meant for endpoints that stream plain text, like log tails.
+//
+// out is always closed when StreamLines returns. The returned error is nil at EOF,
+// ctx.Err() if the context was cancelled, *HTTPError for non-2xx responses, and the
+// read error otherwise (bufio.ErrTooLong for lines longer than 1MB).
+//
+// Example:
+//
+//	lines := make(chan string, 100)
+//	go func() {
+//		for line := range lines {
+//			fmt.Println(line)
+//		}
+//	}()
+//	err := client.StreamLines(ctx, lines, reqws.GET("/logs/tail"))
+func (c *Client) StreamLines(ctx context.Context, out chan<- string, opts ...RequestOption) error {
+	defer close(out)
+
+	config := newRequestConfig(http.MethodGet, opts)
+	config.streamBufferSize = maxStreamLineSize
+
+	resp, err := c.execute(ctx, config)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return NewHTTPError(resp.StatusCode, body)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		select {
+		case out <- scanner.Text():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package reqws
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDoStreamTrailers(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		io.WriteString(w, "chunk one\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "chunk two\n")
+		w.Header().Set("X-Checksum", "abc123")
+		w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+	})
+
+	resp, err := NewClient(server.URL, 5*time.Second).DoStream(context.Background(), GET("/export"))
+	if err != nil {
+		t.Fatalf("DoStream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.IPFamily != IPv4 {
+		t.Errorf("status = %d, IP family = %q", resp.StatusCode, resp.IPFamily)
+	}
+	if trailers := resp.Trailers(); trailers != nil {
+		t.Errorf("Trailers before EOF = %v, want nil", trailers)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(body) != "chunk one\nchunk two\n" {
+		t.Errorf("body = %q", body)
+	}
+	trailers := resp.Trailers()
+	if trailers.Get("X-Checksum") != "abc123" || trailers.Get("Grpc-Status") != "0" {
+		t.Errorf("Trailers = %v, want the declared and the undeclared trailer", trailers)
+	}
+}
+
+func TestDoStreamNonSuccessStatus(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "missing", http.StatusNotFound)
+	})
+
+	resp, err := NewClient(server.URL, 5*time.Second).DoStream(context.Background(), GET("/export"))
+	if err != nil {
+		t.Fatalf("DoStream: %v, want no error for a 404", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusNotFound || string(body) != "missing\n" {
+		t.Errorf("response = %d %q", resp.StatusCode, body)
+	}
+	if trailers := resp.Trailers(); trailers != nil && len(trailers) != 0 {
+		t.Errorf("Trailers = %v, want none", trailers)
+	}
+}
+
+func TestStreamLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantLines []string
+		check     func(err error) bool
+	}{
+		{"lines", http.StatusOK, "first\r\nsecond\n\nlast", []string{"first", "second", "", "last"}, func(err error) bool { return err == nil }},
+		{"HTTP error", http.StatusBadGateway, "upstream down", nil, func(err error) bool {
+			var httpErr *HTTPError
+			return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusBadGateway
+		}},
+		{"line too long", http.StatusOK, "ok\n" + strings.Repeat("x", maxStreamLineSize+1), []string{"ok"}, func(err error) bool {
+			return errors.Is(err, bufio.ErrTooLong)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			})
+
+			out := make(chan string, 10)
+			err := NewClient(server.URL, 5*time.Second).StreamLines(context.Background(), out, GET("/logs/tail"))
+			if !tt.check(err) {
+				t.Errorf("StreamLines error = %v", err)
+			}
+			var lines []string
+			for line := range out {
+				lines = append(lines, line)
+			}
+			if strings.Join(lines, "|") != strings.Join(tt.wantLines, "|") {
+				t.Errorf("lines = %q, want %q", lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestStreamLinesContextCancel(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 100; i++ {
+			io.WriteString(w, "line\n")
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- NewClient(server.URL, 5*time.Second).StreamLines(ctx, out, GET("/logs/tail"))
+	}()
+	<-out
+	cancel()
+
+	// The unread channel doesn't block StreamLines once ctx is done
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("StreamLines error = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("StreamLines did not return after cancel")
+	}
+	if _, ok := <-out; ok {
+		t.Error("out was not closed")
+	}
+}
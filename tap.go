@@ -0,0 +1,190 @@
+package reqws
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultTapPreviewSize is how many bytes of a request body a TapEvent keeps by default.
+const defaultTapPreviewSize = 256
+
+// TapEventType identifies the kind of a TapEvent.
+type TapEventType string
+
+const (
+	TapRequest   TapEventType = "request"   // A request attempt is being sent
+	TapResponse  TapEventType = "response"  // A response was received
+	TapError     TapEventType = "error"     // A request or WebSocket dial failed
+	TapWebSocket TapEventType = "websocket" // A WebSocket connected, closed, is reconnecting or suspended
+)
+
+// TapEvent is a lightweight summary of client traffic delivered to taps.
+// Headers are never included; request bodies are truncated to the preview size.
+type TapEvent struct {
+	Type        TapEventType  `json:"type"`
+	Time        time.Time     `json:"time"`
+	Method      string        `json:"method,omitempty"`
+	URL         string        `json:"url"`
+	Attempt     int           `json:"attempt,omitempty"`      // Retry attempt (1 = first) or reconnect attempt
+	StatusCode  int           `json:"status,omitempty"`       // Response status code
+	Duration    time.Duration `json:"duration,omitempty"`     // Time until the response or error
+	BodySize    int64         `json:"body_size,omitempty"`    // Full body size, if known
+	BodyPreview string        `json:"body_preview,omitempty"` // Start of the request body
+	State       string        `json:"state,omitempty"`        // WebSocket state: "connected", "closed", "reconnecting", "suspended" or "forced_reconnect"
+	IPFamily    IPFamily      `json:"ip_family,omitempty"`    // Address family of the connection that served a response
+	Error       string        `json:"error,omitempty"`
+}
+
+type tapHub struct {
+	active      atomic.Int32
+	dropped     atomic.Uint64
+	previewSize int
+
+	mu   sync.RWMutex
+	subs map[chan TapEvent]struct{}
+}
+
+// Tap returns a channel that receives a TapEvent for every request attempt, response,
+// error and WebSocket state change of this client, plus a function that stops the tap
+// and closes the channel. Several taps can be active at once.
+//
+// Delivery never blocks the client: when a tap's buffer is full the event is dropped
+// and counted (see TapDropped). With no active taps, tapping costs a single atomic load.
+// Event URLs include the query string, so treat taps like debug logs.
+//
+// Example:
+//
+//	events, stop := client.Tap(100)
+//	defer stop()
+//	for event := range events {
+//		fmt.Println(event.Type, event.Method, event.URL, event.StatusCode)
+//	}
+func (c *Client) Tap(buffer int) (<-chan TapEvent, func()) {
+	ch := make(chan TapEvent, buffer)
+
+	c.taps.mu.Lock()
+	if c.taps.subs == nil {
+		c.taps.subs = make(map[chan TapEvent]struct{})
+	}
+	c.taps.subs[ch] = struct{}{}
+	c.taps.active.Add(1)
+	c.taps.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.taps.mu.Lock()
+			delete(c.taps.subs, ch)
+			c.taps.active.Add(-1)
+			c.taps.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// WithTapPreviewSize sets how many bytes of each request body TapEvent.BodyPreview keeps
+// (default: 256). Use a negative size to disable previews.
+func (c *Client) WithTapPreviewSize(size int) *Client {
+	c.taps.previewSize = size
+	return c
+}
+
+// TapDropped returns how many events were dropped because a tap's buffer was full.
+func (c *Client) TapDropped() uint64 {
+	return c.taps.dropped.Load()
+}
+
+// TapHandler returns an http.Handler that streams this client's tap events as NDJSON,
+// one JSON object per line, until the caller disconnects. Mount it on an internal
+// debug server only; it exposes request URLs and body previews.
+//
+// Example:
+//
+//	debugMux.Handle("/debug/reqws/tap", client.TapHandler(256))
+//
+//	// curl -N http://localhost:6060/debug/reqws/tap
+func (c *Client) TapHandler(buffer int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events, stop := c.Tap(buffer)
+		defer stop()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher, _ := w.(http.Flusher)
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		encoder := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				if err := encoder.Encode(event); err != nil {
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		}
+	})
+}
+
+// tapping reports whether any tap is active.
+func (c *Client) tapping() bool {
+	return c.taps.active.Load() > 0
+}
+
+// tap delivers event to every active tap without blocking.
+func (c *Client) tap(event TapEvent) {
+	event.Time = time.Now()
+
+	c.taps.mu.RLock()
+	defer c.taps.mu.RUnlock()
+	for ch := range c.taps.subs {
+		select {
+		case ch <- event:
+		default:
+			c.taps.dropped.Add(1)
+		}
+	}
+}
+
+// tapPreview returns the start of a request body for TapEvent.BodyPreview, or a
+// summary if the body is binary.
+func (c *Client) tapPreview(body io.Reader, contentType string) string {
+	size := c.taps.previewSize
+	if size == 0 {
+		size = defaultTapPreviewSize
+	}
+	if size < 0 {
+		return ""
+	}
+
+	var data []byte
+	switch b := body.(type) {
+	case *bytes.Buffer:
+		data = b.Bytes()
+	case *pooledBody:
+		data = b.buf.Bytes()
+	}
+	if len(data) == 0 {
+		// Streamed and raw bodies are not previewed
+		return ""
+	}
+	if isBinaryBody(contentType, data) {
+		return binarySummary(contentType, data)
+	}
+	if len(data) > size {
+		data = data[:size]
+	}
+	return strings.ToValidUTF8(string(data), "")
+}
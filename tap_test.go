@@ -0,0 +1,109 @@
+package reqws
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTapEvents(t *testing.T) {
+	server, _ := recordingServer(t, failFirst(1))
+	client := NewClient(server.URL, 5*time.Second).WithTapPreviewSize(8)
+	events, stop := client.Tap(10)
+
+	_, err := client.Do(context.Background(), POST("/orders"), WithQueryParam("page", "2"),
+		WithJSON(map[string]string{"note": "a long body"}), WithRetry(fastRetry(1)))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	stop()
+	stop() // Stopping twice is harmless
+
+	var got []TapEvent
+	for event := range events {
+		got = append(got, event)
+	}
+	want := []struct {
+		typ     TapEventType
+		attempt int
+		status  int
+	}{
+		{TapRequest, 1, 0},
+		{TapResponse, 1, http.StatusServiceUnavailable},
+		{TapRequest, 2, 0},
+		{TapResponse, 2, http.StatusOK},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		event := got[i]
+		if event.Type != w.typ || event.Attempt != w.attempt || event.StatusCode != w.status {
+			t.Errorf("event %d = %s attempt %d status %d, want %s attempt %d status %d",
+				i, event.Type, event.Attempt, event.StatusCode, w.typ, w.attempt, w.status)
+		}
+		if event.Method != http.MethodPost || event.URL != server.URL+"/orders?page=2" || event.Time.IsZero() {
+			t.Errorf("event %d = %+v", i, event)
+		}
+	}
+	if got[0].BodyPreview != `{"note":` || got[0].BodySize != int64(len(`{"note":"a long body"}`)) {
+		t.Errorf("preview = %q, size = %d", got[0].BodyPreview, got[0].BodySize)
+	}
+}
+
+func TestTapDropsWhenFull(t *testing.T) {
+	server, _ := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second).WithTapPreviewSize(-1)
+	events, stop := client.Tap(1)
+	defer stop()
+
+	if _, err := client.Do(context.Background(), POST("/"), WithJSON("body")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if event := <-events; event.Type != TapRequest || event.BodyPreview != "" {
+		t.Errorf("event = %+v, want a request event without a preview", event)
+	}
+	if got := client.TapDropped(); got != 1 {
+		t.Errorf("TapDropped = %d, want the response event dropped", got)
+	}
+}
+
+func TestTapHandler(t *testing.T) {
+	server, _ := recordingServer(t, nil)
+	client := NewClient(server.URL, 5*time.Second)
+	debug := testServer(t, client.TapHandler(10).ServeHTTP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, debug.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("tap stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// The headers are flushed once the tap is registered
+	if _, err := client.Do(context.Background(), GET("/status")); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	lines := bufio.NewScanner(resp.Body)
+	for _, want := range []TapEventType{TapRequest, TapResponse} {
+		if !lines.Scan() {
+			t.Fatalf("stream ended before the %s event: %v", want, lines.Err())
+		}
+		var event TapEvent
+		if err := json.Unmarshal(lines.Bytes(), &event); err != nil {
+			t.Fatalf("line %q: %v", lines.Text(), err)
+		}
+		if event.Type != want || !strings.HasSuffix(event.URL, "/status") {
+			t.Errorf("event = %+v, want %s for /status", event, want)
+		}
+	}
+}
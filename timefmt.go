@@ -0,0 +1,323 @@
+package reqws
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TimeFormat describes how time.Time values are written to query parameters and JSON bodies.
+type TimeFormat struct {
+	Format  TimeFormatter
+	Numeric bool // Write the value as a JSON number in bodies instead of a string
+}
+
+var (
+	// TimeFormatRFC3339 writes times like "2023-11-14T22:13:20+07:00".
+	TimeFormatRFC3339 = TimeFormatLayout(time.RFC3339, nil)
+	// TimeFormatRFC3339Nano writes times like "2023-11-14T22:13:20.123456789+07:00".
+	TimeFormatRFC3339Nano = TimeFormatLayout(time.RFC3339Nano, nil)
+	// TimeFormatUnixSeconds writes times as Unix seconds, e.g. 1700000000.
+	TimeFormatUnixSeconds = TimeFormat{Format: FormatUnix, Numeric: true}
+	// TimeFormatUnixMillis writes times as Unix milliseconds, e.g. 1700000000000.
+	TimeFormatUnixMillis = TimeFormat{Format: FormatUnixMilli, Numeric: true}
+)
+
+// TimeFormatLayout writes times with a custom layout.
+// If loc is not nil, times are converted to that location first, e.g. to always
+// send RFC 3339 with a fixed offset:
+//
+//	reqws.TimeFormatLayout(time.RFC3339, time.FixedZone("WIB", 7*60*60))
+func TimeFormatLayout(layout string, loc *time.Location) TimeFormat {
+	return TimeFormat{
+		Format: func(t time.Time) string {
+			if loc != nil {
+				t = t.In(loc)
+			}
+			return t.Format(layout)
+		},
+	}
+}
+
+// WithTimeFormat sets how time.Time values are written by this client.
+// The query format applies to WithQueryParamTime(); the body format applies to
+// time.Time values anywhere in JSON request bodies (struct fields, maps, slices).
+//
+// Without a body format, bodies use encoding/json's default (RFC 3339 with
+// nanoseconds). With one, bodies are encoded by walking the value and replacing
+// time.Time values before marshaling; json struct tags (names, omitempty, "-")
+// are honored and types implementing json.Marshaler are left to encode themselves.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second).
+//		WithTimeFormat(reqws.TimeFormatUnixMillis, reqws.TimeFormatRFC3339)
+func (c *Client) WithTimeFormat(query, body TimeFormat) *Client {
+	c.queryTimeFormat = &query
+	c.bodyTimeFormat = &body
+	return c
+}
+
+// WithOmitZeroTime omits zero time.Time values instead of formatting them.
+// Zero times are skipped in WithQueryParamTime() and dropped from JSON bodies
+// (struct fields and map entries; slice elements become null). Bodies are only
+// affected when a body format is set with WithTimeFormat().
+func (c *Client) WithOmitZeroTime() *Client {
+	c.omitZeroTime = true
+	return c
+}
+
+// WithQueryParamTime adds a query parameter holding a time, formatted with the
+// client's query time format (RFC 3339 by default).
+//
+// Example:
+//
+//	client.Request(ctx,
+//		reqws.GET("/events"),
+//		reqws.WithQueryParamTime("since", time.Now().Add(-time.Hour)),
+//	)
+func WithQueryParamTime(key string, t time.Time) RequestOption {
+	return func(c *requestConfig) {
+		c.addQuery(func(client *Client, query url.Values) error {
+			if client.omitZeroTime && t.IsZero() {
+				return nil
+			}
+			format := TimeFormatRFC3339
+			if client.queryTimeFormat != nil {
+				format = *client.queryTimeFormat
+			}
+			query.Add(key, format.Format(t))
+			return nil
+		})
+	}
+}
+
+// queryOp is one query option, applied to the query being built for a request.
+type queryOp func(c *Client, query url.Values) error
+
+// addQuery records a query option. Options only ever append, so configs copied by
+// DoPrepared share the recorded options without changing them.
+func (config *requestConfig) addQuery(op queryOp) {
+	config.query = append(config.query, op)
+}
+
+// requestQuery returns the query parameters set by the request's options, applied
+// in the order the options were given so that the values of a key keep that order
+// whichever options added them.
+func (config *requestConfig) requestQuery(c *Client) (url.Values, error) {
+	query := url.Values{}
+	for _, op := range config.query {
+		if err := op(c, query); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// buildQuery returns the query parameters for a request: those of its options,
+// in call order (see requestQuery), and the client's default parameters not set
+// by the request.
+func (c *Client) buildQuery(config *requestConfig) (url.Values, error) {
+	query, err := config.requestQuery(c)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.queryParams {
+		if _, ok := query[key]; !ok {
+			query[key] = append([]string(nil), values...)
+		}
+	}
+	return query, nil
+}
+
+// jsonBodyValue returns the value to marshal for a JSON body, applying the
+// client's body time format if one is set.
+func (c *Client) jsonBodyValue(body interface{}) interface{} {
+	if c.bodyTimeFormat == nil {
+		return body
+	}
+	value, _ := formatTimes(reflect.ValueOf(body), *c.bodyTimeFormat, c.omitZeroTime)
+	return value
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// formatTimes converts v into a JSON-ready value with time.Time values formatted.
+// The second result is true if the value should be omitted.
+func formatTimes(v reflect.Value, format TimeFormat, omitZero bool) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if omitZero && t.IsZero() {
+			return nil, true
+		}
+		formatted := format.Format(t)
+		if format.Numeric {
+			return json.Number(formatted), false
+		}
+		return formatted, false
+	}
+
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface(), false
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return formatTimes(v.Elem(), format, omitZero)
+
+	case reflect.Struct:
+		object := &orderedObject{}
+		formatStructFields(v, format, omitZero, object)
+		return object, false
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, false
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface(), false
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value, omit := formatTimes(iter.Value(), format, omitZero)
+			if !omit {
+				m[iter.Key().String()] = value
+			}
+		}
+		return m, false
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, false
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte is encoded as base64 by encoding/json
+			return v.Interface(), false
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i], _ = formatTimes(v.Index(i), format, omitZero)
+		}
+		return items, false
+
+	default:
+		return v.Interface(), false
+	}
+}
+
+// formatStructFields appends the JSON fields of struct v to object, following
+// encoding/json tag rules and flattening untagged embedded structs.
+func formatStructFields(v reflect.Value, format TimeFormat, omitZero bool, object *orderedObject) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldValue := v.Field(i)
+
+		// Untagged embedded structs have their fields promoted
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				formatStructFields(embedded, format, omitZero, object)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyJSONValue(fieldValue) {
+			continue
+		}
+
+		value, omit := formatTimes(fieldValue, format, omitZero)
+		if !omit {
+			object.fields = append(object.fields, objectField{name: name, value: value})
+		}
+	}
+}
+
+// isEmptyJSONValue mirrors encoding/json's omitempty rules.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedObject is a JSON object that keeps struct field order when marshaled.
+type orderedObject struct {
+	fields []objectField
+}
+
+type objectField struct {
+	name  string
+	value interface{}
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o.fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
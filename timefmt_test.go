@@ -0,0 +1,108 @@
+package reqws
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryParamTimeFormats(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	moment := time.Unix(1700000000, 123000000).UTC()
+	wib := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		name   string
+		client func() *Client
+		value  time.Time
+		want   string
+	}{
+		{"default RFC 3339", func() *Client { return NewClient(server.URL, 5*time.Second) }, moment, "since=2023-11-14T22%3A13%3A20Z"},
+		{"unix seconds", func() *Client {
+			return NewClient(server.URL, 5*time.Second).WithTimeFormat(TimeFormatUnixSeconds, TimeFormatRFC3339)
+		}, moment, "since=1700000000"},
+		{"unix millis", func() *Client {
+			return NewClient(server.URL, 5*time.Second).WithTimeFormat(TimeFormatUnixMillis, TimeFormatRFC3339)
+		}, moment, "since=1700000000123"},
+		{"layout in location", func() *Client {
+			return NewClient(server.URL, 5*time.Second).WithTimeFormat(TimeFormatLayout(time.DateTime, wib), TimeFormatRFC3339)
+		}, moment, "since=2023-11-15+05%3A13%3A20"},
+		{"zero kept", func() *Client { return NewClient(server.URL, 5*time.Second) }, time.Time{}, "since=0001-01-01T00%3A00%3A00Z"},
+		{"zero omitted", func() *Client { return NewClient(server.URL, 5*time.Second).WithOmitZeroTime() }, time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.client().Do(context.Background(), GET("/"), WithQueryParamTime("since", tt.value)); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Query; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type auditFields struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type timedEvent struct {
+	auditFields
+	Name      string               `json:"name"`
+	At        time.Time            `json:"at"`
+	Expires   *time.Time           `json:"expires,omitempty"`
+	Deleted   time.Time            `json:"deleted"`
+	Internal  time.Time            `json:"-"`
+	Checkins  []time.Time          `json:"checkins"`
+	Deadlines map[string]time.Time `json:"deadlines"`
+}
+
+func TestJSONBodyTimeFormat(t *testing.T) {
+	server, log := recordingServer(t, nil)
+	moment := time.Unix(1700000000, 0).UTC()
+	event := timedEvent{
+		auditFields: auditFields{CreatedAt: moment},
+		Name:        "deploy",
+		At:          moment,
+		Internal:    moment,
+		Checkins:    []time.Time{moment, {}},
+		Deadlines:   map[string]time.Time{"review": moment, "ship": {}},
+	}
+	tests := []struct {
+		name   string
+		client func() *Client
+		want   string
+	}{
+		{
+			"unix seconds",
+			func() *Client {
+				return NewClient(server.URL, 5*time.Second).WithTimeFormat(TimeFormatRFC3339, TimeFormatUnixSeconds)
+			},
+			`{"created_at":1700000000,"name":"deploy","at":1700000000,"deleted":-62135596800,` +
+				`"checkins":[1700000000,-62135596800],"deadlines":{"review":1700000000,"ship":-62135596800}}`,
+		},
+		{
+			"omit zero",
+			func() *Client {
+				return NewClient(server.URL, 5*time.Second).WithTimeFormat(TimeFormatRFC3339, TimeFormatRFC3339).WithOmitZeroTime()
+			},
+			`{"created_at":"2023-11-14T22:13:20Z","name":"deploy","at":"2023-11-14T22:13:20Z",` +
+				`"checkins":["2023-11-14T22:13:20Z",null],"deadlines":{"review":"2023-11-14T22:13:20Z"}}`,
+		},
+		{
+			"no body format",
+			func() *Client { return NewClient(server.URL, 5*time.Second).WithOmitZeroTime() },
+			`{"created_at":"2023-11-14T22:13:20Z","name":"deploy","at":"2023-11-14T22:13:20Z","deleted":"0001-01-01T00:00:00Z",` +
+				`"checkins":["2023-11-14T22:13:20Z","0001-01-01T00:00:00Z"],"deadlines":{"review":"2023-11-14T22:13:20Z","ship":"0001-01-01T00:00:00Z"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.client().Do(context.Background(), POST("/"), WithJSON(event)); err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+			if got := log.Last(t).Body; got != tt.want {
+				t.Errorf("body =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package reqws
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Timeouts groups all HTTP client timeouts in one place.
+// Zero fields leave the current setting unchanged.
+type Timeouts struct {
+	Request        time.Duration // Whole request attempt including reading the body (same as NewClient timeout)
+	Dial           time.Duration // Establishing the TCP connection
+	TLSHandshake   time.Duration // TLS handshake after the connection is established
+	ResponseHeader time.Duration // Waiting for response headers after the request is written
+	IdleConn       time.Duration // How long idle keep-alive connections are kept in the pool
+}
+
+// WithTimeouts configures the client's timeouts.
+// Request bounds every attempt end to end, so it should be larger than
+// Dial + TLSHandshake + ResponseHeader; the other timeouts fail faster on
+// a specific phase of the connection.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 0).
+//		WithTimeouts(reqws.Timeouts{
+//			Request:        30 * time.Second,
+//			Dial:           5 * time.Second,
+//			TLSHandshake:   5 * time.Second,
+//			ResponseHeader: 10 * time.Second,
+//		})
+func (c *Client) WithTimeouts(timeouts Timeouts) *Client {
+	if timeouts.Request > 0 {
+		c.client.Timeout = timeouts.Request
+	}
+
+	if timeouts.Dial > 0 {
+		c.dialer().timeout = timeouts.Dial
+	}
+	transport := c.transport()
+	if timeouts.TLSHandshake > 0 {
+		transport.TLSHandshakeTimeout = timeouts.TLSHandshake
+	}
+	if timeouts.ResponseHeader > 0 {
+		transport.ResponseHeaderTimeout = timeouts.ResponseHeader
+	}
+	if timeouts.IdleConn > 0 {
+		transport.IdleConnTimeout = timeouts.IdleConn
+	}
+	return c
+}
+
+// transport returns the client's own *http.Transport, creating one from
+// http.DefaultTransport on first use so settings never leak into other clients.
+func (c *Client) transport() *http.Transport {
+	if transport, ok := c.client.Transport.(*http.Transport); ok {
+		return transport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c.client.Transport = transport
+	return transport
+}
+
+// transportVariant identifies a transport derived from the client's for
+// per-request options.
+type transportVariant struct {
+	proxy    string // WithProxy() URL, empty for the client's proxy settings
+	insecure bool   // WithInsecureSkipVerify()
+	http1    bool   // HTTP/1.1 only, for WithHeaderExactCase()
+}
+
+// transportVariants holds the derived transports, so requests with the same
+// options reuse connections.
+type transportVariants struct {
+	mu    sync.Mutex
+	byKey map[transportVariant]*http.Transport
+}
+
+// requestClient returns httpClient, or a copy of it using a transport derived
+// from the client's if the request sets WithProxy(), WithInsecureSkipVerify() or
+// WithHeaderExactCase().
+func (c *Client) requestClient(httpClient *http.Client, config *requestConfig) *http.Client {
+	if config.proxy == nil && !config.insecureSkipVerify && !config.forceHTTP1 {
+		return httpClient
+	}
+	key := transportVariant{insecure: config.insecureSkipVerify, http1: config.forceHTTP1}
+	if config.proxy != nil {
+		key.proxy = config.proxy.String()
+	}
+
+	c.variantsOnce.Do(func() {
+		c.variants = &transportVariants{byKey: make(map[transportVariant]*http.Transport)}
+	})
+	c.variants.mu.Lock()
+	transport, ok := c.variants.byKey[key]
+	if !ok {
+		base, isTransport := c.client.Transport.(*http.Transport)
+		if !isTransport {
+			base = http.DefaultTransport.(*http.Transport)
+		}
+		transport = base.Clone()
+		if config.proxy != nil {
+			transport.Proxy = http.ProxyURL(config.proxy)
+		}
+		if config.insecureSkipVerify {
+			if transport.TLSClientConfig == nil {
+				transport.TLSClientConfig = &tls.Config{}
+			}
+			transport.TLSClientConfig.InsecureSkipVerify = true
+		}
+		if config.forceHTTP1 {
+			// A non-nil empty TLSNextProto disables HTTP/2; the clone may already offer h2 via ALPN
+			transport.ForceAttemptHTTP2 = false
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+			if transport.TLSClientConfig != nil {
+				// Clone shares the NextProtos array with the base transport; filter a copy
+				transport.TLSClientConfig.NextProtos = slices.DeleteFunc(slices.Clone(transport.TLSClientConfig.NextProtos), func(proto string) bool {
+					return proto == "h2"
+				})
+			}
+		}
+		c.variants.byKey[key] = transport
+	}
+	c.variants.mu.Unlock()
+
+	derived := *httpClient
+	derived.Transport = transport
+	return &derived
+}
@@ -0,0 +1,100 @@
+package reqws
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// isUnknownAuthority reports whether err is a certificate verification failure.
+func isUnknownAuthority(err error) bool {
+	var unknown x509.UnknownAuthorityError
+	return errors.As(err, &unknown)
+}
+
+func TestInsecureSkipVerifyHTTP(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+
+	if _, err := client.Do(ctx, GET("/")); !isUnknownAuthority(err) {
+		t.Fatalf("Do without the option: err = %v, want a certificate error", err)
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Do(ctx, GET("/"), WithInsecureSkipVerify())
+		if err != nil {
+			t.Fatalf("Do with WithInsecureSkipVerify: %v", err)
+		}
+		if resp.String() != "ok" {
+			t.Errorf("body = %q", resp.String())
+		}
+	}
+	// The option is per request: the client's transport still verifies
+	if _, err := client.Do(ctx, GET("/")); !isUnknownAuthority(err) {
+		t.Errorf("Do after an insecure request: err = %v, want a certificate error", err)
+	}
+	if n := len(client.variants.byKey); n != 1 {
+		t.Errorf("derived transports = %d, want one shared by insecure requests", n)
+	}
+}
+
+func TestInsecureSkipVerifyKeepsClientTransportSettings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := NewClient(server.URL, 5*time.Second)
+	client.transport().MaxIdleConnsPerHost = 7
+
+	if _, err := client.Do(context.Background(), GET("/"), WithInsecureSkipVerify()); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	for key, transport := range client.variants.byKey {
+		if !key.insecure || !transport.TLSClientConfig.InsecureSkipVerify {
+			t.Errorf("variant %+v does not skip verification", key)
+		}
+		if transport.MaxIdleConnsPerHost != 7 {
+			t.Errorf("MaxIdleConnsPerHost = %d, want the client's setting", transport.MaxIdleConnsPerHost)
+		}
+	}
+	if tlsConfig := client.transport().TLSClientConfig; tlsConfig != nil && tlsConfig.InsecureSkipVerify {
+		t.Error("the client's transport skips verification")
+	}
+}
+
+func TestInsecureSkipVerifyWebSocket(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Write(r.Context(), websocket.MessageText, []byte(`{"hello":"tls"}`))
+		conn.Close(websocket.StatusNormalClosure, "done")
+	}))
+	defer server.Close()
+	client := NewClient("wss"+strings.TrimPrefix(server.URL, "https"), 5*time.Second)
+	ctx := context.Background()
+
+	if _, _, err := client.OpenWebSocket(ctx, nil); !isUnknownAuthority(err) {
+		t.Fatalf("OpenWebSocket without the option: err = %v, want a certificate error", err)
+	}
+	recv, errs, err := client.OpenWebSocket(ctx, nil, WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatalf("OpenWebSocket with WithInsecureSkipVerify: %v", err)
+	}
+	msg := <-recv
+	if msg.Error != nil || msg.Data.(map[string]interface{})["hello"] != "tls" {
+		t.Errorf("message = %+v", msg)
+	}
+	for range recv {
+	}
+	<-errs
+}
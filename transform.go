@@ -0,0 +1,214 @@
+package reqws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ResponseTransform rewrites a successful response body before it is decoded.
+type ResponseTransform func(body []byte) ([]byte, error)
+
+// TransformError is returned when a response transform fails.
+type TransformError struct {
+	Index     int    // Position of the failed transform in the pipeline
+	Transform string // Name of the failed transform
+	Err       error
+}
+
+func (e *TransformError) Error() string {
+	return fmt.Sprintf("response transform %d (%s) failed: %v", e.Index, e.Transform, e.Err)
+}
+
+// Unwrap returns the underlying error for error chain support.
+func (e *TransformError) Unwrap() error {
+	return e.Err
+}
+
+// WithResponseTransform adds a transform applied to 2xx response bodies before
+// they are returned from Request() or stored in Response.Body by Do().
+// Multiple transforms run in the order they were added, after any transforms
+// registered on the client. The untransformed body stays available in Response.RawBody.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.GET("/partner/orders"),
+//		reqws.WithResponseTransform(reqws.UnwrapEnvelope("result.payload")),
+//		reqws.WithResponseTransform(reqws.StringsToNumbers("total", "quantity")),
+//	)
+func WithResponseTransform(transform ResponseTransform) RequestOption {
+	return func(c *requestConfig) {
+		c.responseTransforms = append(c.responseTransforms, transform)
+	}
+}
+
+// WithResponseTransform adds a transform applied to the 2xx response bodies of
+// every request made by the client, before any per-request transforms.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://partner.example.com", 30*time.Second).
+//		WithResponseTransform(reqws.UnwrapEnvelope("data"))
+func (c *Client) WithResponseTransform(transform ResponseTransform) *Client {
+	c.responseTransforms = append(c.responseTransforms, transform)
+	return c
+}
+
+// WithTransformRequestBody adds a transform that rewrites the serialized request
+// body, e.g. to encrypt it. It runs on the bytes of any body (JSON, raw, form or
+// multipart, which is then buffered in memory) once they are encoded, on every
+// attempt. Multiple transforms run in the order they were added, and requests
+// without a body are not transformed. Set the Content-Type of the result with
+// WithHeader() or the body option.
+//
+// Transforms run before WithGzipBody() compresses the body, and before
+// request interceptors and before-request hooks run, so a signing hook reading
+// req.GetBody() signs the transformed bytes.
+//
+// Example:
+//
+//	client.Do(ctx,
+//		reqws.POST("/payments"),
+//		reqws.WithJSON(payment),
+//		reqws.WithTransformRequestBody(func(body []byte) ([]byte, error) {
+//			return jwe.Encrypt(body, partnerKey)
+//		}),
+//		reqws.WithHeader("Content-Type", "application/jose"),
+//	)
+func WithTransformRequestBody(fn func([]byte) ([]byte, error)) RequestOption {
+	return func(c *requestConfig) {
+		c.requestTransforms = append(c.requestTransforms, fn)
+	}
+}
+
+// UnwrapEnvelope replaces the body with the sub-document at a dot-separated JSON path.
+// Returns an error if the path does not exist.
+func UnwrapEnvelope(path string) ResponseTransform {
+	keys := strings.Split(path, ".")
+	name := fmt.Sprintf("UnwrapEnvelope(%q)", path)
+	return func(body []byte) ([]byte, error) {
+		value, err := decodeJSONValue(body)
+		if err != nil {
+			return nil, &TransformError{Transform: name, Err: err}
+		}
+
+		inner := lookupJSONPath(value, keys)
+		if inner == nil {
+			return nil, &TransformError{Transform: name, Err: fmt.Errorf("path %q not found", path)}
+		}
+
+		out, err := json.Marshal(inner)
+		if err != nil {
+			return nil, &TransformError{Transform: name, Err: err}
+		}
+		return out, nil
+	}
+}
+
+// RenameFields renames top-level object fields (old name -> new name).
+// Fields not present in the body are ignored.
+func RenameFields(renames map[string]string) ResponseTransform {
+	return func(body []byte) ([]byte, error) {
+		object, err := decodeJSONObject(body)
+		if err != nil {
+			return nil, &TransformError{Transform: "RenameFields", Err: err}
+		}
+
+		for from, to := range renames {
+			if value, ok := object[from]; ok {
+				delete(object, from)
+				object[to] = value
+			}
+		}
+
+		out, err := json.Marshal(object)
+		if err != nil {
+			return nil, &TransformError{Transform: "RenameFields", Err: err}
+		}
+		return out, nil
+	}
+}
+
+// StringsToNumbers converts top-level string fields holding numbers (e.g. "42.5")
+// into JSON numbers. Missing fields are ignored; non-numeric strings are an error.
+func StringsToNumbers(fields ...string) ResponseTransform {
+	return func(body []byte) ([]byte, error) {
+		object, err := decodeJSONObject(body)
+		if err != nil {
+			return nil, &TransformError{Transform: "StringsToNumbers", Err: err}
+		}
+
+		for _, field := range fields {
+			str, ok := object[field].(string)
+			if !ok {
+				continue
+			}
+			number := json.Number(strings.TrimSpace(str))
+			if _, err := number.Float64(); err != nil {
+				return nil, &TransformError{Transform: "StringsToNumbers", Err: fmt.Errorf("field %q is not numeric: %q", field, str)}
+			}
+			object[field] = number
+		}
+
+		out, err := json.Marshal(object)
+		if err != nil {
+			return nil, &TransformError{Transform: "StringsToNumbers", Err: err}
+		}
+		return out, nil
+	}
+}
+
+// applyResponseTransforms runs the dialect, client and request transforms over body in order.
+func (c *Client) applyResponseTransforms(body []byte, config *requestConfig) ([]byte, error) {
+	transforms := append(c.responseTransforms[:len(c.responseTransforms):len(c.responseTransforms)], config.responseTransforms...)
+	if c.dialect.Transform != nil {
+		transforms = append([]ResponseTransform{c.dialect.Transform}, transforms...)
+	}
+	for i, transform := range transforms {
+		out, err := transform(body)
+		if err != nil {
+			var transformErr *TransformError
+			if errors.As(err, &transformErr) {
+				transformErr.Index = i
+				return nil, transformErr
+			}
+			return nil, &TransformError{Index: i, Transform: "custom", Err: err}
+		}
+		body = out
+	}
+	return body, nil
+}
+
+// transformRequestBody reads body and runs the request body transforms over it in order.
+func transformRequestBody(body io.Reader, transforms []func([]byte) ([]byte, error)) ([]byte, error) {
+	data, err := io.ReadAll(body)
+	if closer, ok := body.(io.Closer); ok {
+		// Returns a pooled buffer or stops a multipart writer
+		closer.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	for i, transform := range transforms {
+		if data, err = transform(data); err != nil {
+			return nil, fmt.Errorf("request body transform %d failed: %w", i, err)
+		}
+	}
+	return data, nil
+}
+
+// decodeJSONObject decodes body as a JSON object keeping numbers as json.Number.
+func decodeJSONObject(body []byte) (map[string]interface{}, error) {
+	value, err := decodeJSONValue(body)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("body is not a JSON object")
+	}
+	return object, nil
+}
@@ -0,0 +1,105 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResponseTransformPipeline(t *testing.T) {
+	const raw = `{"result":{"payload":{"order_id":"7","total":"42.5"}}}`
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(raw))
+	})
+
+	client := NewClient(server.URL, 5*time.Second).WithResponseTransform(UnwrapEnvelope("result.payload"))
+	resp, err := client.Do(context.Background(),
+		GET("/"),
+		WithResponseTransform(RenameFields(map[string]string{"order_id": "id"})),
+		WithResponseTransform(StringsToNumbers("total")),
+	)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got, want := string(resp.Body), `{"id":"7","total":42.5}`; got != want {
+		t.Errorf("Body = %s, want %s", got, want)
+	}
+	if string(resp.RawBody) != raw {
+		t.Errorf("RawBody = %s, want the untransformed body", resp.RawBody)
+	}
+}
+
+func TestResponseTransformSkipsErrorResponses(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"nope"}`, http.StatusBadRequest)
+	})
+
+	resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(),
+		GET("/"), WithResponseTransform(UnwrapEnvelope("data")))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := string(resp.Body); got != "{\"error\":\"nope\"}\n" {
+		t.Errorf("Body = %q, want the error body untouched", got)
+	}
+}
+
+func TestResponseTransformError(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"total":"n/a"}}`))
+	})
+
+	custom := errors.New("custom failure")
+	tests := []struct {
+		name          string
+		transforms    []ResponseTransform
+		wantIndex     int
+		wantTransform string
+	}{
+		{"missing envelope", []ResponseTransform{UnwrapEnvelope("result")}, 0, `UnwrapEnvelope("result")`},
+		{"not numeric", []ResponseTransform{UnwrapEnvelope("data"), StringsToNumbers("total")}, 1, "StringsToNumbers"},
+		{"custom", []ResponseTransform{func([]byte) ([]byte, error) { return nil, custom }}, 0, "custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []RequestOption{GET("/")}
+			for _, transform := range tt.transforms {
+				opts = append(opts, WithResponseTransform(transform))
+			}
+			_, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), opts...)
+			var transformErr *TransformError
+			if !errors.As(err, &transformErr) {
+				t.Fatalf("err = %v, want a TransformError", err)
+			}
+			if transformErr.Index != tt.wantIndex || transformErr.Transform != tt.wantTransform {
+				t.Errorf("TransformError = %d %q, want %d %q", transformErr.Index, transformErr.Transform, tt.wantIndex, tt.wantTransform)
+			}
+		})
+	}
+}
+
+func TestBuiltinTransforms(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform ResponseTransform
+		in, want  string
+	}{
+		{"unwrap nested", UnwrapEnvelope("a.b"), `{"a":{"b":[1,2]}}`, `[1,2]`},
+		{"unwrap array index", UnwrapEnvelope("items.1"), `{"items":[{"id":1},{"id":2}]}`, `{"id":2}`},
+		{"rename missing field", RenameFields(map[string]string{"x": "y"}), `{"a":1}`, `{"a":1}`},
+		{"numbers keep non-strings", StringsToNumbers("a", "b"), `{"a":" 3 ","b":4}`, `{"a":3,"b":4}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.transform([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("transform: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("got %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
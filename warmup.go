@@ -0,0 +1,91 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+)
+
+// Warmup opens n connections to the base URL's host and leaves them in the idle
+// pool, so the first real requests skip DNS, TCP and TLS setup. It sends n
+// concurrent HEAD requests to the base URL and holds each connection until all
+// of them are established, which forces n separate connections. The status codes
+// of the HEAD responses are ignored.
+//
+// Warmed connections are closed like any other idle connection after the
+// transport's IdleConnTimeout (90s by default, see Timeouts.IdleConn), so warm up
+// shortly before the traffic is expected, or pair it with WithIdleConnProbe() to keep
+// them alive. The pool keeps at most MaxIdleConnsPerHost connections per host
+// (2 by default); Warmup raises it to n, so call it before sending traffic.
+// Servers that speak HTTP/2 multiplex on one connection, so a single connection
+// is opened for them regardless of n.
+//
+// Returns an error joining the failures if any connection could not be opened.
+//
+// Example:
+//
+//	client := reqws.NewClient("https://api.example.com", 30*time.Second)
+//	if err := client.Warmup(ctx, 8); err != nil {
+//		log.Printf("warmup incomplete: %v", err)
+//	}
+func (c *Client) Warmup(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	transport := c.transport()
+	if transport.MaxIdleConnsPerHost < n {
+		transport.MaxIdleConnsPerHost = n
+	}
+
+	// Every request holds its connection until all have one or failed
+	var arrived sync.WaitGroup
+	arrived.Add(n)
+	allArrived := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(allArrived)
+	}()
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var once sync.Once
+			arrive := func() { once.Do(arrived.Done) }
+			defer arrive()
+
+			trace := &httptrace.ClientTrace{
+				GotConn: func(httptrace.GotConnInfo) {
+					arrive()
+					select {
+					case <-allArrived:
+					case <-ctx.Done():
+					}
+				},
+			}
+			req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, c.baseURL, nil)
+			if err != nil {
+				errs[i] = fmt.Errorf("invalid URL: %w", err)
+				return
+			}
+			resp, err := c.client.Do(req)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if c.logger != nil {
+		c.logger.Debug("connection warmup finished", "connections", n, "error", err)
+	}
+	return err
+}
@@ -1,12 +1,17 @@
 package reqws
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coder/websocket"
@@ -14,10 +19,12 @@ import (
 )
 
 type WebSocketResponse struct {
-	Data    interface{}
-	RawData []byte
-	Error   error
-	Closed  bool
+	Data        interface{}
+	RawData     []byte
+	Error       error
+	Closed      bool
+	WireSize    int // Bytes of the received frame
+	DecodedSize int // Bytes after InboundTransform and gzip decompression, zero if they failed
 }
 
 // WebSocketConfig defines configuration for WebSocket connections.
@@ -28,6 +35,53 @@ type WebSocketConfig struct {
 	MaxReconnectDelay    time.Duration // Maximum delay between reconnections
 	ReconnectMultiplier  float64       // Backoff multiplier for reconnection delay
 	OnReconnect          func()        // Callback function called on each reconnection attempt
+
+	// OnDial is called after each successful dial with its timing breakdown.
+	// Requires client.WithWebSocketDialCache().
+	OnDial func(timing WebSocketDialTiming)
+
+	// SuspendWindows and SuspendFunc suspend reconnection, e.g. during upstream
+	// maintenance. While suspended the stream sleeps until the suspension ends instead
+	// of dialing; suspended time does not count against MaxReconnectAttempts.
+	// SuspendFunc may return a zero until if the end is unknown; it is then polled.
+	SuspendWindows []SuspendWindow
+	SuspendFunc    func(now time.Time) (suspend bool, until time.Time)
+
+	// DecodeWorkers decodes incoming JSON messages on this many goroutines (> 1 enables).
+	// The read goroutine then only reads raw frames. Messages are still delivered in
+	// the order they were received unless UnorderedDelivery is set. At most
+	// DecodeWorkers messages are read ahead of delivery, so one slow message holds
+	// back reading instead of buffering later ones. A message that fails to decode
+	// is delivered with Error and RawData set and Closed false.
+	// Pass the config with WithWebSocketAutoReconnect(); AutoReconnect may stay false.
+	DecodeWorkers     int
+	UnorderedDelivery bool // Deliver messages as soon as they are decoded (requires DecodeWorkers > 1)
+
+	// SendRetries retries a message write up to this many times, waiting
+	// SendRetryDelay (default: 50ms) and doubling it after each attempt, when the
+	// write timed out after SendTimeout (default: 5s when SendRetries is set)
+	// before any of the message was written, e.g. while a control frame held up the
+	// connection. Any other failed write may have sent part of a frame and leaves
+	// the connection unusable, so it is given up right away and, with
+	// AutoReconnect, redialed. A write that times out once it has started also
+	// closes the connection.
+	// Pass the config with WithWebSocketAutoReconnect(); AutoReconnect may stay false.
+	SendRetries    int
+	SendRetryDelay time.Duration
+	SendTimeout    time.Duration
+
+	// InboundTransform rewrites each received message before it is decoded, e.g.
+	// GzipInbound() or DeflateInbound() for servers that compress payloads inside
+	// the frames. A message it fails on is delivered with Error and RawData set and
+	// Closed false; the stream continues.
+	// Pass the config with WithWebSocketAutoReconnect(); AutoReconnect may stay false.
+	InboundTransform InboundTransform
+
+	// OnShutdown is called with the timing of each connection's shutdown, after
+	// the connection is closed and its read loop stopped; see WebSocketShutdownStats
+	// for the sequence.
+	// Pass the config with WithWebSocketAutoReconnect(); AutoReconnect may stay false.
+	OnShutdown func(stats WebSocketShutdownStats)
 }
 
 // DefaultWebSocketConfig returns a sensible default WebSocket configuration.
@@ -45,7 +99,8 @@ func DefaultWebSocketConfig() WebSocketConfig {
 // WithWebSocketAutoReconnect enables WebSocket auto-reconnection with custom configuration.
 func WithWebSocketAutoReconnect(config WebSocketConfig) RequestOption {
 	return func(c *requestConfig) {
-		c.wsConfig = &config
+		cfg := config // Each request gets its own copy of a reused option
+		c.wsConfig = &cfg
 	}
 }
 
@@ -57,26 +112,89 @@ func WithWebSocketAutoReconnect(config WebSocketConfig) RequestOption {
 func WithDefaultWebSocketReconnect() RequestOption {
 	config := DefaultWebSocketConfig()
 	return func(c *requestConfig) {
-		c.wsConfig = &config
+		cfg := config
+		c.wsConfig = &cfg
+	}
+}
+
+// WithWebSocketSendContext sets a separate context for the send side of a WebSocket stream.
+// When sendCtx is done the stream stops sending; the context passed to WebSocketStream
+// still governs the receive side and the connection as a whole.
+//
+// Combine with WithWebSocketHalfClose() to keep receiving after sending stops.
+func WithWebSocketSendContext(sendCtx context.Context) RequestOption {
+	return func(c *requestConfig) {
+		c.wsSendContext = sendCtx
+	}
+}
+
+// WithWebSocketHalfClose keeps a WebSocket stream receiving after the send side is done.
+//
+// Without this option the connection is closed as soon as sendChan is closed (or the
+// send context is done). With it, the stream stops writing but keeps reading until the
+// server closes the connection or the stream context is done. WebSocket has no
+// write-only close frame, so the server is not notified that sending has stopped;
+// the protocol on top must signal that itself (e.g. with a final "done" message).
+func WithWebSocketHalfClose() RequestOption {
+	return func(c *requestConfig) {
+		c.wsHalfClose = true
+	}
+}
+
+// WithWebSocketGzip enables application-level gzip for WebSocket messages.
+// Received frames that start with the gzip magic bytes are decompressed before JSON
+// decoding (uncompressed frames are still accepted), and sent messages are gzipped
+// and written as binary frames.
+//
+// This is independent of permessage-deflate, which compresses every frame at the
+// transport level and is negotiated automatically during the handshake. Use this
+// option only for servers that gzip payloads themselves inside the frames.
+func WithWebSocketGzip() RequestOption {
+	return func(c *requestConfig) {
+		c.wsGzip = true
 	}
 }
 
 // WebSocketStream - Persistent connection with channel-based communication
+//
+// Closing sendChan closes the send side of the stream. Exactly one goroutine may
+// close it, after every producer has stopped sending; sending on a closed channel
+// panics. For several independent producers use OpenWebSocketStream and a
+// WebSocketWriter per producer instead.
+//
+// receiveChan is closed when the connection's read side ends, unless the dial
+// failed. OpenWebSocket() returns library-owned channels instead.
 func (c *Client) WebSocketStream(ctx context.Context, sendChan <-chan interface{}, receiveChan chan<- WebSocketResponse, opts ...RequestOption) error {
-	config := &requestConfig{
-		queryParams: url.Values{},
-		headers:     http.Header{},
-	}
+	return c.webSocketStream(ctx, c.beginStream(), sendChan, receiveChan, opts)
+}
 
-	for _, opt := range opts {
-		opt(config)
+// webSocketStream serves a WebSocketStream started with beginStream and releases
+// the stream's reference when it returns.
+func (c *Client) webSocketStream(ctx context.Context, stream *wsStream, sendChan <-chan interface{}, receiveChan chan<- WebSocketResponse, opts []RequestOption) error {
+	defer stream.release()
+	config := newRequestConfig("", opts)
+	config.wsStream = stream
+	conn, wsURL, err := c.wsDial(ctx, config)
+	if err != nil {
+		return err
 	}
+	return c.wsServe(ctx, conn, wsURL, sendChan, receiveChan, config)
+}
 
+// wsDial opens one WebSocket connection and returns it with its URL.
+func (c *Client) wsDial(ctx context.Context, config *requestConfig) (*websocket.Conn, string, error) {
+	if config.pathErr != nil {
+		return nil, "", config.pathErr
+	}
 	fullURL, err := url.Parse(c.baseURL + config.path)
 	if err != nil {
-		return err
+		return nil, "", err
+	}
+	query, err := config.requestQuery(c)
+	if err != nil {
+		return nil, "", err
 	}
-	fullURL.RawQuery = config.queryParams.Encode()
+	fullURL.RawQuery = query.Encode()
 
 	if c.logger != nil {
 		c.logger.Info("opening WebSocket stream", "url", fullURL.String())
@@ -85,11 +203,38 @@ func (c *Client) WebSocketStream(ctx context.Context, sendChan <-chan interface{
 	// Default DialOptions
 	dialOpts := &websocket.DialOptions{
 		CompressionMode: websocket.CompressionContextTakeover,
+		Host:            config.host,
+	}
+	if config.apiKey != nil || c.apiKey != nil {
+		dialOpts.HTTPHeader = http.Header{}
+		fullURL.RawQuery = c.applyAPIKey(config, dialOpts.HTTPHeader, fullURL.RawQuery)
 	}
 
 	// Only skip TLS verification if explicitly requested via WithInsecureSkipVerify()
 	// Default: Secure TLS verification (InsecureSkipVerify = false)
-	if config.insecureSkipVerify && (strings.HasPrefix(fullURL.String(), "https://") || strings.HasPrefix(fullURL.String(), "wss://")) {
+	insecure := config.insecureSkipVerify && (strings.HasPrefix(fullURL.String(), "https://") || strings.HasPrefix(fullURL.String(), "wss://"))
+	dialCtx := ctx
+	var timing *WebSocketDialTiming
+	if c.wsDialer != nil {
+		// Resolve through the dial cache and share TLS sessions across reconnects
+		var base *http.Transport
+		if c.proxyAuth != nil {
+			base = c.transport()
+		}
+		dialOpts.HTTPClient = &http.Client{Transport: c.wsDialer.transport(base, insecure, c.dialConfig())}
+		dialCtx, timing = c.wsDialer.trace(ctx)
+	} else if c.proxyAuth != nil || c.dial != nil {
+		// Dial through the client's transport so the proxy CONNECT carries
+		// credentials and the client's dialer is used
+		transport := c.transport()
+		if insecure {
+			transport = transport.Clone()
+			transport.TLSClientConfig = &tls.Config{
+				InsecureSkipVerify: true,
+			}
+		}
+		dialOpts.HTTPClient = &http.Client{Transport: transport}
+	} else if insecure {
 		dialOpts.HTTPClient = &http.Client{
 			Transport: &http.Transport{
 				TLSClientConfig: &tls.Config{
@@ -99,49 +244,190 @@ func (c *Client) WebSocketStream(ctx context.Context, sendChan <-chan interface{
 		}
 	}
 
-	conn, resp, err := websocket.Dial(ctx, fullURL.String(), dialOpts)
+	dialStart := time.Now()
+	conn, resp, err := websocket.Dial(dialCtx, fullURL.String(), dialOpts)
+	if err != nil && c.proxyAuth != nil && isProxyAuthFailure(resp, err) {
+		// Retry once with refreshed proxy credentials
+		refreshCtx := context.WithValue(dialCtx, proxyAuthRefreshKey{}, true)
+		conn, resp, err = websocket.Dial(refreshCtx, fullURL.String(), dialOpts)
+		if err != nil && isProxyAuthFailure(resp, err) {
+			return nil, "", NewWebSocketError("dial failed", &ProxyAuthError{Err: err})
+		}
+	}
 	if err != nil {
+		if c.tapping() {
+			c.tap(TapEvent{Type: TapError, URL: fullURL.String(), Error: err.Error()})
+		}
 		if resp != nil {
-			return NewWebSocketError(fmt.Sprintf("dial failed with status %d", resp.StatusCode), err)
+			return nil, "", NewWebSocketError(fmt.Sprintf("dial failed with status %d", resp.StatusCode), err)
+		}
+		return nil, "", NewWebSocketError("dial failed", err)
+	}
+
+	if timing != nil {
+		timing.Total = time.Since(dialStart)
+		timing.Upgrade = timing.Total - timing.DNS - timing.Connect - timing.TLS
+		c.wsDialer.record(*timing)
+		if c.logger != nil {
+			c.logger.Debug("WebSocket dialed", "total", timing.Total, "dns", timing.DNS,
+				"connect", timing.Connect, "tls", timing.TLS, "tls_resumed", timing.TLSResumed,
+				"addr_cache_hit", timing.AddrCacheHit, "ip_family", timing.IPFamily)
+		}
+		if config.wsConfig != nil && config.wsConfig.OnDial != nil {
+			config.wsConfig.OnDial(*timing)
 		}
-		return NewWebSocketError("dial failed", err)
 	}
-	defer conn.Close(websocket.StatusNormalClosure, "closing stream")
+
+	if c.tapping() {
+		c.tap(TapEvent{Type: TapWebSocket, URL: fullURL.String(), State: "connected"})
+	}
+	return conn, fullURL.String(), nil
+}
+
+// wsServe runs the read and write loops of a dialed connection until it ends.
+// receiveChan is closed when reading ends, unless config.wsKeepReceive is set:
+// then receiveChan outlives the connection and wsServe returns only after the
+// reader has stopped.
+func (c *Client) wsServe(ctx context.Context, conn *websocket.Conn, wsURL string, sendChan <-chan interface{}, receiveChan chan<- WebSocketResponse, config *requestConfig) (err error) {
+	// Every way this connection ends goes through one shutdown sequence (see
+	// WebSocketShutdownStats). When receiveChan outlives the connection, shutdown
+	// waits for the read goroutine so it never sends late.
+	readDone := make(chan struct{})
+	shutdown := newWSShutdown(conn, readDone, config.wsKeepReceive)
+	defer func() {
+		stats := shutdown.close(websocket.StatusNormalClosure, "closing stream")
+		if c.tapping() {
+			event := TapEvent{Type: TapWebSocket, URL: wsURL, State: "closed", Duration: stats.Total}
+			if err != nil {
+				event.Error = err.Error()
+			}
+			c.tap(event)
+		}
+		if config.wsConfig != nil && config.wsConfig.OnShutdown != nil {
+			config.wsConfig.OnShutdown(stats)
+		}
+	}()
 
 	conn.SetReadLimit(1024 * 1024) // 1MB
 
+	// Send side may have its own context, always bounded by ctx
+	sendCtx := ctx
+	if config.wsSendContext != nil {
+		var cancel context.CancelFunc
+		sendCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(config.wsSendContext, cancel)
+		defer stop()
+	}
+
 	// Goroutine for reading messages
-	go func() {
-		defer close(receiveChan)
-		for {
-			var msg map[string]interface{}
-			err := wsjson.Read(ctx, conn, &msg)
-			if err != nil {
-				receiveChan <- WebSocketResponse{
-					Error:  err,
-					Closed: true,
+	config.wsStream.acquire()
+	if config.wsConfig != nil && config.wsConfig.DecodeWorkers > 1 {
+		go c.wsReadLoopParallel(ctx, conn, receiveChan, readDone, config.wsStream, config.wsConfig, config.wsGzip, config.wsKeepReceive)
+	} else {
+		go func() {
+			defer close(readDone)
+			// Released before readDone is closed, so the stream has ended when its call returns
+			defer config.wsStream.release()
+			if !config.wsKeepReceive {
+				defer close(receiveChan)
+			}
+			var transform InboundTransform
+			if config.wsConfig != nil {
+				transform = config.wsConfig.InboundTransform
+			}
+			for {
+				typ, data, err := conn.Read(ctx)
+				if err != nil {
+					receiveChan <- WebSocketResponse{
+						Error:  err,
+						Closed: true,
+					}
+					return
 				}
-				return
+				receiveChan <- decodeWSFrame(typ, data, config.wsGzip, transform)
 			}
-			receiveChan <- WebSocketResponse{
-				Data:   msg,
-				Closed: false,
+		}()
+	}
+
+	// A controller can end this connection, to close the stream or force a
+	// reconnect. Only the send loop watches connCtx; writes and the reader keep ctx,
+	// so a write in progress completes and the close handshake stays clean.
+	connCtx := ctx
+	if config.wsController != nil {
+		var cancel context.CancelCauseFunc
+		connCtx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		attached := config.wsController.attach(func(reason string, reconnect bool) {
+			// Ending the send loop starts the shutdown sequence
+			if reconnect {
+				shutdown.setReason("reconnect: " + reason)
+				cancel(fmt.Errorf("%w: %s", errForcedReconnect, reason))
+				return
 			}
+			shutdown.setReason(reason)
+			cancel(errControllerClosed)
+		})
+		if !attached {
+			cancel(errControllerClosed)
 		}
-	}()
+		defer config.wsController.detach()
+	}
 
 	// Goroutine for writing messages
+	sendErr := c.wsSendLoop(connCtx, sendCtx, conn, sendChan, readDone, shutdown, config)
+	if cause := context.Cause(connCtx); errors.Is(cause, errForcedReconnect) {
+		return cause
+	} else if errors.Is(cause, errControllerClosed) {
+		return nil
+	}
+	if sendErr != nil || !config.wsHalfClose {
+		return sendErr
+	}
+
+	// Half-close: sending is done, keep receiving until the server closes
+	if c.logger != nil {
+		c.logger.Debug("WebSocket send side done, waiting for server to close")
+	}
+	select {
+	case <-connCtx.Done():
+		if cause := context.Cause(connCtx); errors.Is(cause, errForcedReconnect) {
+			return cause
+		} else if errors.Is(cause, errControllerClosed) {
+			return nil
+		}
+		return ctx.Err()
+	case <-readDone:
+		return nil
+	}
+}
+
+// wsSendLoop writes messages from sendChan until it is closed, a context is done or
+// the read side ended because the connection was lost.
+// It returns nil when only the send side finished (sendChan closed or sendCtx done).
+func (c *Client) wsSendLoop(ctx, sendCtx context.Context, conn *websocket.Conn, sendChan <-chan interface{}, readDone <-chan struct{}, shutdown *wsShutdown, config *requestConfig) error {
 	for {
+		// Stop before taking another message once the connection is ending
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-readDone:
+			return NewWebSocketError("connection closed", nil)
+		case <-sendCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Only the send context is done
+			return nil
 		case msg, ok := <-sendChan:
 			if !ok {
 				// Send channel closed, close connection
 				return nil
 			}
-			err := wsjson.Write(ctx, conn, msg)
-			if err != nil {
+			if err := c.wsWrite(sendCtx, conn, msg, shutdown, config); err != nil {
 				return NewWebSocketError("failed to send message", err)
 			}
 			if c.logger != nil {
@@ -151,36 +437,193 @@ func (c *Client) WebSocketStream(ctx context.Context, sendChan <-chan interface{
 	}
 }
 
+// wsWrite writes one message, retrying writes that timed out before they started
+// as configured by WebSocketConfig.SendRetries. Once shutdown has started no
+// attempt is made and ErrStreamClosed is returned.
+func (c *Client) wsWrite(ctx context.Context, conn *websocket.Conn, msg interface{}, shutdown *wsShutdown, config *requestConfig) error {
+	retries, delay, timeout := 0, 50*time.Millisecond, time.Duration(0)
+	if config.wsConfig != nil {
+		retries = config.wsConfig.SendRetries
+		if config.wsConfig.SendRetryDelay > 0 {
+			delay = config.wsConfig.SendRetryDelay
+		}
+		timeout = config.wsConfig.SendTimeout
+		if timeout <= 0 && retries > 0 {
+			timeout = 5 * time.Second
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		if !shutdown.beginWrite() {
+			return ErrStreamClosed
+		}
+		writeCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			writeCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		var err error
+		if config.wsGzip {
+			err = writeGzipWSMessage(writeCtx, conn, msg)
+		} else {
+			err = wsjson.Write(writeCtx, conn, msg)
+		}
+		cancel()
+		shutdown.endWrite()
+		if err == nil || attempt >= retries || !isUnstartedWSWrite(ctx, err) {
+			return err
+		}
+		if c.logger != nil {
+			c.logger.Debug("retrying WebSocket message send", "attempt", attempt+1, "delay", delay, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+// isUnstartedWSWrite reports whether a write failed by timing out while waiting
+// for the connection's message writer, before any byte of it was written, so it
+// can be retried on the same connection. Once a write has started, the
+// connection's buffered writer keeps its error and a partial frame may have been
+// sent, so nothing else is safe to retry. ctx is the send context, whose end
+// stops retrying.
+func isUnstartedWSWrite(ctx context.Context, err error) bool {
+	// coder/websocket reports a message writer it could not acquire as "failed to
+	// acquire lock"; it fails a started write with the context error alone
+	return ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) &&
+		strings.Contains(err.Error(), "failed to acquire lock")
+}
+
 // WebSocketStreamWithReconnect wraps WebSocketStream with automatic reconnection logic.
 // If the connection drops, it will automatically attempt to reconnect with exponential backoff.
 // Use WithWebSocketAutoReconnect() or WithDefaultWebSocketReconnect() to configure reconnection behavior.
+//
+// receiveChan stays open across reconnects; each dropped connection delivers a message
+// with Closed set, and receiveChan is closed when this function returns.
+// Reconnection pauses during WebSocketConfig.SuspendWindows, and a WebSocketController
+// passed with WithWebSocketController() can force an immediate reconnect.
 func (c *Client) WebSocketStreamWithReconnect(ctx context.Context, sendChan <-chan interface{}, receiveChan chan<- WebSocketResponse, opts ...RequestOption) error {
 	// Parse config from options
-	config := &requestConfig{
-		queryParams: url.Values{},
-		headers:     http.Header{},
-	}
-	for _, opt := range opts {
-		opt(config)
-	}
+	config := newRequestConfig("", opts)
 
 	// If no WebSocket config or auto-reconnect disabled, just call normal WebSocketStream
 	if config.wsConfig == nil || !config.wsConfig.AutoReconnect {
 		return c.WebSocketStream(ctx, sendChan, receiveChan, opts...)
 	}
 
-	// Auto-reconnect enabled
+	defer close(receiveChan)
+	config.wsKeepReceive = true
+	config.wsStream = c.beginStream()
+	return c.wsRun(ctx, sendChan, receiveChan, config, nil, "")
+}
+
+// OpenWebSocket dials a WebSocket stream and returns channels owned by the library:
+// recv delivers received messages and errs the error that ended the stream.
+// The first connection is dialed before OpenWebSocket returns, and a failed dial is
+// returned as err; with auto-reconnect, later dials are retried as in
+// WebSocketStreamWithReconnect.
+//
+// recv is closed exactly once, after the stream has permanently ended (including all
+// reconnect attempts), and then errs delivers the terminal error, if any, and is
+// closed. Each dropped connection delivers a message with Closed set first. recv is
+// buffered (16 messages by default, see WithWebSocketReceiveBuffer()); once the buffer
+// is full the stream stops reading until recv is drained, so always drain it.
+// sendChan follows the same rules as in WebSocketStream and may be nil for a
+// receive-only stream.
+//
+// Migrating from WebSocketStreamWithReconnect: drop the receiveChan argument and
+// range over recv instead; the error returned by the old call arrives on errs.
+//
+// Example:
+//
+//	recv, errs, err := client.OpenWebSocket(ctx, sendChan,
+//		reqws.WithPath("/ws"),
+//		reqws.WithDefaultWebSocketReconnect(),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	for msg := range recv {
+//		handle(msg)
+//	}
+//	return <-errs
+func (c *Client) OpenWebSocket(ctx context.Context, sendChan <-chan interface{}, opts ...RequestOption) (<-chan WebSocketResponse, <-chan error, error) {
+	config := newRequestConfig("", opts)
+	config.wsKeepReceive = true
+	config.wsStream = c.beginStream()
+
+	conn, wsURL, err := c.wsDial(context.WithValue(ctx, wsAttemptKey{}, 0), config)
+	if err != nil {
+		config.wsStream.release()
+		return nil, nil, err
+	}
+
+	size := config.wsReceiveBuffer
+	if size <= 0 {
+		size = defaultWSReceiveBuffer
+	}
+	recv := make(chan WebSocketResponse, size)
+	errs := make(chan error, 1)
+	go func() {
+		err := c.wsRun(ctx, sendChan, recv, config, conn, wsURL)
+		close(recv)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return recv, errs, nil
+}
+
+// WithWebSocketReceiveBuffer sets the buffer size of the receive channel returned by
+// OpenWebSocket (default: 16).
+func WithWebSocketReceiveBuffer(n int) RequestOption {
+	return func(c *requestConfig) {
+		c.wsReceiveBuffer = n
+	}
+}
+
+// wsRun serves connections until the stream permanently ends, re-dialing dropped
+// connections if auto-reconnect is enabled. conn is an already dialed first
+// connection, or nil. config.wsKeepReceive must be set; receiveChan is not closed.
+// wsRun releases config.wsStream when it returns.
+func (c *Client) wsRun(ctx context.Context, sendChan <-chan interface{}, receiveChan chan<- WebSocketResponse, config *requestConfig, conn *websocket.Conn, wsURL string) error {
+	defer config.wsStream.release()
+	reconnect := config.wsConfig != nil && config.wsConfig.AutoReconnect
 	attempt := 0
-	delay := config.wsConfig.ReconnectDelay
+	var delay time.Duration
+	if reconnect {
+		delay = config.wsConfig.ReconnectDelay
+	}
+	forced := false
+	var closed <-chan struct{} // Closed by WebSocketController.Close
+	if config.wsController != nil {
+		closed = config.wsController.closedChan()
+	}
 
 	for {
 		// Check if context is cancelled
 		if ctx.Err() != nil {
+			if conn != nil {
+				conn.Close(websocket.StatusNormalClosure, "closing stream")
+			}
 			return ctx.Err()
 		}
+		// A closed controller ends the stream without dialing again
+		if conn == nil && config.wsController.isClosed() {
+			return nil
+		}
+
+		// Back off before reconnecting, except on the first attempt and forced reconnects
+		if attempt > 0 && !forced {
+			// Wait out suspend windows without dialing or counting attempts
+			if !c.waitSuspended(ctx, config) {
+				return ctx.Err()
+			}
 
-		// Log reconnection attempt if not first attempt
-		if attempt > 0 {
 			if c.logger != nil {
 				c.logger.Info("attempting to reconnect WebSocket",
 					"attempt", attempt,
@@ -189,6 +632,10 @@ func (c *Client) WebSocketStreamWithReconnect(ctx context.Context, sendChan <-ch
 				)
 			}
 
+			if c.tapping() {
+				c.tap(TapEvent{Type: TapWebSocket, URL: c.baseURL + config.path, Attempt: attempt, State: "reconnecting"})
+			}
+
 			// Call OnReconnect callback if provided
 			if config.wsConfig.OnReconnect != nil {
 				config.wsConfig.OnReconnect()
@@ -198,6 +645,8 @@ func (c *Client) WebSocketStreamWithReconnect(ctx context.Context, sendChan <-ch
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
+			case <-closed:
+				return nil
 			case <-time.After(delay):
 				// Calculate next delay
 				delay = time.Duration(float64(delay) * config.wsConfig.ReconnectMultiplier)
@@ -206,15 +655,39 @@ func (c *Client) WebSocketStreamWithReconnect(ctx context.Context, sendChan <-ch
 				}
 			}
 		}
+		forced = false
 
-		// Attempt connection
-		err := c.WebSocketStream(ctx, sendChan, receiveChan, opts...)
+		// Attempt connection, using the already dialed one first
+		attemptCtx := context.WithValue(ctx, wsAttemptKey{}, attempt)
+		var err error
+		if conn == nil {
+			conn, wsURL, err = c.wsDial(attemptCtx, config)
+		}
+		if err == nil {
+			err = c.wsServe(attemptCtx, conn, wsURL, sendChan, receiveChan, config)
+		}
+		conn = nil
+		if !reconnect || (err == nil && config.wsController.isClosed()) {
+			return err
+		}
 
 		// If context was cancelled, don't reconnect
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
+		// A forced reconnect re-dials immediately and is not a failure
+		if errors.Is(err, errForcedReconnect) {
+			forced = true
+			if c.logger != nil {
+				c.logger.Info("WebSocket reconnect forced", "reason", err)
+			}
+			if c.tapping() {
+				c.tap(TapEvent{Type: TapWebSocket, URL: c.baseURL + config.path, Attempt: attempt, State: "forced_reconnect", Error: err.Error()})
+			}
+			continue
+		}
+
 		// Check if we should stop reconnecting
 		attempt++
 		if config.wsConfig.MaxReconnectAttempts > 0 && attempt >= config.wsConfig.MaxReconnectAttempts {
@@ -235,3 +708,108 @@ func (c *Client) WebSocketStreamWithReconnect(ctx context.Context, sendChan <-ch
 		// Continue to next iteration for reconnection
 	}
 }
+
+// defaultWSReceiveBuffer is the receive channel buffer of OpenWebSocket.
+const defaultWSReceiveBuffer = 16
+
+// wsFrame is a raw message stamped with its receive order.
+type wsFrame struct {
+	seq  uint64
+	typ  websocket.MessageType
+	data []byte
+}
+
+// wsDecoded is a decoded message stamped with its receive order.
+type wsDecoded struct {
+	seq uint64
+	msg WebSocketResponse
+}
+
+// wsReadLoopParallel reads raw frames and decodes them on a worker pool,
+// re-sequencing results into receive order unless unordered delivery is enabled.
+func (c *Client) wsReadLoopParallel(ctx context.Context, conn *websocket.Conn, receiveChan chan<- WebSocketResponse, readDone chan<- struct{}, stream *wsStream, wsConfig *WebSocketConfig, gzipped, keepOpen bool) {
+	defer close(readDone)
+	defer stream.release()
+	if !keepOpen {
+		defer close(receiveChan)
+	}
+
+	workers := wsConfig.DecodeWorkers
+	frames := make(chan wsFrame, workers)
+	results := make(chan wsDecoded, workers)
+	// One slot per frame read but not yet delivered. The reader blocks while all
+	// are taken, so a slow decode cannot grow the reorder buffer past workers.
+	inFlight := make(chan struct{}, workers)
+
+	// Reader: only reads frames, never decodes
+	var readErr error
+	go func() {
+		defer close(frames)
+		for seq := uint64(0); ; seq++ {
+			inFlight <- struct{}{}
+			typ, data, err := conn.Read(ctx)
+			if err != nil {
+				readErr = err
+				return
+			}
+			frames <- wsFrame{seq: seq, typ: typ, data: data}
+		}
+	}()
+
+	// Decoders
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for frame := range frames {
+				results <- wsDecoded{seq: frame.seq, msg: decodeWSFrame(frame.typ, frame.data, gzipped, wsConfig.InboundTransform)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Delivery, re-sequenced through a small reorder buffer
+	pending := make(map[uint64]WebSocketResponse, workers)
+	next := uint64(0)
+	for result := range results {
+		if wsConfig.UnorderedDelivery {
+			receiveChan <- result.msg
+			<-inFlight
+			continue
+		}
+		pending[result.seq] = result.msg
+		for {
+			msg, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			receiveChan <- msg
+			<-inFlight
+		}
+	}
+
+	// results is closed only after the reader exited, so readErr is set
+	receiveChan <- WebSocketResponse{
+		Error:  readErr,
+		Closed: true,
+	}
+}
+
+// writeGzipWSMessage writes msg as gzipped JSON in a binary frame.
+func writeGzipWSMessage(ctx context.Context, conn *websocket.Conn, msg interface{}) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(msg); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return conn.Write(ctx, websocket.MessageBinary, buf.Bytes())
+}
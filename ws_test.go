@@ -0,0 +1,493 @@
+package reqws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestWSWriteRetriesWriteThatNeverStarted(t *testing.T) {
+	received := make(chan string, 2)
+	conn := wsTestConn(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			received <- string(data)
+		}
+	})
+
+	// Hold the connection's message writer so the first attempts cannot start
+	held, err := conn.Writer(context.Background(), websocket.MessageText)
+	if err != nil {
+		t.Fatalf("Writer: %v", err)
+	}
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		held.Write([]byte(`"held"`))
+		held.Close()
+	}()
+
+	config := &requestConfig{wsConfig: &WebSocketConfig{
+		SendRetries:    10,
+		SendRetryDelay: 10 * time.Millisecond,
+		SendTimeout:    20 * time.Millisecond,
+	}}
+	shutdown := newWSShutdown(conn, make(chan struct{}), false)
+	client := NewClient("", time.Second)
+	if err := client.wsWrite(context.Background(), conn, "retried", shutdown, config); err != nil {
+		t.Fatalf("wsWrite: %v", err)
+	}
+
+	for _, want := range []string{`"held"`, `"retried"` + "\n"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("received %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("message %q not received", want)
+		}
+	}
+}
+
+func TestWSWriteFailureIsNotRetried(t *testing.T) {
+	// The server drops the connection, so writes fail once the client notices
+	conn := wsTestConn(t, func(conn *websocket.Conn) {
+		conn.CloseNow()
+	})
+
+	const retryDelay = time.Second
+	config := &requestConfig{wsConfig: &WebSocketConfig{SendRetries: 3, SendRetryDelay: retryDelay}}
+	shutdown := newWSShutdown(conn, make(chan struct{}), false)
+	client := NewClient("", time.Second)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		start := time.Now()
+		err := client.wsWrite(context.Background(), conn, "message", shutdown, config)
+		if err == nil {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		if elapsed := time.Since(start); elapsed >= retryDelay {
+			t.Errorf("failed write returned after %v, want no retry", elapsed)
+		}
+		if isUnstartedWSWrite(context.Background(), err) {
+			t.Errorf("write error %v classified as retryable", err)
+		}
+		return
+	}
+	t.Fatal("writes to a dropped connection kept succeeding")
+}
+
+func TestWSWriteNotRetriedAfterSendContextEnds(t *testing.T) {
+	conn := wsTestConn(t, func(conn *websocket.Conn) {
+		conn.Read(context.Background())
+	})
+	held, err := conn.Writer(context.Background(), websocket.MessageText)
+	if err != nil {
+		t.Fatalf("Writer: %v", err)
+	}
+	defer held.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	config := &requestConfig{wsConfig: &WebSocketConfig{SendRetries: 100, SendRetryDelay: time.Millisecond}}
+	shutdown := newWSShutdown(conn, make(chan struct{}), false)
+	start := time.Now()
+	if err := NewClient("", time.Second).wsWrite(ctx, conn, "message", shutdown, config); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wsWrite returned after %v, want it to stop with the send context", elapsed)
+	}
+}
+
+func TestWebSocketParallelDecode(t *testing.T) {
+	const messages = 200
+	url := wsServer(t, func(conn *websocket.Conn) {
+		for i := 0; i < messages; i++ {
+			msg := fmt.Sprintf(`{"n":%d}`, i)
+			if i == 50 {
+				msg = "not json"
+			}
+			if err := conn.Write(context.Background(), websocket.MessageText, []byte(msg)); err != nil {
+				return
+			}
+		}
+		conn.Close(websocket.StatusNormalClosure, "done")
+	})
+
+	tests := []struct {
+		name      string
+		unordered bool
+	}{
+		{"ordered", false},
+		{"unordered", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiveChan := make(chan WebSocketResponse, messages+1)
+			NewClient(url, 5*time.Second).WebSocketStream(context.Background(), nil, receiveChan,
+				WithWebSocketAutoReconnect(WebSocketConfig{DecodeWorkers: 4, UnorderedDelivery: tt.unordered}))
+
+			var got []int
+			var decodeErrors, closed int
+			for msg := range receiveChan {
+				switch {
+				case msg.Closed:
+					closed++
+				case msg.Error != nil:
+					decodeErrors++
+					if string(msg.RawData) != "not json" {
+						t.Errorf("RawData = %q, want the undecodable frame", msg.RawData)
+					}
+				default:
+					got = append(got, int(msg.Data.(map[string]interface{})["n"].(float64)))
+				}
+			}
+			if closed != 1 || decodeErrors != 1 || len(got) != messages-1 {
+				t.Fatalf("received %d messages, %d decode errors, %d closes; want %d, 1, 1", len(got), decodeErrors, closed, messages-1)
+			}
+			seen := make(map[int]bool)
+			for i, n := range got {
+				seen[n] = true
+				want := i
+				if i >= 50 {
+					want = i + 1
+				}
+				if !tt.unordered && n != want {
+					t.Fatalf("message %d has n = %d, want %d: delivery out of order", i, n, want)
+				}
+			}
+			if len(seen) != messages-1 {
+				t.Errorf("received %d distinct messages, want %d", len(seen), messages-1)
+			}
+		})
+	}
+}
+
+// droppingWSServer returns the URL of a WebSocket server that sends each
+// connection its number and then closes it, and the number of connections made.
+func droppingWSServer(t *testing.T) (string, *atomic.Int32) {
+	var conns atomic.Int32
+	url := wsServer(t, func(conn *websocket.Conn) {
+		n := conns.Add(1)
+		conn.Write(context.Background(), websocket.MessageText, []byte(fmt.Sprintf(`{"conn":%d}`, n)))
+		conn.Close(websocket.StatusNormalClosure, "bye")
+	})
+	return url, &conns
+}
+
+// drainWS reads receiveChan until it is closed and returns the connection
+// numbers received and the number of Closed notices.
+func drainWS(t *testing.T, receiveChan <-chan WebSocketResponse) (conns []int, closed int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-receiveChan:
+			if !ok {
+				return conns, closed
+			}
+			if msg.Closed {
+				closed++
+			} else if m, isMap := msg.Data.(map[string]interface{}); isMap {
+				conns = append(conns, int(m["conn"].(float64)))
+			}
+		case <-timeout:
+			t.Fatal("receive channel not closed")
+		}
+	}
+}
+
+func TestOpenWebSocketClosesRecvOnceAcrossReconnects(t *testing.T) {
+	url, dials := droppingWSServer(t)
+	config := WebSocketConfig{AutoReconnect: true, ReconnectDelay: time.Millisecond, ReconnectMultiplier: 2, MaxReconnectDelay: 5 * time.Millisecond, MaxReconnectAttempts: 3}
+	recv, errs, err := NewClient(url, 5*time.Second).OpenWebSocket(context.Background(), nil, WithWebSocketAutoReconnect(config))
+	if err != nil {
+		t.Fatalf("OpenWebSocket: %v", err)
+	}
+
+	// Every connection delivers its message and one Closed notice, and recv is
+	// closed once after the last reconnect attempt
+	conns, closed := drainWS(t, recv)
+	if len(conns) != 3 || conns[0] != 1 || conns[2] != 3 || closed != 3 || dials.Load() != 3 {
+		t.Errorf("received %v with %d Closed notices over %d connections, want 3 of each", conns, closed, dials.Load())
+	}
+	var wsErr *WebSocketError
+	if err := <-errs; !errors.As(err, &wsErr) || wsErr.Reason != "max reconnection attempts exceeded" {
+		t.Errorf("terminal error = %v, want max reconnection attempts exceeded", err)
+	}
+	if _, ok := <-errs; ok {
+		t.Error("errs delivered a second error")
+	}
+	if _, ok := <-recv; ok {
+		t.Error("recv delivered a message after it was closed")
+	}
+}
+
+func TestOpenWebSocketCancelClosesRecv(t *testing.T) {
+	url := wsServer(t, func(conn *websocket.Conn) {
+		conn.Write(context.Background(), websocket.MessageText, []byte(`{"conn":1}`))
+		conn.Read(context.Background())
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recv, errs, err := NewClient(url, 5*time.Second).OpenWebSocket(ctx, nil, WithDefaultWebSocketReconnect())
+	if err != nil {
+		t.Fatalf("OpenWebSocket: %v", err)
+	}
+	if msg := <-recv; msg.Closed || msg.Error != nil {
+		t.Fatalf("first message = %+v, want data", msg)
+	}
+
+	cancel()
+	if _, closed := drainWS(t, recv); closed != 1 {
+		t.Errorf("%d Closed notices, want 1", closed)
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("terminal error = %v, want context.Canceled", err)
+	}
+}
+
+func TestOpenWebSocketDialFailure(t *testing.T) {
+	recv, errs, err := NewClient("ws://127.0.0.1:1", 5*time.Second).OpenWebSocket(context.Background(), nil, WithDefaultWebSocketReconnect())
+	if err == nil || recv != nil || errs != nil {
+		t.Errorf("OpenWebSocket = %v, %v, %v; want only the dial error", recv, errs, err)
+	}
+}
+
+func TestLegacyWebSocketStream(t *testing.T) {
+	url, _ := droppingWSServer(t)
+	client := NewClient(url, 5*time.Second)
+
+	// receiveChan is closed once the connection's read side ends
+	receiveChan := make(chan WebSocketResponse, 4)
+	if err := client.WebSocketStream(context.Background(), nil, receiveChan); err == nil {
+		t.Error("WebSocketStream returned nil after the server closed the connection")
+	}
+	if conns, closed := drainWS(t, receiveChan); len(conns) != 1 || closed != 1 {
+		t.Errorf("received %v with %d Closed notices, want one message and one notice", conns, closed)
+	}
+
+	// A failed dial leaves receiveChan open
+	receiveChan = make(chan WebSocketResponse, 1)
+	if err := NewClient("ws://127.0.0.1:1", 5*time.Second).WebSocketStream(context.Background(), nil, receiveChan); err == nil {
+		t.Error("WebSocketStream dialed an unreachable server")
+	}
+	select {
+	case msg, ok := <-receiveChan:
+		t.Errorf("receiveChan delivered %+v (open %v) after a failed dial, want it untouched", msg, ok)
+	default:
+	}
+}
+
+func TestLegacyWebSocketStreamWithReconnect(t *testing.T) {
+	config := WebSocketConfig{AutoReconnect: true, ReconnectDelay: time.Millisecond, ReconnectMultiplier: 2, MaxReconnectDelay: 5 * time.Millisecond, MaxReconnectAttempts: 2}
+
+	t.Run("reconnects", func(t *testing.T) {
+		url, dials := droppingWSServer(t)
+		receiveChan := make(chan WebSocketResponse, 16)
+		err := NewClient(url, 5*time.Second).WebSocketStreamWithReconnect(context.Background(), nil, receiveChan, WithWebSocketAutoReconnect(config))
+		var wsErr *WebSocketError
+		if !errors.As(err, &wsErr) || wsErr.Reason != "max reconnection attempts exceeded" {
+			t.Errorf("error = %v, want max reconnection attempts exceeded", err)
+		}
+		// receiveChan is already closed when the call returns
+		conns, closed := drainWS(t, receiveChan)
+		if len(conns) != 2 || closed != 2 || dials.Load() != 2 {
+			t.Errorf("received %v with %d Closed notices over %d connections, want 2 of each", conns, closed, dials.Load())
+		}
+	})
+
+	t.Run("dial failures", func(t *testing.T) {
+		receiveChan := make(chan WebSocketResponse, 1)
+		err := NewClient("ws://127.0.0.1:1", 5*time.Second).WebSocketStreamWithReconnect(context.Background(), nil, receiveChan, WithWebSocketAutoReconnect(config))
+		if err == nil {
+			t.Error("WebSocketStreamWithReconnect dialed an unreachable server")
+		}
+		if _, ok := <-receiveChan; ok {
+			t.Error("receiveChan delivered a message, want it closed after failed dials")
+		}
+	})
+
+	t.Run("without auto-reconnect", func(t *testing.T) {
+		url, dials := droppingWSServer(t)
+		receiveChan := make(chan WebSocketResponse, 4)
+		if err := NewClient(url, 5*time.Second).WebSocketStreamWithReconnect(context.Background(), nil, receiveChan); err == nil {
+			t.Error("WebSocketStreamWithReconnect returned nil after the server closed the connection")
+		}
+		if conns, closed := drainWS(t, receiveChan); len(conns) != 1 || closed != 1 || dials.Load() != 1 {
+			t.Errorf("received %v with %d Closed notices over %d connections, want one of each", conns, closed, dials.Load())
+		}
+	})
+}
+
+func TestWebSocketParallelDecodeBoundsReadAhead(t *testing.T) {
+	const messages, workers = 50, 4
+	url := wsServer(t, func(conn *websocket.Conn) {
+		for i := 0; i < messages; i++ {
+			if err := conn.Write(context.Background(), websocket.MessageText, []byte(fmt.Sprintf(`{"n":%d}`, i))); err != nil {
+				return
+			}
+		}
+		conn.Read(context.Background())
+	})
+
+	// The first message stalls in its decoder until released
+	release := make(chan struct{})
+	var decodes atomic.Int32
+	transform := func(raw []byte, typ MessageType) ([]byte, error) {
+		decodes.Add(1)
+		if string(raw) == `{"n":0}` {
+			<-release
+		}
+		return raw, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	recv, _, err := NewClient(url, 5*time.Second).OpenWebSocket(ctx, nil,
+		WithWebSocketAutoReconnect(WebSocketConfig{DecodeWorkers: workers, InboundTransform: transform}))
+	if err != nil {
+		t.Fatalf("OpenWebSocket: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := decodes.Load(); got > workers {
+		t.Errorf("%d messages read behind a stalled one, want at most %d", got, workers)
+	}
+	close(release)
+	for i := 0; i < messages; i++ {
+		select {
+		case msg := <-recv:
+			if n := msg.Data.(map[string]interface{})["n"]; n != float64(i) {
+				t.Fatalf("message %d has n = %v, want delivery in order", i, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d not received", i)
+		}
+	}
+}
+
+func BenchmarkWebSocketDecode(b *testing.B) {
+	items := make([]map[string]interface{}, 20)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "name": "item", "price": 9.99, "tags": []string{"a", "b", "c"}}
+	}
+	payload, _ := json.Marshal(map[string]interface{}{"type": "snapshot", "items": items})
+
+	tests := []struct {
+		name   string
+		config WebSocketConfig
+	}{
+		{"sequential", WebSocketConfig{}},
+		{"4 workers", WebSocketConfig{DecodeWorkers: 4}},
+		{"4 workers unordered", WebSocketConfig{DecodeWorkers: 4, UnorderedDelivery: true}},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			url := wsServer(b, func(conn *websocket.Conn) {
+				for i := 0; i < b.N; i++ {
+					if err := conn.Write(context.Background(), websocket.MessageText, payload); err != nil {
+						return
+					}
+				}
+				conn.Close(websocket.StatusNormalClosure, "done")
+			})
+			b.SetBytes(int64(len(payload)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			recv, _, err := NewClient(url, 5*time.Second).OpenWebSocket(context.Background(), nil, WithWebSocketAutoReconnect(tt.config))
+			if err != nil {
+				b.Fatalf("OpenWebSocket: %v", err)
+			}
+			var received int
+			for msg := range recv {
+				if !msg.Closed {
+					received++
+				}
+			}
+			if received != b.N {
+				b.Fatalf("received %d messages, want %d", received, b.N)
+			}
+		})
+	}
+}
+
+func TestWebSocketHalfCloseKeepsReading(t *testing.T) {
+	// The server answers only after the client says it is done sending
+	url := wsServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				return
+			}
+			if strings.Contains(string(data), "done") {
+				break
+			}
+		}
+		for i := 1; i <= 3; i++ {
+			time.Sleep(10 * time.Millisecond)
+			conn.Write(context.Background(), websocket.MessageText, []byte(fmt.Sprintf(`{"result":%d}`, i)))
+		}
+		conn.Close(websocket.StatusNormalClosure, "finished")
+	})
+
+	tests := []struct {
+		name string
+		// endSends ends the send side after the "done" message was taken
+		endSends func(sendChan chan interface{}, cancelSend context.CancelFunc)
+	}{
+		{"send context done", func(_ chan interface{}, cancelSend context.CancelFunc) { cancelSend() }},
+		{"sendChan closed", func(sendChan chan interface{}, _ context.CancelFunc) { close(sendChan) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sendCtx, cancelSend := context.WithCancel(context.Background())
+			defer cancelSend()
+			sendChan := make(chan interface{})
+			receiveChan := make(chan WebSocketResponse, 8)
+			result := make(chan error, 1)
+			go func() {
+				result <- NewClient(url, 5*time.Second).WebSocketStream(context.Background(), sendChan, receiveChan,
+					WithWebSocketSendContext(sendCtx), WithWebSocketHalfClose())
+			}()
+
+			sendChan <- map[string]string{"op": "done"}
+			tt.endSends(sendChan, cancelSend)
+
+			var results []float64
+			var closed int
+			for msg := range receiveChan {
+				if msg.Closed {
+					closed++
+					continue
+				}
+				results = append(results, msg.Data.(map[string]interface{})["result"].(float64))
+			}
+			if !slices.Equal(results, []float64{1, 2, 3}) || closed != 1 {
+				t.Errorf("received %v with %d Closed notices, want all 3 results and one notice", results, closed)
+			}
+			select {
+			case err := <-result:
+				if err != nil {
+					t.Errorf("WebSocketStream = %v, want nil after the server closed", err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("WebSocketStream did not return")
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// wsSuspendPoll is how often a suspension without an end time is checked again.
+const wsSuspendPoll = time.Second
+
+// wsSuspendMaxWait bounds each sleep while suspended, so a SuspendFunc that ends a
+// suspension early is noticed.
+const wsSuspendMaxWait = time.Minute
+
+// errForcedReconnect ends a connection closed by WebSocketController.ForceReconnect.
+var errForcedReconnect = errors.New("forced reconnect")
+
+// errControllerClosed ends a connection closed by WebSocketController.Close.
+var errControllerClosed = errors.New("stream closed by controller")
+
+// SuspendWindow is a recurring time range during which WebSocket reconnection is
+// suspended, e.g. a nightly maintenance window.
+type SuspendWindow struct {
+	Days     []time.Weekday // Days the window starts on; empty means every day
+	Start    time.Duration  // Time of day the window starts, as an offset from midnight
+	End      time.Duration  // Time of day the window ends; less than Start spans midnight
+	Location *time.Location // Time zone of Start and End (default: time.Local)
+}
+
+// activeUntil reports whether now falls inside the window and when it ends.
+func (w SuspendWindow) activeUntil(now time.Time) (bool, time.Time) {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	offset := now.Sub(midnight)
+
+	if w.Start < w.End {
+		if w.startsOn(midnight.Weekday()) && offset >= w.Start && offset < w.End {
+			return true, midnight.Add(w.End)
+		}
+		return false, time.Time{}
+	}
+
+	// The window spans midnight
+	if w.startsOn(midnight.Weekday()) && offset >= w.Start {
+		return true, midnight.AddDate(0, 0, 1).Add(w.End)
+	}
+	if w.startsOn(midnight.AddDate(0, 0, -1).Weekday()) && offset < w.End {
+		return true, midnight.Add(w.End)
+	}
+	return false, time.Time{}
+}
+
+func (w SuspendWindow) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// suspendedUntil reports whether reconnection is suspended at now and until when.
+// A zero time means the end is unknown.
+func (cfg *WebSocketConfig) suspendedUntil(now time.Time) (bool, time.Time) {
+	suspended := false
+	var until time.Time
+	for _, w := range cfg.SuspendWindows {
+		if active, end := w.activeUntil(now); active {
+			suspended = true
+			if end.After(until) {
+				until = end
+			}
+		}
+	}
+	if cfg.SuspendFunc != nil {
+		if active, end := cfg.SuspendFunc(now); active {
+			if !suspended || end.IsZero() || end.After(until) {
+				until = end
+			}
+			suspended = true
+		}
+	}
+	return suspended, until
+}
+
+// WebSocketController controls a running WebSocket stream from outside.
+// Pass it with WithWebSocketController(); one controller belongs to one stream.
+type WebSocketController struct {
+	mu     sync.Mutex
+	force  func(reason string, reconnect bool)
+	closed bool
+	done   chan struct{} // Closed by Close, created on first use
+}
+
+// NewWebSocketController creates a controller for WithWebSocketController().
+func NewWebSocketController() *WebSocketController {
+	return &WebSocketController{}
+}
+
+// WithWebSocketController attaches a controller to the stream, e.g. to force a
+// reconnect after rotating credentials.
+//
+// Example:
+//
+//	ctrl := reqws.NewWebSocketController()
+//	go client.WebSocketStreamWithReconnect(ctx, sendChan, receiveChan,
+//		reqws.WithDefaultWebSocketReconnect(),
+//		reqws.WithWebSocketController(ctrl),
+//	)
+//	// later
+//	ctrl.ForceReconnect("credentials rotated")
+func WithWebSocketController(ctrl *WebSocketController) RequestOption {
+	return func(c *requestConfig) {
+		c.wsController = ctrl
+	}
+}
+
+// ForceReconnect closes the current connection cleanly with reason. With
+// WebSocketStreamWithReconnect the stream re-dials immediately, without backoff,
+// suspend windows or counting against MaxReconnectAttempts; a plain WebSocketStream
+// just ends. Returns false if no connection is open.
+func (wc *WebSocketController) ForceReconnect(reason string) bool {
+	wc.mu.Lock()
+	force := wc.force
+	wc.force = nil
+	wc.mu.Unlock()
+
+	if force == nil {
+		return false
+	}
+	force(reason, true)
+	return true
+}
+
+// Close ends the stream cleanly: the current connection goes through the
+// shutdown sequence (see WebSocketShutdownStats) with reason as the close reason,
+// and the stream returns nil without reconnecting. If no connection is open, e.g.
+// while waiting to reconnect, the stream ends before dialing again. Close returns
+// at once, before the shutdown completes; calling it again is a no-op.
+//
+// Example:
+//
+//	ctrl := reqws.NewWebSocketController()
+//	go func() {
+//		<-shutdownSignal
+//		ctrl.Close("service stopping")
+//	}()
+//	err := client.WebSocketStreamWithReconnect(ctx, sendChan, receiveChan,
+//		reqws.WithDefaultWebSocketReconnect(),
+//		reqws.WithWebSocketController(ctrl),
+//	)
+func (wc *WebSocketController) Close(reason string) {
+	wc.mu.Lock()
+	if wc.closed {
+		wc.mu.Unlock()
+		return
+	}
+	wc.closed = true
+	if wc.done == nil {
+		wc.done = make(chan struct{})
+	}
+	close(wc.done)
+	force := wc.force
+	wc.force = nil
+	wc.mu.Unlock()
+
+	if force != nil {
+		force(reason, false)
+	}
+}
+
+// closedChan returns a channel that is closed once Close has been called.
+func (wc *WebSocketController) closedChan() <-chan struct{} {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.done == nil {
+		wc.done = make(chan struct{})
+	}
+	return wc.done
+}
+
+// isClosed reports whether Close has been called. A nil controller is never closed.
+func (wc *WebSocketController) isClosed() bool {
+	if wc == nil {
+		return false
+	}
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	return wc.closed
+}
+
+// attach registers the current connection's close function. It returns false
+// without registering it if Close was already called.
+func (wc *WebSocketController) attach(force func(reason string, reconnect bool)) bool {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.closed {
+		return false
+	}
+	wc.force = force
+	return true
+}
+
+// detach drops the close function once the connection has ended.
+func (wc *WebSocketController) detach() {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.force = nil
+}
+
+// waitSuspended sleeps while reconnection is suspended. It returns false if ctx
+// was done first.
+func (c *Client) waitSuspended(ctx context.Context, config *requestConfig) bool {
+	wsConfig := config.wsConfig
+	notified := false
+	for {
+		suspended, until := wsConfig.suspendedUntil(time.Now())
+		if !suspended {
+			if notified && c.logger != nil {
+				c.logger.Info("WebSocket reconnection resumed")
+			}
+			return true
+		}
+		if !notified {
+			notified = true
+			if c.logger != nil {
+				c.logger.Info("WebSocket reconnection suspended", "until", until)
+			}
+			if c.tapping() {
+				c.tap(TapEvent{Type: TapWebSocket, URL: c.baseURL + config.path, State: "suspended"})
+			}
+		}
+
+		wait := time.Until(until)
+		if until.IsZero() || wait <= 0 {
+			wait = wsSuspendPoll
+		} else if wait > wsSuspendMaxWait {
+			wait = wsSuspendMaxWait
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
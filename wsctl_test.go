@@ -0,0 +1,196 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestSuspendWindowActiveUntil(t *testing.T) {
+	utc := time.UTC
+	// 2024-01-01 is a Monday
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 1, day, hour, minute, 0, 0, utc) }
+	nightly := SuspendWindow{Start: 23 * time.Hour, End: 2 * time.Hour, Location: utc}
+	tests := []struct {
+		name      string
+		window    SuspendWindow
+		now       time.Time
+		active    bool
+		wantUntil time.Time
+	}{
+		{"inside same-day window", SuspendWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: utc}, at(1, 3, 0), true, at(1, 4, 0)},
+		{"end is exclusive", SuspendWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: utc}, at(1, 4, 0), false, time.Time{}},
+		{"before midnight", nightly, at(1, 23, 30), true, at(2, 2, 0)},
+		{"after midnight", nightly, at(2, 1, 0), true, at(2, 2, 0)},
+		{"outside", nightly, at(2, 12, 0), false, time.Time{}},
+		{"other day", SuspendWindow{Days: []time.Weekday{time.Sunday}, Start: 0, End: 6 * time.Hour, Location: utc}, at(1, 1, 0), false, time.Time{}},
+		{"spanning from the listed day", SuspendWindow{Days: []time.Weekday{time.Sunday}, Start: 22 * time.Hour, End: 2 * time.Hour, Location: utc}, at(1, 1, 0), true, at(1, 2, 0)},
+		{"location", SuspendWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.FixedZone("WIB", 7*60*60)}, at(1, 20, 0), true, at(1, 21, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, until := tt.window.activeUntil(tt.now)
+			if active != tt.active || !until.Equal(tt.wantUntil) {
+				t.Errorf("activeUntil = %v, %v; want %v, %v", active, until, tt.active, tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestSuspendedUntil(t *testing.T) {
+	now := time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)
+	window := SuspendWindow{Start: 2 * time.Hour, End: 4 * time.Hour, Location: time.UTC}
+	tests := []struct {
+		name      string
+		fn        func(time.Time) (bool, time.Time)
+		active    bool
+		wantUntil time.Time
+	}{
+		{"window only", nil, true, now.Add(time.Hour)},
+		{"func ends later", func(time.Time) (bool, time.Time) { return true, now.Add(2 * time.Hour) }, true, now.Add(2 * time.Hour)},
+		{"func ends earlier", func(time.Time) (bool, time.Time) { return true, now.Add(time.Minute) }, true, now.Add(time.Hour)},
+		{"func end unknown", func(time.Time) (bool, time.Time) { return true, time.Time{} }, true, time.Time{}},
+		{"func not suspended", func(time.Time) (bool, time.Time) { return false, time.Time{} }, true, now.Add(time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &WebSocketConfig{SuspendWindows: []SuspendWindow{window}, SuspendFunc: tt.fn}
+			active, until := cfg.suspendedUntil(now)
+			if active != tt.active || !until.Equal(tt.wantUntil) {
+				t.Errorf("suspendedUntil = %v, %v; want %v, %v", active, until, tt.active, tt.wantUntil)
+			}
+		})
+	}
+}
+
+// controlledServer returns a WebSocket server that greets every connection and
+// reports the close reason of each connection the client ends.
+func controlledServer(t *testing.T) (string, *atomic.Int32, chan string) {
+	t.Helper()
+	var conns atomic.Int32
+	reasons := make(chan string, 10)
+	url := wsServer(t, func(conn *websocket.Conn) {
+		conns.Add(1)
+		conn.Write(context.Background(), websocket.MessageText, []byte(`{"hello":true}`))
+		for {
+			if _, _, err := conn.Read(context.Background()); err != nil {
+				var closeErr websocket.CloseError
+				if errors.As(err, &closeErr) {
+					reasons <- closeErr.Reason
+				}
+				return
+			}
+		}
+	})
+	return url, &conns, reasons
+}
+
+func TestWebSocketControllerForceReconnectAndClose(t *testing.T) {
+	url, conns, reasons := controlledServer(t)
+	ctrl := NewWebSocketController()
+	if ctrl.ForceReconnect("early") {
+		t.Error("ForceReconnect without a connection returned true")
+	}
+
+	receiveChan := make(chan WebSocketResponse, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient(url, 5*time.Second).WebSocketStreamWithReconnect(context.Background(), nil, receiveChan,
+			WithWebSocketAutoReconnect(WebSocketConfig{AutoReconnect: true, ReconnectDelay: time.Hour, MaxReconnectDelay: time.Hour, ReconnectMultiplier: 2}),
+			WithWebSocketController(ctrl))
+	}()
+
+	waitHello := func() {
+		t.Helper()
+		for {
+			select {
+			case msg := <-receiveChan:
+				if !msg.Closed && msg.Error == nil {
+					return
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no greeting received")
+			}
+		}
+	}
+	waitReason := func(want string) {
+		t.Helper()
+		select {
+		case got := <-reasons:
+			if got != want {
+				t.Errorf("close reason = %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connection not closed with %q", want)
+		}
+	}
+
+	waitHello()
+	if !ctrl.ForceReconnect("credentials rotated") {
+		t.Fatal("ForceReconnect returned false with a connection open")
+	}
+	waitReason("reconnect: credentials rotated")
+	// The forced reconnect skips the hour-long backoff
+	waitHello()
+
+	ctrl.Close("service stopping")
+	ctrl.Close("again")
+	waitReason("service stopping")
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("stream returned %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after Close")
+	}
+	if got := conns.Load(); got != 2 {
+		t.Errorf("connections = %d, want 2", got)
+	}
+}
+
+func TestWebSocketReconnectWaitsOutSuspension(t *testing.T) {
+	var conns atomic.Int32
+	url := wsServer(t, func(conn *websocket.Conn) {
+		conns.Add(1)
+		conn.Close(websocket.StatusGoingAway, "maintenance")
+	})
+
+	suspendedUntil := time.Now().Add(100 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	receiveChan := make(chan WebSocketResponse)
+	go func() {
+		for range receiveChan {
+		}
+	}()
+	var reconnectedAt atomic.Int64
+	go NewClient(url, 5*time.Second).WebSocketStreamWithReconnect(ctx, nil, receiveChan,
+		WithWebSocketAutoReconnect(WebSocketConfig{
+			AutoReconnect:       true,
+			ReconnectDelay:      time.Millisecond,
+			MaxReconnectDelay:   time.Millisecond,
+			ReconnectMultiplier: 1,
+			SuspendFunc: func(now time.Time) (bool, time.Time) {
+				return now.Before(suspendedUntil), suspendedUntil
+			},
+			OnReconnect: func() {
+				reconnectedAt.CompareAndSwap(0, time.Now().UnixNano())
+			},
+		}))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for conns.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if conns.Load() < 2 {
+		t.Fatal("stream did not reconnect after the suspension")
+	}
+	if at := time.Unix(0, reconnectedAt.Load()); at.Before(suspendedUntil) {
+		t.Errorf("reconnected %v before the suspension ended", suspendedUntil.Sub(at))
+	}
+}
@@ -0,0 +1,285 @@
+package reqws
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// wsCachedDialTimeout bounds each dial to a cached address, so a stale address
+// falls back to a fresh lookup quickly instead of waiting for the context.
+const wsCachedDialTimeout = 3 * time.Second
+
+// wsSessionCacheSize is the number of TLS sessions kept for resumption.
+const wsSessionCacheSize = 64
+
+// WebSocketDialTiming is the time spent in each phase of one WebSocket dial.
+type WebSocketDialTiming struct {
+	Attempt      int           // Reconnect attempt (0 for the first connection)
+	DNS          time.Duration // Name resolution, zero when cached addresses were used
+	Connect      time.Duration // TCP connect
+	TLS          time.Duration // TLS handshake, zero for ws:// URLs
+	Upgrade      time.Duration // HTTP upgrade request and response
+	Total        time.Duration
+	AddrCacheHit bool     // Connected to an address cached from a previous dial
+	TLSResumed   bool     // The TLS session was resumed instead of a full handshake
+	IPFamily     IPFamily // Address family of the connection (of the proxy's, through a proxy)
+}
+
+// WebSocketDialStats holds WebSocket dial cache counters.
+type WebSocketDialStats struct {
+	Dials          uint64 // Successful dials
+	AddrCacheHits  uint64 // Dials that used cached addresses
+	AddrFallbacks  uint64 // Times all cached addresses failed and the host was resolved again
+	TLSResumptions uint64 // Dials that resumed a TLS session
+	Last           WebSocketDialTiming
+}
+
+// WithWebSocketDialCache speeds up WebSocket reconnects by caching what the previous
+// dial learned. TLS sessions are shared across dials so reconnects resume them instead
+// of doing a full handshake, and the addresses a host resolved to are kept for addrTTL
+// and tried first (the last working one leading). If every cached address fails, the
+// host is resolved again. An addrTTL of 0 disables address caching but keeps TLS
+// resumption.
+//
+// Each successful dial is measured; read the breakdown with WebSocketDialStats() or
+// WebSocketConfig.OnDial. Dials through a proxy cache the proxy's addresses.
+//
+// Example:
+//
+//	client := reqws.NewClient("wss://feed.example.com", 0).
+//		WithWebSocketDialCache(5 * time.Minute)
+func (c *Client) WithWebSocketDialCache(addrTTL time.Duration) *Client {
+	c.wsDialer = &wsDialer{
+		ttl:      addrTTL,
+		sessions: tls.NewLRUClientSessionCache(wsSessionCacheSize),
+		lookup:   net.DefaultResolver.LookupIPAddr,
+		addrs:    make(map[string]wsCachedAddrs),
+	}
+	return c
+}
+
+// WebSocketDialStats returns the WebSocket dial cache counters.
+// Returns zero values if the dial cache is not enabled.
+func (c *Client) WebSocketDialStats() WebSocketDialStats {
+	if c.wsDialer == nil {
+		return WebSocketDialStats{}
+	}
+	c.wsDialer.mu.Lock()
+	defer c.wsDialer.mu.Unlock()
+	return c.wsDialer.stats
+}
+
+type wsDialer struct {
+	ttl      time.Duration
+	sessions tls.ClientSessionCache
+	lookup   func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+	mu    sync.Mutex
+	addrs map[string]wsCachedAddrs
+	stats WebSocketDialStats
+}
+
+type wsCachedAddrs struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+type wsDialTimingKey struct{}
+
+type wsAttemptKey struct{}
+
+// transport returns a transport for one dial that resolves through the cache,
+// connects with the client's dialer and shares the TLS session cache. base may be nil.
+func (d *wsDialer) transport(base *http.Transport, insecure bool, dial *dialConfig) *http.Transport {
+	var transport *http.Transport
+	if base != nil {
+		transport = base.Clone()
+	} else {
+		transport = &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSHandshakeTimeout: 10 * time.Second,
+		}
+	}
+
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	tlsConfig.ClientSessionCache = d.sessions
+	if insecure {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return d.dialContext(ctx, network, addr, dial)
+	}
+	return transport
+}
+
+// trace returns a context that records the timing of the dial made with it.
+func (d *wsDialer) trace(ctx context.Context) (context.Context, *WebSocketDialTiming) {
+	timing := &WebSocketDialTiming{}
+	timing.Attempt, _ = ctx.Value(wsAttemptKey{}).(int)
+
+	var tlsStart time.Time
+	ctx = context.WithValue(ctx, wsDialTimingKey{}, timing)
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			timing.TLS = time.Since(tlsStart)
+			timing.TLSResumed = err == nil && state.DidResume
+		},
+	})
+	return ctx, timing
+}
+
+// record stores the timing of a successful dial.
+func (d *wsDialer) record(timing WebSocketDialTiming) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stats.Dials++
+	if timing.AddrCacheHit {
+		d.stats.AddrCacheHits++
+	}
+	if timing.TLSResumed {
+		d.stats.TLSResumptions++
+	}
+	d.stats.Last = timing
+}
+
+// dialContext dials addr with dial, trying cached addresses for the host before
+// resolving it.
+func (d *wsDialer) dialContext(ctx context.Context, network, addr string, dial *dialConfig) (net.Conn, error) {
+	timing, _ := ctx.Value(wsDialTimingKey{}).(*WebSocketDialTiming)
+	if timing == nil {
+		timing = &WebSocketDialTiming{}
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		start := time.Now()
+		conn, err := dial.dialContext(ctx, network, addr)
+		timing.Connect = time.Since(start)
+		if err == nil {
+			timing.IPFamily = addrFamily(conn.RemoteAddr())
+		}
+		return conn, err
+	}
+
+	if ips := d.cached(host); len(ips) > 0 {
+		start := time.Now()
+		conn, ip, err := d.dialCached(ctx, network, ips, port, dial)
+		if err == nil {
+			timing.Connect = time.Since(start)
+			timing.AddrCacheHit = true
+			timing.IPFamily = addrFamily(conn.RemoteAddr())
+			d.remember(host, ip, ips)
+			return conn, nil
+		}
+		d.forget(host)
+	}
+
+	start := time.Now()
+	addrs, err := d.lookup(ctx, host)
+	timing.DNS = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+
+	start = time.Now()
+	conn, err := dial.dialIPs(ctx, network, ips, port)
+	timing.Connect = time.Since(start)
+	if err != nil {
+		return nil, err
+	}
+	timing.IPFamily = addrFamily(conn.RemoteAddr())
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		d.remember(host, tcpAddr.IP, ips)
+	}
+	return conn, nil
+}
+
+// dialCached dials each cached address the client's IP family policy allows in
+// turn, the last working one first, each for at most wsCachedDialTimeout, and
+// returns the first connection.
+func (d *wsDialer) dialCached(ctx context.Context, network string, ips []net.IP, port string, dial *dialConfig) (net.Conn, net.IP, error) {
+	dialer := dial.netDialer()
+	err := errors.New("no addresses to dial")
+	for _, ip := range ips {
+		if !dial.allows(ip) {
+			continue
+		}
+		dialCtx, cancel := context.WithTimeout(ctx, wsCachedDialTimeout)
+		var conn net.Conn
+		conn, err = dialer.DialContext(dialCtx, network, net.JoinHostPort(ip.String(), port))
+		cancel()
+		if err == nil {
+			return conn, ip, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+	}
+	return nil, nil, err
+}
+
+// cached returns the unexpired cached addresses for host.
+func (d *wsDialer) cached(host string) []net.IP {
+	if d.ttl <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.addrs[host]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.ips
+}
+
+// remember caches ips for host with the address that worked first.
+func (d *wsDialer) remember(host string, working net.IP, ips []net.IP) {
+	if d.ttl <= 0 {
+		return
+	}
+	ordered := make([]net.IP, 0, len(ips))
+	ordered = append(ordered, working)
+	for _, ip := range ips {
+		if !ip.Equal(working) {
+			ordered = append(ordered, ip)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expires := time.Now().Add(d.ttl)
+	if entry, ok := d.addrs[host]; ok && time.Now().Before(entry.expires) {
+		// A cache hit keeps the original expiry so the host is still re-resolved every TTL
+		expires = entry.expires
+	}
+	d.addrs[host] = wsCachedAddrs{ips: ordered, expires: expires}
+}
+
+// forget drops the cached addresses for host after they all failed.
+func (d *wsDialer) forget(host string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.addrs, host)
+	d.stats.AddrFallbacks++
+}
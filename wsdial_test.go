@@ -0,0 +1,106 @@
+package reqws
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// wsDialCacheClient returns a client for a TLS WebSocket server that closes every
+// connection right away, reached through the name feed.test, and a count of the
+// name's lookups.
+func wsDialCacheClient(t *testing.T) (*Client, *atomic.Int32) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn.Close(websocket.StatusNormalClosure, "bye")
+	}))
+	t.Cleanup(server.Close)
+
+	var lookups atomic.Int32
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "https://"))
+	client := NewClient("wss://feed.test:"+port, 5*time.Second).WithWebSocketDialCache(time.Minute)
+	client.wsDialer.lookup = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		lookups.Add(1)
+		if host != "feed.test" {
+			t.Errorf("lookup of %q", host)
+		}
+		return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+	}
+	return client, &lookups
+}
+
+// wsDialOnce opens a stream and waits for the server to close it.
+func wsDialOnce(t *testing.T, client *Client, opts ...RequestOption) {
+	t.Helper()
+	receiveChan := make(chan WebSocketResponse, 1)
+	opts = append([]RequestOption{WithInsecureSkipVerify()}, opts...)
+	// The stream ends with "connection closed" once the server hangs up
+	err := client.WebSocketStream(context.Background(), nil, receiveChan, opts...)
+	if err != nil && strings.Contains(err.Error(), "dial failed") {
+		t.Fatalf("WebSocketStream: %v", err)
+	}
+	if msg := <-receiveChan; !msg.Closed {
+		t.Errorf("message = %+v, want the close", msg)
+	}
+}
+
+func TestWebSocketDialCache(t *testing.T) {
+	client, lookups := wsDialCacheClient(t)
+	var timings []WebSocketDialTiming
+	onDial := WithWebSocketAutoReconnect(WebSocketConfig{OnDial: func(timing WebSocketDialTiming) {
+		timings = append(timings, timing)
+	}})
+
+	wsDialOnce(t, client, onDial)
+	wsDialOnce(t, client, onDial)
+
+	if got := lookups.Load(); got != 1 {
+		t.Errorf("lookups = %d, want the second dial to use the cached address", got)
+	}
+	if len(timings) != 2 {
+		t.Fatalf("OnDial calls = %d, want 2", len(timings))
+	}
+	if first := timings[0]; first.AddrCacheHit || first.TLSResumed || first.TLS <= 0 || first.IPFamily != IPv4 {
+		t.Errorf("first dial = %+v, want a full handshake over IPv4", first)
+	}
+	if second := timings[1]; !second.AddrCacheHit || !second.TLSResumed || second.DNS != 0 {
+		t.Errorf("second dial = %+v, want a cache hit and a resumed session", second)
+	}
+	stats := client.WebSocketDialStats()
+	if stats.Dials != 2 || stats.AddrCacheHits != 1 || stats.TLSResumptions != 1 || stats.Last != timings[1] {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWebSocketDialCacheFallback(t *testing.T) {
+	client, lookups := wsDialCacheClient(t)
+	// A cached address nothing listens on
+	client.wsDialer.addrs["feed.test"] = wsCachedAddrs{ips: []net.IP{net.IPv4(127, 0, 0, 2)}, expires: time.Now().Add(time.Minute)}
+
+	wsDialOnce(t, client)
+
+	stats := client.WebSocketDialStats()
+	if stats.AddrFallbacks != 1 || stats.AddrCacheHits != 0 || lookups.Load() != 1 {
+		t.Errorf("stats = %+v after %d lookups, want one fallback to a fresh lookup", stats, lookups.Load())
+	}
+	if ips := client.wsDialer.cached("feed.test"); len(ips) != 1 || !ips[0].Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("cached addresses = %v, want the resolved address", ips)
+	}
+}
+
+func TestWebSocketDialStatsWithoutCache(t *testing.T) {
+	if stats := NewClient("wss://unused", time.Second).WebSocketDialStats(); stats != (WebSocketDialStats{}) {
+		t.Errorf("stats = %+v, want zero values", stats)
+	}
+}
@@ -0,0 +1,136 @@
+package reqws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coder/websocket"
+)
+
+// MessageType is the frame type of a WebSocket message.
+type MessageType int
+
+const (
+	MessageText   MessageType = MessageType(websocket.MessageText)   // UTF-8 text frame
+	MessageBinary MessageType = MessageType(websocket.MessageBinary) // Binary frame
+)
+
+// InboundTransform rewrites a received WebSocket message before it is decoded,
+// e.g. to decompress payloads the server compressed itself. It must not retain raw.
+type InboundTransform func(raw []byte, typ MessageType) ([]byte, error)
+
+// GzipInbound returns an InboundTransform that decompresses gzipped messages,
+// sent either as raw bytes or base64-encoded. Messages without the gzip magic
+// bytes are passed through untouched, so plain and compressed messages can be
+// mixed on one stream.
+//
+// Example:
+//
+//	recv, errs, err := client.OpenWebSocket(ctx, nil,
+//		reqws.WithPath("/feed"),
+//		reqws.WithWebSocketAutoReconnect(reqws.WebSocketConfig{
+//			InboundTransform: reqws.GzipInbound(),
+//		}),
+//	)
+func GzipInbound() InboundTransform {
+	return func(raw []byte, _ MessageType) ([]byte, error) {
+		return inflateInbound(raw, isGzip, func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		})
+	}
+}
+
+// DeflateInbound returns an InboundTransform that decompresses zlib-wrapped
+// deflate messages (RFC 1950), sent either as raw bytes or base64-encoded.
+// Messages without a zlib header are passed through untouched.
+func DeflateInbound() InboundTransform {
+	return func(raw []byte, _ MessageType) ([]byte, error) {
+		return inflateInbound(raw, isZlib, func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		})
+	}
+}
+
+// inflateInbound decompresses raw, or its base64 decoding, if match recognizes
+// the compression header, and returns raw unchanged otherwise.
+func inflateInbound(raw []byte, match func([]byte) bool, open func(io.Reader) (io.Reader, error)) ([]byte, error) {
+	data := raw
+	if !match(data) {
+		decoded, err := base64.StdEncoding.AppendDecode(nil, bytes.TrimSpace(raw))
+		if err != nil || !match(decoded) {
+			return raw, nil
+		}
+		data = decoded
+	}
+	r, err := open(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	return readDecompressed(r)
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func isZlib(data []byte) bool {
+	// CM 8 (deflate) with a window of at most 32K, and a valid header checksum
+	return len(data) >= 2 && data[0]&0x0f == 8 && data[0]>>4 <= 7 && (uint16(data[0])<<8|uint16(data[1]))%31 == 0
+}
+
+// wsMaxGunzipSize limits the decompressed size of a compressed WebSocket message.
+const wsMaxGunzipSize = 16 * 1024 * 1024
+
+// readDecompressed reads a decompressing reader up to wsMaxGunzipSize.
+func readDecompressed(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, wsMaxGunzipSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
+	}
+	if len(data) > wsMaxGunzipSize {
+		return nil, fmt.Errorf("decompressed message exceeds %d bytes", wsMaxGunzipSize)
+	}
+	return data, nil
+}
+
+// decodeWSFrame decodes a received frame into a message: the inbound transform
+// runs first, then gzip decompression if gzipped is set and the frame carries
+// the gzip magic bytes, then JSON decoding. A failure is reported on the
+// message with RawData set to the frame as received.
+func decodeWSFrame(typ websocket.MessageType, data []byte, gzipped bool, transform InboundTransform) WebSocketResponse {
+	resp := WebSocketResponse{WireSize: len(data)}
+	decoded := data
+	var err error
+	if transform != nil {
+		if decoded, err = transform(data, MessageType(typ)); err != nil {
+			resp.RawData, resp.Error = data, fmt.Errorf("inbound transform failed: %w", err)
+			return resp
+		}
+	}
+	if gzipped && isGzip(decoded) {
+		var zr *gzip.Reader
+		if zr, err = gzip.NewReader(bytes.NewReader(decoded)); err == nil {
+			decoded, err = readDecompressed(zr)
+		} else {
+			err = fmt.Errorf("failed to decompress message: %w", err)
+		}
+		if err != nil {
+			resp.RawData, resp.Error = data, err
+			return resp
+		}
+	}
+	resp.DecodedSize = len(decoded)
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(decoded, &msg); err != nil {
+		resp.RawData, resp.Error = data, fmt.Errorf("failed to decode JSON message: %w", err)
+		return resp
+	}
+	resp.Data = msg
+	return resp
+}
@@ -0,0 +1,171 @@
+package reqws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zlibBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zlib: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeWSFrame(t *testing.T) {
+	msg := []byte(`{"price":42}`)
+	gz, zl := gzipBytes(t, msg), zlibBytes(t, msg)
+	b64 := func(data []byte) []byte { return []byte(base64.StdEncoding.EncodeToString(data) + "\n") }
+	failing := func([]byte, MessageType) ([]byte, error) { return nil, errors.New("bad frame") }
+
+	tests := []struct {
+		name      string
+		data      []byte
+		gzipped   bool
+		transform InboundTransform
+		wantErr   string
+	}{
+		{"plain", msg, false, nil, ""},
+		{"gzip transform, raw", gz, false, GzipInbound(), ""},
+		{"gzip transform, base64", b64(gz), false, GzipInbound(), ""},
+		{"gzip transform, plain passes through", msg, false, GzipInbound(), ""},
+		{"deflate transform, raw", zl, false, DeflateInbound(), ""},
+		{"deflate transform, base64", b64(zl), false, DeflateInbound(), ""},
+		{"deflate transform, plain passes through", msg, false, DeflateInbound(), ""},
+		{"gzip option", gz, true, nil, ""},
+		{"gzip option, plain", msg, true, nil, ""},
+		{"corrupt gzip", append(gz[:10:10], "garbage"...), false, GzipInbound(), "failed to decompress message"},
+		{"failing transform", msg, false, failing, "inbound transform failed: bad frame"},
+		{"not JSON", []byte("hello"), false, GzipInbound(), "failed to decode JSON message"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeWSFrame(websocket.MessageBinary, tt.data, tt.gzipped, tt.transform)
+			if resp.WireSize != len(tt.data) {
+				t.Errorf("WireSize = %d, want %d", resp.WireSize, len(tt.data))
+			}
+			if tt.wantErr != "" {
+				if resp.Error == nil || !strings.Contains(resp.Error.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want %q", resp.Error, tt.wantErr)
+				}
+				if !bytes.Equal(resp.RawData, tt.data) {
+					t.Errorf("RawData = %q, want the frame as received", resp.RawData)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("Error = %v", resp.Error)
+			}
+			if resp.DecodedSize != len(msg) {
+				t.Errorf("DecodedSize = %d, want %d", resp.DecodedSize, len(msg))
+			}
+			if price := resp.Data.(map[string]interface{})["price"]; price != 42.0 {
+				t.Errorf("Data = %v", resp.Data)
+			}
+		})
+	}
+}
+
+func TestInboundDecompressionLimit(t *testing.T) {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	zw.Write(make([]byte, wsMaxGunzipSize+1))
+	zw.Close()
+	bomb := buf.Bytes()
+	resp := decodeWSFrame(websocket.MessageBinary, bomb, false, GzipInbound())
+	if resp.Error == nil || !strings.Contains(resp.Error.Error(), "exceeds") {
+		t.Errorf("Error = %v, want the size limit", resp.Error)
+	}
+}
+
+func TestIsZlib(t *testing.T) {
+	for _, tt := range []struct {
+		data []byte
+		want bool
+	}{
+		{[]byte{0x78, 0x9c}, true},  // Default compression
+		{[]byte{0x78, 0x01}, true},  // No compression
+		{[]byte{0x78, 0xda}, true},  // Best compression
+		{[]byte{0x78, 0x9d}, false}, // Bad header checksum
+		{[]byte{0x88, 0x98}, false}, // Window over 32K
+		{[]byte(`{"`), false},
+		{[]byte{0x78}, false},
+	} {
+		if got := isZlib(tt.data); got != tt.want {
+			t.Errorf("isZlib(%x) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestWebSocketInboundTransform(t *testing.T) {
+	msg := []byte(`{"price":42}`)
+	gz := gzipBytes(t, msg)
+	url := wsServer(t, func(conn *websocket.Conn) {
+		ctx := context.Background()
+		conn.Write(ctx, websocket.MessageBinary, gz)
+		conn.Write(ctx, websocket.MessageText, []byte(base64.StdEncoding.EncodeToString(gz)))
+		conn.Write(ctx, websocket.MessageText, msg)
+		conn.Close(websocket.StatusNormalClosure, "done")
+	})
+
+	for _, workers := range []int{0, 4} {
+		var mu sync.Mutex // Decode workers run the transform concurrently
+		var types []MessageType
+		receiveChan := make(chan WebSocketResponse, 10)
+		NewClient(url, 5*time.Second).WebSocketStream(context.Background(), nil, receiveChan,
+			WithWebSocketAutoReconnect(WebSocketConfig{
+				DecodeWorkers: workers,
+				InboundTransform: func(raw []byte, typ MessageType) ([]byte, error) {
+					mu.Lock()
+					types = append(types, typ)
+					mu.Unlock()
+					return GzipInbound()(raw, typ)
+				},
+			}))
+
+		var decoded int
+		for resp := range receiveChan {
+			if resp.Closed {
+				continue
+			}
+			if resp.Error != nil {
+				t.Errorf("workers %d: Error = %v", workers, resp.Error)
+				continue
+			}
+			if resp.Data.(map[string]interface{})["price"] == 42.0 {
+				decoded++
+			}
+		}
+		if decoded != 3 {
+			t.Errorf("workers %d: decoded %d messages, want 3", workers, decoded)
+		}
+		if workers == 0 && (len(types) != 3 || types[0] != MessageBinary || types[1] != MessageText) {
+			t.Errorf("transform saw types %v", types)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package reqws
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// WebSocketShutdownStats is the timing of one connection's shutdown, reported to
+// WebSocketConfig.OnShutdown.
+//
+// A connection always shuts down in this order, whatever ended it (sendChan
+// closed, the send context done, the stream context done, the server closing, a
+// failed write or a WebSocketController):
+//
+//  1. Stop sends: no new message write starts, and a write in progress finishes.
+//  2. Close handshake: the close frame is sent, then the server's close frame
+//     is awaited (up to 5 seconds) before the connection is closed.
+//  3. Stop the read loop: the reader delivers its final message with Closed set
+//     and stops.
+//  4. Final event: the "closed" TapEvent is emitted and OnShutdown is called.
+//
+// Nothing is written to the connection after the close frame.
+//
+// The package has no heartbeats, send batching or persistent send queue, so the
+// sequence has no phases to stop, flush or drain them: every message is written
+// as it is taken from sendChan, and a message not yet taken when sends stop is
+// not sent.
+type WebSocketShutdownStats struct {
+	StopSends time.Duration // Waiting for a write in progress to finish
+	Handshake time.Duration // Sending the close frame until the connection is closed
+	StopRead  time.Duration // Waiting for the read loop to stop
+	Total     time.Duration
+
+	// ReadTimedOut is set if the read loop did not stop within 5 seconds because
+	// its final message was not received from receiveChan; only WebSocketStream
+	// waits a bounded time for it.
+	ReadTimedOut bool
+}
+
+// wsStopTimeout bounds the waits for a write in progress and, unless receiveChan
+// outlives the connection, for the read loop to stop.
+const wsStopTimeout = 5 * time.Second
+
+// wsPhase is a step of a connection's shutdown. Phases only move forward, one at a time.
+type wsPhase int
+
+const (
+	wsPhaseOpen      wsPhase = iota // Writes allowed
+	wsPhaseStopSends                // No new writes; waiting for the one in progress
+	wsPhaseHandshake                // Close frame being sent; the server's awaited
+	wsPhaseStopRead                 // Connection closed; waiting for the read loop
+	wsPhaseClosed                   // Shutdown complete
+)
+
+// wsShutdown sequences the shutdown of one connection. Writes are admitted with
+// beginWrite only while the connection is open, and shutdown runs its phases in
+// order exactly once, however many goroutines ask for it.
+type wsShutdown struct {
+	conn        *websocket.Conn
+	readDone    <-chan struct{}
+	keepReceive bool // receiveChan outlives the connection: wait for the read loop without a limit
+
+	mu      sync.Mutex
+	idle    *sync.Cond // Signaled when the last write in progress ends
+	phase   wsPhase
+	writing int
+	reason  string // Close reason set before shutdown was requested, see setReason
+
+	once  sync.Once
+	stats WebSocketShutdownStats
+}
+
+func newWSShutdown(conn *websocket.Conn, readDone <-chan struct{}, keepReceive bool) *wsShutdown {
+	s := &wsShutdown{conn: conn, readDone: readDone, keepReceive: keepReceive}
+	s.idle = sync.NewCond(&s.mu)
+	return s
+}
+
+// beginWrite admits one write and reports false once shutdown has started.
+// Every admitted write must be ended with endWrite.
+func (s *wsShutdown) beginWrite() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.phase != wsPhaseOpen {
+		return false
+	}
+	s.writing++
+	return true
+}
+
+func (s *wsShutdown) endWrite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.writing--; s.writing == 0 {
+		s.idle.Broadcast()
+	}
+}
+
+// setReason sets the close reason to send instead of the one passed to close, for
+// a shutdown requested by a controller before the connection notices it ends.
+func (s *wsShutdown) setReason(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.phase == wsPhaseOpen && s.reason == "" {
+		s.reason = reason
+	}
+}
+
+// advance moves to the next phase. Skipping or repeating a phase is a bug.
+func (s *wsShutdown) advance(to wsPhase) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if to != s.phase+1 {
+		panic(fmt.Sprintf("reqws: WebSocket shutdown phase %d after %d", to, s.phase))
+	}
+	s.phase = to
+}
+
+// close runs the shutdown sequence with the given close status, or waits for the
+// run already started by another goroutine, and returns its timing.
+func (s *wsShutdown) close(code websocket.StatusCode, reason string) WebSocketShutdownStats {
+	s.once.Do(func() {
+		start := time.Now()
+
+		s.advance(wsPhaseStopSends)
+		s.waitWrites()
+		s.stats.StopSends = time.Since(start)
+
+		s.advance(wsPhaseHandshake)
+		s.mu.Lock()
+		if s.reason != "" {
+			reason = s.reason
+		}
+		s.mu.Unlock()
+		handshakeStart := time.Now()
+		s.conn.Close(code, reason)
+		s.stats.Handshake = time.Since(handshakeStart)
+
+		s.advance(wsPhaseStopRead)
+		readStart := time.Now()
+		s.stats.ReadTimedOut = !s.waitRead()
+		s.stats.StopRead = time.Since(readStart)
+
+		s.advance(wsPhaseClosed)
+		s.stats.Total = time.Since(start)
+	})
+	return s.stats
+}
+
+// waitWrites waits up to wsStopTimeout for the writes in progress to end. A write
+// still blocked then fails when the connection is closed; the websocket library
+// never interleaves the close frame with it.
+func (s *wsShutdown) waitWrites() {
+	timer := time.AfterFunc(wsStopTimeout, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.idle.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(wsStopTimeout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.writing > 0 && time.Now().Before(deadline) {
+		s.idle.Wait()
+	}
+}
+
+// waitRead waits for the read loop to stop and reports whether it did.
+func (s *wsShutdown) waitRead() bool {
+	if s.keepReceive {
+		<-s.readDone
+		return true
+	}
+	timer := time.NewTimer(wsStopTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.readDone:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
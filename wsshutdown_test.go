@@ -0,0 +1,297 @@
+package reqws
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestWSShutdownWaitsForWriteInProgress(t *testing.T) {
+	conn := wsTestConn(t, func(conn *websocket.Conn) {
+		conn.Read(context.Background())
+	})
+	readDone := make(chan struct{})
+	close(readDone)
+	shutdown := newWSShutdown(conn, readDone, false)
+
+	if !shutdown.beginWrite() {
+		t.Fatal("beginWrite refused on an open connection")
+	}
+	results := make(chan WebSocketShutdownStats, 2)
+	for range 2 {
+		go func() { results <- shutdown.close(websocket.StatusNormalClosure, "closing stream") }()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if shutdown.beginWrite() {
+		t.Error("beginWrite admitted a write after shutdown started")
+	}
+	select {
+	case <-results:
+		t.Fatal("shutdown finished while a write was in progress")
+	default:
+	}
+
+	shutdown.endWrite()
+	first, second := <-results, <-results
+	if first != second {
+		t.Errorf("concurrent close calls got different stats: %+v and %+v", first, second)
+	}
+	if first.StopSends < 50*time.Millisecond {
+		t.Errorf("StopSends = %v, want it to include the write in progress", first.StopSends)
+	}
+	if first.ReadTimedOut || first.Total < first.StopSends+first.Handshake+first.StopRead {
+		t.Errorf("stats = %+v, want the phases within Total and the read loop stopped", first)
+	}
+
+	err := NewClient("", 5*time.Second).wsWrite(context.Background(), conn, map[string]int{"n": 1}, shutdown, &requestConfig{})
+	if !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("wsWrite after shutdown = %v, want ErrStreamClosed", err)
+	}
+}
+
+func TestWebSocketShutdownSequence(t *testing.T) {
+	received := make(chan string, 1)
+	reasons := make(chan string, 1)
+	url := wsServer(t, func(conn *websocket.Conn) {
+		for {
+			_, data, err := conn.Read(context.Background())
+			var closeErr websocket.CloseError
+			if errors.As(err, &closeErr) {
+				reasons <- closeErr.Reason
+			}
+			if err != nil {
+				return
+			}
+			received <- strings.TrimSpace(string(data))
+		}
+	})
+
+	client := NewClient(url, 5*time.Second)
+	events, stop := client.Tap(16)
+	defer stop()
+
+	receiveChan := make(chan WebSocketResponse, 4)
+	var mu sync.Mutex
+	var stats []WebSocketShutdownStats
+	var delivered []WebSocketResponse
+	onShutdown := func(s WebSocketShutdownStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats = append(stats, s)
+		// The read loop has stopped: its final message is already delivered
+		for {
+			select {
+			case msg, ok := <-receiveChan:
+				if !ok {
+					return
+				}
+				delivered = append(delivered, msg)
+			default:
+				return
+			}
+		}
+	}
+
+	sendChan := make(chan interface{}, 1)
+	sendChan <- map[string]string{"op": "subscribe"}
+	close(sendChan)
+	err := client.WebSocketStream(context.Background(), sendChan, receiveChan,
+		WithWebSocketAutoReconnect(WebSocketConfig{OnShutdown: onShutdown}))
+	if err != nil {
+		t.Fatalf("WebSocketStream: %v", err)
+	}
+
+	if got := <-received; got != `{"op":"subscribe"}` {
+		t.Errorf("server received %q", got)
+	}
+	select {
+	case got := <-reasons:
+		if got != "closing stream" {
+			t.Errorf("close reason = %q, want %q", got, "closing stream")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not receive a close frame")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stats) != 1 {
+		t.Fatalf("OnShutdown called %d times, want 1", len(stats))
+	}
+	if len(delivered) == 0 || !delivered[len(delivered)-1].Closed {
+		t.Errorf("messages delivered before OnShutdown = %+v, want a final Closed message", delivered)
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if event.Type != TapWebSocket || event.State != "closed" {
+				continue
+			}
+			if event.Duration != stats[0].Total {
+				t.Errorf("closed event Duration = %v, want the shutdown Total %v", event.Duration, stats[0].Total)
+			}
+			return
+		case <-time.After(time.Second):
+			t.Fatal("no closed tap event")
+		}
+	}
+}
+
+// frameRecorder is a scripted WebSocket server that speaks the wire protocol
+// itself, so it sees every frame the client writes, including any written after
+// the close frame. It sends text frames until the client's close frame arrives,
+// answers it, and records the opcodes received until the client disconnects.
+func frameRecorder(t *testing.T) (url string, opcodes <-chan []byte) {
+	done := make(chan []byte, 1)
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			base64.StdEncoding.EncodeToString(sum[:]))
+		rw.Flush()
+
+		var writeMu sync.Mutex
+		writeFrame := func(opcode byte, payload []byte) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			conn.Write(append([]byte{0x80 | opcode, byte(len(payload))}, payload...))
+		}
+		stopTicks := make(chan struct{})
+		go func() {
+			// Keep reads in flight throughout the shutdown
+			for n := 0; ; n++ {
+				select {
+				case <-stopTicks:
+					return
+				case <-time.After(time.Millisecond):
+					writeFrame(0x1, []byte(fmt.Sprintf(`{"tick":%d}`, n)))
+				}
+			}
+		}()
+
+		var received []byte
+		defer func() { done <- received }()
+		for {
+			var header [2]byte
+			if _, err := io.ReadFull(rw, header[:]); err != nil {
+				return
+			}
+			opcode, length := header[0]&0x0f, int64(header[1]&0x7f)
+			switch length {
+			case 126:
+				var ext [2]byte
+				io.ReadFull(rw, ext[:])
+				length = int64(binary.BigEndian.Uint16(ext[:]))
+			case 127:
+				var ext [8]byte
+				io.ReadFull(rw, ext[:])
+				length = int64(binary.BigEndian.Uint64(ext[:]))
+			}
+			var mask [4]byte
+			io.ReadFull(rw, mask[:])
+			if _, err := io.CopyN(io.Discard, rw, length); err != nil {
+				return
+			}
+			received = append(received, opcode)
+			if opcode == 0x8 && bytes.Count(received, []byte{0x8}) == 1 {
+				close(stopTicks)
+				writeFrame(0x8, []byte{0x03, 0xe8}) // 1000, normal closure
+			}
+		}
+	})
+	return "ws" + strings.TrimPrefix(server.URL, "http"), done
+}
+
+func TestWebSocketShutdownFrameOrderWithAllFeatures(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(ctrl *WebSocketController, writers []*WebSocketWriter)
+	}{
+		{"writers closed", func(_ *WebSocketController, writers []*WebSocketWriter) {
+			for _, w := range writers {
+				w.Close()
+			}
+		}},
+		{"controller closed", func(ctrl *WebSocketController, _ []*WebSocketWriter) {
+			ctrl.Close("done")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, opcodes := frameRecorder(t)
+			var shutdowns atomic.Int32
+			ctrl := NewWebSocketController()
+			receiveChan := make(chan WebSocketResponse, 4)
+			session := NewClient(url, 5*time.Second).OpenWebSocketStream(context.Background(), receiveChan,
+				WithWebSocketGzip(),
+				WithWebSocketController(ctrl),
+				WithWebSocketAutoReconnect(WebSocketConfig{
+					SendRetries: 2,
+					OnShutdown:  func(WebSocketShutdownStats) { shutdowns.Add(1) },
+				}))
+			var reads atomic.Int32
+			go func() {
+				for range receiveChan {
+					reads.Add(1)
+				}
+			}()
+
+			// Concurrent producers keep sending until the stream refuses them
+			writers := make([]*WebSocketWriter, 4)
+			var wg sync.WaitGroup
+			for i := range writers {
+				writers[i] = session.NewWriter()
+				wg.Add(1)
+				go func(w *WebSocketWriter) {
+					defer wg.Done()
+					for n := 0; w.Send(context.Background(), map[string]int{"n": n}) == nil; n++ {
+					}
+				}(writers[i])
+			}
+			time.Sleep(50 * time.Millisecond)
+			tt.stop(ctrl, writers)
+			session.Wait()
+			wg.Wait()
+
+			var got []byte
+			select {
+			case got = <-opcodes:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the client did not disconnect")
+			}
+			closeAt := bytes.IndexByte(got, 0x8)
+			if closeAt < 0 {
+				t.Fatalf("received opcodes %v, want a close frame", got)
+			}
+			if closeAt != len(got)-1 {
+				t.Errorf("received %d frames after the close frame: %v", len(got)-1-closeAt, got[closeAt:])
+			}
+			if binaryFrames := bytes.Count(got, []byte{0x2}); binaryFrames == 0 || binaryFrames != closeAt {
+				t.Errorf("received opcodes %v before the close frame, want only gzipped binary messages", got[:closeAt])
+			}
+			if reads.Load() == 0 || shutdowns.Load() != 1 {
+				t.Errorf("%d messages read and %d shutdowns, want reads in flight and one shutdown", reads.Load(), shutdowns.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package reqws
+
+import "sync/atomic"
+
+// ActiveStreams returns the number of the client's WebSocket streams that have
+// not fully ended, for detecting goroutine leaks in tests and production. A stream
+// counts from the call that starts it (WebSocketStream,
+// WebSocketStreamWithReconnect, OpenWebSocket or OpenWebSocketStream) until that
+// call has returned, or its background goroutine has finished, and every read
+// goroutine of its connections has exited, including after a panic.
+//
+// A count that stays above zero once every stream was expected to end points to
+// a leak, typically a read goroutine blocked on a receiveChan nobody drains (see
+// WebSocketShutdownStats.ReadTimedOut) or an OpenWebSocket stream whose context
+// is never cancelled.
+//
+// Example:
+//
+//	err := client.WebSocketStream(ctx, sendChan, receiveChan, reqws.WithPath("/ws"))
+//	if n := client.ActiveStreams(); n != 0 {
+//		t.Errorf("%d WebSocket streams still active", n)
+//	}
+func (c *Client) ActiveStreams() int {
+	return int(c.activeStreams.Load())
+}
+
+// wsStream tracks one stream for ActiveStreams. It holds a reference for the
+// call serving the stream and one for each read goroutine; the stream ends with
+// the last release. Releases are deferred so a panic cannot leave it counted.
+type wsStream struct {
+	client *Client
+	refs   atomic.Int32
+}
+
+// beginStream counts a new stream holding the reference of the calling code.
+func (c *Client) beginStream() *wsStream {
+	c.activeStreams.Add(1)
+	s := &wsStream{client: c}
+	s.refs.Store(1)
+	return s
+}
+
+// acquire adds a reference for a goroutine about to be started.
+func (s *wsStream) acquire() {
+	if s != nil {
+		s.refs.Add(1)
+	}
+}
+
+// release drops a reference and ends the stream with the last one.
+func (s *wsStream) release() {
+	if s != nil && s.refs.Add(-1) == 0 {
+		s.client.activeStreams.Add(-1)
+	}
+}
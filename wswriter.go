@@ -0,0 +1,154 @@
+package reqws
+
+import (
+	"context"
+	"sync"
+)
+
+// WebSocketSession is a WebSocket stream shared by several producers.
+// Each producer gets its own WebSocketWriter; the send side of the stream is only
+// closed after every writer has been closed, so producers can shut down
+// independently without closing a channel another producer still sends on.
+type WebSocketSession struct {
+	sendChan chan interface{}
+	done     chan struct{}
+	err      error
+
+	mu       sync.Mutex
+	writers  int
+	opened   bool
+	closing  bool
+	inflight sync.WaitGroup
+}
+
+// WebSocketWriter is one producer's handle on a WebSocketSession.
+// Send and Close are safe for concurrent use.
+type WebSocketWriter struct {
+	session *WebSocketSession
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// OpenWebSocketStream starts a WebSocket stream in the background and returns a
+// session to create writers on. Messages received from the server are delivered to
+// receiveChan exactly as with WebSocketStream, which is closed when the stream ends.
+//
+// The send side closes when the last writer is closed (after at least one writer
+// was created); the stream then ends like WebSocketStream after sendChan is closed,
+// or keeps receiving with WithWebSocketHalfClose(). Use Wait() for the result.
+//
+// Example:
+//
+//	session := client.OpenWebSocketStream(ctx, receiveChan, reqws.WithPath("/ws"))
+//	for i := 0; i < producers; i++ {
+//		w := session.NewWriter()
+//		go func() {
+//			defer w.Close()
+//			for msg := range work {
+//				if err := w.Send(ctx, msg); err != nil {
+//					return // reqws.ErrStreamClosed once the stream ended
+//				}
+//			}
+//		}()
+//	}
+//	err := session.Wait()
+func (c *Client) OpenWebSocketStream(ctx context.Context, receiveChan chan<- WebSocketResponse, opts ...RequestOption) *WebSocketSession {
+	s := &WebSocketSession{
+		sendChan: make(chan interface{}),
+		done:     make(chan struct{}),
+	}
+	stream := c.beginStream()
+	go func() {
+		s.err = c.webSocketStream(ctx, stream, s.sendChan, receiveChan, opts)
+		close(s.done)
+	}()
+	return s
+}
+
+// NewWriter returns a new producer handle. Writers created after the send side
+// has closed return ErrStreamClosed from Send.
+func (s *WebSocketSession) NewWriter() *WebSocketWriter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := &WebSocketWriter{session: s}
+	if s.closing {
+		w.closed = true
+		return w
+	}
+	s.writers++
+	s.opened = true
+	return w
+}
+
+// Done returns a channel that is closed when the stream has ended.
+func (s *WebSocketSession) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until the stream has ended and returns its error.
+func (s *WebSocketSession) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// Send sends v on the stream, blocking until the stream accepts it.
+// Returns ErrStreamClosed if the writer was closed, the send side has closed or
+// the stream has ended, and ctx.Err() if ctx is done first.
+func (w *WebSocketWriter) Send(ctx context.Context, v interface{}) error {
+	s := w.session
+
+	w.mu.Lock()
+	closed := w.closed
+	w.mu.Unlock()
+	if closed {
+		return ErrStreamClosed
+	}
+
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return ErrStreamClosed
+	}
+	s.inflight.Add(1)
+	s.mu.Unlock()
+	defer s.inflight.Done()
+
+	select {
+	case s.sendChan <- v:
+		return nil
+	case <-s.done:
+		return ErrStreamClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close releases the writer. Closing the last writer closes the send side of the
+// stream once sends in progress have finished. Closing a writer twice is a no-op.
+func (w *WebSocketWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	s := w.session
+	s.mu.Lock()
+	s.writers--
+	last := s.writers == 0 && s.opened && !s.closing
+	if last {
+		s.closing = true
+	}
+	s.mu.Unlock()
+
+	if last {
+		// No new sends can start; wait for the ones blocked on the stream
+		s.inflight.Wait()
+		close(s.sendChan)
+	}
+	return nil
+}
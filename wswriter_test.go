@@ -0,0 +1,101 @@
+package reqws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestWebSocketSessionWriters(t *testing.T) {
+	received := make(chan []string, 1)
+	url := wsServer(t, func(conn *websocket.Conn) {
+		var messages []string
+		for {
+			_, data, err := conn.Read(context.Background())
+			if err != nil {
+				received <- messages
+				return
+			}
+			messages = append(messages, string(data))
+		}
+	})
+
+	const producers, perProducer = 5, 20
+	receiveChan := make(chan WebSocketResponse, 1)
+	session := NewClient(url, 5*time.Second).OpenWebSocketStream(context.Background(), receiveChan)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		w := session.NewWriter()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.Close()
+			for i := 0; i < perProducer; i++ {
+				if err := w.Send(context.Background(), fmt.Sprintf("%d-%d", p, i)); err != nil {
+					t.Errorf("Send: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := session.Wait(); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+	select {
+	case messages := <-received:
+		if len(messages) != producers*perProducer {
+			t.Errorf("server received %d messages, want %d", len(messages), producers*perProducer)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not see the connection close")
+	}
+
+	late := session.NewWriter()
+	if err := late.Send(context.Background(), "late"); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Send on a writer created after the send side closed = %v, want ErrStreamClosed", err)
+	}
+}
+
+func TestWebSocketWriterClose(t *testing.T) {
+	url := wsServer(t, func(conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.Read(context.Background()); err != nil {
+				return
+			}
+		}
+	})
+	receiveChan := make(chan WebSocketResponse, 1)
+	session := NewClient(url, 5*time.Second).OpenWebSocketStream(context.Background(), receiveChan)
+
+	first, second := session.NewWriter(), session.NewWriter()
+	first.Close()
+	first.Close() // Closing twice must not release the other writer's hold
+	if err := first.Send(context.Background(), "x"); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Send on a closed writer = %v, want ErrStreamClosed", err)
+	}
+	select {
+	case <-session.Done():
+		t.Fatal("stream ended while a writer was still open")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := second.Send(ctx, "x"); err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("Send with a cancelled context = %v", err)
+	}
+	second.Close()
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after the last writer closed")
+	}
+}
@@ -0,0 +1,94 @@
+package reqws
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type xmlOrder struct {
+	XMLName xml.Name `xml:"order"`
+	ID      int      `xml:"id,attr"`
+	Note    string   `xml:"note"`
+}
+
+func TestResponseXML(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		want      string
+		wantEmpty bool
+		wantErr   bool
+	}{
+		{"plain", `<order id="1"><note>café</note></order>`, "café", false, false},
+		{"declaration", `<?xml version="1.0" encoding="UTF-8"?><order id="1"><note>ok</note></order>`, "ok", false, false},
+		{"byte order mark", "\xef\xbb\xbf<order id=\"1\"><note>ok</note></order>", "ok", false, false},
+		{"ISO-8859-1", "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><order id=\"1\"><note>caf\xe9</note></order>", "café", false, false},
+		{"US-ASCII", `<?xml version="1.0" encoding="us-ascii"?><order id="1"><note>ok</note></order>`, "ok", false, false},
+		{"empty", "", "", true, true},
+		{"whitespace only", " \r\n\t", "", true, true},
+		{"byte order mark only", "\xef\xbb\xbf\n", "", true, true},
+		{"malformed", `<order id="1"><note>`, "", false, true},
+		{"unsupported charset", `<?xml version="1.0" encoding="Shift_JIS"?><order id="1"/>`, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/xml")
+				io.WriteString(w, tt.body)
+			})
+			resp, err := NewClient(server.URL, 5*time.Second).Do(context.Background(), GET("/orders/1"))
+			if err != nil {
+				t.Fatalf("Do: %v", err)
+			}
+
+			var order xmlOrder
+			err = resp.XML(&order)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("XML error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrEmptyBody) != tt.wantEmpty {
+				t.Errorf("XML error = %v, want ErrEmptyBody %v", err, tt.wantEmpty)
+			}
+			if err == nil && (order.ID != 1 || order.Note != tt.want) {
+				t.Errorf("order = %+v, want note %q", order, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithXML(t *testing.T) {
+	server := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		io.Copy(w, r.Body)
+	})
+	client := NewClient(server.URL, 5*time.Second)
+	ctx := context.Background()
+
+	resp, err := client.Do(ctx, POST("/orders"), WithXML(xmlOrder{ID: 7, Note: "a < b"}))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := resp.Headers.Get("Content-Type"); got != "application/xml" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got, want := resp.String(), `<order id="7"><note>a &lt; b</note></order>`; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+
+	resp, err = client.Do(ctx, POST("/orders"), WithXML(xmlOrder{ID: 7}), WithHeader("Content-Type", "text/xml"))
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got := resp.Headers.Get("Content-Type"); got != "text/xml" {
+		t.Errorf("Content-Type = %q, want the header set on the request", got)
+	}
+
+	if _, err := client.Do(ctx, POST("/orders"), WithXML(make(chan int))); err == nil {
+		t.Error("marshaling a channel succeeded")
+	}
+}